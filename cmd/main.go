@@ -12,7 +12,9 @@ import (
 	"github.com/keptn-contrib/dynatrace-service/internal/env"
 	"github.com/keptn-contrib/dynatrace-service/internal/event_handler"
 	"github.com/keptn-contrib/dynatrace-service/internal/health"
+	"github.com/keptn-contrib/dynatrace-service/internal/monitoring"
 	"github.com/keptn-contrib/dynatrace-service/internal/onboard"
+	"github.com/keptn-contrib/dynatrace-service/internal/problem"
 
 	log "github.com/sirupsen/logrus"
 
@@ -70,6 +72,24 @@ func _main(envCfg envConfig) int {
 		}()
 	}
 
+	if env.IsMonitoringReconciliationEnabled() {
+		workerWaitGroup.Add(1)
+		go func() {
+			defer workerWaitGroup.Done()
+			monitoring.NewDefaultMonitoringReconciler().Run(notifyCtx, workCtx)
+		}()
+	}
+
+	if env.IsProblemPollingEnabled() {
+		workerWaitGroup.Add(1)
+		go func() {
+			defer workerWaitGroup.Done()
+			problem.NewDefaultProblemPoller(func(event cloudevents.Event) {
+				gotEvent(workCtx, replyCtx, event)
+			}).Run(notifyCtx, workCtx)
+		}()
+	}
+
 	log.WithFields(log.Fields{"port": envCfg.Port, "path": envCfg.Path}).Debug("Initializing cloudevents client")
 	c, err := cloudevents.NewClientHTTP(cloudevents.WithPath(envCfg.Path), cloudevents.WithPort(envCfg.Port), cloudevents.WithGetHandlerFunc(health.HTTPGetHandler))
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
@@ -11,19 +12,21 @@ import (
 )
 
 type ActionFinishedEventHandler struct {
-	event       ActionFinishedAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                 ActionFinishedAdapterInterface
+	dtClient              dynatrace.ClientInterface
+	eClient               keptn.EventClientInterface
+	attachRules           *dynatrace.AttachRules
+	attachRulesValidation *config.AttachRulesValidationConfig
 }
 
 // NewActionFinishedEventHandler creates a new ActionFinishedEventHandler
-func NewActionFinishedEventHandler(event ActionFinishedAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *ActionFinishedEventHandler {
+func NewActionFinishedEventHandler(event ActionFinishedAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig) *ActionFinishedEventHandler {
 	return &ActionFinishedEventHandler{
-		event:       event,
-		dtClient:    dtClient,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                 event,
+		dtClient:              dtClient,
+		eClient:               eClient,
+		attachRules:           attachRules,
+		attachRulesValidation: attachRulesValidation,
 	}
 }
 
@@ -43,11 +46,12 @@ func (eh *ActionFinishedEventHandler) HandleEvent(workCtx context.Context, reply
 		eh.event.GetSource(),
 		eh.event.GetResult(),
 		eh.event.GetStatus())
-	dynatrace.NewProblemsClient(eh.dtClient).AddProblemComment(workCtx, pid, comment)
+	newRemediationCommenter(eh.dtClient, eh.eClient).comment(workCtx, eh.event, pid, bridgeURL, comment)
 
 	// https://github.com/keptn-contrib/dynatrace-service/issues/174
 	// Additionally to the problem comment, send Info or Configuration Change Event to the entities in Dynatrace to indicate that remediation actions have been executed
 	customProperties := createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), bridgeURL)
+	attachRules := newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties)
 	if eh.event.GetStatus() == keptnv2.StatusSucceeded {
 		configurationEvent := dynatrace.ConfigurationEvent{
 			EventType:        dynatrace.ConfigurationEventType,
@@ -55,7 +59,7 @@ func (eh *ActionFinishedEventHandler) HandleEvent(workCtx context.Context, reply
 			Source:           eventSource,
 			Configuration:    "successful",
 			CustomProperties: customProperties,
-			AttachRules:      *eh.attachRules,
+			AttachRules:      attachRules,
 		}
 
 		dynatrace.NewEventsClient(eh.dtClient).AddConfigurationEvent(workCtx, configurationEvent)
@@ -66,7 +70,7 @@ func (eh *ActionFinishedEventHandler) HandleEvent(workCtx context.Context, reply
 			Title:            "Keptn Remediation Action Finished",
 			Description:      "error during execution",
 			CustomProperties: customProperties,
-			AttachRules:      *eh.attachRules,
+			AttachRules:      attachRules,
 		}
 
 		dynatrace.NewEventsClient(eh.dtClient).AddInfoEvent(workCtx, infoEvent)
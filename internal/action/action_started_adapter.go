@@ -3,6 +3,7 @@ package action
 import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn/go-utils/pkg/api/models"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 )
 
@@ -81,3 +82,8 @@ func (a ActionStartedAdapter) GetDeploymentStrategy() string {
 func (a ActionStartedAdapter) GetLabels() map[string]string {
 	return a.event.Labels
 }
+
+// ToKeptnEvent returns the underlying event as a Keptn event
+func (a ActionStartedAdapter) ToKeptnEvent() (*models.KeptnContextExtendedCE, error) {
+	return a.cloudEvent.ToKeptnEvent()
+}
@@ -32,8 +32,9 @@ func (eh *ActionStartedEventHandler) HandleEvent(workCtx context.Context, replyC
 		return err
 	}
 
-	comment := fmt.Sprintf("[Keptn remediation action](%s) started execution by: %s", keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event), eh.event.GetSource())
-	dynatrace.NewProblemsClient(eh.dtClient).AddProblemComment(workCtx, pid, comment)
+	bridgeURL := keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)
+	comment := fmt.Sprintf("[Keptn remediation action](%s) started execution by: %s", bridgeURL, eh.event.GetSource())
+	newRemediationCommenter(eh.dtClient, eh.eClient).comment(workCtx, eh.event, pid, bridgeURL, comment)
 
 	return nil
 }
@@ -3,6 +3,7 @@ package action
 import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn/go-utils/pkg/api/models"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 )
 
@@ -92,3 +93,8 @@ func (a ActionTriggeredAdapter) GetAction() string {
 func (a ActionTriggeredAdapter) GetActionDescription() string {
 	return a.event.Action.Description
 }
+
+// ToKeptnEvent returns the underlying event as a Keptn event
+func (a ActionTriggeredAdapter) ToKeptnEvent() (*models.KeptnContextExtendedCE, error) {
+	return a.cloudEvent.ToKeptnEvent()
+}
@@ -5,25 +5,28 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	log "github.com/sirupsen/logrus"
 )
 
 type ActionTriggeredEventHandler struct {
-	event       ActionTriggeredAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                 ActionTriggeredAdapterInterface
+	dtClient              dynatrace.ClientInterface
+	eClient               keptn.EventClientInterface
+	attachRules           *dynatrace.AttachRules
+	attachRulesValidation *config.AttachRulesValidationConfig
 }
 
 // NewActionTriggeredEventHandler creates a new ActionTriggeredEventHandler
-func NewActionTriggeredEventHandler(event ActionTriggeredAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *ActionTriggeredEventHandler {
+func NewActionTriggeredEventHandler(event ActionTriggeredAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig) *ActionTriggeredEventHandler {
 	return &ActionTriggeredEventHandler{
-		event:       event,
-		dtClient:    dtClient,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                 event,
+		dtClient:              dtClient,
+		eClient:               eClient,
+		attachRules:           attachRules,
+		attachRulesValidation: attachRulesValidation,
 	}
 }
 
@@ -47,17 +50,18 @@ func (eh *ActionTriggeredEventHandler) HandleEvent(workCtx context.Context, repl
 		comment = comment + ": " + eh.event.GetActionDescription()
 	}
 
-	dynatrace.NewProblemsClient(eh.dtClient).AddProblemComment(workCtx, pid, comment)
+	newRemediationCommenter(eh.dtClient, eh.eClient).comment(workCtx, eh.event, pid, bridgeURL, comment)
 
 	// https://github.com/keptn-contrib/dynatrace-service/issues/174
 	// In addition to the problem comment, send Info and Configuration Change Event to the entities in Dynatrace to indicate that remediation actions have been executed
+	customProperties := createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), bridgeURL)
 	infoEvent := dynatrace.InfoEvent{
 		EventType:        dynatrace.InfoEventType,
 		Source:           eventSource,
 		Title:            "Keptn Remediation Action Triggered",
 		Description:      eh.event.GetAction(),
-		CustomProperties: createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), bridgeURL),
-		AttachRules:      *eh.attachRules,
+		CustomProperties: customProperties,
+		AttachRules:      newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties),
 	}
 
 	dynatrace.NewEventsClient(eh.dtClient).AddInfoEvent(workCtx, infoEvent)
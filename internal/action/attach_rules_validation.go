@@ -0,0 +1,186 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	log "github.com/sirupsen/logrus"
+)
+
+const matchedEntitiesKey = "Matched entities"
+
+// entityIDsCacheTTL is kept short so that changes to tagging are picked up quickly, while still avoiding repeated lookups for the events of a single sequence.
+const entityIDsCacheTTL = time.Minute
+
+var entityIDsCache = newEntitySelectorCache(entityIDsCacheTTL)
+
+// attachRulesValidator checks whether attach rules match any entities before they are used for a Dynatrace event.
+type attachRulesValidator struct {
+	dtClient dynatrace.ClientInterface
+	config   *config.AttachRulesValidationConfig
+}
+
+func newAttachRulesValidator(dtClient dynatrace.ClientInterface, config *config.AttachRulesValidationConfig) *attachRulesValidator {
+	return &attachRulesValidator{
+		dtClient: dtClient,
+		config:   config,
+	}
+}
+
+// validate returns the attach rules to be used for an event.
+// If validation is enabled, the number of matched entities is added to the custom properties and, if no entities are matched, entities matching the fallback entity selector are used instead.
+func (v *attachRulesValidator) validate(ctx context.Context, attachRules dynatrace.AttachRules, customProperties map[string]string) dynatrace.AttachRules {
+	if !v.config.IsEnabled() {
+		return attachRules
+	}
+
+	entityIDs, err := v.getMatchedEntityIDs(ctx, attachRules)
+	if err != nil {
+		log.WithError(err).Warn("Could not validate attach rules, sending event without validation")
+		return attachRules
+	}
+
+	if len(entityIDs) > 0 {
+		log.WithField("matchedEntities", len(entityIDs)).Info("Attach rules match entities")
+		customProperties[matchedEntitiesKey] = strconv.Itoa(len(entityIDs))
+		return attachRules
+	}
+
+	if v.config.FallbackEntitySelector == "" {
+		log.Warn("Attach rules match no entities, the event will not be visible on any entity in Dynatrace")
+		customProperties[matchedEntitiesKey] = "0"
+		return attachRules
+	}
+
+	fallbackEntityIDs, err := v.getEntityIDs(ctx, v.config.FallbackEntitySelector)
+	if err != nil {
+		log.WithError(err).WithField("entitySelector", v.config.FallbackEntitySelector).Error("Attach rules match no entities and fallback entity selector could not be resolved")
+		customProperties[matchedEntitiesKey] = "0"
+		return attachRules
+	}
+
+	if len(fallbackEntityIDs) == 0 {
+		log.WithField("entitySelector", v.config.FallbackEntitySelector).Warn("Neither attach rules nor fallback entity selector match any entities, the event will not be visible on any entity in Dynatrace")
+		customProperties[matchedEntitiesKey] = "0"
+		return attachRules
+	}
+
+	log.WithFields(
+		log.Fields{
+			"entitySelector":  v.config.FallbackEntitySelector,
+			"matchedEntities": len(fallbackEntityIDs),
+		}).Warn("Attach rules match no entities, using fallback entity selector")
+	customProperties[matchedEntitiesKey] = fmt.Sprintf("%d (fallback entity selector)", len(fallbackEntityIDs))
+	return dynatrace.AttachRules{
+		EntityIDs: fallbackEntityIDs,
+		TagRule:   []dynatrace.TagRule{},
+	}
+}
+
+// getMatchedEntityIDs returns the distinct IDs of all entities matched by the attach rules.
+func (v *attachRulesValidator) getMatchedEntityIDs(ctx context.Context, attachRules dynatrace.AttachRules) ([]string, error) {
+	entityIDs := make([]string, 0, len(attachRules.EntityIDs))
+	seenEntityIDs := make(map[string]bool)
+	addEntityIDs := func(ids []string) {
+		for _, id := range ids {
+			if !seenEntityIDs[id] {
+				seenEntityIDs[id] = true
+				entityIDs = append(entityIDs, id)
+			}
+		}
+	}
+
+	addEntityIDs(attachRules.EntityIDs)
+	for _, tagRule := range attachRules.TagRule {
+		for _, entitySelector := range tagRule.EntitySelectors() {
+			ids, err := v.getEntityIDs(ctx, entitySelector)
+			if err != nil {
+				return nil, err
+			}
+			addEntityIDs(ids)
+		}
+	}
+
+	return entityIDs, nil
+}
+
+// getEntityIDs returns the IDs of all entities matching the entity selector, using cached results if available.
+func (v *attachRulesValidator) getEntityIDs(ctx context.Context, entitySelector string) ([]string, error) {
+	cacheKey := v.dtClient.Credentials().GetTenant() + " " + entitySelector
+	if ids, ok := entityIDsCache.get(cacheKey); ok {
+		return ids, nil
+	}
+
+	ids, err := dynatrace.NewEntitiesClient(v.dtClient).GetIDsBySelector(ctx, entitySelector)
+	if err != nil {
+		return nil, fmt.Errorf("could not get entities matching selector %s: %w", entitySelector, err)
+	}
+
+	entityIDsCache.set(cacheKey, ids)
+	return ids, nil
+}
+
+type entitySelectorCacheEntry struct {
+	ids       []string
+	expiresAt time.Time
+}
+
+// entitySelectorCache caches the entity IDs matched by entity selectors for a fixed time.
+// Expired entries are removed whenever an entry is stored, so that entries of selectors no longer used do not accumulate.
+type entitySelectorCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]entitySelectorCacheEntry
+	now     func() time.Time
+}
+
+func newEntitySelectorCache(ttl time.Duration) *entitySelectorCache {
+	return &entitySelectorCache{
+		ttl:     ttl,
+		entries: make(map[string]entitySelectorCacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (c *entitySelectorCache) get(key string) ([]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if c.now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return entry.ids, true
+}
+
+func (c *entitySelectorCache) set(key string, ids []string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	c.removeExpired(now)
+	c.entries[key] = entitySelectorCacheEntry{
+		ids:       ids,
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+// removeExpired removes all entries that have expired at the specified time. The mutex must be held by the caller.
+func (c *entitySelectorCache) removeExpired(now time.Time) {
+	for key, entry := range c.entries {
+		if now.After(entry.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
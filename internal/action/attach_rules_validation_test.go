@@ -0,0 +1,187 @@
+package action
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const testDynatraceAPIToken = "dt0c01.ST2EY72KQINMH574WMNVI7YN.G3DFPBEJYMODIDAEX454M7YWBUVEFOWKPRVMWFASS64NFH52PX6BNDVFFM572RZM"
+
+const attachRulesValidationTestDataFolder = "./testdata/attach_rules_validation/"
+
+// cartsEntitiesURL is requested for the entity selector type("SERVICE"),tag("keptn_project:sockshop"),tag("keptn_service:carts").
+const cartsEntitiesURL = "/api/v2/entities?entitySelector=type%28%22SERVICE%22%29%2Ctag%28%22keptn_project%3Asockshop%22%29%2Ctag%28%22keptn_service%3Acarts%22%29&pageSize=500"
+
+// fallbackEntitiesURL is requested for the fallback entity selector type("SERVICE"),tag("keptn_project:sockshop").
+const fallbackEntitiesURL = "/api/v2/entities?entitySelector=type%28%22SERVICE%22%29%2Ctag%28%22keptn_project%3Asockshop%22%29&pageSize=500"
+
+const fallbackEntitySelector = `type("SERVICE"),tag("keptn_project:sockshop")`
+
+func Test_attachRulesValidator_validate(t *testing.T) {
+	cartsTagRules := []dynatrace.TagRule{
+		{
+			MeTypes: []string{"SERVICE"},
+			Tags: []dynatrace.TagEntry{
+				{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"},
+				{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"},
+			},
+		},
+	}
+
+	cartsAttachRules := dynatrace.AttachRules{
+		TagRule: cartsTagRules,
+	}
+
+	cartsAttachRulesWithEntityID := dynatrace.AttachRules{
+		EntityIDs: []string{"SERVICE-1A2B3C4D5E6F7A8B"},
+		TagRule:   cartsTagRules,
+	}
+
+	fallbackAttachRules := dynatrace.AttachRules{
+		EntityIDs: []string{"SERVICE-3C4D5E6F7A8B9C0D"},
+		TagRule:   []dynatrace.TagRule{},
+	}
+
+	tests := []struct {
+		name                     string
+		config                   *config.AttachRulesValidationConfig
+		attachRules              dynatrace.AttachRules
+		setupHandler             func(handler *test.FileBasedURLHandler)
+		expectedAttachRules      dynatrace.AttachRules
+		expectedCustomProperties map[string]string
+	}{
+		{
+			name:                     "validation not configured",
+			config:                   nil,
+			attachRules:              cartsAttachRules,
+			setupHandler:             func(handler *test.FileBasedURLHandler) {},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{},
+		},
+		{
+			name:                     "validation disabled",
+			config:                   &config.AttachRulesValidationConfig{Enabled: false, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules:              cartsAttachRules,
+			setupHandler:             func(handler *test.FileBasedURLHandler) {},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{},
+		},
+		{
+			name:        "attach rules match entities, entity IDs are counted once",
+			config:      &config.AttachRulesValidationConfig{Enabled: true, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules: cartsAttachRulesWithEntityID,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExact(cartsEntitiesURL, attachRulesValidationTestDataFolder+"entities_carts.json")
+			},
+			expectedAttachRules:      cartsAttachRulesWithEntityID,
+			expectedCustomProperties: map[string]string{matchedEntitiesKey: "2"},
+		},
+		{
+			name:        "attach rules match no entities and no fallback entity selector",
+			config:      &config.AttachRulesValidationConfig{Enabled: true},
+			attachRules: cartsAttachRules,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExact(cartsEntitiesURL, attachRulesValidationTestDataFolder+"entities_empty.json")
+			},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{matchedEntitiesKey: "0"},
+		},
+		{
+			name:        "attach rules match no entities, fallback entity selector is used",
+			config:      &config.AttachRulesValidationConfig{Enabled: true, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules: cartsAttachRules,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExact(cartsEntitiesURL, attachRulesValidationTestDataFolder+"entities_empty.json")
+				handler.AddExact(fallbackEntitiesURL, attachRulesValidationTestDataFolder+"entities_sockshop.json")
+			},
+			expectedAttachRules:      fallbackAttachRules,
+			expectedCustomProperties: map[string]string{matchedEntitiesKey: "1 (fallback entity selector)"},
+		},
+		{
+			name:        "neither attach rules nor fallback entity selector match entities",
+			config:      &config.AttachRulesValidationConfig{Enabled: true, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules: cartsAttachRules,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExact(cartsEntitiesURL, attachRulesValidationTestDataFolder+"entities_empty.json")
+				handler.AddExact(fallbackEntitiesURL, attachRulesValidationTestDataFolder+"entities_empty.json")
+			},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{matchedEntitiesKey: "0"},
+		},
+		{
+			name:        "fallback entity selector cannot be resolved",
+			config:      &config.AttachRulesValidationConfig{Enabled: true, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules: cartsAttachRules,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExact(cartsEntitiesURL, attachRulesValidationTestDataFolder+"entities_empty.json")
+				handler.AddExactError(fallbackEntitiesURL, http.StatusInternalServerError, attachRulesValidationTestDataFolder+"entities_error.json")
+			},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{matchedEntitiesKey: "0"},
+		},
+		{
+			name:        "attach rules cannot be validated",
+			config:      &config.AttachRulesValidationConfig{Enabled: true, FallbackEntitySelector: fallbackEntitySelector},
+			attachRules: cartsAttachRules,
+			setupHandler: func(handler *test.FileBasedURLHandler) {
+				handler.AddExactError(cartsEntitiesURL, http.StatusInternalServerError, attachRulesValidationTestDataFolder+"entities_error.json")
+			},
+			expectedAttachRules:      cartsAttachRules,
+			expectedCustomProperties: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := test.NewFileBasedURLHandler(t)
+			tt.setupHandler(handler)
+
+			// each test server has its own URL, so cached entity IDs are not shared between test cases
+			httpClient, url, teardown := test.CreateHTTPSClient(handler)
+			defer teardown()
+
+			dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			customProperties := map[string]string{}
+			validator := newAttachRulesValidator(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), tt.config)
+			assert.EqualValues(t, tt.expectedAttachRules, validator.validate(context.TODO(), tt.attachRules, customProperties))
+			assert.EqualValues(t, tt.expectedCustomProperties, customProperties)
+		})
+	}
+}
+
+// Test_entitySelectorCache tests that expired entries are not returned and are removed when looked up or when another entry is stored.
+func Test_entitySelectorCache(t *testing.T) {
+	now := time.Date(2022, 5, 2, 11, 0, 0, 0, time.UTC)
+	cache := newEntitySelectorCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	cache.set("carts", []string{"SERVICE-1A2B3C4D5E6F7A8B"})
+	cache.set("orders", []string{"SERVICE-3C4D5E6F7A8B9C0D"})
+
+	ids, ok := cache.get("carts")
+	assert.True(t, ok)
+	assert.EqualValues(t, []string{"SERVICE-1A2B3C4D5E6F7A8B"}, ids)
+
+	now = now.Add(2 * time.Minute)
+
+	_, ok = cache.get("carts")
+	assert.False(t, ok)
+	assert.NotContains(t, cache.entries, "carts")
+	assert.Contains(t, cache.entries, "orders")
+
+	cache.set("payment", []string{"SERVICE-5E6F7A8B9C0D1E2F"})
+	assert.NotContains(t, cache.entries, "orders")
+	assert.Contains(t, cache.entries, "payment")
+}
@@ -7,6 +7,7 @@ import (
 
 const eventSource = "Keptn dynatrace-service"
 const bridgeURLKey = "Keptns Bridge"
+const problemClosedKey = "Problem closed"
 
 func createCustomProperties(a adapter.EventContentAdapter, imageAndTag common.ImageAndTag, bridgeURL string) map[string]string {
 	customProperties := map[string]string{
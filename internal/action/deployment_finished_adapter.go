@@ -8,6 +8,10 @@ import (
 
 type DeploymentFinishedAdapterInterface interface {
 	adapter.EventContentAdapter
+
+	GetGitCommit() string
+	GetDeploymentURIsLocal() []string
+	GetDeploymentURIsPublic() []string
 }
 
 // DeploymentFinishedAdapter godoc
@@ -91,3 +95,21 @@ func (a DeploymentFinishedAdapter) GetLabels() map[string]string {
 	}
 	return labels
 }
+
+// GetGitCommit returns the Git commit of the deployment, falling back to the Git commit ID of the event
+func (a DeploymentFinishedAdapter) GetGitCommit() string {
+	if a.event.Deployment.GitCommit != "" {
+		return a.event.Deployment.GitCommit
+	}
+	return a.cloudEvent.GetGitCommitID()
+}
+
+// GetDeploymentURIsLocal returns the local URIs of the deployment
+func (a DeploymentFinishedAdapter) GetDeploymentURIsLocal() []string {
+	return a.event.Deployment.DeploymentURIsLocal
+}
+
+// GetDeploymentURIsPublic returns the public URIs of the deployment
+func (a DeploymentFinishedAdapter) GetDeploymentURIsPublic() []string {
+	return a.event.Deployment.DeploymentURIsPublic
+}
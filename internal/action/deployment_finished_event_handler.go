@@ -3,31 +3,42 @@ package action
 import (
 	"context"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	log "github.com/sirupsen/logrus"
 )
 
 // DeploymentFinishedEventHandler handles a deployment finished event.
 type DeploymentFinishedEventHandler struct {
-	event       DeploymentFinishedAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                   DeploymentFinishedAdapterInterface
+	dtClient                dynatrace.ClientInterface
+	eClient                 keptn.EventClientInterface
+	attachRules             *dynatrace.AttachRules
+	attachRulesValidation   *config.AttachRulesValidationConfig
+	maintenanceWindowConfig *config.DeploymentMaintenanceWindowConfig
 }
 
 // NewDeploymentFinishedEventHandler creates a new DeploymentFinishedEventHandler.
-func NewDeploymentFinishedEventHandler(event DeploymentFinishedAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *DeploymentFinishedEventHandler {
+func NewDeploymentFinishedEventHandler(event DeploymentFinishedAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig, maintenanceWindowConfig *config.DeploymentMaintenanceWindowConfig) *DeploymentFinishedEventHandler {
 	return &DeploymentFinishedEventHandler{
-		event:       event,
-		dtClient:    dtClient,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                   event,
+		dtClient:                dtClient,
+		eClient:                 eClient,
+		attachRules:             attachRules,
+		attachRulesValidation:   attachRulesValidation,
+		maintenanceWindowConfig: maintenanceWindowConfig,
 	}
 }
 
 // HandleEvent handles a deployment finished event.
 func (eh *DeploymentFinishedEventHandler) HandleEvent(workCtx context.Context, replyCtx context.Context) error {
-	imageAndTag := eh.eClient.GetImageAndTag(eh.event)
+	deploymentConfiguration := eh.eClient.GetDeploymentConfiguration(eh.event)
+	imageAndTag := deploymentConfiguration.GetImageAndTag()
+	bridgeURL := keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)
+
+	customProperties := createCustomProperties(eh.event, imageAndTag, bridgeURL)
+	newDeploymentProperties(eh.event, deploymentConfiguration).addTo(customProperties)
 
 	deploymentEvent := dynatrace.DeploymentEvent{
 		EventType:         dynatrace.DeploymentEventType,
@@ -35,12 +46,20 @@ func (eh *DeploymentFinishedEventHandler) HandleEvent(workCtx context.Context, r
 		DeploymentName:    getValueFromLabels(eh.event, "deploymentName", "Deploy "+eh.event.GetService()+" "+imageAndTag.Tag()+" with strategy "+eh.event.GetDeploymentStrategy()),
 		DeploymentProject: getValueFromLabels(eh.event, "deploymentProject", eh.event.GetProject()),
 		DeploymentVersion: getValueFromLabels(eh.event, "deploymentVersion", imageAndTag.Tag()),
-		CiBackLink:        getValueFromLabels(eh.event, "ciBackLink", ""),
+		CiBackLink:        getValueFromLabels(eh.event, "ciBackLink", bridgeURL),
 		RemediationAction: getValueFromLabels(eh.event, "remediationAction", ""),
-		CustomProperties:  createCustomProperties(eh.event, imageAndTag, keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)),
-		AttachRules:       *eh.attachRules,
+		CustomProperties:  customProperties,
+		AttachRules:       newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties),
 	}
 
 	dynatrace.NewEventsClient(eh.dtClient).AddDeploymentEvent(workCtx, deploymentEvent)
+
+	if eh.maintenanceWindowConfig.IsEnabled() {
+		err := newDeploymentMaintenanceWindows(eh.dtClient, eh.maintenanceWindowConfig).close(workCtx, eh.event)
+		if err != nil {
+			log.WithError(err).Error("Could not close maintenance window for deployment")
+		}
+	}
+
 	return nil
 }
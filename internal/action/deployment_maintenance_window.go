@@ -0,0 +1,157 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	log "github.com/sirupsen/logrus"
+)
+
+const deploymentMaintenanceWindowNamePrefix = "Keptn deployment: "
+
+// deploymentMaintenanceWindowEndSeparator precedes the end of a deployment maintenance window in its description, so that expired windows can be found by listing them.
+const deploymentMaintenanceWindowEndSeparator = ", ends at "
+
+// deploymentMaintenanceWindows opens and closes the Dynatrace maintenance windows used while Keptn deployments are in progress.
+type deploymentMaintenanceWindows struct {
+	dtClient dynatrace.ClientInterface
+	client   *dynatrace.MaintenanceWindowsClient
+	config   *config.DeploymentMaintenanceWindowConfig
+	now      func() time.Time
+}
+
+func newDeploymentMaintenanceWindows(dtClient dynatrace.ClientInterface, config *config.DeploymentMaintenanceWindowConfig) *deploymentMaintenanceWindows {
+	return &deploymentMaintenanceWindows{
+		dtClient: dtClient,
+		client:   dynatrace.NewMaintenanceWindowsClient(dtClient),
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// open creates a maintenance window for the deployment, scoped either by the configured management zone or by the attach rules.
+// The window ends automatically after the configured maximum duration, even if it is never closed.
+func (w *deploymentMaintenanceWindows) open(ctx context.Context, event adapter.EventContentAdapter, attachRules dynatrace.AttachRules) error {
+	w.deleteExpired(ctx)
+
+	scope, err := w.createScope(ctx, attachRules)
+	if err != nil {
+		return err
+	}
+
+	start := w.now()
+	end := start.Add(w.config.GetMaxDuration())
+	maintenanceWindow := &dynatrace.MaintenanceWindow{
+		Name:        getDeploymentMaintenanceWindowName(event),
+		Description: fmt.Sprintf("Opened by %s for the deployment of service %s in stage %s of project %s%s%s", eventSource, event.GetService(), event.GetStage(), event.GetProject(), deploymentMaintenanceWindowEndSeparator, end.UTC().Format(time.RFC3339)),
+		Type:        "PLANNED",
+		Suppression: w.config.GetSuppression(),
+		Scope:       scope,
+		Schedule:    dynatrace.NewOnceMaintenanceWindowSchedule(start, end),
+	}
+
+	id, err := w.client.Create(ctx, maintenanceWindow)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"name": maintenanceWindow.Name, "id": id}).Info("Opened maintenance window for deployment")
+	return nil
+}
+
+// close deletes the maintenance window opened for the deployment, if it still exists.
+func (w *deploymentMaintenanceWindows) close(ctx context.Context, event adapter.EventContentAdapter) error {
+	name := getDeploymentMaintenanceWindowName(event)
+	stubs, err := w.client.GetStubsByNamePrefix(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	id := ""
+	for _, stub := range stubs {
+		if stub.Name == name {
+			id = stub.ID
+		}
+	}
+
+	if id == "" {
+		log.WithField("name", name).Debug("No maintenance window found for deployment")
+		return nil
+	}
+
+	err = w.client.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"name": name, "id": id}).Info("Closed maintenance window for deployment")
+	return nil
+}
+
+// deleteExpired deletes all deployment maintenance windows that have already ended, e.g. because the corresponding deployment.finished event was never processed.
+// The end of each window is taken from its description, so that a single request suffices to find the expired windows.
+func (w *deploymentMaintenanceWindows) deleteExpired(ctx context.Context) {
+	stubs, err := w.client.GetStubsByNamePrefix(ctx, deploymentMaintenanceWindowNamePrefix)
+	if err != nil {
+		log.WithError(err).Error("Could not retrieve deployment maintenance windows")
+		return
+	}
+
+	now := w.now()
+	for _, stub := range stubs {
+		end, err := getDeploymentMaintenanceWindowEnd(stub.Description)
+		if err != nil {
+			log.WithError(err).WithField("name", stub.Name).Warn("Could not determine end of deployment maintenance window")
+			continue
+		}
+
+		if end.After(now) {
+			continue
+		}
+
+		err = w.client.Delete(ctx, stub.ID)
+		if err != nil {
+			log.WithError(err).WithField("name", stub.Name).Error("Could not delete expired deployment maintenance window")
+			continue
+		}
+
+		log.WithFields(log.Fields{"name": stub.Name, "id": stub.ID}).Info("Deleted expired deployment maintenance window")
+	}
+}
+
+func (w *deploymentMaintenanceWindows) createScope(ctx context.Context, attachRules dynatrace.AttachRules) (*dynatrace.MWScope, error) {
+	if w.config.ManagementZone == "" {
+		return dynatrace.NewMWScopeFromAttachRules(attachRules), nil
+	}
+
+	managementZones, err := dynatrace.NewManagementZonesClient(w.dtClient).GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	managementZoneID, found := managementZones.GetNumericIDByName(w.config.ManagementZone)
+	if !found {
+		return nil, fmt.Errorf("could not find management zone %s", w.config.ManagementZone)
+	}
+
+	return dynatrace.NewMWScopeForManagementZone(managementZoneID), nil
+}
+
+func getDeploymentMaintenanceWindowName(event adapter.EventContentAdapter) string {
+	return fmt.Sprintf("%s%s %s %s (%s)", deploymentMaintenanceWindowNamePrefix, event.GetProject(), event.GetStage(), event.GetService(), event.GetShKeptnContext())
+}
+
+// getDeploymentMaintenanceWindowEnd returns the end of a deployment maintenance window stored in its description.
+func getDeploymentMaintenanceWindowEnd(description string) (time.Time, error) {
+	i := strings.LastIndex(description, deploymentMaintenanceWindowEndSeparator)
+	if i < 0 {
+		return time.Time{}, fmt.Errorf("description does not contain the end of the maintenance window: %s", description)
+	}
+
+	return time.Parse(time.RFC3339, description[i+len(deploymentMaintenanceWindowEndSeparator):])
+}
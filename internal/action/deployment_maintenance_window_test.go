@@ -0,0 +1,221 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const deploymentMaintenanceWindowTestDataFolder = "./testdata/deployment_maintenance_window/"
+
+const maintenanceWindowsURL = "/api/config/v1/maintenanceWindows"
+
+// maintenanceWindowsRecordingHandler records all requests and serves reads and writes from separate handlers, as both share the same URL.
+type maintenanceWindowsRecordingHandler struct {
+	readHandler        http.Handler
+	writeHandler       http.Handler
+	requests           []string
+	maintenanceWindows []dynatrace.MaintenanceWindow
+}
+
+func newMaintenanceWindowsRecordingHandler(t *testing.T) *maintenanceWindowsRecordingHandler {
+	readHandler := test.NewFileBasedURLHandler(t)
+	readHandler.AddExact(maintenanceWindowsURL, deploymentMaintenanceWindowTestDataFolder+"maintenance_windows.json")
+
+	writeHandler := test.NewFileBasedURLHandler(t)
+	writeHandler.AddExact(maintenanceWindowsURL, deploymentMaintenanceWindowTestDataFolder+"maintenance_window_created.json")
+	writeHandler.AddStartsWith(maintenanceWindowsURL+"/", deploymentMaintenanceWindowTestDataFolder+"maintenance_window_deleted.json")
+
+	return &maintenanceWindowsRecordingHandler{
+		readHandler:  readHandler,
+		writeHandler: writeHandler,
+	}
+}
+
+func (h *maintenanceWindowsRecordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests = append(h.requests, r.Method+" "+r.URL.Path)
+	if r.Method == http.MethodGet {
+		h.readHandler.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			panic("could not read request body")
+		}
+
+		maintenanceWindow := dynatrace.MaintenanceWindow{}
+		err = json.Unmarshal(body, &maintenanceWindow)
+		if err != nil {
+			panic("could not unmarshal maintenance window")
+		}
+		h.maintenanceWindows = append(h.maintenanceWindows, maintenanceWindow)
+	}
+	h.writeHandler.ServeHTTP(w, r)
+}
+
+func createDeploymentMaintenanceWindows(t *testing.T, handler http.Handler, now time.Time) (*deploymentMaintenanceWindows, func()) {
+	httpClient, url, teardown := test.CreateHTTPSClient(handler)
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	if !assert.NoError(t, err) {
+		teardown()
+		t.FailNow()
+	}
+
+	windows := newDeploymentMaintenanceWindows(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), &config.DeploymentMaintenanceWindowConfig{Enabled: true})
+	windows.now = func() time.Time { return now }
+	return windows, teardown
+}
+
+func newCartsProductionDeploymentEvent() *test.EventData {
+	return &test.EventData{
+		Context: "a1b2c3d4-0000-1111-2222-333344445555",
+		Project: "sockshop",
+		Stage:   "production",
+		Service: "carts",
+	}
+}
+
+// TestDeploymentMaintenanceWindows_Open tests that expired deployment maintenance windows are deleted and a new one is created ending after the maximum duration.
+func TestDeploymentMaintenanceWindows_Open(t *testing.T) {
+	handler := newMaintenanceWindowsRecordingHandler(t)
+	windows, teardown := createDeploymentMaintenanceWindows(t, handler, time.Date(2022, 5, 2, 11, 0, 0, 0, time.UTC))
+	defer teardown()
+
+	attachRules := dynatrace.AttachRules{
+		TagRule: []dynatrace.TagRule{
+			{
+				MeTypes: []string{"SERVICE"},
+				Tags: []dynatrace.TagEntry{
+					{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"},
+				},
+			},
+		},
+	}
+
+	err := windows.open(context.TODO(), newCartsProductionDeploymentEvent(), attachRules)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.EqualValues(t,
+		[]string{
+			"GET " + maintenanceWindowsURL,
+			"DELETE " + maintenanceWindowsURL + "/1b5e8f2a-3c4d-4e6f-8a9b-0c1d2e3f4a5b",
+			"POST " + maintenanceWindowsURL,
+		},
+		handler.requests)
+
+	if !assert.Len(t, handler.maintenanceWindows, 1) {
+		return
+	}
+
+	maintenanceWindow := handler.maintenanceWindows[0]
+	assert.Equal(t, "Keptn deployment: sockshop production carts (a1b2c3d4-0000-1111-2222-333344445555)", maintenanceWindow.Name)
+	assert.Equal(t, "Opened by Keptn dynatrace-service for the deployment of service carts in stage production of project sockshop, ends at 2022-05-02T12:00:00Z", maintenanceWindow.Description)
+	assert.Equal(t, dynatrace.MaintenanceWindowSuppressionDetectProblemsDontAlert, maintenanceWindow.Suppression)
+	assert.EqualValues(t, dynatrace.NewOnceMaintenanceWindowSchedule(time.Date(2022, 5, 2, 11, 0, 0, 0, time.UTC), time.Date(2022, 5, 2, 12, 0, 0, 0, time.UTC)), maintenanceWindow.Schedule)
+	assert.EqualValues(t, dynatrace.NewMWScopeFromAttachRules(attachRules), maintenanceWindow.Scope)
+}
+
+// TestDeploymentMaintenanceWindows_Close tests that only the maintenance window opened for the deployment is deleted.
+func TestDeploymentMaintenanceWindows_Close(t *testing.T) {
+	tests := []struct {
+		name             string
+		event            *test.EventData
+		expectedRequests []string
+	}{
+		{
+			name:  "maintenance window exists",
+			event: newCartsProductionDeploymentEvent(),
+			expectedRequests: []string{
+				"GET " + maintenanceWindowsURL,
+				"DELETE " + maintenanceWindowsURL + "/7c9f6d7e-5b0a-4b4e-9b4f-6c1d3f7a2b10",
+			},
+		},
+		{
+			name: "maintenance window does not exist",
+			event: &test.EventData{
+				Context: "c3d4e5f6-0000-1111-2222-333344445555",
+				Project: "sockshop",
+				Stage:   "production",
+				Service: "carts",
+			},
+			expectedRequests: []string{
+				"GET " + maintenanceWindowsURL,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newMaintenanceWindowsRecordingHandler(t)
+			windows, teardown := createDeploymentMaintenanceWindows(t, handler, time.Date(2022, 5, 2, 11, 0, 0, 0, time.UTC))
+			defer teardown()
+
+			err := windows.close(context.TODO(), tt.event)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.EqualValues(t, tt.expectedRequests, handler.requests)
+		})
+	}
+}
+
+// TestDeploymentMaintenanceWindows_DeleteExpired tests that expired deployment maintenance windows are found with a single request and deleted,
+// while other maintenance windows and those whose end cannot be determined are kept.
+func TestDeploymentMaintenanceWindows_DeleteExpired(t *testing.T) {
+	tests := []struct {
+		name             string
+		now              time.Time
+		expectedRequests []string
+	}{
+		{
+			name: "no maintenance window expired",
+			now:  time.Date(2022, 5, 2, 9, 0, 0, 0, time.UTC),
+			expectedRequests: []string{
+				"GET " + maintenanceWindowsURL,
+			},
+		},
+		{
+			name: "one maintenance window expired",
+			now:  time.Date(2022, 5, 2, 11, 0, 0, 0, time.UTC),
+			expectedRequests: []string{
+				"GET " + maintenanceWindowsURL,
+				"DELETE " + maintenanceWindowsURL + "/1b5e8f2a-3c4d-4e6f-8a9b-0c1d2e3f4a5b",
+			},
+		},
+		{
+			name: "maintenance window ending now is expired",
+			now:  time.Date(2022, 5, 2, 11, 15, 0, 0, time.UTC),
+			expectedRequests: []string{
+				"GET " + maintenanceWindowsURL,
+				"DELETE " + maintenanceWindowsURL + "/1b5e8f2a-3c4d-4e6f-8a9b-0c1d2e3f4a5b",
+				"DELETE " + maintenanceWindowsURL + "/7c9f6d7e-5b0a-4b4e-9b4f-6c1d3f7a2b10",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newMaintenanceWindowsRecordingHandler(t)
+			windows, teardown := createDeploymentMaintenanceWindows(t, handler, tt.now)
+			defer teardown()
+
+			windows.deleteExpired(context.TODO())
+
+			assert.EqualValues(t, tt.expectedRequests, handler.requests)
+		})
+	}
+}
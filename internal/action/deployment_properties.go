@@ -0,0 +1,64 @@
+package action
+
+import (
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+)
+
+const (
+	imagesKey               = "Images"
+	gitCommitKey            = "GitCommit"
+	gitRemoteURLKey         = "GitRemoteURL"
+	helmChartVersionKey     = "HelmChartVersion"
+	deploymentURIsLocalKey  = "DeploymentURIsLocal"
+	deploymentURIsPublicKey = "DeploymentURIsPublic"
+)
+
+// gitRemoteURLLabel is the label that may be used to specify the Git remote URL of a deployment.
+const gitRemoteURLLabel = "gitRemoteURL"
+
+// deploymentProperties are the details of a deployment added to Dynatrace deployment events as custom properties.
+type deploymentProperties struct {
+	imagesAndTags        []common.ImageAndTag
+	gitCommit            string
+	gitRemoteURL         string
+	helmChartVersion     string
+	deploymentURIsLocal  []string
+	deploymentURIsPublic []string
+}
+
+func newDeploymentProperties(event DeploymentFinishedAdapterInterface, deploymentConfiguration keptn.DeploymentConfiguration) deploymentProperties {
+	return deploymentProperties{
+		imagesAndTags:        deploymentConfiguration.ImagesAndTags,
+		gitCommit:            event.GetGitCommit(),
+		gitRemoteURL:         getValueFromLabels(event, gitRemoteURLLabel, ""),
+		helmChartVersion:     deploymentConfiguration.HelmChartVersion,
+		deploymentURIsLocal:  event.GetDeploymentURIsLocal(),
+		deploymentURIsPublic: event.GetDeploymentURIsPublic(),
+	}
+}
+
+// addTo adds all available deployment properties to the custom properties.
+func (p deploymentProperties) addTo(customProperties map[string]string) {
+	if len(p.imagesAndTags) > 0 {
+		images := make([]string, 0, len(p.imagesAndTags))
+		for _, imageAndTag := range p.imagesAndTags {
+			images = append(images, imageAndTag.Image()+":"+imageAndTag.Tag())
+		}
+		customProperties[imagesKey] = strings.Join(images, ", ")
+	}
+
+	addIfNotEmpty(customProperties, gitCommitKey, p.gitCommit)
+	addIfNotEmpty(customProperties, gitRemoteURLKey, p.gitRemoteURL)
+	addIfNotEmpty(customProperties, helmChartVersionKey, p.helmChartVersion)
+	addIfNotEmpty(customProperties, deploymentURIsLocalKey, strings.Join(p.deploymentURIsLocal, ", "))
+	addIfNotEmpty(customProperties, deploymentURIsPublicKey, strings.Join(p.deploymentURIsPublic, ", "))
+}
+
+func addIfNotEmpty(customProperties map[string]string, key string, value string) {
+	if value != "" {
+		customProperties[key] = value
+	}
+}
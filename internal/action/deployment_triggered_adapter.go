@@ -0,0 +1,83 @@
+package action
+
+import (
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+type DeploymentTriggeredAdapterInterface interface {
+	adapter.EventContentAdapter
+}
+
+// DeploymentTriggeredAdapter is a content adaptor for events of type sh.keptn.event.deployment.triggered
+type DeploymentTriggeredAdapter struct {
+	event      keptnv2.DeploymentTriggeredEventData
+	cloudEvent adapter.CloudEventAdapter
+}
+
+// NewDeploymentTriggeredAdapterFromEvent creates a new DeploymentTriggeredAdapter from a cloudevents Event
+func NewDeploymentTriggeredAdapterFromEvent(e cloudevents.Event) (*DeploymentTriggeredAdapter, error) {
+	ceAdapter := adapter.NewCloudEventAdapter(e)
+
+	dtData := &keptnv2.DeploymentTriggeredEventData{}
+	err := ceAdapter.PayloadAs(dtData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeploymentTriggeredAdapter{
+		event:      *dtData,
+		cloudEvent: ceAdapter,
+	}, nil
+}
+
+// GetShKeptnContext returns the shkeptncontext
+func (a DeploymentTriggeredAdapter) GetShKeptnContext() string {
+	return a.cloudEvent.GetShKeptnContext()
+}
+
+// GetSource returns the source specified in the CloudEvent context
+func (a DeploymentTriggeredAdapter) GetSource() string {
+	return a.cloudEvent.GetSource()
+}
+
+// GetEvent returns the event type
+func (a DeploymentTriggeredAdapter) GetEvent() string {
+	return keptnv2.GetTriggeredEventType(keptnv2.DeploymentTaskName)
+}
+
+// GetProject returns the project
+func (a DeploymentTriggeredAdapter) GetProject() string {
+	return a.event.Project
+}
+
+// GetStage returns the stage
+func (a DeploymentTriggeredAdapter) GetStage() string {
+	return a.event.Stage
+}
+
+// GetService returns the service
+func (a DeploymentTriggeredAdapter) GetService() string {
+	return a.event.Service
+}
+
+// GetDeployment returns the name of the deployment
+func (a DeploymentTriggeredAdapter) GetDeployment() string {
+	return ""
+}
+
+// GetTestStrategy returns the used test strategy
+func (a DeploymentTriggeredAdapter) GetTestStrategy() string {
+	return ""
+}
+
+// GetDeploymentStrategy returns the used deployment strategy
+func (a DeploymentTriggeredAdapter) GetDeploymentStrategy() string {
+	return a.event.Deployment.DeploymentStrategy
+}
+
+// GetLabels returns a map of labels
+func (a DeploymentTriggeredAdapter) GetLabels() map[string]string {
+	return a.event.Labels
+}
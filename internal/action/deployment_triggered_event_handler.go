@@ -0,0 +1,42 @@
+package action
+
+import (
+	"context"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	log "github.com/sirupsen/logrus"
+)
+
+// DeploymentTriggeredEventHandler handles a deployment triggered event.
+type DeploymentTriggeredEventHandler struct {
+	event                   DeploymentTriggeredAdapterInterface
+	dtClient                dynatrace.ClientInterface
+	attachRules             *dynatrace.AttachRules
+	maintenanceWindowConfig *config.DeploymentMaintenanceWindowConfig
+}
+
+// NewDeploymentTriggeredEventHandler creates a new DeploymentTriggeredEventHandler.
+func NewDeploymentTriggeredEventHandler(event DeploymentTriggeredAdapterInterface, dtClient dynatrace.ClientInterface, attachRules *dynatrace.AttachRules, maintenanceWindowConfig *config.DeploymentMaintenanceWindowConfig) *DeploymentTriggeredEventHandler {
+	return &DeploymentTriggeredEventHandler{
+		event:                   event,
+		dtClient:                dtClient,
+		attachRules:             attachRules,
+		maintenanceWindowConfig: maintenanceWindowConfig,
+	}
+}
+
+// HandleEvent handles a deployment triggered event by opening a maintenance window, if configured to do so.
+func (eh *DeploymentTriggeredEventHandler) HandleEvent(workCtx context.Context, replyCtx context.Context) error {
+	if !eh.maintenanceWindowConfig.IsEnabled() {
+		log.Debug("Deployment maintenance windows are not enabled")
+		return nil
+	}
+
+	err := newDeploymentMaintenanceWindows(eh.dtClient, eh.maintenanceWindowConfig).open(workCtx, eh.event, *eh.attachRules)
+	if err != nil {
+		log.WithError(err).Error("Could not open maintenance window for deployment")
+	}
+
+	return nil
+}
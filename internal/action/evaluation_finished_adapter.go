@@ -5,6 +5,7 @@ import (
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn/go-utils/pkg/api/models"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 )
 
@@ -13,6 +14,7 @@ type EvaluationFinishedAdapterInterface interface {
 
 	GetEvaluationScore() float64
 	GetResult() keptnv2.ResultType
+	GetIndicatorResults() []*keptnv2.SLIEvaluationResult
 }
 
 // EvaluationFinishedAdapter is a content adaptor for events of type sh.keptn.event.evaluation.finished
@@ -102,3 +104,13 @@ func (a EvaluationFinishedAdapter) GetEvaluationScore() float64 {
 func (a EvaluationFinishedAdapter) GetResult() keptnv2.ResultType {
 	return a.event.Result
 }
+
+// GetIndicatorResults returns the results of the individual SLIs
+func (a EvaluationFinishedAdapter) GetIndicatorResults() []*keptnv2.SLIEvaluationResult {
+	return a.event.Evaluation.IndicatorResults
+}
+
+// ToKeptnEvent returns the underlying event as a Keptn event
+func (a EvaluationFinishedAdapter) ToKeptnEvent() (*models.KeptnContextExtendedCE, error) {
+	return a.cloudEvent.ToKeptnEvent()
+}
@@ -3,8 +3,11 @@ package action
 import (
 	"context"
 	"fmt"
+	"strconv"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	log "github.com/sirupsen/logrus"
@@ -12,19 +15,21 @@ import (
 
 // EvaluationFinishedEventHandler handles an evaluation finished event.
 type EvaluationFinishedEventHandler struct {
-	event       EvaluationFinishedAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                 EvaluationFinishedAdapterInterface
+	dtClient              dynatrace.ClientInterface
+	eClient               keptn.EventClientInterface
+	attachRules           *dynatrace.AttachRules
+	attachRulesValidation *config.AttachRulesValidationConfig
 }
 
 // NewEvaluationFinishedEventHandler creates a new EvaluationFinishedEventHandler.
-func NewEvaluationFinishedEventHandler(event EvaluationFinishedAdapterInterface, client dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *EvaluationFinishedEventHandler {
+func NewEvaluationFinishedEventHandler(event EvaluationFinishedAdapterInterface, client dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig) *EvaluationFinishedEventHandler {
 	return &EvaluationFinishedEventHandler{
-		event:       event,
-		dtClient:    client,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                 event,
+		dtClient:              client,
+		eClient:               eClient,
+		attachRules:           attachRules,
+		attachRulesValidation: attachRulesValidation,
 	}
 }
 
@@ -37,12 +42,18 @@ func (eh *EvaluationFinishedEventHandler) HandleEvent(workCtx context.Context, r
 	}
 
 	bridgeURL := keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)
+	imageAndTag := eh.eClient.GetImageAndTag(eh.event)
+	customProperties := createCustomProperties(eh.event, imageAndTag, bridgeURL)
 
 	if isPartOfRemediation {
 		pid, err := eh.eClient.FindProblemID(eh.event)
 		if err == nil && pid != "" {
 			comment := fmt.Sprintf("[Keptn remediation evaluation](%s) resulted in %s (%.2f/100)", bridgeURL, eh.event.GetResult(), eh.event.GetEvaluationScore())
-			dynatrace.NewProblemsClient(eh.dtClient).AddProblemComment(workCtx, pid, comment)
+			newRemediationCommenter(eh.dtClient, eh.eClient).comment(workCtx, eh.event, pid, bridgeURL, comment)
+
+			if eh.isRemediationSuccessful() && env.IsProblemClosingAfterSuccessfulRemediationEnabled() {
+				customProperties[problemClosedKey] = strconv.FormatBool(eh.closeProblem(workCtx, pid, bridgeURL))
+			}
 		}
 	}
 
@@ -51,23 +62,47 @@ func (eh *EvaluationFinishedEventHandler) HandleEvent(workCtx context.Context, r
 		Source:           eventSource,
 		Title:            eh.getTitle(isPartOfRemediation),
 		Description:      fmt.Sprintf("Quality Gate Result in stage %s: %s (%.2f/100)", eh.event.GetStage(), eh.event.GetResult(), eh.event.GetEvaluationScore()),
-		CustomProperties: createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), bridgeURL),
-		AttachRules:      *eh.attachRules,
+		CustomProperties: customProperties,
+		AttachRules:      newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties),
 	}
 
 	dynatrace.NewEventsClient(eh.dtClient).AddInfoEvent(workCtx, infoEvent)
 
+	if env.IsQualityGateMetricsSendingEnabled() {
+		err = dynatrace.NewMetricsIngestClient(eh.dtClient).Ingest(workCtx, createQualityGateMetricLines(eh.event, imageAndTag))
+		if err != nil {
+			log.WithError(err).Error("Could not send quality gate metrics to Dynatrace")
+		}
+	}
+
 	return nil
 }
 
+// closeProblem closes the Dynatrace problem with the specified PID and returns whether this was successful.
+func (eh *EvaluationFinishedEventHandler) closeProblem(ctx context.Context, pid string, bridgeURL string) bool {
+	closingComment := fmt.Sprintf("Problem closed after successful [Keptn remediation](%s)", bridgeURL)
+	err := dynatrace.NewProblemsV2Client(eh.dtClient).Close(ctx, pid, closingComment)
+	if err != nil {
+		log.WithError(err).WithField("PID", pid).Error("Could not close problem")
+		return false
+	}
+
+	log.WithField("PID", pid).Info("Closed problem after successful remediation")
+	return true
+}
+
 func (eh *EvaluationFinishedEventHandler) getTitle(isPartOfRemediation bool) string {
 	if !isPartOfRemediation {
 		return fmt.Sprintf("Evaluation result: %s", eh.event.GetResult())
 	}
 
-	if eh.event.GetResult() == keptnv2.ResultPass || eh.event.GetResult() == keptnv2.ResultWarning {
+	if eh.isRemediationSuccessful() {
 		return "Remediation action successful"
 	}
 
 	return "Remediation action not successful"
 }
+
+func (eh *EvaluationFinishedEventHandler) isRemediationSuccessful() bool {
+	return eh.event.GetResult() == keptnv2.ResultPass || eh.event.GetResult() == keptnv2.ResultWarning
+}
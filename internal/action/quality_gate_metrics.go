@@ -0,0 +1,49 @@
+package action
+
+import (
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+const (
+	qualityGateScoreMetricKey     = "keptn.quality_gate.score"
+	qualityGateResultMetricKey    = "keptn.quality_gate.result"
+	qualityGateSLIValueMetricKey  = "keptn.quality_gate.sli.value"
+	qualityGateSLITargetMetricKey = "keptn.quality_gate.sli.target"
+)
+
+// createQualityGateMetricLines creates metric lines for the overall score and result of an evaluation as well as the values and targets of its individual SLIs.
+func createQualityGateMetricLines(event EvaluationFinishedAdapterInterface, imageAndTag common.ImageAndTag) []*dynatrace.MetricLine {
+	newLine := func(key string, value float64) *dynatrace.MetricLine {
+		return dynatrace.NewMetricLine(key, value).
+			AddDimension("project", event.GetProject()).
+			AddDimension("stage", event.GetStage()).
+			AddDimension("service", event.GetService()).
+			AddDimension("version", imageAndTag.Tag())
+	}
+
+	lines := []*dynatrace.MetricLine{
+		newLine(qualityGateScoreMetricKey, event.GetEvaluationScore()),
+		newLine(qualityGateResultMetricKey, 1).AddDimension("result", string(event.GetResult())),
+	}
+
+	for _, indicatorResult := range event.GetIndicatorResults() {
+		// SLIs that could not be retrieved have no meaningful value
+		if indicatorResult == nil || indicatorResult.Value == nil || !indicatorResult.Value.Success {
+			continue
+		}
+
+		sli := indicatorResult.Value.Metric
+		lines = append(lines, newLine(qualityGateSLIValueMetricKey, indicatorResult.Value.Value).AddDimension("sli", sli))
+
+		for _, target := range indicatorResult.PassTargets {
+			lines = append(lines, newLine(qualityGateSLITargetMetricKey, target.TargetValue).AddDimension("sli", sli).AddDimension("criteria", target.Criteria).AddDimension("target_type", "pass"))
+		}
+
+		for _, target := range indicatorResult.WarningTargets {
+			lines = append(lines, newLine(qualityGateSLITargetMetricKey, target.TargetValue).AddDimension("sli", sli).AddDimension("criteria", target.Criteria).AddDimension("target_type", "warning"))
+		}
+	}
+
+	return lines
+}
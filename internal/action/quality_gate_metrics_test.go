@@ -0,0 +1,124 @@
+package action
+
+import (
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+type evaluationFinishedAdapterMock struct {
+	test.EventData
+	score            float64
+	result           keptnv2.ResultType
+	indicatorResults []*keptnv2.SLIEvaluationResult
+}
+
+// GetEvaluationScore returns the evaluation score
+func (m *evaluationFinishedAdapterMock) GetEvaluationScore() float64 {
+	return m.score
+}
+
+// GetResult returns the result of the evaluation
+func (m *evaluationFinishedAdapterMock) GetResult() keptnv2.ResultType {
+	return m.result
+}
+
+// GetIndicatorResults returns the SLI evaluation results
+func (m *evaluationFinishedAdapterMock) GetIndicatorResults() []*keptnv2.SLIEvaluationResult {
+	return m.indicatorResults
+}
+
+func Test_createQualityGateMetricLines(t *testing.T) {
+	eventData := test.EventData{
+		Project: "sockshop",
+		Stage:   "staging",
+		Service: "carts",
+	}
+
+	responseTimeResult := &keptnv2.SLIEvaluationResult{
+		Value: &keptnv2.SLIResult{Metric: "response_time_p95", Value: 212.5, Success: true},
+		PassTargets: []*keptnv2.SLITarget{
+			{Criteria: "<=+10%", TargetValue: 220},
+			{Criteria: "<600", TargetValue: 600},
+		},
+		WarningTargets: []*keptnv2.SLITarget{
+			{Criteria: "<=800", TargetValue: 800},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		event         *evaluationFinishedAdapterMock
+		imageAndTag   common.ImageAndTag
+		expectedLines []string
+	}{
+		{
+			name: "pass with targets",
+			event: &evaluationFinishedAdapterMock{
+				EventData:        eventData,
+				score:            100,
+				result:           keptnv2.ResultPass,
+				indicatorResults: []*keptnv2.SLIEvaluationResult{responseTimeResult},
+			},
+			imageAndTag: common.NewImageAndTag("docker.io/keptnexamples/carts", "0.13.1"),
+			expectedLines: []string{
+				`keptn.quality_gate.score,project="sockshop",stage="staging",service="carts",version="0.13.1" 100`,
+				`keptn.quality_gate.result,project="sockshop",stage="staging",service="carts",version="0.13.1",result="pass" 1`,
+				`keptn.quality_gate.sli.value,project="sockshop",stage="staging",service="carts",version="0.13.1",sli="response_time_p95" 212.5`,
+				`keptn.quality_gate.sli.target,project="sockshop",stage="staging",service="carts",version="0.13.1",sli="response_time_p95",criteria="<=+10%",target_type="pass" 220`,
+				`keptn.quality_gate.sli.target,project="sockshop",stage="staging",service="carts",version="0.13.1",sli="response_time_p95",criteria="<600",target_type="pass" 600`,
+				`keptn.quality_gate.sli.target,project="sockshop",stage="staging",service="carts",version="0.13.1",sli="response_time_p95",criteria="<=800",target_type="warning" 800`,
+			},
+		},
+		{
+			name: "warning without targets",
+			event: &evaluationFinishedAdapterMock{
+				EventData: eventData,
+				score:     75.5,
+				result:    keptnv2.ResultWarning,
+				indicatorResults: []*keptnv2.SLIEvaluationResult{
+					{Value: &keptnv2.SLIResult{Metric: "error_rate", Value: 0.02, Success: true}},
+				},
+			},
+			imageAndTag: common.NewImageAndTag("docker.io/keptnexamples/carts", "0.13.2"),
+			expectedLines: []string{
+				`keptn.quality_gate.score,project="sockshop",stage="staging",service="carts",version="0.13.2" 75.5`,
+				`keptn.quality_gate.result,project="sockshop",stage="staging",service="carts",version="0.13.2",result="warning" 1`,
+				`keptn.quality_gate.sli.value,project="sockshop",stage="staging",service="carts",version="0.13.2",sli="error_rate" 0.02`,
+			},
+		},
+		{
+			name: "fail with missing image and tag and SLIs that could not be retrieved",
+			event: &evaluationFinishedAdapterMock{
+				EventData: eventData,
+				score:     0,
+				result:    keptnv2.ResultFailed,
+				indicatorResults: []*keptnv2.SLIEvaluationResult{
+					nil,
+					{Value: nil},
+					{Value: &keptnv2.SLIResult{Metric: "throughput", Success: false, Message: "could not retrieve SLI"}},
+				},
+			},
+			imageAndTag: common.NewNotAvailableImageAndTag(),
+			expectedLines: []string{
+				`keptn.quality_gate.score,project="sockshop",stage="staging",service="carts",version="n/a" 0`,
+				`keptn.quality_gate.result,project="sockshop",stage="staging",service="carts",version="n/a",result="fail" 1`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := createQualityGateMetricLines(tt.event, tt.imageAndTag)
+
+			actualLines := make([]string, 0, len(lines))
+			for _, line := range lines {
+				actualLines = append(actualLines, line.String())
+			}
+			assert.EqualValues(t, tt.expectedLines, actualLines)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	keptnevents "github.com/keptn/go-utils/pkg/lib"
@@ -12,19 +13,21 @@ import (
 )
 
 type ReleaseTriggeredEventHandler struct {
-	event       ReleaseTriggeredAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                 ReleaseTriggeredAdapterInterface
+	dtClient              dynatrace.ClientInterface
+	eClient               keptn.EventClientInterface
+	attachRules           *dynatrace.AttachRules
+	attachRulesValidation *config.AttachRulesValidationConfig
 }
 
 // NewReleaseTriggeredEventHandler creates a new ReleaseTriggeredEventHandler
-func NewReleaseTriggeredEventHandler(event ReleaseTriggeredAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *ReleaseTriggeredEventHandler {
+func NewReleaseTriggeredEventHandler(event ReleaseTriggeredAdapterInterface, dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig) *ReleaseTriggeredEventHandler {
 	return &ReleaseTriggeredEventHandler{
-		event:       event,
-		dtClient:    dtClient,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                 event,
+		dtClient:              dtClient,
+		eClient:               eClient,
+		attachRules:           attachRules,
+		attachRulesValidation: attachRulesValidation,
 	}
 }
 
@@ -36,13 +39,14 @@ func (eh *ReleaseTriggeredEventHandler) HandleEvent(workCtx context.Context, rep
 		return err
 	}
 
+	customProperties := createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event))
 	infoEvent := dynatrace.InfoEvent{
 		EventType:        dynatrace.InfoEventType,
 		Source:           eventSource,
 		Title:            eh.getTitle(strategy, eh.event.GetLabels()["title"]),
 		Description:      eh.getTitle(strategy, eh.event.GetLabels()["description"]),
-		CustomProperties: createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)),
-		AttachRules:      *eh.attachRules,
+		CustomProperties: customProperties,
+		AttachRules:      newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties),
 	}
 
 	dynatrace.NewEventsClient(eh.dtClient).AddInfoEvent(workCtx, infoEvent)
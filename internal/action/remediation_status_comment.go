@@ -0,0 +1,224 @@
+package action
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	log "github.com/sirupsen/logrus"
+)
+
+// remediationStatusCommentContext is the context of the consolidated remediation status comment, used to tell it apart from other comments.
+const remediationStatusCommentContext = "keptn-remediation-status"
+
+const remediationStatusTimeLayout = "2006-01-02 15:04:05 UTC"
+
+// remediationStatusCommentLocks holds a mutex for each Keptn context, as events of the same remediation may be handled concurrently.
+var remediationStatusCommentLocks sync.Map
+
+// lockRemediationStatusComment locks the remediation status comment of the Keptn context and returns the function unlocking it.
+func lockRemediationStatusComment(keptnContext string) func() {
+	value, _ := remediationStatusCommentLocks.LoadOrStore(keptnContext, &sync.Mutex{})
+	mutex := value.(*sync.Mutex)
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// deleteRemediationStatusCommentLock deletes the mutex of the Keptn context, so that the locks of finished remediations do not accumulate.
+func deleteRemediationStatusCommentLock(keptnContext string) {
+	remediationStatusCommentLocks.Delete(keptnContext)
+}
+
+// remediationCommenter comments on the Dynatrace problem associated with a remediation sequence.
+type remediationCommenter struct {
+	dtClient dynatrace.ClientInterface
+	eClient  keptn.EventClientInterface
+}
+
+func newRemediationCommenter(dtClient dynatrace.ClientInterface, eClient keptn.EventClientInterface) *remediationCommenter {
+	return &remediationCommenter{
+		dtClient: dtClient,
+		eClient:  eClient,
+	}
+}
+
+// comment adds the specified comment to the problem with the specified PID.
+// If consolidated remediation comments are enabled, the remediation status comment of the Keptn context is created or updated instead.
+// Should this fail, e.g. because comments cannot be edited, the specified comment is added including the Keptn context as correlation ID.
+func (c *remediationCommenter) comment(ctx context.Context, event adapter.EventContentAdapter, pid string, bridgeURL string, comment string) {
+	if !env.IsRemediationStatusCommentEnabled() {
+		dynatrace.NewProblemsClient(c.dtClient).AddProblemComment(ctx, pid, comment)
+		return
+	}
+
+	err := c.updateRemediationStatusComment(ctx, event, pid, bridgeURL)
+	if err == nil {
+		return
+	}
+
+	log.WithError(err).WithField("PID", pid).Warn("Could not update remediation status comment, adding a separate comment instead")
+	dynatrace.NewProblemsClient(c.dtClient).AddProblemComment(ctx, pid, comment+"\n"+formatCorrelationID(event.GetShKeptnContext()))
+}
+
+// updateRemediationStatusComment replaces the remediation status comment of the Keptn context with the current timeline of the remediation or adds it if there is none.
+// Updates for the same Keptn context are serialized. As events may also be handled by other instances of the dynatrace-service, duplicate comments are removed after adding one.
+func (c *remediationCommenter) updateRemediationStatusComment(ctx context.Context, event adapter.EventContentAdapter, pid string, bridgeURL string) error {
+	unlock := lockRemediationStatusComment(event.GetShKeptnContext())
+	defer unlock()
+
+	timeline, err := c.eClient.GetRemediationTimeline(event)
+	if err != nil {
+		return err
+	}
+
+	// no further updates are expected once the remediation has finished; deferred calls run in reverse order, so the lock is deleted before it is released
+	if timeline.GetVerdict() != keptn.RemediationVerdictInProgress {
+		defer deleteRemediationStatusCommentLock(event.GetShKeptnContext())
+	}
+
+	problemsClient := dynatrace.NewProblemsV2Client(c.dtClient)
+	comments, err := problemsClient.GetComments(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("could not retrieve comments of problem: %w", err)
+	}
+
+	message := formatRemediationStatusComment(*timeline, bridgeURL)
+	correlationID := formatCorrelationID(timeline.KeptnContext)
+	statusComments := getRemediationStatusComments(comments, correlationID)
+	if len(statusComments) > 0 {
+		log.WithFields(log.Fields{"PID": pid, "commentID": statusComments[0].ID}).Info("Updating remediation status comment")
+		return problemsClient.UpdateComment(ctx, pid, statusComments[0].ID, message, remediationStatusCommentContext)
+	}
+
+	log.WithField("PID", pid).Info("Adding remediation status comment")
+	err = problemsClient.AddComment(ctx, pid, message, remediationStatusCommentContext)
+	if err != nil {
+		return err
+	}
+
+	// the comment was added, so returning an error here would cause a second fallback comment to be added
+	err = removeDuplicateRemediationStatusComments(ctx, problemsClient, pid, correlationID, message)
+	if err != nil {
+		log.WithError(err).WithField("PID", pid).Error("Could not remove duplicate remediation status comments")
+	}
+	return nil
+}
+
+// removeDuplicateRemediationStatusComments keeps the oldest remediation status comment with the correlation ID, updated with the message, and deletes any others.
+func removeDuplicateRemediationStatusComments(ctx context.Context, problemsClient *dynatrace.ProblemsV2Client, pid string, correlationID string, message string) error {
+	comments, err := problemsClient.GetComments(ctx, pid)
+	if err != nil {
+		return fmt.Errorf("could not retrieve comments of problem: %w", err)
+	}
+
+	statusComments := getRemediationStatusComments(comments, correlationID)
+	if len(statusComments) <= 1 {
+		return nil
+	}
+
+	log.WithFields(log.Fields{"PID": pid, "count": len(statusComments)}).Info("Removing duplicate remediation status comments")
+	for _, comment := range statusComments[1:] {
+		err = problemsClient.DeleteComment(ctx, pid, comment.ID)
+		if err != nil {
+			return fmt.Errorf("could not delete duplicate remediation status comment: %w", err)
+		}
+	}
+	return problemsClient.UpdateComment(ctx, pid, statusComments[0].ID, message, remediationStatusCommentContext)
+}
+
+// getRemediationStatusComments returns the remediation status comments with the correlation ID, oldest first.
+func getRemediationStatusComments(comments []dynatrace.ProblemComment, correlationID string) []dynatrace.ProblemComment {
+	var statusComments []dynatrace.ProblemComment
+	for _, comment := range comments {
+		if comment.Context == remediationStatusCommentContext && strings.Contains(comment.Content, correlationID) {
+			statusComments = append(statusComments, comment)
+		}
+	}
+
+	sort.SliceStable(statusComments, func(i, j int) bool {
+		if statusComments[i].CreatedAtTimestamp != statusComments[j].CreatedAtTimestamp {
+			return statusComments[i].CreatedAtTimestamp < statusComments[j].CreatedAtTimestamp
+		}
+		return statusComments[i].ID < statusComments[j].ID
+	})
+	return statusComments
+}
+
+// formatRemediationStatusComment formats the timeline of a remediation as Markdown, listing each action followed by the evaluations and the Keptn context as correlation ID.
+func formatRemediationStatusComment(timeline keptn.RemediationTimeline, bridgeURL string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("**[Keptn remediation](%s): %s**\n", bridgeURL, timeline.GetVerdict()))
+
+	if len(timeline.Actions) > 0 {
+		sb.WriteString("\nActions:\n")
+	}
+	for i, action := range timeline.Actions {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, formatRemediationAction(action)))
+	}
+
+	if len(timeline.Evaluations) > 0 {
+		sb.WriteString("\nEvaluations:\n")
+	}
+	for _, evaluation := range timeline.Evaluations {
+		sb.WriteString(fmt.Sprintf("- %s (%.2f/100), finished %s\n", evaluation.Result, evaluation.Score, formatRemediationTime(evaluation.FinishedAt)))
+	}
+
+	sb.WriteString("\n" + formatCorrelationID(timeline.KeptnContext))
+	return sb.String()
+}
+
+func formatRemediationAction(action keptn.RemediationAction) string {
+	name := action.Action
+	if name == "" {
+		name = "unknown action"
+	}
+
+	text := "**" + name + "**"
+	if action.Description != "" {
+		text += " (" + action.Description + ")"
+	}
+
+	switch {
+	case action.IsFinished():
+		text += fmt.Sprintf(": %s, result %s", action.Status, action.Result)
+	case !action.StartedAt.IsZero():
+		text += ": running"
+	default:
+		text += ": pending"
+	}
+
+	steps := []string{}
+	if !action.TriggeredAt.IsZero() {
+		steps = append(steps, "triggered "+formatRemediationTime(action.TriggeredAt))
+	}
+	if !action.StartedAt.IsZero() {
+		step := "started " + formatRemediationTime(action.StartedAt)
+		if action.StartedBy != "" {
+			step += " by " + action.StartedBy
+		}
+		steps = append(steps, step)
+	}
+	if action.IsFinished() {
+		steps = append(steps, "finished "+formatRemediationTime(action.FinishedAt))
+	}
+
+	if len(steps) > 0 {
+		text += " - " + strings.Join(steps, ", ")
+	}
+	return text
+}
+
+func formatRemediationTime(t time.Time) string {
+	return t.UTC().Format(remediationStatusTimeLayout)
+}
+
+func formatCorrelationID(keptnContext string) string {
+	return "Correlation ID: " + keptnContext
+}
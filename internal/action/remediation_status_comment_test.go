@@ -0,0 +1,177 @@
+package action
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const remediationStatusCommentTestDataFolder = "./testdata/remediation_status_comment/"
+
+const remediationKeptnContext = "5d3b1e2a-0f8c-4c1a-9a7e-6b2d4f8e1c3a"
+
+const remediationPID = "-1234567890123456789_1638271000000V2"
+
+const remediationCommentsPath = "/api/v2/problems/" + remediationPID + "/comments"
+
+// remediationTimelineEventClientMock returns the timeline of the remediation, all other methods are not implemented.
+type remediationTimelineEventClientMock struct {
+	keptn.EventClientInterface
+	timeline keptn.RemediationTimeline
+}
+
+func (m *remediationTimelineEventClientMock) GetRemediationTimeline(_ adapter.EventContentAdapter) (*keptn.RemediationTimeline, error) {
+	return &m.timeline, nil
+}
+
+// remediationCommentsHandler records all requests and serves successive requests for the comments of the problem from the specified files in order.
+type remediationCommentsHandler struct {
+	t                 *testing.T
+	handler           *test.FileBasedURLHandler
+	getCommentsFiles  []string
+	getCommentsStatus []int
+	requests          []string
+}
+
+func newRemediationCommentsHandler(t *testing.T, getCommentsFiles []string, getCommentsStatus []int) *remediationCommentsHandler {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact(remediationCommentsPath, remediationStatusCommentTestDataFolder+"comment_written.json")
+	handler.AddStartsWith(remediationCommentsPath+"/", remediationStatusCommentTestDataFolder+"comment_written.json")
+
+	return &remediationCommentsHandler{
+		t:                 t,
+		handler:           handler,
+		getCommentsFiles:  getCommentsFiles,
+		getCommentsStatus: getCommentsStatus,
+	}
+}
+
+func (h *remediationCommentsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests = append(h.requests, r.Method+" "+r.URL.Path)
+	if r.Method != http.MethodGet {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if len(h.getCommentsFiles) == 0 {
+		h.t.Fatalf("unexpected request for comments: %s", r.URL.String())
+	}
+
+	handler := test.NewFileBasedURLHandler(h.t)
+	handler.AddExactError(remediationCommentsPath+"?pageSize=500", h.getCommentsStatus[0], h.getCommentsFiles[0])
+	h.getCommentsFiles = h.getCommentsFiles[1:]
+	h.getCommentsStatus = h.getCommentsStatus[1:]
+	handler.ServeHTTP(w, r)
+}
+
+func Test_getRemediationStatusComments(t *testing.T) {
+	correlationID := formatCorrelationID(remediationKeptnContext)
+	comments := []dynatrace.ProblemComment{
+		{ID: "3", CreatedAtTimestamp: 1638271080000, Content: "**Keptn remediation**\n\n" + correlationID, Context: remediationStatusCommentContext},
+		{ID: "1", CreatedAtTimestamp: 1638271020000, Content: "Restarted pod\n" + correlationID, Context: "keptn-remediation"},
+		{ID: "2", CreatedAtTimestamp: 1638271020000, Content: "**Keptn remediation**\n\n" + correlationID, Context: remediationStatusCommentContext},
+		{ID: "4", CreatedAtTimestamp: 1638271000000, Content: "**Keptn remediation**\n\n" + formatCorrelationID("other-context"), Context: remediationStatusCommentContext},
+	}
+
+	statusComments := getRemediationStatusComments(comments, correlationID)
+	if assert.Len(t, statusComments, 2) {
+		assert.Equal(t, "2", statusComments[0].ID)
+		assert.Equal(t, "3", statusComments[1].ID)
+	}
+
+	assert.Empty(t, getRemediationStatusComments(nil, correlationID))
+}
+
+// Test_remediationCommenter_updateRemediationStatusComment tests that the remediation status comment is added or updated,
+// that a failure to remove duplicates after adding it is not reported as an error, and that the lock is deleted once the remediation has finished.
+func Test_remediationCommenter_updateRemediationStatusComment(t *testing.T) {
+	inProgressTimeline := keptn.RemediationTimeline{
+		KeptnContext: remediationKeptnContext,
+		Actions: []keptn.RemediationAction{
+			{Action: "scaling", TriggeredAt: time.Date(2021, 11, 30, 11, 17, 0, 0, time.UTC)},
+		},
+	}
+
+	finishedTimeline := keptn.RemediationTimeline{
+		KeptnContext: remediationKeptnContext,
+		Actions: []keptn.RemediationAction{
+			{
+				Action:      "scaling",
+				TriggeredAt: time.Date(2021, 11, 30, 11, 17, 0, 0, time.UTC),
+				FinishedAt:  time.Date(2021, 11, 30, 11, 18, 0, 0, time.UTC),
+				Status:      keptnv2.StatusSucceeded,
+				Result:      keptnv2.ResultPass,
+			},
+		},
+		Evaluations: []keptn.RemediationEvaluation{
+			{FinishedAt: time.Date(2021, 11, 30, 11, 20, 0, 0, time.UTC), Result: keptnv2.ResultPass, Score: 100},
+		},
+	}
+
+	tests := []struct {
+		name              string
+		timeline          keptn.RemediationTimeline
+		getCommentsFiles  []string
+		getCommentsStatus []int
+		expectedRequests  []string
+		expectLock        bool
+	}{
+		{
+			name:              "remediation in progress, status comment is added",
+			timeline:          inProgressTimeline,
+			getCommentsFiles:  []string{remediationStatusCommentTestDataFolder + "comments_empty.json", remediationStatusCommentTestDataFolder + "comments_empty.json"},
+			getCommentsStatus: []int{http.StatusOK, http.StatusOK},
+			expectedRequests:  []string{"GET " + remediationCommentsPath, "POST " + remediationCommentsPath, "GET " + remediationCommentsPath},
+			expectLock:        true,
+		},
+		{
+			name:              "remediation finished, status comment is updated",
+			timeline:          finishedTimeline,
+			getCommentsFiles:  []string{remediationStatusCommentTestDataFolder + "comments_status_comment.json"},
+			getCommentsStatus: []int{http.StatusOK},
+			expectedRequests:  []string{"GET " + remediationCommentsPath, "PUT " + remediationCommentsPath + "/7843212345678901235"},
+			expectLock:        false,
+		},
+		{
+			name:              "status comment is added, but duplicates cannot be removed",
+			timeline:          finishedTimeline,
+			getCommentsFiles:  []string{remediationStatusCommentTestDataFolder + "comments_empty.json", remediationStatusCommentTestDataFolder + "comments_error.json"},
+			getCommentsStatus: []int{http.StatusOK, http.StatusInternalServerError},
+			expectedRequests:  []string{"GET " + remediationCommentsPath, "POST " + remediationCommentsPath, "GET " + remediationCommentsPath},
+			expectLock:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleteRemediationStatusCommentLock(remediationKeptnContext)
+			defer deleteRemediationStatusCommentLock(remediationKeptnContext)
+
+			handler := newRemediationCommentsHandler(t, tt.getCommentsFiles, tt.getCommentsStatus)
+			httpClient, url, teardown := test.CreateHTTPSClient(handler)
+			defer teardown()
+
+			dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			commenter := newRemediationCommenter(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), &remediationTimelineEventClientMock{timeline: tt.timeline})
+			err = commenter.updateRemediationStatusComment(context.TODO(), &test.EventData{Context: remediationKeptnContext}, remediationPID, "https://bridge.example.com")
+			assert.NoError(t, err)
+			assert.EqualValues(t, tt.expectedRequests, handler.requests)
+
+			_, found := remediationStatusCommentLocks.Load(remediationKeptnContext)
+			assert.Equal(t, tt.expectLock, found)
+		})
+	}
+}
@@ -3,36 +3,40 @@ package action
 import (
 	"context"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 )
 
 type TestFinishedEventHandler struct {
-	event       TestFinishedAdapterInterface
-	dtClient    dynatrace.ClientInterface
-	eClient     keptn.EventClientInterface
-	attachRules *dynatrace.AttachRules
+	event                 TestFinishedAdapterInterface
+	dtClient              dynatrace.ClientInterface
+	eClient               keptn.EventClientInterface
+	attachRules           *dynatrace.AttachRules
+	attachRulesValidation *config.AttachRulesValidationConfig
 }
 
 // NewTestFinishedEventHandler creates a new TestFinishedEventHandler
-func NewTestFinishedEventHandler(event TestFinishedAdapterInterface, client dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules) *TestFinishedEventHandler {
+func NewTestFinishedEventHandler(event TestFinishedAdapterInterface, client dynatrace.ClientInterface, eClient keptn.EventClientInterface, attachRules *dynatrace.AttachRules, attachRulesValidation *config.AttachRulesValidationConfig) *TestFinishedEventHandler {
 	return &TestFinishedEventHandler{
-		event:       event,
-		dtClient:    client,
-		eClient:     eClient,
-		attachRules: attachRules,
+		event:                 event,
+		dtClient:              client,
+		eClient:               eClient,
+		attachRules:           attachRules,
+		attachRulesValidation: attachRulesValidation,
 	}
 }
 
 // HandleEvent handles an action finished event.
 func (eh *TestFinishedEventHandler) HandleEvent(workCtx context.Context, replyCtx context.Context) error {
+	customProperties := createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event))
 	annotationEvent := dynatrace.AnnotationEvent{
 		EventType:             dynatrace.AnnotationEventType,
 		Source:                eventSource,
 		AnnotationType:        getValueFromLabels(eh.event, "type", "Stop Tests"),
 		AnnotationDescription: getValueFromLabels(eh.event, "description", "Stop running tests: against "+eh.event.GetService()),
-		CustomProperties:      createCustomProperties(eh.event, eh.eClient.GetImageAndTag(eh.event), keptn.TryGetBridgeURLForKeptnContext(workCtx, eh.event)),
-		AttachRules:           *eh.attachRules,
+		CustomProperties:      customProperties,
+		AttachRules:           newAttachRulesValidator(eh.dtClient, eh.attachRulesValidation).validate(workCtx, *eh.attachRules, customProperties),
 	}
 
 	dynatrace.NewEventsClient(eh.dtClient).AddAnnotationEvent(workCtx, annotationEvent)
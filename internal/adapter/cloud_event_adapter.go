@@ -5,10 +5,13 @@ import (
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/types"
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	log "github.com/sirupsen/logrus"
 )
 
 const shKeptnContext = "shkeptncontext"
+const gitCommitID = "gitcommitid"
 
 type TriggeredCloudEventContentAdapter interface {
 	CloudEventContentAdapter
@@ -44,6 +47,15 @@ func (a CloudEventAdapter) GetShKeptnContext() string {
 	return context
 }
 
+// GetGitCommitID returns the Git commit ID of the Keptn configuration repository the event refers to, if available.
+func (a CloudEventAdapter) GetGitCommitID() string {
+	commitID, err := types.ToString(a.ce.Context.GetExtensions()[gitCommitID])
+	if err != nil {
+		log.WithError(err).Debug("Event does not contain " + gitCommitID)
+	}
+	return commitID
+}
+
 func (a CloudEventAdapter) GetSource() string {
 	return a.ce.Source()
 }
@@ -56,6 +68,16 @@ func (a CloudEventAdapter) GetType() string {
 	return a.ce.Type()
 }
 
+// ToKeptnEvent converts the CloudEvent into a Keptn event as returned by the Keptn API or returns an error.
+func (a CloudEventAdapter) ToKeptnEvent() (*models.KeptnContextExtendedCE, error) {
+	keptnEvent, err := keptnv2.ToKeptnEvent(a.ce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keptnEvent, nil
+}
+
 // PayloadAs attempts to populate the provided content object with the event payload. Will return an error otherwise.
 // content should be a pointer type.
 func (a CloudEventAdapter) PayloadAs(content interface{}) error {
@@ -205,6 +205,34 @@ func ParseSLOFromString(customName string) (*keptncommon.SLO, error) {
 	return result, nil
 }
 
+// FormatSLOToString formats an SLO as a tile title that can be read back by ParseSLOFromString, e.g.
+//   sli=svc_rt_p95;pass=<600,<+10%;warning=<800;weight=1;key=true
+// Each criteria object is added as a separate pass or warning value, whitespace within criteria is removed.
+func FormatSLOToString(slo *keptncommon.SLO) string {
+	nameValuePairs := []string{sloDefSli + "=" + slo.SLI}
+	for _, criteria := range slo.Pass {
+		nameValuePairs = append(nameValuePairs, sloDefPass+"="+formatSLOCriteria(criteria))
+	}
+
+	for _, criteria := range slo.Warning {
+		nameValuePairs = append(nameValuePairs, sloDefWarning+"="+formatSLOCriteria(criteria))
+	}
+
+	nameValuePairs = append(nameValuePairs,
+		sloDefWeight+"="+strconv.Itoa(slo.Weight),
+		sloDefKey+"="+strconv.FormatBool(slo.KeySLI))
+
+	return strings.Join(nameValuePairs, ";")
+}
+
+func formatSLOCriteria(criteria *keptncommon.SLOCriteria) string {
+	criteriaChunks := make([]string, 0, len(criteria.Criteria))
+	for _, criterion := range criteria.Criteria {
+		criteriaChunks = append(criteriaChunks, strings.Join(strings.Fields(criterion), ""))
+	}
+	return strings.Join(criteriaChunks, ",")
+}
+
 func parseSLOCriteriaString(criteria string) (*keptncommon.SLOCriteria, error) {
 	criteriaChunks := strings.Split(criteria, ",")
 	var invalidCriteria []string
@@ -217,3 +217,42 @@ func createSLO(indicatorName string, pass [][]string, warning [][]string, weight
 		KeySLI:  isKey,
 	}
 }
+
+func TestFormatSLOToString(t *testing.T) {
+	tests := []struct {
+		name string
+		slo  *keptnapi.SLO
+		want string
+	}{
+		{
+			name: "multiple pass and warning criteria",
+			slo:  createSLO("teststep_rt", [][]string{{">=500", ">-10%"}, {">=400", ">=-15%"}}, [][]string{{"<1000", "<+20%"}}, 2, true),
+			want: "sli=teststep_rt;pass=>=500,>-10%;pass=>=400,>=-15%;warning=<1000,<+20%;weight=2;key=true",
+		},
+		{
+			name: "criteria with whitespace",
+			slo:  createSLO("response_time_p95", [][]string{{"<= 600", "<=+10 %"}}, [][]string{}, 1, false),
+			want: "sli=response_time_p95;pass=<=600,<=+10%;weight=1;key=false",
+		},
+		{
+			name: "informational SLI only - no pass or warn",
+			slo:  createSLO("host_cpu", [][]string{}, [][]string{}, 1, false),
+			want: "sli=host_cpu;weight=1;key=false",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sloString := FormatSLOToString(tt.slo)
+			assert.Equal(t, tt.want, sloString)
+
+			got, err := ParseSLOFromString(sloString)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.slo.SLI, got.SLI)
+				assert.Equal(t, len(tt.slo.Pass), len(got.Pass))
+				assert.Equal(t, len(tt.slo.Warning), len(got.Warning))
+				assert.Equal(t, tt.slo.Weight, got.Weight)
+				assert.Equal(t, tt.slo.KeySLI, got.KeySLI)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package config
 
-import "github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+import (
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
 
 // DynatraceConfig defines the Dynatrace configuration structure
 type DynatraceConfig struct {
@@ -8,6 +12,124 @@ type DynatraceConfig struct {
 	DtCreds     string                 `json:"dtCreds,omitempty" yaml:"dtCreds,omitempty"`
 	Dashboard   string                 `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
 	AttachRules *dynatrace.AttachRules `json:"attachRules,omitempty" yaml:"attachRules,omitempty"`
+
+	AttachRulesValidation       *AttachRulesValidationConfig       `json:"attachRulesValidation,omitempty" yaml:"attachRulesValidation,omitempty"`
+	DeploymentMaintenanceWindow *DeploymentMaintenanceWindowConfig `json:"deploymentMaintenanceWindow,omitempty" yaml:"deploymentMaintenanceWindow,omitempty"`
+	ProblemFilter               *ProblemFilterConfig               `json:"problemFilter,omitempty" yaml:"problemFilter,omitempty"`
+	Naming                      *NamingConfig                      `json:"naming,omitempty" yaml:"naming,omitempty"`
+	Monitoring                  *MonitoringConfig                  `json:"monitoring,omitempty" yaml:"monitoring,omitempty"`
+	ProblemNotification         *ProblemNotificationConfig         `json:"problemNotification,omitempty" yaml:"problemNotification,omitempty"`
+}
+
+// ProblemNotificationConfig defines how the problem notification sending Dynatrace problems to Keptn is set up.
+// The payload template must contain the field "KeptnProject":"$KEPTN_PROJECT", whose placeholder is replaced by the Keptn project.
+type ProblemNotificationConfig struct {
+	AcceptAnyCertificate *bool                             `json:"acceptAnyCertificate,omitempty" yaml:"acceptAnyCertificate,omitempty"`
+	Headers              []ProblemNotificationHeaderConfig `json:"headers,omitempty" yaml:"headers,omitempty"`
+	PayloadTemplate      string                            `json:"payloadTemplate,omitempty" yaml:"payloadTemplate,omitempty"`
+	URL                  string                            `json:"url,omitempty" yaml:"url,omitempty"`
+	TestTimeoutSeconds   int                               `json:"testTimeoutSeconds,omitempty" yaml:"testTimeoutSeconds,omitempty"`
+}
+
+// ProblemNotificationHeaderConfig defines an additional HTTP header sent with the problem notification.
+type ProblemNotificationHeaderConfig struct {
+	Name   string `json:"name" yaml:"name"`
+	Value  string `json:"value" yaml:"value"`
+	Secret bool   `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// IsAnyCertificateAccepted returns true if Dynatrace should accept any certificate of the Keptn API, which is the default.
+func (c *ProblemNotificationConfig) IsAnyCertificateAccepted() bool {
+	return c == nil || c.AcceptAnyCertificate == nil || *c.AcceptAnyCertificate
+}
+
+// GetTestTimeout returns how long to wait for the test notification to arrive in Keptn, by default 30 seconds.
+func (c *ProblemNotificationConfig) GetTestTimeout() time.Duration {
+	if c == nil || c.TestTimeoutSeconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.TestTimeoutSeconds) * time.Second
+}
+
+// MonitoringConfig defines which Dynatrace objects are generated when configuring monitoring for a project or stage.
+// Unset settings fall back to those of the project or to those set via the Helm chart.
+type MonitoringConfig struct {
+	GenerateTaggingRules         *bool  `json:"generateTaggingRules,omitempty" yaml:"generateTaggingRules,omitempty"`
+	GenerateProblemNotifications *bool  `json:"generateProblemNotifications,omitempty" yaml:"generateProblemNotifications,omitempty"`
+	GenerateManagementZones      *bool  `json:"generateManagementZones,omitempty" yaml:"generateManagementZones,omitempty"`
+	GenerateDashboards           *bool  `json:"generateDashboards,omitempty" yaml:"generateDashboards,omitempty"`
+	GenerateKQGDashboards        *bool  `json:"generateKQGDashboards,omitempty" yaml:"generateKQGDashboards,omitempty"`
+	GenerateMetricEvents         *bool  `json:"generateMetricEvents,omitempty" yaml:"generateMetricEvents,omitempty"`
+	GenerateSLOs                 *bool  `json:"generateSLOs,omitempty" yaml:"generateSLOs,omitempty"`
+	GenerateTestStepMetrics      *bool  `json:"generateTestStepMetrics,omitempty" yaml:"generateTestStepMetrics,omitempty"`
+	MetricEventsBaselineModel    string `json:"metricEventsBaselineModel,omitempty" yaml:"metricEventsBaselineModel,omitempty"`
+}
+
+// NamingConfig defines Go templates for the names of the Dynatrace objects generated when configuring monitoring.
+// Empty templates fall back to those set via the Helm chart or to the default names.
+type NamingConfig struct {
+	ProjectManagementZone string `json:"projectManagementZone,omitempty" yaml:"projectManagementZone,omitempty"`
+	StageManagementZone   string `json:"stageManagementZone,omitempty" yaml:"stageManagementZone,omitempty"`
+	Dashboard             string `json:"dashboard,omitempty" yaml:"dashboard,omitempty"`
+	MetricEvent           string `json:"metricEvent,omitempty" yaml:"metricEvent,omitempty"`
+	ProblemNotification   string `json:"problemNotification,omitempty" yaml:"problemNotification,omitempty"`
+	SLO                   string `json:"slo,omitempty" yaml:"slo,omitempty"`
+}
+
+// ProblemFilterConfig defines which open problems are forwarded to Keptn to trigger remediation sequences.
+// Each non-empty criterion must be satisfied for a problem to be forwarded.
+type ProblemFilterConfig struct {
+	SeverityLevels []string `json:"severityLevels,omitempty" yaml:"severityLevels,omitempty"`
+	ImpactLevels   []string `json:"impactLevels,omitempty" yaml:"impactLevels,omitempty"`
+	EntityTypes    []string `json:"entityTypes,omitempty" yaml:"entityTypes,omitempty"`
+	Tags           []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	TitlePatterns  []string `json:"titlePatterns,omitempty" yaml:"titlePatterns,omitempty"`
+	MinAgeMinutes  int      `json:"minAgeMinutes,omitempty" yaml:"minAgeMinutes,omitempty"`
+}
+
+// GetMinAge returns the minimum age a problem must have to be forwarded.
+func (c *ProblemFilterConfig) GetMinAge() time.Duration {
+	return time.Duration(c.MinAgeMinutes) * time.Minute
+}
+
+// AttachRulesValidationConfig defines whether attach rules are checked to match entities before events are sent to Dynatrace
+type AttachRulesValidationConfig struct {
+	Enabled                bool   `json:"enabled" yaml:"enabled"`
+	FallbackEntitySelector string `json:"fallbackEntitySelector,omitempty" yaml:"fallbackEntitySelector,omitempty"`
+}
+
+// IsEnabled returns true if attach rules should be validated.
+func (c *AttachRulesValidationConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// DeploymentMaintenanceWindowConfig defines the maintenance window opened in Dynatrace while a deployment is in progress
+type DeploymentMaintenanceWindowConfig struct {
+	Enabled            bool   `json:"enabled" yaml:"enabled"`
+	ManagementZone     string `json:"managementZone,omitempty" yaml:"managementZone,omitempty"`
+	MaxDurationMinutes int    `json:"maxDurationMinutes,omitempty" yaml:"maxDurationMinutes,omitempty"`
+	Suppression        string `json:"suppression,omitempty" yaml:"suppression,omitempty"`
+}
+
+// IsEnabled returns true if a maintenance window should be opened for deployments.
+func (c *DeploymentMaintenanceWindowConfig) IsEnabled() bool {
+	return c != nil && c.Enabled
+}
+
+// GetMaxDuration returns the maximum duration of a deployment maintenance window, by default 60 minutes.
+func (c *DeploymentMaintenanceWindowConfig) GetMaxDuration() time.Duration {
+	if c.MaxDurationMinutes <= 0 {
+		return 60 * time.Minute
+	}
+	return time.Duration(c.MaxDurationMinutes) * time.Minute
+}
+
+// GetSuppression returns the suppression used for the maintenance window, by default DETECT_PROBLEMS_DONT_ALERT.
+func (c *DeploymentMaintenanceWindowConfig) GetSuppression() string {
+	if c.Suppression == "" {
+		return dynatrace.MaintenanceWindowSuppressionDetectProblemsDontAlert
+	}
+	return c.Suppression
 }
 
 // NewDynatraceConfigWithDefaults returns a new DynatraceConfig with values set to defaults
@@ -54,6 +54,61 @@ func replacePlaceholdersInDynatraceConfig(dynatraceConfig *DynatraceConfig, even
 		DtCreds:     common.ReplaceKeptnPlaceholders(dynatraceConfig.DtCreds, event),
 		Dashboard:   common.ReplaceKeptnPlaceholders(dynatraceConfig.Dashboard, event),
 		AttachRules: replacePlaceholdersInAttachRules(dynatraceConfig.AttachRules, event),
+
+		AttachRulesValidation:       replacePlaceholdersInAttachRulesValidation(dynatraceConfig.AttachRulesValidation, event),
+		DeploymentMaintenanceWindow: replacePlaceholdersInDeploymentMaintenanceWindow(dynatraceConfig.DeploymentMaintenanceWindow, event),
+		ProblemFilter:               replacePlaceholdersInProblemFilter(dynatraceConfig.ProblemFilter, event),
+
+		// naming templates use Go template syntax and are therefore not subject to placeholder replacement
+		Naming:     dynatraceConfig.Naming,
+		Monitoring: dynatraceConfig.Monitoring,
+
+		// problem notification payload templates contain placeholders replaced by Dynatrace and are therefore not subject to placeholder replacement
+		ProblemNotification: dynatraceConfig.ProblemNotification,
+	}
+}
+
+func replacePlaceholdersInProblemFilter(problemFilter *ProblemFilterConfig, event adapter.EventContentAdapter) *ProblemFilterConfig {
+	if problemFilter == nil {
+		return nil
+	}
+
+	tagsWithReplacedPlaceholders := make([]string, 0, len(problemFilter.Tags))
+	for _, tag := range problemFilter.Tags {
+		tagsWithReplacedPlaceholders = append(tagsWithReplacedPlaceholders, common.ReplaceKeptnPlaceholders(tag, event))
+	}
+
+	return &ProblemFilterConfig{
+		SeverityLevels: problemFilter.SeverityLevels,
+		ImpactLevels:   problemFilter.ImpactLevels,
+		EntityTypes:    problemFilter.EntityTypes,
+		Tags:           tagsWithReplacedPlaceholders,
+		TitlePatterns:  problemFilter.TitlePatterns,
+		MinAgeMinutes:  problemFilter.MinAgeMinutes,
+	}
+}
+
+func replacePlaceholdersInAttachRulesValidation(attachRulesValidation *AttachRulesValidationConfig, event adapter.EventContentAdapter) *AttachRulesValidationConfig {
+	if attachRulesValidation == nil {
+		return nil
+	}
+
+	return &AttachRulesValidationConfig{
+		Enabled:                attachRulesValidation.Enabled,
+		FallbackEntitySelector: common.ReplaceKeptnPlaceholders(attachRulesValidation.FallbackEntitySelector, event),
+	}
+}
+
+func replacePlaceholdersInDeploymentMaintenanceWindow(maintenanceWindow *DeploymentMaintenanceWindowConfig, event adapter.EventContentAdapter) *DeploymentMaintenanceWindowConfig {
+	if maintenanceWindow == nil {
+		return nil
+	}
+
+	return &DeploymentMaintenanceWindowConfig{
+		Enabled:            maintenanceWindow.Enabled,
+		ManagementZone:     common.ReplaceKeptnPlaceholders(maintenanceWindow.ManagementZone, event),
+		MaxDurationMinutes: maintenanceWindow.MaxDurationMinutes,
+		Suppression:        maintenanceWindow.Suppression,
 	}
 }
 
@@ -68,7 +123,8 @@ func replacePlaceholdersInAttachRules(attachRules *dynatrace.AttachRules, event
 	}
 
 	return &dynatrace.AttachRules{
-		TagRule: tagRulesWithReplacedPlaceholders,
+		EntityIDs: attachRules.EntityIDs,
+		TagRule:   tagRulesWithReplacedPlaceholders,
 	}
 }
 
@@ -232,6 +232,73 @@ dashboard: $LABEL.dashboard`,
 				AttachRules: &expectedDefaultAttachRules,
 			},
 		},
+		{
+			name: "Test with deployment maintenance window",
+			configString: `spec_version: '0.1.0'
+dtCreds: dynatrace-$PROJECT
+deploymentMaintenanceWindow:
+  enabled: true
+  managementZone: 'Keptn: $PROJECT $STAGE'
+  maxDurationMinutes: 30`,
+			wantConfig: DynatraceConfig{
+				SpecVersion: "0.1.0",
+				DtCreds:     "dynatrace-myproject",
+				AttachRules: &expectedDefaultAttachRules,
+				DeploymentMaintenanceWindow: &DeploymentMaintenanceWindowConfig{
+					Enabled:            true,
+					ManagementZone:     "Keptn: myproject mystage",
+					MaxDurationMinutes: 30,
+				},
+			},
+		},
+		{
+			name: "Test with attach rules validation",
+			configString: `spec_version: '0.1.0'
+dtCreds: dynatrace-$PROJECT
+attachRulesValidation:
+  enabled: true
+  fallbackEntitySelector: 'type("SERVICE"),tag("keptn_project:$PROJECT")'`,
+			wantConfig: DynatraceConfig{
+				SpecVersion: "0.1.0",
+				DtCreds:     "dynatrace-myproject",
+				AttachRules: &expectedDefaultAttachRules,
+				AttachRulesValidation: &AttachRulesValidationConfig{
+					Enabled:                true,
+					FallbackEntitySelector: `type("SERVICE"),tag("keptn_project:myproject")`,
+				},
+			},
+		},
+		{
+			name: "Test with problem filter",
+			configString: `spec_version: '0.1.0'
+dtCreds: dynatrace-$PROJECT
+problemFilter:
+  severityLevels:
+  - AVAILABILITY
+  - ERROR
+  impactLevels:
+  - SERVICE
+  entityTypes:
+  - SERVICE
+  tags:
+  - keptn_project:$PROJECT
+  titlePatterns:
+  - '^Response time'
+  minAgeMinutes: 5`,
+			wantConfig: DynatraceConfig{
+				SpecVersion: "0.1.0",
+				DtCreds:     "dynatrace-myproject",
+				AttachRules: &expectedDefaultAttachRules,
+				ProblemFilter: &ProblemFilterConfig{
+					SeverityLevels: []string{"AVAILABILITY", "ERROR"},
+					ImpactLevels:   []string{"SERVICE"},
+					EntityTypes:    []string{"SERVICE"},
+					Tags:           []string{"keptn_project:myproject"},
+					TitlePatterns:  []string{"^Response time"},
+					MinAgeMinutes:  5,
+				},
+			},
+		},
 		{
 			name: "Test with label that does not exist",
 			configString: `spec_version: '0.1.0'
@@ -0,0 +1,256 @@
+package credentials
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// DynatraceCredentialsNameStore stores the name of the secret containing the Dynatrace credentials used for each Keptn project, stage and service.
+// This allows the credentials of a project to be found once its dynatrace/dynatrace.conf.yaml file is no longer available, i.e. after it has been deleted.
+type DynatraceCredentialsNameStore interface {
+	// Get returns the name of the secret containing the Dynatrace credentials of the service in the stage of the project, falling back to those of the stage and then of the project.
+	// An empty string is returned if none are known. Stage and service may be empty.
+	Get(project string, stage string, service string) (string, error)
+
+	// Put stores the name of the secret containing the Dynatrace credentials of the service in the stage of the project, replacing any existing one.
+	// If the stage is empty, the secret name is stored for the project, as the dynatrace/dynatrace.conf.yaml file of the project is used in this case.
+	Put(project string, stage string, service string, secretName string) error
+
+	// Delete deletes the names of the secrets stored for the project and all of its stages and services.
+	Delete(project string) error
+}
+
+// credentialsNameKeySeparator separates project, stage and service in the keys of secret names. Keptn project, stage and service names never contain it.
+const credentialsNameKeySeparator = "."
+
+// getCredentialsNameKey returns the key the secret name used for the service in the stage of the project is stored under.
+func getCredentialsNameKey(project string, stage string, service string) string {
+	if stage == "" {
+		return project
+	}
+
+	if service == "" {
+		return project + credentialsNameKeySeparator + stage
+	}
+
+	return project + credentialsNameKeySeparator + stage + credentialsNameKeySeparator + service
+}
+
+// getCredentialsNameLookupKeys returns the keys to look up the secret name used for the service in the stage of the project, most specific first.
+func getCredentialsNameLookupKeys(project string, stage string, service string) []string {
+	keys := []string{}
+	if stage != "" && service != "" {
+		keys = append(keys, getCredentialsNameKey(project, stage, service))
+	}
+	if stage != "" {
+		keys = append(keys, getCredentialsNameKey(project, stage, ""))
+	}
+	return append(keys, getCredentialsNameKey(project, "", ""))
+}
+
+// isCredentialsNameKeyOfProject returns true if the key belongs to the project or one of its stages or services.
+func isCredentialsNameKeyOfProject(key string, project string) bool {
+	return key == project || strings.HasPrefix(key, project+credentialsNameKeySeparator)
+}
+
+// InMemoryDynatraceCredentialsNameStore is a DynatraceCredentialsNameStore that keeps the secret names in memory.
+type InMemoryDynatraceCredentialsNameStore struct {
+	mutex       sync.Mutex
+	secretNames map[string]string
+}
+
+// NewInMemoryDynatraceCredentialsNameStore creates a new, empty InMemoryDynatraceCredentialsNameStore.
+func NewInMemoryDynatraceCredentialsNameStore() *InMemoryDynatraceCredentialsNameStore {
+	return &InMemoryDynatraceCredentialsNameStore{
+		secretNames: make(map[string]string),
+	}
+}
+
+// Get returns the name of the secret containing the Dynatrace credentials of the service in the stage of the project, falling back to those of the stage and then of the project.
+func (s *InMemoryDynatraceCredentialsNameStore) Get(project string, stage string, service string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return getSecretName(s.secretNames, project, stage, service), nil
+}
+
+// Put stores the name of the secret containing the Dynatrace credentials of the service in the stage of the project, replacing any existing one.
+func (s *InMemoryDynatraceCredentialsNameStore) Put(project string, stage string, service string, secretName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.secretNames[getCredentialsNameKey(project, stage, service)] = secretName
+	return nil
+}
+
+// Delete deletes the names of the secrets stored for the project and all of its stages and services.
+func (s *InMemoryDynatraceCredentialsNameStore) Delete(project string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deleteSecretNames(s.secretNames, project)
+	return nil
+}
+
+func getSecretName(secretNames map[string]string, project string, stage string, service string) string {
+	for _, key := range getCredentialsNameLookupKeys(project, stage, service) {
+		if secretName := secretNames[key]; secretName != "" {
+			return secretName
+		}
+	}
+	return ""
+}
+
+func deleteSecretNames(secretNames map[string]string, project string) {
+	for key := range secretNames {
+		if isCredentialsNameKeyOfProject(key, project) {
+			delete(secretNames, key)
+		}
+	}
+}
+
+// ConfigMapDynatraceCredentialsNameStore is a DynatraceCredentialsNameStore that keeps the secret names in a Kubernetes ConfigMap, so that they survive restarts.
+// The ConfigMap is read once and its data is cached, so that it is only updated if a secret name changes.
+type ConfigMapDynatraceCredentialsNameStore struct {
+	mutex       sync.Mutex
+	k8sClient   kubernetes.Interface
+	namespace   string
+	name        string
+	secretNames map[string]string
+}
+
+// NewConfigMapDynatraceCredentialsNameStore creates a new ConfigMapDynatraceCredentialsNameStore using the ConfigMap with the specified name and namespace, which is created if it does not exist.
+func NewConfigMapDynatraceCredentialsNameStore(k8sClient kubernetes.Interface, namespace string, name string) *ConfigMapDynatraceCredentialsNameStore {
+	return &ConfigMapDynatraceCredentialsNameStore{
+		k8sClient: k8sClient,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Get returns the name of the secret containing the Dynatrace credentials of the service in the stage of the project, falling back to those of the stage and then of the project.
+func (s *ConfigMapDynatraceCredentialsNameStore) Get(project string, stage string, service string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return "", err
+	}
+
+	return getSecretName(s.secretNames, project, stage, service), nil
+}
+
+// Put stores the name of the secret containing the Dynatrace credentials of the service in the stage of the project, replacing any existing one.
+func (s *ConfigMapDynatraceCredentialsNameStore) Put(project string, stage string, service string, secretName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	key := getCredentialsNameKey(project, stage, service)
+	if s.secretNames[key] == secretName {
+		return nil
+	}
+
+	err = s.update(func(data map[string]string) {
+		data[key] = secretName
+	})
+	if err != nil {
+		return err
+	}
+
+	s.secretNames[key] = secretName
+	return nil
+}
+
+// Delete deletes the names of the secrets stored for the project and all of its stages and services.
+func (s *ConfigMapDynatraceCredentialsNameStore) Delete(project string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	err = s.update(func(data map[string]string) {
+		deleteSecretNames(data, project)
+	})
+	if err != nil {
+		return err
+	}
+
+	deleteSecretNames(s.secretNames, project)
+	return nil
+}
+
+// load reads the secret names from the ConfigMap unless they have already been read. The mutex must be held by the caller.
+func (s *ConfigMapDynatraceCredentialsNameStore) load() error {
+	if s.secretNames != nil {
+		return nil
+	}
+
+	configMap, err := s.k8sClient.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		s.secretNames = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not get ConfigMap %s: %w", s.name, err)
+	}
+
+	s.secretNames = make(map[string]string, len(configMap.Data))
+	for key, secretName := range configMap.Data {
+		s.secretNames[key] = secretName
+	}
+	return nil
+}
+
+// update applies the modification to the data of the ConfigMap, which is created if it does not exist.
+func (s *ConfigMapDynatraceCredentialsNameStore) update(modify func(data map[string]string)) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := s.k8sClient.CoreV1().ConfigMaps(s.namespace)
+		configMap, err := configMaps.Get(context.Background(), s.name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Data:       map[string]string{},
+			}
+			modify(configMap.Data)
+			_, err = configMaps.Create(context.Background(), configMap, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// handled like a conflict, so that the update is retried on the created ConfigMap
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		modify(configMap.Data)
+
+		_, err = configMaps.Update(context.Background(), configMap, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("could not update ConfigMap %s: %w", s.name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+package credentials
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInMemoryDynatraceCredentialsNameStore(t *testing.T) {
+	testDynatraceCredentialsNameStore(t, NewInMemoryDynatraceCredentialsNameStore())
+}
+
+func TestConfigMapDynatraceCredentialsNameStore(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	testDynatraceCredentialsNameStore(t, NewConfigMapDynatraceCredentialsNameStore(k8sClient, "keptn", "dynatrace-service-credentials"))
+
+	configMap, err := k8sClient.CoreV1().ConfigMaps("keptn").Get(context.Background(), "dynatrace-service-credentials", metav1.GetOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.EqualValues(t, map[string]string{"orders": "dynatrace"}, configMap.Data)
+}
+
+func TestConfigMapDynatraceCredentialsNameStore_restart(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	store := NewConfigMapDynatraceCredentialsNameStore(k8sClient, "keptn", "dynatrace-service-credentials")
+
+	err := store.Put("sockshop", "", "", "dynatrace-prod")
+	assert.NoError(t, err)
+
+	err = store.Put("sockshop", "production", "carts", "dynatrace-carts")
+	assert.NoError(t, err)
+
+	err = store.Put("sockshop", "", "", "dynatrace-sockshop")
+	assert.NoError(t, err)
+
+	// a new store using the same ConfigMap, e.g. after a restart, sees the stored secret names
+	store = NewConfigMapDynatraceCredentialsNameStore(k8sClient, "keptn", "dynatrace-service-credentials")
+	secretName, err := store.Get("sockshop", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "dynatrace-sockshop", secretName)
+
+	secretName, err = store.Get("sockshop", "production", "carts")
+	assert.NoError(t, err)
+	assert.Equal(t, "dynatrace-carts", secretName)
+}
+
+// testDynatraceCredentialsNameStore tests that secret names are stored per project, stage and service, looked up with fallbacks and deleted per project.
+func testDynatraceCredentialsNameStore(t *testing.T, store DynatraceCredentialsNameStore) {
+	secretName, err := store.Get("sockshop", "", "")
+	assert.NoError(t, err)
+	assert.Empty(t, secretName)
+
+	assert.NoError(t, store.Put("sockshop", "", "carts", "dynatrace"))
+	assert.NoError(t, store.Put("sockshop", "production", "", "dynatrace-production"))
+	assert.NoError(t, store.Put("sockshop", "production", "carts", "dynatrace-carts"))
+	assert.NoError(t, store.Put("orders", "", "", "dynatrace"))
+
+	tests := []struct {
+		name               string
+		project            string
+		stage              string
+		service            string
+		expectedSecretName string
+	}{
+		{name: "project", project: "sockshop", expectedSecretName: "dynatrace"},
+		{name: "service without stage uses project", project: "sockshop", service: "carts", expectedSecretName: "dynatrace"},
+		{name: "stage", project: "sockshop", stage: "production", expectedSecretName: "dynatrace-production"},
+		{name: "service in stage", project: "sockshop", stage: "production", service: "carts", expectedSecretName: "dynatrace-carts"},
+		{name: "other service in stage falls back to stage", project: "sockshop", stage: "production", service: "orders", expectedSecretName: "dynatrace-production"},
+		{name: "other stage falls back to project", project: "sockshop", stage: "staging", service: "carts", expectedSecretName: "dynatrace"},
+		{name: "unknown project", project: "sock", stage: "production", service: "carts", expectedSecretName: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secretName, err := store.Get(tt.project, tt.stage, tt.service)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSecretName, secretName)
+		})
+	}
+
+	assert.NoError(t, store.Delete("sockshop"))
+
+	secretName, err = store.Get("sockshop", "production", "carts")
+	assert.NoError(t, err)
+	assert.Empty(t, secretName)
+
+	secretName, err = store.Get("orders", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "dynatrace", secretName)
+}
@@ -8,6 +8,46 @@ import (
 
 const alertingProfilesPath = "/api/config/v1/alertingProfiles"
 
+// alertingProfilesSchemaID is the Settings 2.0 schema of alerting profiles
+const alertingProfilesSchemaID = "builtin:alerting.profile"
+
+// alertingProfileAndNotificationSchemaIDs are the schemas that must both be available to manage alerting profiles and problem notifications using the Settings 2.0 API, as notifications reference alerting profiles by ID
+var alertingProfileAndNotificationSchemaIDs = []string{alertingProfilesSchemaID, problemNotificationsSchemaID}
+
+// alertingProfileSettingsValue is the value of an alerting profile settings object
+type alertingProfileSettingsValue struct {
+	Name          string                                `json:"name"`
+	SeverityRules []alertingProfileSettingsSeverityRule `json:"severityRules"`
+	EventFilters  []interface{}                         `json:"eventFilters"`
+}
+
+type alertingProfileSettingsSeverityRule struct {
+	SeverityLevel        string   `json:"severityLevel"`
+	DelayInMinutes       int      `json:"delayInMinutes"`
+	TagFilterIncludeMode string   `json:"tagFilterIncludeMode"`
+	TagFilter            []string `json:"tagFilter,omitempty"`
+}
+
+// newAlertingProfileSettingsValue converts an alerting profile of the Configuration API v1 into a settings value.
+func newAlertingProfileSettingsValue(alertingProfile *AlertingProfile) alertingProfileSettingsValue {
+	value := alertingProfileSettingsValue{
+		Name:          alertingProfile.DisplayName,
+		SeverityRules: make([]alertingProfileSettingsSeverityRule, 0, len(alertingProfile.Rules)),
+		EventFilters:  []interface{}{},
+	}
+
+	for _, rule := range alertingProfile.Rules {
+		value.SeverityRules = append(value.SeverityRules, alertingProfileSettingsSeverityRule{
+			SeverityLevel:        rule.SeverityLevel,
+			DelayInMinutes:       rule.DelayInMinutes,
+			TagFilterIncludeMode: rule.TagFilter.IncludeMode,
+			TagFilter:            rule.TagFilter.TagFilters,
+		})
+	}
+
+	return value
+}
+
 type AlertingProfile struct {
 	Metadata         AlertingProfileMetadata           `json:"metadata"`
 	ID               string                            `json:"id"`
@@ -44,17 +84,34 @@ type CustomEventFilter struct {
 	CustomTitleFilter CustomTitleFilter `json:"customTitleFilter"`
 }
 
+// AlertingProfilesClient is a client for managing alerting profiles using the Settings 2.0 API or, if not available, the Configuration API v1.
 type AlertingProfilesClient struct {
-	client ClientInterface
+	client           ClientInterface
+	settingsSelector *settingsAPISelector
 }
 
 func NewAlertingProfilesClient(client ClientInterface) *AlertingProfilesClient {
 	return &AlertingProfilesClient{
-		client: client,
+		client:           client,
+		settingsSelector: newSettingsAPISelector(client, alertingProfileAndNotificationSchemaIDs...),
 	}
 }
 
 func (apc *AlertingProfilesClient) getAll(ctx context.Context) (*listResponse, error) {
+	useSettingsAPI, err := apc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		alertingProfiles, err := getSettingsObjectsAsListResponse(ctx, NewSettingsClient(apc.client), alertingProfilesSchemaID, "name")
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve alerting profiles: %v", err)
+		}
+
+		return alertingProfiles, nil
+	}
+
 	response, err := apc.client.Get(ctx, alertingProfilesPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve alerting profiles: %v", err)
@@ -70,6 +127,7 @@ func (apc *AlertingProfilesClient) getAll(ctx context.Context) (*listResponse, e
 }
 
 // GetProfileID returns the profile ID for the given profileName if found, an empty string otherwise.
+// If the Settings 2.0 API is used, the ID is the object ID of the alerting profile.
 func (apc *AlertingProfilesClient) GetProfileID(ctx context.Context, profileName string) (string, error) {
 	res, err := apc.getAll(ctx)
 	if err != nil {
@@ -87,6 +145,20 @@ func (apc *AlertingProfilesClient) GetProfileID(ctx context.Context, profileName
 
 // Create creates and alerting profile.
 func (apc *AlertingProfilesClient) Create(ctx context.Context, alertingProfile *AlertingProfile) (string, error) {
+	useSettingsAPI, err := apc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if useSettingsAPI {
+		objectID, err := NewSettingsClient(apc.client).Create(ctx, alertingProfilesSchemaID, newAlertingProfileSettingsValue(alertingProfile))
+		if err != nil {
+			return "", fmt.Errorf("failed to setup alerting profile: %v", err)
+		}
+
+		return objectID, nil
+	}
+
 	alertingProfilePayload, err := json.Marshal(alertingProfile)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal alerting profile: %v", err)
@@ -3,12 +3,19 @@ package dynatrace
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const autoTagsPath = "/api/config/v1/autoTags"
 
+// autoTagsSchemaID is the Settings 2.0 schema of auto-tagging rules
+const autoTagsSchemaID = "builtin:tags.auto-tagging"
+
 type DTTaggingRule struct {
 	Name  string  `json:"name"`
 	Rules []Rules `json:"rules"`
@@ -41,23 +48,185 @@ type Rules struct {
 	Conditions       []Conditions `json:"conditions"`
 }
 
-type TagNames struct {
-	*StringSet
+// AutoTags are the auto-tagging rules of a tenant, keyed by name.
+type AutoTags struct {
+	values map[string]values
+}
+
+// GetID returns the ID of the auto-tagging rule with the specified name and whether it exists.
+func (at *AutoTags) GetID(name string) (string, bool) {
+	value, exists := at.values[name]
+	return value.ID, exists
+}
+
+// autoTagSettingsValue is the value of an auto-tagging rule settings object
+type autoTagSettingsValue struct {
+	Name  string                     `json:"name"`
+	Rules []autoTagSettingsValueRule `json:"rules"`
+}
+
+type autoTagSettingsValueRule struct {
+	Enabled            bool                         `json:"enabled"`
+	Type               string                       `json:"type"`
+	ValueFormat        string                       `json:"valueFormat"`
+	ValueNormalization string                       `json:"valueNormalization"`
+	AttributeRule      autoTagSettingsAttributeRule `json:"attributeRule"`
+}
+
+type autoTagSettingsAttributeRule struct {
+	EntityType               string                     `json:"entityType"`
+	ServiceToPGPropagation   bool                       `json:"serviceToPGPropagation"`
+	ServiceToHostPropagation bool                       `json:"serviceToHostPropagation"`
+	Conditions               []autoTagSettingsCondition `json:"conditions"`
+}
+
+type autoTagSettingsCondition struct {
+	Key              string      `json:"key"`
+	DynamicKey       string      `json:"dynamicKey,omitempty"`
+	DynamicKeySource string      `json:"dynamicKeySource,omitempty"`
+	Operator         string      `json:"operator"`
+	CaseSensitive    interface{} `json:"caseSensitive,omitempty"`
+	StringValue      interface{} `json:"stringValue,omitempty"`
+}
+
+// newAutoTagSettingsValue converts an auto-tagging rule of the Configuration API v1 into a settings value.
+func newAutoTagSettingsValue(rule *DTTaggingRule) autoTagSettingsValue {
+	value := autoTagSettingsValue{
+		Name:  rule.Name,
+		Rules: make([]autoTagSettingsValueRule, 0, len(rule.Rules)),
+	}
+
+	for _, r := range rule.Rules {
+		attributeRule := autoTagSettingsAttributeRule{
+			EntityType: r.Type,
+			Conditions: make([]autoTagSettingsCondition, 0, len(r.Conditions)),
+		}
+
+		for _, propagationType := range r.PropagationTypes {
+			switch propagationType {
+			case "SERVICE_TO_PROCESS_GROUP_LIKE":
+				attributeRule.ServiceToPGPropagation = true
+			case "SERVICE_TO_HOST_LIKE":
+				attributeRule.ServiceToHostPropagation = true
+			}
+		}
+
+		for _, condition := range r.Conditions {
+			operator := condition.ComparisonInfo.Operator
+			if condition.ComparisonInfo.Negate {
+				operator = "NOT_" + operator
+			}
+
+			attributeRule.Conditions = append(attributeRule.Conditions, autoTagSettingsCondition{
+				Key:              condition.Key.Attribute,
+				DynamicKey:       condition.Key.DynamicKey.Key,
+				DynamicKeySource: condition.Key.DynamicKey.Source,
+				Operator:         operator,
+				CaseSensitive:    condition.ComparisonInfo.CaseSensitive,
+				StringValue:      condition.ComparisonInfo.Value,
+			})
+		}
+
+		value.Rules = append(value.Rules, autoTagSettingsValueRule{
+			Enabled:            r.Enabled,
+			Type:               "ME",
+			ValueFormat:        r.ValueFormat,
+			ValueNormalization: "Leave text as-is",
+			AttributeRule:      attributeRule,
+		})
+	}
+
+	return value
+}
+
+// newTaggingRuleFromSettingsValue converts an auto-tagging rule settings value into an auto-tagging rule of the Configuration API v1.
+func newTaggingRuleFromSettingsValue(value autoTagSettingsValue) *DTTaggingRule {
+	rule := &DTTaggingRule{
+		Name:  value.Name,
+		Rules: make([]Rules, 0, len(value.Rules)),
+	}
+
+	for _, r := range value.Rules {
+		propagationTypes := []string{}
+		if r.AttributeRule.ServiceToPGPropagation {
+			propagationTypes = append(propagationTypes, "SERVICE_TO_PROCESS_GROUP_LIKE")
+		}
+		if r.AttributeRule.ServiceToHostPropagation {
+			propagationTypes = append(propagationTypes, "SERVICE_TO_HOST_LIKE")
+		}
+
+		conditions := make([]Conditions, 0, len(r.AttributeRule.Conditions))
+		for _, condition := range r.AttributeRule.Conditions {
+			operator := strings.TrimPrefix(condition.Operator, "NOT_")
+			conditions = append(conditions, Conditions{
+				Key: Key{
+					Attribute: condition.Key,
+					DynamicKey: DynamicKey{
+						Source: condition.DynamicKeySource,
+						Key:    condition.DynamicKey,
+					},
+					Type: getTaggingRuleKeyType(condition.Key),
+				},
+				ComparisonInfo: ComparisonInfo{
+					Type:          "STRING",
+					Operator:      operator,
+					Value:         condition.StringValue,
+					Negate:        operator != condition.Operator,
+					CaseSensitive: condition.CaseSensitive,
+				},
+			})
+		}
+
+		rule.Rules = append(rule.Rules, Rules{
+			Type:             r.AttributeRule.EntityType,
+			Enabled:          r.Enabled,
+			ValueFormat:      r.ValueFormat,
+			PropagationTypes: propagationTypes,
+			Conditions:       conditions,
+		})
+	}
+
+	return rule
 }
 
+// getTaggingRuleKeyType returns the Configuration API v1 key type of a condition on the attribute, which is not part of the settings value.
+func getTaggingRuleKeyType(attribute string) string {
+	switch attribute {
+	case "PROCESS_GROUP_CUSTOM_METADATA":
+		return "PROCESS_CUSTOM_METADATA_KEY"
+	case "HOST_CUSTOM_METADATA":
+		return "HOST_CUSTOM_METADATA_KEY"
+	default:
+		return "STATIC"
+	}
+}
+
+// AutoTagsClient is a client for managing auto-tagging rules using the Settings 2.0 API or, if not available, the Configuration API v1.
 type AutoTagsClient struct {
-	client ClientInterface
+	client           ClientInterface
+	settingsSelector *settingsAPISelector
 }
 
 func NewAutoTagClient(client ClientInterface) *AutoTagsClient {
 	return &AutoTagsClient{
-		client: client,
+		client:           client,
+		settingsSelector: newSettingsAPISelector(client, autoTagsSchemaID),
 	}
 }
 
 // Create creates an auto-tagging rule.
 func (atc *AutoTagsClient) Create(ctx context.Context, rule *DTTaggingRule) error {
 	log.WithField("name", rule.Name).Info("Creating DT tagging rule")
+	useSettingsAPI, err := atc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		_, err := NewSettingsClient(atc.client).Create(ctx, autoTagsSchemaID, newAutoTagSettingsValue(rule))
+		return err
+	}
+
 	payload, err := json.Marshal(rule)
 	if err != nil {
 		return err
@@ -67,23 +236,107 @@ func (atc *AutoTagsClient) Create(ctx context.Context, rule *DTTaggingRule) erro
 	return err
 }
 
-// GetAllTagNames gets names of all tag rules.
-func (atc *AutoTagsClient) GetAllTagNames(ctx context.Context) (*TagNames, error) {
-	response, err := atc.client.Get(ctx, autoTagsPath)
+// GetAll gets all auto-tagging rules.
+// If the Settings 2.0 API is used, the IDs of the auto-tagging rules are their object IDs.
+func (atc *AutoTagsClient) GetAll(ctx context.Context) (*AutoTags, error) {
+	useSettingsAPI, err := atc.settingsSelector.isSettingsAPIAvailable(ctx)
 	if err != nil {
-		log.WithError(err).Error("Could not get existing tagging rules")
 		return nil, err
 	}
 
+	if useSettingsAPI {
+		existingDTRules, err := getSettingsObjectsAsListResponse(ctx, NewSettingsClient(atc.client), autoTagsSchemaID, "name")
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve auto-tagging rules: %v", err)
+		}
+
+		return transformToAutoTags(existingDTRules), nil
+	}
+
+	response, err := atc.client.Get(ctx, autoTagsPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve auto-tagging rules: %v", err)
+	}
+
 	existingDTRules := &listResponse{}
 	err = json.Unmarshal(response, existingDTRules)
 	if err != nil {
-		log.WithError(err).Error("Failed to unmarshal Dynatrace tagging rules")
+		return nil, fmt.Errorf("failed to parse auto-tagging rules list: %v", err)
+	}
+
+	return transformToAutoTags(existingDTRules), nil
+}
+
+func transformToAutoTags(response *listResponse) *AutoTags {
+	autoTags := &AutoTags{
+		values: make(map[string]values, len(response.Values)),
+	}
+	for _, value := range response.Values {
+		autoTags.values[value.Name] = value
+	}
+
+	return autoTags
+}
+
+// GetByID gets the auto-tagging rule with the specified ID.
+func (atc *AutoTagsClient) GetByID(ctx context.Context, ruleID string) (*DTTaggingRule, error) {
+	useSettingsAPI, err := atc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	return &TagNames{
-		existingDTRules.ToStringSetWith(
-			func(values values) string { return values.Name }),
-	}, nil
+	if useSettingsAPI {
+		response, err := atc.client.Get(ctx, settingsObjectsPath+"/"+ruleID)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve auto-tagging rule with ID: %s, %v", ruleID, err)
+		}
+
+		object := &SettingsObject{}
+		err = json.Unmarshal(response, object)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("auto-tagging rule settings object", err)
+		}
+
+		value := autoTagSettingsValue{}
+		err = json.Unmarshal(object.Value, &value)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("auto-tagging rule settings value", err)
+		}
+
+		return newTaggingRuleFromSettingsValue(value), nil
+	}
+
+	response, err := atc.client.Get(ctx, autoTagsPath+"/"+ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve auto-tagging rule with ID: %s, %v", ruleID, err)
+	}
+
+	rule := &DTTaggingRule{}
+	err = json.Unmarshal(response, rule)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("auto-tagging rule", err)
+	}
+
+	return rule, nil
+}
+
+// Update updates the auto-tagging rule with the specified ID.
+func (atc *AutoTagsClient) Update(ctx context.Context, ruleID string, rule *DTTaggingRule) error {
+	log.WithField("name", rule.Name).Info("Updating DT tagging rule")
+	useSettingsAPI, err := atc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		return NewSettingsClient(atc.client).Update(ctx, ruleID, newAutoTagSettingsValue(rule))
+	}
+
+	payload, err := json.Marshal(rule)
+	if err != nil {
+		return err
+	}
+
+	_, err = atc.client.Put(ctx, autoTagsPath+"/"+ruleID, payload)
+	return err
 }
@@ -0,0 +1,121 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+)
+
+const calculatedServiceMetricsPath = "/api/config/v1/calculatedMetrics/service"
+
+const (
+	// TestStepResponseTimeMetricKey is the key of the calculated service metric of the response time of Keptn test steps.
+	TestStepResponseTimeMetricKey = "calc:service.teststepresponsetime"
+
+	// TestStepFailureRateMetricKey is the key of the calculated service metric of the failure rate of Keptn test steps.
+	TestStepFailureRateMetricKey = "calc:service.teststepfailurerate"
+
+	// TestStepDimensionName is the name of the dimension of the test step metrics holding the name of the test step.
+	TestStepDimensionName = "Test Step"
+)
+
+// CalculatedServiceMetric is a calculated service metric of the Configuration API v1, e.g. calc:service.teststepresponsetime.
+type CalculatedServiceMetric struct {
+	TsmMetricKey        string                                      `json:"tsmMetricKey"`
+	Name                string                                      `json:"name"`
+	Enabled             bool                                        `json:"enabled"`
+	MetricDefinition    CalculatedServiceMetricDefinition           `json:"metricDefinition"`
+	Unit                string                                      `json:"unit"`
+	UnitDisplayName     string                                      `json:"unitDisplayName,omitempty"`
+	Conditions          []CalculatedServiceMetricCondition          `json:"conditions"`
+	DimensionDefinition *CalculatedServiceMetricDimensionDefinition `json:"dimensionDefinition,omitempty"`
+}
+
+// CalculatedServiceMetricDefinition defines the request metric a calculated service metric is based on, e.g. RESPONSE_TIME.
+type CalculatedServiceMetricDefinition struct {
+	Metric           string `json:"metric"`
+	RequestAttribute string `json:"requestAttribute,omitempty"`
+}
+
+// CalculatedServiceMetricCondition restricts the requests a calculated service metric is calculated from.
+type CalculatedServiceMetricCondition struct {
+	Attribute      string                                `json:"attribute"`
+	ComparisonInfo CalculatedServiceMetricComparisonInfo `json:"comparisonInfo"`
+}
+
+// CalculatedServiceMetricComparisonInfo is the comparison of a condition of a calculated service metric.
+// Depending on its type, either Value or RequestAttribute is set.
+type CalculatedServiceMetricComparisonInfo struct {
+	Type             string      `json:"type"`
+	Comparison       string      `json:"comparison"`
+	Value            interface{} `json:"value"`
+	Negate           bool        `json:"negate"`
+	RequestAttribute string      `json:"requestAttribute,omitempty"`
+	CaseSensitive    bool        `json:"caseSensitive"`
+}
+
+// CalculatedServiceMetricTag is the value of a tag comparison of a calculated service metric.
+type CalculatedServiceMetricTag struct {
+	Context string `json:"context"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+}
+
+// CalculatedServiceMetricDimensionDefinition splits a calculated service metric by a dimension, e.g. the value of a request attribute.
+type CalculatedServiceMetricDimensionDefinition struct {
+	Name            string   `json:"name"`
+	Dimension       string   `json:"dimension"`
+	Placeholders    []string `json:"placeholders"`
+	TopX            int      `json:"topX"`
+	TopXDirection   string   `json:"topXDirection"`
+	TopXAggregation string   `json:"topXAggregation"`
+}
+
+// CalculatedServiceMetricKeys are the metric keys of the existing calculated service metrics.
+type CalculatedServiceMetricKeys struct {
+	*StringSet
+}
+
+// CalculatedServiceMetricsClient is a client for managing calculated service metrics using the Configuration API v1.
+type CalculatedServiceMetricsClient struct {
+	client ClientInterface
+}
+
+// NewCalculatedServiceMetricsClient creates a new CalculatedServiceMetricsClient.
+func NewCalculatedServiceMetricsClient(client ClientInterface) *CalculatedServiceMetricsClient {
+	return &CalculatedServiceMetricsClient{
+		client: client,
+	}
+}
+
+// GetAllMetricKeys gets the metric keys of all calculated service metrics or returns an error.
+func (c *CalculatedServiceMetricsClient) GetAllMetricKeys(ctx context.Context) (*CalculatedServiceMetricKeys, error) {
+	response, err := c.client.Get(ctx, calculatedServiceMetricsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	calculatedMetrics := &listResponse{}
+	err = json.Unmarshal(response, calculatedMetrics)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("Dynatrace calculated service metrics", err)
+	}
+
+	// the ID of a calculated service metric is its metric key
+	return &CalculatedServiceMetricKeys{
+		calculatedMetrics.ToStringSetWith(
+			func(values values) string { return values.ID }),
+	}, nil
+}
+
+// Create creates the calculated service metric or returns an error.
+func (c *CalculatedServiceMetricsClient) Create(ctx context.Context, metric *CalculatedServiceMetric) error {
+	payload, err := json.Marshal(metric)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace calculated service metric", err)
+	}
+
+	_, err = c.client.Post(ctx, calculatedServiceMetricsPath, payload)
+	return err
+}
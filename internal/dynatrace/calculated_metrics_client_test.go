@@ -0,0 +1,24 @@
+package dynatrace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+func TestCalculatedServiceMetricsClient_GetAllMetricKeys(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact(calculatedServiceMetricsPath, "./testdata/test_calculatedservicemetricsclient_getall.json")
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	metricKeys, err := NewCalculatedServiceMetricsClient(dtClient).GetAllMetricKeys(context.TODO())
+	assert.NoError(t, err)
+	assert.True(t, metricKeys.Contains(TestStepResponseTimeMetricKey))
+	assert.True(t, metricKeys.Contains("calc:service.checkoutduration"))
+	assert.False(t, metricKeys.Contains(TestStepFailureRateMetricKey))
+	assert.False(t, metricKeys.Contains("Test Step Response Time"))
+}
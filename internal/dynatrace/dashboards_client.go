@@ -56,6 +56,23 @@ func (dc *DashboardsClient) GetByID(ctx context.Context, dashboardID string) (*D
 	return dynatraceDashboard, nil
 }
 
+// GetJSONByID gets a dashboard by ID as a generic JSON object or returns an error.
+// In contrast to GetByID, fields not modelled by Dashboard, e.g. of tiles added by users, are retained.
+func (dc *DashboardsClient) GetJSONByID(ctx context.Context, dashboardID string) (map[string]interface{}, error) {
+	body, err := dc.client.Get(ctx, DashboardsPath+"/"+dashboardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var dashboard map[string]interface{}
+	err = json.Unmarshal(body, &dashboard)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("Dynatrace dashboard", err)
+	}
+
+	return dashboard, nil
+}
+
 // Create creates the specified dashboard or returns an error.
 func (dc *DashboardsClient) Create(ctx context.Context, dashboard *Dashboard) error {
 	dashboardPayload, err := json.Marshal(dashboard)
@@ -71,6 +88,36 @@ func (dc *DashboardsClient) Create(ctx context.Context, dashboard *Dashboard) er
 	return nil
 }
 
+// Update updates the specified dashboard, which must have an ID, or returns an error.
+func (dc *DashboardsClient) Update(ctx context.Context, dashboard *Dashboard) error {
+	dashboardPayload, err := json.Marshal(dashboard)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace dashboard", err)
+	}
+
+	_, err = dc.client.Put(ctx, DashboardsPath+"/"+dashboard.ID, dashboardPayload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateJSON updates the dashboard referenced by the specified ID using a generic JSON object, e.g. as returned by GetJSONByID, or returns an error.
+func (dc *DashboardsClient) UpdateJSON(ctx context.Context, dashboardID string, dashboard map[string]interface{}) error {
+	dashboardPayload, err := json.Marshal(dashboard)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace dashboard", err)
+	}
+
+	_, err = dc.client.Put(ctx, DashboardsPath+"/"+dashboardID, dashboardPayload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Delete deletes the dashboard referenced by the specified ID or returns an error.
 func (dc *DashboardsClient) Delete(ctx context.Context, dashboardID string) error {
 	_, err := dc.client.Delete(ctx, DashboardsPath+"/"+dashboardID)
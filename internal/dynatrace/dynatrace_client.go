@@ -83,6 +83,9 @@ type ClientInterface interface {
 	// Post performs a post request.
 	Post(ctx context.Context, apiPath string, body []byte) ([]byte, error)
 
+	// PostText performs a post request with a plain text body.
+	PostText(ctx context.Context, apiPath string, body []byte) ([]byte, error)
+
 	// Put performs a put request.
 	Put(ctx context.Context, apiPath string, body []byte) ([]byte, error)
 
@@ -142,6 +145,16 @@ func (dt *Client) Post(ctx context.Context, apiPath string, body []byte) ([]byte
 	return validateResponse(body, status, url)
 }
 
+// PostText performs a post request with a plain text body.
+func (dt *Client) PostText(ctx context.Context, apiPath string, body []byte) ([]byte, error) {
+	body, status, url, err := dt.restClient.PostText(ctx, apiPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return validateResponse(body, status, url)
+}
+
 // Put performs a put request.
 func (dt *Client) Put(ctx context.Context, apiPath string, body []byte) ([]byte, error) {
 	body, status, url, err := dt.restClient.Put(ctx, apiPath, body)
@@ -4,11 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strconv"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 )
 
 const entitiesPath = "/api/v2/entities"
 
+const (
+	pageSizeKey    = "pageSize"
+	nextPageKeyKey = "nextPageKey"
+	fieldsKey      = "fields"
+)
+
 // EntitiesResponse represents the response from Dynatrace entities endpoints
 type EntitiesResponse struct {
 	TotalCount  int      `json:"totalCount"`
@@ -27,9 +36,33 @@ type Tag struct {
 
 // Entity represents a Dynatrace entity
 type Entity struct {
-	EntityID    string `json:"entityId"`
-	DisplayName string `json:"displayName"`
-	Tags        []Tag  `json:"tags"`
+	EntityID          string                `json:"entityId"`
+	Type              string                `json:"type"`
+	DisplayName       string                `json:"displayName"`
+	Tags              []Tag                 `json:"tags"`
+	FromRelationships map[string][]EntityID `json:"fromRelationships"`
+	ToRelationships   map[string][]EntityID `json:"toRelationships"`
+}
+
+// EntityID represents the ID and type of a Dynatrace entity, e.g. as referenced in relationships
+type EntityID struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// GetRelatedEntityIDs returns the IDs of all entities of the specified type that are related to the entity in either direction.
+func (e Entity) GetRelatedEntityIDs(entityType string) []string {
+	ids := []string{}
+	for _, relationships := range []map[string][]EntityID{e.FromRelationships, e.ToRelationships} {
+		for _, relatedEntities := range relationships {
+			for _, relatedEntity := range relatedEntities {
+				if relatedEntity.Type == entityType {
+					ids = append(ids, relatedEntity.ID)
+				}
+			}
+		}
+	}
+	return ids
 }
 
 // EntitiesClient is a client for interacting with the Dynatrace entities endpoints
@@ -78,3 +111,80 @@ func (ec *EntitiesClient) GetKeptnManagedServices(ctx context.Context) ([]Entity
 	}
 	return entities, nil
 }
+
+// GetIDsBySelector gets the IDs of all entities matching the specified entity selector.
+func (ec *EntitiesClient) GetIDsBySelector(ctx context.Context, entitySelector string) ([]string, error) {
+	entities, err := ec.getBySelector(ctx, entitySelector, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		ids = append(ids, entity.EntityID)
+	}
+
+	return ids, nil
+}
+
+// GetWithTagsBySelector gets all entities matching the specified entity selector including their tags.
+func (ec *EntitiesClient) GetWithTagsBySelector(ctx context.Context, entitySelector string) ([]Entity, error) {
+	return ec.getBySelector(ctx, entitySelector, "+tags")
+}
+
+// GetWithRelationshipsByID gets the entity with the specified ID including its relationships to other entities.
+func (ec *EntitiesClient) GetWithRelationshipsByID(ctx context.Context, entityID string) (*Entity, error) {
+	queryParameters := newQueryParameters()
+	queryParameters.add(fieldsKey, "+fromRelationships,+toRelationships")
+
+	response, err := ec.Client.Get(ctx, entitiesPath+"/"+url.PathEscape(entityID)+"?"+queryParameters.encode())
+	if err != nil {
+		return nil, err
+	}
+
+	entity := &Entity{}
+	err = json.Unmarshal(response, entity)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("entity", err)
+	}
+
+	return entity, nil
+}
+
+// getBySelector gets all entities matching the specified entity selector, following next page keys.
+// If fields is not empty, it is used to request additional properties of the entities.
+func (ec *EntitiesClient) getBySelector(ctx context.Context, entitySelector string, fields string) ([]Entity, error) {
+	queryParameters := newQueryParameters()
+	queryParameters.add(entitySelectorKey, entitySelector)
+	queryParameters.add(pageSizeKey, "500")
+	if fields != "" {
+		queryParameters.add(fieldsKey, fields)
+	}
+
+	entities := []Entity{}
+	path := entitiesPath + "?" + queryParameters.encode()
+	for {
+		response, err := ec.Client.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		entitiesResponse := &EntitiesResponse{}
+		err = json.Unmarshal(response, entitiesResponse)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("entities", err)
+		}
+
+		entities = append(entities, entitiesResponse.Entities...)
+
+		if entitiesResponse.NextPageKey == "" {
+			break
+		}
+
+		nextPageQueryParameters := newQueryParameters()
+		nextPageQueryParameters.add(nextPageKeyKey, entitiesResponse.NextPageKey)
+		path = entitiesPath + "?" + nextPageQueryParameters.encode()
+	}
+
+	return entities, nil
+}
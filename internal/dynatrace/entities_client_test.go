@@ -8,6 +8,8 @@ import (
 	"testing"
 
 	"github.com/go-test/deep"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestEntitiesClient_GetKeptnManagedServices(t *testing.T) {
@@ -114,3 +116,69 @@ func TestEntitiesClient_GetKeptnManagedServices(t *testing.T) {
 		})
 	}
 }
+
+func TestEntitiesClient_GetIDsBySelector(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/entities?entitySelector=type%28%22SERVICE%22%29%2Ctag%28%22keptn_project%3Asockshop%22%29&pageSize=500", "./testdata/test_entitiesclient_getidsbyselector_page1.json")
+	handler.AddExact("/api/v2/entities?nextPageKey=AQAAABQBAAAABQ%3D%3D", "./testdata/test_entitiesclient_getidsbyselector_page2.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	ids, err := NewEntitiesClient(dtClient).GetIDsBySelector(context.TODO(), `type("SERVICE"),tag("keptn_project:sockshop")`)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"SERVICE-1A2B3C4D5E6F7A8B", "SERVICE-2B3C4D5E6F7A8B9C", "SERVICE-3C4D5E6F7A8B9C0D"}, ids)
+}
+
+func TestTagRule_EntitySelectors(t *testing.T) {
+	tagRule := TagRule{
+		MeTypes: []string{"SERVICE", "PROCESS_GROUP_INSTANCE"},
+		Tags: []TagEntry{
+			{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"},
+			{Context: "ENVIRONMENT", Key: "keptn_service", Value: `carts "v2"`},
+			{Context: "CONTEXTLESS", Key: "keptn_managed"},
+		},
+	}
+
+	assert.EqualValues(t,
+		[]string{
+			`type("SERVICE"),tag("keptn_project:sockshop"),tag("[ENVIRONMENT]keptn_service:carts ~"v2~""),tag("keptn_managed")`,
+			`type("PROCESS_GROUP_INSTANCE"),tag("keptn_project:sockshop"),tag("[ENVIRONMENT]keptn_service:carts ~"v2~""),tag("keptn_managed")`,
+		},
+		tagRule.EntitySelectors())
+}
+
+func TestTagEntry_String(t *testing.T) {
+	tests := []struct {
+		name     string
+		tagEntry TagEntry
+		want     string
+	}{
+		{
+			name:     "contextless tag with value",
+			tagEntry: TagEntry{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"},
+			want:     "keptn_project:sockshop",
+		},
+		{
+			name:     "tag without context and value",
+			tagEntry: TagEntry{Key: "keptn_managed"},
+			want:     "keptn_managed",
+		},
+		{
+			name:     "context is kept as is",
+			tagEntry: TagEntry{Context: "ENVIRONMENT", Key: "keptn_service", Value: "carts"},
+			want:     "[ENVIRONMENT]keptn_service:carts",
+		},
+		{
+			name:     "context casing is not changed",
+			tagEntry: TagEntry{Context: "Kubernetes", Key: "app"},
+			want:     "[Kubernetes]app",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.tagEntry.String())
+		})
+	}
+}
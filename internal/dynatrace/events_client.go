@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -79,9 +80,45 @@ type TagRule struct {
 	Tags    []TagEntry `json:"tags" yaml:"tags"`
 }
 
+// EntitySelectors returns an entity selector for each of the TagRule's entity types, matching entities with all of its tags.
+func (r TagRule) EntitySelectors() []string {
+	tagSelectors := make([]string, 0, len(r.Tags))
+	for _, tag := range r.Tags {
+		tagSelectors = append(tagSelectors, "tag("+quoteEntitySelectorValue(tag.String())+")")
+	}
+
+	selectors := make([]string, 0, len(r.MeTypes))
+	for _, meType := range r.MeTypes {
+		selectors = append(selectors, strings.Join(append([]string{"type(" + quoteEntitySelectorValue(meType) + ")"}, tagSelectors...), ","))
+	}
+	return selectors
+}
+
+// String returns the TagEntry in the format used by entity selectors, e.g. [ENVIRONMENT]key:value.
+// The context is used as is, without changing its casing, and omitted if it is empty or CONTEXTLESS.
+func (e TagEntry) String() string {
+	tag := e.Key
+	if e.Context != "" && e.Context != "CONTEXTLESS" {
+		tag = "[" + e.Context + "]" + tag
+	}
+
+	if e.Value != "" {
+		tag = tag + ":" + e.Value
+	}
+	return tag
+}
+
+// quoteEntitySelectorValue quotes a value for use in an entity selector, escaping tildes and quotes.
+func quoteEntitySelectorValue(value string) string {
+	value = strings.ReplaceAll(value, "~", "~~")
+	value = strings.ReplaceAll(value, `"`, `~"`)
+	return `"` + value + `"`
+}
+
 // AttachRules defines a Dynatrace configuration structure
 type AttachRules struct {
-	TagRule []TagRule `json:"tagRule" yaml:"tagRule"`
+	EntityIDs []string  `json:"entityIds,omitempty" yaml:"entityIds,omitempty"`
+	TagRule   []TagRule `json:"tagRule" yaml:"tagRule"`
 }
 
 type EventsClient struct {
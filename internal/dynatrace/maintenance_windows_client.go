@@ -0,0 +1,187 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+)
+
+const maintenanceWindowsPath = "/api/config/v1/maintenanceWindows"
+
+// maintenanceWindowTimeLayout is the layout used by the maintenance windows API for schedule start and end times.
+const maintenanceWindowTimeLayout = "2006-01-02 15:04"
+
+// MaintenanceWindowSuppressionDetectProblemsDontAlert is the default suppression used for maintenance windows: problems are detected, but no alerts are sent.
+const MaintenanceWindowSuppressionDetectProblemsDontAlert = "DETECT_PROBLEMS_DONT_ALERT"
+
+// MaintenanceWindow defines a Dynatrace maintenance window.
+type MaintenanceWindow struct {
+	ID                                 string                    `json:"id,omitempty"`
+	Name                               string                    `json:"name"`
+	Description                        string                    `json:"description"`
+	Type                               string                    `json:"type"`
+	Suppression                        string                    `json:"suppression"`
+	SuppressSyntheticMonitorsExecution bool                      `json:"suppressSyntheticMonitorsExecution"`
+	Scope                              *MWScope                  `json:"scope"`
+	Schedule                           MaintenanceWindowSchedule `json:"schedule"`
+}
+
+// MWScope defines the entities affected by a maintenance window.
+type MWScope struct {
+	Entities []string       `json:"entities"`
+	Matches  []MWScopeMatch `json:"matches"`
+}
+
+// MWScopeMatch defines a single matching rule of a maintenance window scope.
+type MWScopeMatch struct {
+	Type           string        `json:"type,omitempty"`
+	MzID           string        `json:"mzId,omitempty"`
+	Tags           []MWTagFilter `json:"tags"`
+	TagCombination string        `json:"tagCombination,omitempty"`
+}
+
+// MWTagFilter defines a tag that must be present on entities matched by a maintenance window scope.
+type MWTagFilter struct {
+	Context string `json:"context"`
+	Key     string `json:"key"`
+	Value   string `json:"value,omitempty"`
+}
+
+// MaintenanceWindowSchedule defines when a maintenance window is active.
+type MaintenanceWindowSchedule struct {
+	RecurrenceType string `json:"recurrenceType"`
+	Start          string `json:"start"`
+	End            string `json:"end"`
+	ZoneID         string `json:"zoneId"`
+}
+
+// NewOnceMaintenanceWindowSchedule creates a MaintenanceWindowSchedule that is active once between start and end.
+func NewOnceMaintenanceWindowSchedule(start time.Time, end time.Time) MaintenanceWindowSchedule {
+	return MaintenanceWindowSchedule{
+		RecurrenceType: "ONCE",
+		Start:          start.UTC().Format(maintenanceWindowTimeLayout),
+		End:            end.UTC().Format(maintenanceWindowTimeLayout),
+		ZoneID:         "UTC",
+	}
+}
+
+// NewMWScopeFromAttachRules creates a MWScope covering the same entities as the specified attach rules.
+func NewMWScopeFromAttachRules(attachRules AttachRules) *MWScope {
+	scope := &MWScope{
+		Entities: append([]string{}, attachRules.EntityIDs...),
+		Matches:  []MWScopeMatch{},
+	}
+
+	for _, tagRule := range attachRules.TagRule {
+		tags := make([]MWTagFilter, 0, len(tagRule.Tags))
+		for _, tag := range tagRule.Tags {
+			tags = append(tags, MWTagFilter{
+				Context: tag.Context,
+				Key:     tag.Key,
+				Value:   tag.Value,
+			})
+		}
+
+		for _, meType := range tagRule.MeTypes {
+			scope.Matches = append(scope.Matches, MWScopeMatch{
+				Type:           meType,
+				Tags:           tags,
+				TagCombination: "AND",
+			})
+		}
+	}
+
+	return scope
+}
+
+// NewMWScopeForManagementZone creates a MWScope covering all entities in the management zone with the specified ID.
+func NewMWScopeForManagementZone(managementZoneID string) *MWScope {
+	return &MWScope{
+		Entities: []string{},
+		Matches: []MWScopeMatch{
+			{
+				MzID: managementZoneID,
+				Tags: []MWTagFilter{},
+			},
+		},
+	}
+}
+
+// MaintenanceWindowsClient is a client for interacting with the Dynatrace maintenance windows configuration endpoint.
+type MaintenanceWindowsClient struct {
+	client ClientInterface
+}
+
+// NewMaintenanceWindowsClient creates a new MaintenanceWindowsClient.
+func NewMaintenanceWindowsClient(client ClientInterface) *MaintenanceWindowsClient {
+	return &MaintenanceWindowsClient{
+		client: client,
+	}
+}
+
+// MaintenanceWindowStub is the short representation of a maintenance window returned when listing maintenance windows.
+type MaintenanceWindowStub struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// GetStubsByNamePrefix gets all maintenance windows with a name starting with the specified prefix using a single request.
+func (mwc *MaintenanceWindowsClient) GetStubsByNamePrefix(ctx context.Context, namePrefix string) ([]MaintenanceWindowStub, error) {
+	response, err := mwc.client.Get(ctx, maintenanceWindowsPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve maintenance windows: %w", err)
+	}
+
+	maintenanceWindows := &struct {
+		Values []MaintenanceWindowStub `json:"values"`
+	}{}
+	err = json.Unmarshal(response, maintenanceWindows)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("maintenance windows", err)
+	}
+
+	stubs := make([]MaintenanceWindowStub, 0, len(maintenanceWindows.Values))
+	for _, value := range maintenanceWindows.Values {
+		if strings.HasPrefix(value.Name, namePrefix) {
+			stubs = append(stubs, value)
+		}
+	}
+
+	return stubs, nil
+}
+
+// Create creates the specified maintenance window and returns its ID or an error.
+func (mwc *MaintenanceWindowsClient) Create(ctx context.Context, maintenanceWindow *MaintenanceWindow) (string, error) {
+	payload, err := json.Marshal(maintenanceWindow)
+	if err != nil {
+		return "", common.NewMarshalJSONError("maintenance window", err)
+	}
+
+	response, err := mwc.client.Post(ctx, maintenanceWindowsPath, payload)
+	if err != nil {
+		return "", fmt.Errorf("could not create maintenance window: %w", err)
+	}
+
+	createdItem := &values{}
+	err = json.Unmarshal(response, createdItem)
+	if err != nil {
+		return "", common.NewUnmarshalJSONError("created maintenance window", err)
+	}
+
+	return createdItem.ID, nil
+}
+
+// Delete deletes the maintenance window with the specified ID or returns an error.
+func (mwc *MaintenanceWindowsClient) Delete(ctx context.Context, maintenanceWindowID string) error {
+	_, err := mwc.client.Delete(ctx, maintenanceWindowsPath+"/"+maintenanceWindowID)
+	if err != nil {
+		return fmt.Errorf("could not delete maintenance window with ID %s: %w", maintenanceWindowID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package dynatrace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaintenanceWindowsClient_GetStubsByNamePrefix(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/config/v1/maintenanceWindows", "./testdata/test_maintenancewindowsclient_getall.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	stubs, err := NewMaintenanceWindowsClient(dtClient).GetStubsByNamePrefix(context.TODO(), "Keptn deployment: ")
+
+	assert.NoError(t, err)
+	assert.EqualValues(t,
+		[]MaintenanceWindowStub{
+			{
+				ID:          "7c9f6d7e-5b0a-4b4e-9b4f-6c1d3f7a2b10",
+				Name:        "Keptn deployment: sockshop production carts (a1b2c3d4-0000-1111-2222-333344445555)",
+				Description: "Opened by Keptn dynatrace-service for the deployment of service carts in stage production of project sockshop, ends at 2022-05-02T11:15:00Z",
+			},
+		},
+		stubs)
+}
+
+func TestNewMWScopeFromAttachRules(t *testing.T) {
+	attachRules := AttachRules{
+		TagRule: []TagRule{
+			{
+				MeTypes: []string{"SERVICE", "PROCESS_GROUP"},
+				Tags: []TagEntry{
+					{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"},
+					{Context: "ENVIRONMENT", Key: "keptn_managed"},
+				},
+			},
+		},
+	}
+
+	expectedTags := []MWTagFilter{
+		{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"},
+		{Context: "ENVIRONMENT", Key: "keptn_managed"},
+	}
+
+	assert.EqualValues(t,
+		&MWScope{
+			Entities: []string{},
+			Matches: []MWScopeMatch{
+				{Type: "SERVICE", Tags: expectedTags, TagCombination: "AND"},
+				{Type: "PROCESS_GROUP", Tags: expectedTags, TagCombination: "AND"},
+			},
+		},
+		NewMWScopeFromAttachRules(attachRules))
+}
+
+func TestNewOnceMaintenanceWindowSchedule(t *testing.T) {
+	start := time.Date(2022, 5, 2, 10, 15, 30, 0, time.UTC)
+	schedule := NewOnceMaintenanceWindowSchedule(start, start.Add(time.Hour))
+
+	assert.EqualValues(t, "2022-05-02 10:15", schedule.Start)
+	assert.EqualValues(t, "2022-05-02 11:15", schedule.End)
+	assert.EqualValues(t, "UTC", schedule.ZoneID)
+}
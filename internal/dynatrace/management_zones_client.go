@@ -2,8 +2,19 @@ package dynatrace
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 )
 
 type ManagementZone struct {
@@ -38,8 +49,140 @@ type MZRules struct {
 
 const managementZonesPath = "/api/config/v1/managementZones"
 
+// managementZonesSchemaID is the Settings 2.0 schema of management zones
+const managementZonesSchemaID = "builtin:management-zones"
+
+// managementZoneAndMetricEventSchemaIDs are the schemas that must both be available to manage management zones and metric events using the Settings 2.0 API, as metric events reference management zones by ID
+var managementZoneAndMetricEventSchemaIDs = []string{managementZonesSchemaID, metricEventsSchemaID}
+
+// managementZoneSettingsValue is the value of a management zone settings object
+type managementZoneSettingsValue struct {
+	Name  string                            `json:"name"`
+	Rules []managementZoneSettingsValueRule `json:"rules"`
+}
+
+type managementZoneSettingsValueRule struct {
+	Enabled       bool                                `json:"enabled"`
+	Type          string                              `json:"type"`
+	AttributeRule managementZoneSettingsAttributeRule `json:"attributeRule"`
+}
+
+type managementZoneSettingsAttributeRule struct {
+	EntityType string                            `json:"entityType"`
+	Conditions []managementZoneSettingsCondition `json:"conditions"`
+}
+
+type managementZoneSettingsCondition struct {
+	Key      string `json:"key"`
+	Operator string `json:"operator"`
+	Tag      string `json:"tag"`
+}
+
+// newManagementZoneSettingsValue converts a management zone of the Configuration API v1 into a settings value.
+func newManagementZoneSettingsValue(managementZone *ManagementZone) managementZoneSettingsValue {
+	value := managementZoneSettingsValue{
+		Name:  managementZone.Name,
+		Rules: make([]managementZoneSettingsValueRule, 0, len(managementZone.Rules)),
+	}
+
+	for _, rule := range managementZone.Rules {
+		attributeRule := managementZoneSettingsAttributeRule{
+			EntityType: rule.Type,
+			Conditions: make([]managementZoneSettingsCondition, 0, len(rule.Conditions)),
+		}
+
+		for _, condition := range rule.Conditions {
+			operator := condition.ComparisonInfo.Operator
+			if condition.ComparisonInfo.Negate {
+				operator = "NOT_" + operator
+			}
+
+			attributeRule.Conditions = append(attributeRule.Conditions, managementZoneSettingsCondition{
+				Key:      condition.Key.Attribute,
+				Operator: operator,
+				Tag:      formatSettingsTag(condition.ComparisonInfo.Value),
+			})
+		}
+
+		value.Rules = append(value.Rules, managementZoneSettingsValueRule{
+			Enabled:       rule.Enabled,
+			Type:          "ME",
+			AttributeRule: attributeRule,
+		})
+	}
+
+	return value
+}
+
+// formatSettingsTag formats a tag as expected by management zone and metric event settings, e.g. keptn_project:sockshop or [Environment]owner:team-a.
+func formatSettingsTag(tag MZValue) string {
+	formattedTag := tag.Key
+	if tag.Value != "" {
+		formattedTag += ":" + tag.Value
+	}
+
+	if tag.Context != "" && tag.Context != "CONTEXTLESS" {
+		formattedTag = "[" + tag.Context + "]" + formattedTag
+	}
+
+	return formattedTag
+}
+
+// newManagementZoneFromSettingsValue converts a management zone settings value into a management zone of the Configuration API v1.
+func newManagementZoneFromSettingsValue(value managementZoneSettingsValue) *ManagementZone {
+	managementZone := &ManagementZone{
+		Name:  value.Name,
+		Rules: make([]MZRules, 0, len(value.Rules)),
+	}
+
+	for _, rule := range value.Rules {
+		mzRule := MZRules{
+			Type:             rule.AttributeRule.EntityType,
+			Enabled:          rule.Enabled,
+			PropagationTypes: []string{},
+			Conditions:       make([]MZConditions, 0, len(rule.AttributeRule.Conditions)),
+		}
+
+		for _, condition := range rule.AttributeRule.Conditions {
+			operator := strings.TrimPrefix(condition.Operator, "NOT_")
+			mzRule.Conditions = append(mzRule.Conditions, MZConditions{
+				Key: MZKey{Attribute: condition.Key},
+				ComparisonInfo: MZComparisonInfo{
+					Type:     "TAG",
+					Operator: operator,
+					Value:    parseSettingsTag(condition.Tag),
+					Negate:   operator != condition.Operator,
+				},
+			})
+		}
+
+		managementZone.Rules = append(managementZone.Rules, mzRule)
+	}
+
+	return managementZone
+}
+
+// parseSettingsTag parses a tag formatted by formatSettingsTag.
+func parseSettingsTag(formattedTag string) MZValue {
+	tag := MZValue{Context: "CONTEXTLESS"}
+	if strings.HasPrefix(formattedTag, "[") {
+		if i := strings.Index(formattedTag, "]"); i > 0 {
+			tag.Context = formattedTag[1:i]
+			formattedTag = formattedTag[i+1:]
+		}
+	}
+
+	tag.Key = formattedTag
+	if i := strings.Index(formattedTag, ":"); i >= 0 {
+		tag.Key, tag.Value = formattedTag[:i], formattedTag[i+1:]
+	}
+
+	return tag
+}
+
 type ManagementZones struct {
-	values map[string]values
+	values     map[string]values
+	numericIDs map[string]string
 }
 
 func (mz *ManagementZones) GetByName(name string) (values, bool) {
@@ -47,23 +190,58 @@ func (mz *ManagementZones) GetByName(name string) (values, bool) {
 	return value, exists
 }
 
+// GetNumericIDByName returns the numeric ID of the management zone, as referenced by metric events and maintenance windows.
+// Unlike the ID returned by GetByName, it is also numeric if the Settings 2.0 API is used.
+func (mz *ManagementZones) GetNumericIDByName(name string) (string, bool) {
+	numericID, exists := mz.numericIDs[name]
+	return numericID, exists
+}
+
 func (mz *ManagementZones) Contains(name string) bool {
 	_, exists := mz.GetByName(name)
 	return exists
 }
 
+// GetNames returns the sorted names of all management zones.
+func (mz *ManagementZones) GetNames() []string {
+	names := make([]string, 0, len(mz.values))
+	for name := range mz.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ManagementZonesClient is a client for managing management zones using the Settings 2.0 API or, if not available, the Configuration API v1.
 type ManagementZonesClient struct {
-	client ClientInterface
+	client           ClientInterface
+	settingsSelector *settingsAPISelector
 }
 
 func NewManagementZonesClient(client ClientInterface) *ManagementZonesClient {
 	return &ManagementZonesClient{
-		client: client,
+		client:           client,
+		settingsSelector: newSettingsAPISelector(client, managementZoneAndMetricEventSchemaIDs...),
 	}
 }
 
 // GetAll gets all management zones.
+// If the Settings 2.0 API is used, the IDs of the management zones are their object IDs, references to management zones must use GetNumericIDByName instead.
 func (mzc *ManagementZonesClient) GetAll(ctx context.Context) (*ManagementZones, error) {
+	useSettingsAPI, err := mzc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		mzs, err := getSettingsObjectsAsListResponse(ctx, NewSettingsClient(mzc.client), managementZonesSchemaID, "name")
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve management zones: %v", err)
+		}
+
+		return transformSettingsToManagementZones(mzs), nil
+	}
+
 	response, err := mzc.client.Get(ctx, managementZonesPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve management zones: %v", err)
@@ -80,17 +258,93 @@ func (mzc *ManagementZonesClient) GetAll(ctx context.Context) (*ManagementZones,
 
 func transformToManagementZones(response *listResponse) *ManagementZones {
 	managementZones := &ManagementZones{
-		values: make(map[string]values, len(response.Values)),
+		values:     make(map[string]values, len(response.Values)),
+		numericIDs: make(map[string]string, len(response.Values)),
 	}
 	for _, value := range response.Values {
 		managementZones.values[value.Name] = value
+		managementZones.numericIDs[value.Name] = value.ID
 	}
 
 	return managementZones
 }
 
+// transformSettingsToManagementZones transforms management zone settings objects, deriving the numeric IDs from their object IDs.
+func transformSettingsToManagementZones(response *listResponse) *ManagementZones {
+	managementZones := &ManagementZones{
+		values:     make(map[string]values, len(response.Values)),
+		numericIDs: make(map[string]string, len(response.Values)),
+	}
+	for _, value := range response.Values {
+		managementZones.values[value.Name] = value
+
+		numericID, err := getManagementZoneNumericID(value.ID)
+		if err != nil {
+			log.WithError(err).WithField("name", value.Name).Warn("Could not determine numeric ID of management zone")
+			continue
+		}
+		managementZones.numericIDs[value.Name] = numericID
+	}
+
+	return managementZones
+}
+
+// getManagementZoneNumericID returns the numeric ID of the management zone with the specified settings object ID.
+// The object ID encodes the schema, the scope and the UUID of the object, the numeric ID is derived from the UUID by XORing its two halves.
+func getManagementZoneNumericID(objectID string) (string, error) {
+	decodedObjectID, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(objectID, "="))
+	if err != nil {
+		return "", fmt.Errorf("could not decode object ID %s: %w", objectID, err)
+	}
+
+	// skip the 8 byte magic prefix and the 4 byte version, followed by the length-prefixed schema ID, scope type, scope and UUID
+	offset := 12
+	fields := make([]string, 0, 4)
+	for len(fields) < 4 {
+		if len(decodedObjectID) < offset+2 {
+			return "", fmt.Errorf("could not decode object ID %s: %w", objectID, errors.New("object ID is too short"))
+		}
+
+		length := int(binary.BigEndian.Uint16(decodedObjectID[offset:]))
+		offset += 2
+		if len(decodedObjectID) < offset+length {
+			return "", fmt.Errorf("could not decode object ID %s: %w", objectID, errors.New("object ID is too short"))
+		}
+
+		fields = append(fields, string(decodedObjectID[offset:offset+length]))
+		offset += length
+	}
+
+	if fields[0] != managementZonesSchemaID {
+		return "", fmt.Errorf("object ID %s does not belong to a management zone", objectID)
+	}
+
+	objectUUID, err := uuid.Parse(fields[3])
+	if err != nil {
+		return "", fmt.Errorf("could not parse UUID of object ID %s: %w", objectID, err)
+	}
+
+	mostSignificantBits := binary.BigEndian.Uint64(objectUUID[:8])
+	leastSignificantBits := binary.BigEndian.Uint64(objectUUID[8:])
+	return strconv.FormatInt(int64(mostSignificantBits^leastSignificantBits), 10), nil
+}
+
 // Create creates a management zone.
 func (mzc *ManagementZonesClient) Create(ctx context.Context, managementZone *ManagementZone) error {
+	useSettingsAPI, err := mzc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		_, err := NewSettingsClient(mzc.client).Create(ctx, managementZonesSchemaID, newManagementZoneSettingsValue(managementZone))
+		if err != nil {
+			return fmt.Errorf("failed to create management zone: %v", err)
+		}
+
+		return nil
+	}
+
 	mzPayload, err := json.Marshal(managementZone)
 	if err != nil {
 		return fmt.Errorf("failed to marshal management zone for project: %v", err)
@@ -103,3 +357,98 @@ func (mzc *ManagementZonesClient) Create(ctx context.Context, managementZone *Ma
 
 	return nil
 }
+
+// Update updates the management zone with the specified ID.
+func (mzc *ManagementZonesClient) Update(ctx context.Context, managementZoneID string, managementZone *ManagementZone) error {
+	useSettingsAPI, err := mzc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		err := NewSettingsClient(mzc.client).Update(ctx, managementZoneID, newManagementZoneSettingsValue(managementZone))
+		if err != nil {
+			return fmt.Errorf("failed to update management zone with ID: %s, %v", managementZoneID, err)
+		}
+
+		return nil
+	}
+
+	mzPayload, err := json.Marshal(managementZone)
+	if err != nil {
+		return fmt.Errorf("failed to marshal management zone: %v", err)
+	}
+
+	_, err = mzc.client.Put(ctx, managementZonesPath+"/"+managementZoneID, mzPayload)
+	if err != nil {
+		return fmt.Errorf("failed to update management zone with ID: %s, %v", managementZoneID, err)
+	}
+
+	return nil
+}
+
+// GetByID gets the management zone with the specified ID.
+func (mzc *ManagementZonesClient) GetByID(ctx context.Context, managementZoneID string) (*ManagementZone, error) {
+	useSettingsAPI, err := mzc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		response, err := mzc.client.Get(ctx, settingsObjectsPath+"/"+managementZoneID)
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve management zone with ID: %s, %v", managementZoneID, err)
+		}
+
+		object := &SettingsObject{}
+		err = json.Unmarshal(response, object)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("management zone settings object", err)
+		}
+
+		value := managementZoneSettingsValue{}
+		err = json.Unmarshal(object.Value, &value)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("management zone settings value", err)
+		}
+
+		return newManagementZoneFromSettingsValue(value), nil
+	}
+
+	response, err := mzc.client.Get(ctx, managementZonesPath+"/"+managementZoneID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve management zone with ID: %s, %v", managementZoneID, err)
+	}
+
+	managementZone := &ManagementZone{}
+	err = json.Unmarshal(response, managementZone)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("management zone", err)
+	}
+
+	return managementZone, nil
+}
+
+// Delete deletes the management zone with the specified ID.
+func (mzc *ManagementZonesClient) Delete(ctx context.Context, managementZoneID string) error {
+	useSettingsAPI, err := mzc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		err := NewSettingsClient(mzc.client).Delete(ctx, managementZoneID)
+		if err != nil {
+			return fmt.Errorf("failed to delete management zone with ID: %s, %v", managementZoneID, err)
+		}
+
+		return nil
+	}
+
+	_, err = mzc.client.Delete(ctx, managementZonesPath+"/"+managementZoneID)
+	if err != nil {
+		return fmt.Errorf("failed to delete management zone with ID: %s, %v", managementZoneID, err)
+	}
+
+	return nil
+}
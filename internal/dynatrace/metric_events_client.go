@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -28,12 +29,34 @@ type MetricEvent struct {
 	TagFilters        []METagFilter     `json:"tagFilters,omitempty"`
 	AlertingScope     []MEAlertingScope `json:"alertingScope"`
 	Unit              string            `json:"unit,omitempty"`
+
+	// MonitoringStrategy is only set for metric events using a baseline instead of a static threshold
+	MonitoringStrategy *MEMonitoringStrategy `json:"monitoringStrategy,omitempty"`
 }
 type MEMetadata struct {
 	ConfigurationVersions []int  `json:"configurationVersions"`
 	ClusterVersion        string `json:"clusterVersion"`
 }
 
+const (
+	// MonitoringStrategyAutoAdaptiveBaseline alerts if a metric leaves the baseline learned from its recent values by more than a number of signal fluctuations
+	MonitoringStrategyAutoAdaptiveBaseline = "AUTO_ADAPTIVE_BASELINE"
+
+	// MonitoringStrategySeasonalBaseline alerts if a metric leaves the confidence band of its seasonal baseline, which is only supported by the Settings 2.0 API
+	MonitoringStrategySeasonalBaseline = "SEASONAL_BASELINE"
+)
+
+// MEMonitoringStrategy is the monitoring strategy of a metric event using a baseline
+type MEMonitoringStrategy struct {
+	Type                       string  `json:"type"`
+	AlertCondition             string  `json:"alertCondition"`
+	Samples                    int     `json:"samples"`
+	ViolatingSamples           int     `json:"violatingSamples"`
+	DealertingSamples          int     `json:"dealertingSamples"`
+	NumberOfSignalFluctuations float64 `json:"numberOfSignalFluctuations,omitempty"`
+	Tolerance                  float64 `json:"tolerance,omitempty"`
+}
+
 type METagFilter struct {
 	Context string `json:"context"`
 	Key     string `json:"key"`
@@ -42,20 +65,243 @@ type METagFilter struct {
 type MEAlertingScope struct {
 	FilterType       string       `json:"filterType"`
 	TagFilter        *METagFilter `json:"tagFilter"`
-	ManagementZoneID int64        `json:"managementZoneId,omitempty"`
+	ManagementZoneID json.Number  `json:"managementZoneId,omitempty"`
+	EntityID         string       `json:"entityId,omitempty"`
+}
+
+// metricEventsSchemaID is the Settings 2.0 schema of metric events
+const metricEventsSchemaID = "builtin:anomaly-detection.metric-events"
+
+// metricEventSettingsValue is the value of a metric event settings object
+type metricEventSettingsValue struct {
+	Enabled         bool                               `json:"enabled"`
+	Summary         string                             `json:"summary"`
+	QueryDefinition metricEventSettingsQueryDefinition `json:"queryDefinition"`
+	ModelProperties metricEventSettingsModelProperties `json:"modelProperties"`
+	EventTemplate   metricEventSettingsEventTemplate   `json:"eventTemplate"`
+}
+
+type metricEventSettingsQueryDefinition struct {
+	Type           string                          `json:"type"`
+	MetricKey      string                          `json:"metricKey"`
+	Aggregation    string                          `json:"aggregation,omitempty"`
+	ManagementZone string                          `json:"managementZone,omitempty"`
+	EntityFilter   metricEventSettingsEntityFilter `json:"entityFilter"`
+}
+
+type metricEventSettingsEntityFilter struct {
+	Conditions []metricEventSettingsEntityFilterCondition `json:"conditions"`
+}
+
+type metricEventSettingsEntityFilterCondition struct {
+	Type     string `json:"type"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+type metricEventSettingsModelProperties struct {
+	Type              string  `json:"type"`
+	Threshold         float64 `json:"threshold"`
+	AlertOnNoData     bool    `json:"alertOnNoData"`
+	AlertCondition    string  `json:"alertCondition"`
+	Samples           int     `json:"samples"`
+	ViolatingSamples  int     `json:"violatingSamples"`
+	DealertingSamples int     `json:"dealertingSamples"`
+	SignalFluctuation float64 `json:"signalFluctuation,omitempty"`
+	Tolerance         float64 `json:"tolerance,omitempty"`
+}
+
+type metricEventSettingsEventTemplate struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	EventType   string `json:"eventType"`
+	DavisMerge  bool   `json:"davisMerge"`
+}
+
+// metricEventSettingsModelTypes maps the monitoring strategies of the Configuration API v1 to the model types used by settings
+var metricEventSettingsModelTypes = map[string]string{
+	MonitoringStrategyAutoAdaptiveBaseline: "AUTO_ADAPTIVE_THRESHOLD",
+	MonitoringStrategySeasonalBaseline:     "SEASONAL_BASELINE",
+}
+
+const metricEventSettingsStaticThresholdModelType = "STATIC_THRESHOLD"
+
+// metricEventSettingsAggregations maps the aggregation types of the Configuration API v1 which differ from those used by settings
+var metricEventSettingsAggregations = map[string]string{
+	"P90": "PERCENTILE90",
+}
+
+// newMetricEventSettingsValue converts a metric event of the Configuration API v1 into a settings value.
+// Tag and entity filters of the alerting scope are converted into entity filter conditions.
+func newMetricEventSettingsValue(metricEvent *MetricEvent) metricEventSettingsValue {
+	aggregation := metricEvent.AggregationType
+	if settingsAggregation, ok := metricEventSettingsAggregations[aggregation]; ok {
+		aggregation = settingsAggregation
+	}
+
+	value := metricEventSettingsValue{
+		Enabled: metricEvent.Enabled,
+		Summary: metricEvent.Name,
+		QueryDefinition: metricEventSettingsQueryDefinition{
+			Type:        "METRIC_KEY",
+			MetricKey:   metricEvent.MetricID,
+			Aggregation: aggregation,
+			EntityFilter: metricEventSettingsEntityFilter{
+				Conditions: []metricEventSettingsEntityFilterCondition{},
+			},
+		},
+		ModelProperties: metricEventSettingsModelProperties{
+			Type:              metricEventSettingsStaticThresholdModelType,
+			Threshold:         metricEvent.Threshold,
+			AlertCondition:    metricEvent.AlertCondition,
+			Samples:           metricEvent.Samples,
+			ViolatingSamples:  metricEvent.ViolatingSamples,
+			DealertingSamples: metricEvent.DealertingSamples,
+		},
+		EventTemplate: metricEventSettingsEventTemplate{
+			Title:       metricEvent.Name,
+			Description: metricEvent.Description,
+			EventType:   metricEvent.EventType,
+			DavisMerge:  true,
+		},
+	}
+
+	if strategy := metricEvent.MonitoringStrategy; strategy != nil {
+		value.ModelProperties = metricEventSettingsModelProperties{
+			Type:              metricEventSettingsModelTypes[strategy.Type],
+			AlertCondition:    strategy.AlertCondition,
+			Samples:           strategy.Samples,
+			ViolatingSamples:  strategy.ViolatingSamples,
+			DealertingSamples: strategy.DealertingSamples,
+			SignalFluctuation: strategy.NumberOfSignalFluctuations,
+			Tolerance:         strategy.Tolerance,
+		}
+	}
+
+	for _, scope := range metricEvent.AlertingScope {
+		switch {
+		case scope.FilterType == "MANAGEMENT_ZONE":
+			value.QueryDefinition.ManagementZone = scope.ManagementZoneID.String()
+		case scope.FilterType == "TAG" && scope.TagFilter != nil:
+			tag := formatSettingsTag(MZValue{Context: scope.TagFilter.Context, Key: scope.TagFilter.Key, Value: scope.TagFilter.Value})
+			value.QueryDefinition.EntityFilter.Conditions = append(value.QueryDefinition.EntityFilter.Conditions, metricEventSettingsEntityFilterCondition{
+				Type:     "TAG",
+				Operator: "EQUALS",
+				Value:    tag,
+			})
+		case scope.FilterType == "ENTITY_ID":
+			value.QueryDefinition.EntityFilter.Conditions = append(value.QueryDefinition.EntityFilter.Conditions, metricEventSettingsEntityFilterCondition{
+				Type:     "ENTITY_ID",
+				Operator: "EQUALS",
+				Value:    scope.EntityID,
+			})
+		}
+	}
+
+	return value
+}
+
+// newMetricEventFromSettingsValue converts a metric event settings object into a metric event of the Configuration API v1 using the object ID as ID.
+func newMetricEventFromSettingsValue(objectID string, value metricEventSettingsValue) *MetricEvent {
+	aggregation := value.QueryDefinition.Aggregation
+	for v1Aggregation, settingsAggregation := range metricEventSettingsAggregations {
+		if settingsAggregation == aggregation {
+			aggregation = v1Aggregation
+		}
+	}
+
+	metricEvent := &MetricEvent{
+		ID:                objectID,
+		MetricID:          value.QueryDefinition.MetricKey,
+		Name:              value.Summary,
+		Description:       value.EventTemplate.Description,
+		AggregationType:   aggregation,
+		EventType:         value.EventTemplate.EventType,
+		Severity:          value.EventTemplate.EventType,
+		AlertCondition:    value.ModelProperties.AlertCondition,
+		Samples:           value.ModelProperties.Samples,
+		ViolatingSamples:  value.ModelProperties.ViolatingSamples,
+		DealertingSamples: value.ModelProperties.DealertingSamples,
+		Threshold:         value.ModelProperties.Threshold,
+		Enabled:           value.Enabled,
+	}
+
+	for strategyType, modelType := range metricEventSettingsModelTypes {
+		if modelType == value.ModelProperties.Type {
+			metricEvent.MonitoringStrategy = &MEMonitoringStrategy{
+				Type:                       strategyType,
+				AlertCondition:             value.ModelProperties.AlertCondition,
+				Samples:                    value.ModelProperties.Samples,
+				ViolatingSamples:           value.ModelProperties.ViolatingSamples,
+				DealertingSamples:          value.ModelProperties.DealertingSamples,
+				NumberOfSignalFluctuations: value.ModelProperties.SignalFluctuation,
+				Tolerance:                  value.ModelProperties.Tolerance,
+			}
+		}
+	}
+
+	if value.QueryDefinition.ManagementZone != "" {
+		metricEvent.AlertingScope = append(metricEvent.AlertingScope, MEAlertingScope{
+			FilterType:       "MANAGEMENT_ZONE",
+			ManagementZoneID: json.Number(value.QueryDefinition.ManagementZone),
+		})
+	}
+
+	for _, condition := range value.QueryDefinition.EntityFilter.Conditions {
+		if condition.Type == "ENTITY_ID" {
+			metricEvent.AlertingScope = append(metricEvent.AlertingScope, MEAlertingScope{
+				FilterType: "ENTITY_ID",
+				EntityID:   condition.Value,
+			})
+			continue
+		}
+
+		if condition.Type != "TAG" {
+			continue
+		}
+
+		tag := parseSettingsTag(condition.Value)
+		metricEvent.AlertingScope = append(metricEvent.AlertingScope, MEAlertingScope{
+			FilterType: "TAG",
+			TagFilter: &METagFilter{
+				Context: tag.Context,
+				Key:     tag.Key,
+				Value:   tag.Value,
+			},
+		})
+	}
+
+	return metricEvent
 }
 
+// MetricEventsClient is a client for managing metric events using the Settings 2.0 API or, if not available, the Configuration API v1.
 type MetricEventsClient struct {
-	client ClientInterface
+	client           ClientInterface
+	settingsSelector *settingsAPISelector
 }
 
 func NewMetricEventsClient(client ClientInterface) *MetricEventsClient {
 	return &MetricEventsClient{
-		client: client,
+		client:           client,
+		settingsSelector: newSettingsAPISelector(client, managementZoneAndMetricEventSchemaIDs...),
 	}
 }
 
 func (mec *MetricEventsClient) getAll(ctx context.Context) (*listResponse, error) {
+	useSettingsAPI, err := mec.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		response, err := getSettingsObjectsAsListResponse(ctx, NewSettingsClient(mec.client), metricEventsSchemaID, "summary")
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve list of existing Dynatrace metric events: %v", err)
+		}
+
+		return response, nil
+	}
+
 	res, err := mec.client.Get(ctx, metricEventsPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve list of existing Dynatrace metric events: %v", err)
@@ -71,6 +317,15 @@ func (mec *MetricEventsClient) getAll(ctx context.Context) (*listResponse, error
 }
 
 func (mec *MetricEventsClient) getByID(ctx context.Context, metricEventID string) (*MetricEvent, error) {
+	useSettingsAPI, err := mec.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		return mec.getSettingsObjectByID(ctx, metricEventID)
+	}
+
 	res, err := mec.client.Get(ctx, metricEventsPath+"/"+metricEventID)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve metric event with ID: %s, %v", metricEventID, err)
@@ -85,8 +340,48 @@ func (mec *MetricEventsClient) getByID(ctx context.Context, metricEventID string
 	return retrievedMetricEvent, nil
 }
 
+func (mec *MetricEventsClient) getSettingsObjectByID(ctx context.Context, objectID string) (*MetricEvent, error) {
+	res, err := mec.client.Get(ctx, settingsObjectsPath+"/"+objectID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve metric event with ID: %s, %v", objectID, err)
+	}
+
+	object := &SettingsObject{}
+	err = json.Unmarshal(res, object)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("metric event settings object", err)
+	}
+
+	value := metricEventSettingsValue{}
+	err = json.Unmarshal(object.Value, &value)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("metric event settings value", err)
+	}
+
+	return newMetricEventFromSettingsValue(objectID, value), nil
+}
+
 // Create creates a metric event.
 func (mec *MetricEventsClient) Create(ctx context.Context, metricEvent *MetricEvent) error {
+	useSettingsAPI, err := mec.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		_, err := NewSettingsClient(mec.client).Create(ctx, metricEventsSchemaID, newMetricEventSettingsValue(metricEvent))
+		if err != nil {
+			return fmt.Errorf("could not create metric event: %v", err)
+		}
+
+		return nil
+	}
+
+	err = checkMonitoringStrategySupportedByConfigurationAPI(metricEvent)
+	if err != nil {
+		return fmt.Errorf("could not create metric event: %v", err)
+	}
+
 	mePayload, err := json.Marshal(metricEvent)
 	if err != nil {
 		return fmt.Errorf("could not marshal metric event: %v", err)
@@ -102,6 +397,25 @@ func (mec *MetricEventsClient) Create(ctx context.Context, metricEvent *MetricEv
 
 // Update updates a metric event.
 func (mec *MetricEventsClient) Update(ctx context.Context, metricEvent *MetricEvent) error {
+	useSettingsAPI, err := mec.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		err := NewSettingsClient(mec.client).Update(ctx, metricEvent.ID, newMetricEventSettingsValue(metricEvent))
+		if err != nil {
+			return fmt.Errorf("could not update metric event: %v", err)
+		}
+
+		return nil
+	}
+
+	err = checkMonitoringStrategySupportedByConfigurationAPI(metricEvent)
+	if err != nil {
+		return fmt.Errorf("could not update metric event: %v", err)
+	}
+
 	mePayload, err := json.Marshal(metricEvent)
 	if err != nil {
 		return fmt.Errorf("could not marshal metric event: %v", err)
@@ -115,8 +429,31 @@ func (mec *MetricEventsClient) Update(ctx context.Context, metricEvent *MetricEv
 	return nil
 }
 
-func (mec *MetricEventsClient) deleteByID(ctx context.Context, metricEventID string) error {
-	_, err := mec.client.Delete(ctx, metricEventsPath+"/"+metricEventID)
+// checkMonitoringStrategySupportedByConfigurationAPI returns an error if the metric event uses a seasonal baseline, which cannot be managed using the Configuration API v1.
+func checkMonitoringStrategySupportedByConfigurationAPI(metricEvent *MetricEvent) error {
+	if metricEvent.MonitoringStrategy != nil && metricEvent.MonitoringStrategy.Type == MonitoringStrategySeasonalBaseline {
+		return fmt.Errorf("seasonal baselines require the Settings 2.0 API")
+	}
+	return nil
+}
+
+// DeleteByID deletes the metric event with the specified ID.
+func (mec *MetricEventsClient) DeleteByID(ctx context.Context, metricEventID string) error {
+	useSettingsAPI, err := mec.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		err := NewSettingsClient(mec.client).Delete(ctx, metricEventID)
+		if err != nil {
+			return fmt.Errorf("could not delete metric event with ID: %s, %v", metricEventID, err)
+		}
+
+		return nil
+	}
+
+	_, err = mec.client.Delete(ctx, metricEventsPath+"/"+metricEventID)
 	if err != nil {
 		return fmt.Errorf("could not delete metric event with ID: %s, %v", metricEventID, err)
 	}
@@ -146,6 +483,28 @@ func (mec *MetricEventsClient) GetMetricEventByName(ctx context.Context, metricE
 	return nil, nil
 }
 
+// GetMetricEventsByName retrieves all metric events whose name is matched by matchName.
+func (mec *MetricEventsClient) GetMetricEventsByName(ctx context.Context, matchName func(name string) bool) ([]*MetricEvent, error) {
+	res, err := mec.getAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var metricEvents []*MetricEvent
+	for _, metricEvent := range res.Values {
+		if !matchName(metricEvent.Name) {
+			continue
+		}
+
+		existingMetricEvent, err := mec.getByID(ctx, metricEvent.ID)
+		if err != nil {
+			return nil, err
+		}
+		metricEvents = append(metricEvents, existingMetricEvent)
+	}
+	return metricEvents, nil
+}
+
 // DeleteMetricEventByName deletes a metric event with the given name.
 func (mec *MetricEventsClient) DeleteMetricEventByName(ctx context.Context, metricEventName string) error {
 	res, err := mec.getAll(ctx)
@@ -156,7 +515,7 @@ func (mec *MetricEventsClient) DeleteMetricEventByName(ctx context.Context, metr
 
 	for _, metricEvent := range res.Values {
 		if metricEvent.Name == metricEventName {
-			err := mec.deleteByID(ctx, metricEvent.ID)
+			err := mec.DeleteByID(ctx, metricEvent.ID)
 			if err != nil {
 				log.WithError(err).WithField("eventKey", metricEventName).Error("Could not delete existing metric event")
 				return err
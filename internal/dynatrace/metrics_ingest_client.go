@@ -0,0 +1,105 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+)
+
+// MetricsIngestPath is the ingest endpoint for Metrics API v2
+const MetricsIngestPath = MetricsPath + "/ingest"
+
+type metricDimension struct {
+	key   string
+	value string
+}
+
+// MetricLine is a single data point in the Dynatrace metrics ingestion line protocol.
+type MetricLine struct {
+	key        string
+	dimensions []metricDimension
+	value      float64
+}
+
+// NewMetricLine creates a new MetricLine for the specified metric key and value.
+func NewMetricLine(key string, value float64) *MetricLine {
+	return &MetricLine{
+		key:   key,
+		value: value,
+	}
+}
+
+// AddDimension adds a dimension to the MetricLine and returns it to allow chaining.
+func (l *MetricLine) AddDimension(key string, value string) *MetricLine {
+	l.dimensions = append(l.dimensions, metricDimension{key: key, value: value})
+	return l
+}
+
+// String returns the MetricLine encoded in the line protocol, e.g. 'my.metric,dim="value" 42'.
+func (l *MetricLine) String() string {
+	sb := strings.Builder{}
+	sb.WriteString(l.key)
+	for _, dimension := range l.dimensions {
+		sb.WriteString(",")
+		sb.WriteString(dimension.key)
+		sb.WriteString("=")
+		sb.WriteString(quoteDimensionValue(dimension.value))
+	}
+	sb.WriteString(" ")
+	sb.WriteString(strconv.FormatFloat(l.value, 'f', -1, 64))
+	return sb.String()
+}
+
+// quoteDimensionValue quotes a dimension value so that it may contain spaces, commas and equal signs.
+func quoteDimensionValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// metricsIngestResult is the result returned by /api/v2/metrics/ingest
+type metricsIngestResult struct {
+	LinesOk      int `json:"linesOk"`
+	LinesInvalid int `json:"linesInvalid"`
+}
+
+// MetricsIngestClient is a client for ingesting metrics via the Dynatrace Metrics API v2
+type MetricsIngestClient struct {
+	client ClientInterface
+}
+
+// NewMetricsIngestClient creates a new MetricsIngestClient
+func NewMetricsIngestClient(client ClientInterface) *MetricsIngestClient {
+	return &MetricsIngestClient{
+		client: client,
+	}
+}
+
+// Ingest sends the specified lines to the Dynatrace API or returns an error if any of them were not accepted.
+func (c *MetricsIngestClient) Ingest(ctx context.Context, lines []*MetricLine) error {
+	encodedLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		encodedLines = append(encodedLines, line.String())
+	}
+
+	body, err := c.client.PostText(ctx, MetricsIngestPath, []byte(strings.Join(encodedLines, "\n")))
+	if err != nil {
+		return err
+	}
+
+	var result metricsIngestResult
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return common.NewUnmarshalJSONError("metrics ingest result", err)
+	}
+
+	if result.LinesInvalid > 0 {
+		return fmt.Errorf("Dynatrace API rejected %d of %d metric lines", result.LinesInvalid, len(lines))
+	}
+
+	return nil
+}
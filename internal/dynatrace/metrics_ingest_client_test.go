@@ -0,0 +1,70 @@
+package dynatrace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricLine_String(t *testing.T) {
+	tests := []struct {
+		name string
+		line *MetricLine
+		want string
+	}{
+		{
+			name: "without dimensions",
+			line: NewMetricLine("keptn.quality_gate.score", 100),
+			want: "keptn.quality_gate.score 100",
+		},
+		{
+			name: "with dimensions",
+			line: NewMetricLine("keptn.quality_gate.sli.value", 12.5).AddDimension("project", "sockshop").AddDimension("sli", "response_time_p95"),
+			want: `keptn.quality_gate.sli.value,project="sockshop",sli="response_time_p95" 12.5`,
+		},
+		{
+			name: "with dimension values requiring escaping",
+			line: NewMetricLine("keptn.quality_gate.sli.target", 600).AddDimension("criteria", `<=+10% "or" \ 600`),
+			want: `keptn.quality_gate.sli.target,criteria="<=+10% \"or\" \\ 600" 600`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.want, tt.line.String())
+		})
+	}
+}
+
+func TestMetricsIngestClient_Ingest(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/metrics/ingest", "./testdata/test_metricsingestclient_ingest.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	lines := []*MetricLine{
+		NewMetricLine("keptn.quality_gate.score", 100).AddDimension("project", "sockshop"),
+		NewMetricLine("keptn.quality_gate.result", 1).AddDimension("project", "sockshop").AddDimension("result", "pass"),
+	}
+	err := NewMetricsIngestClient(dtClient).Ingest(context.TODO(), lines)
+
+	assert.NoError(t, err)
+}
+
+func TestMetricsIngestClient_IngestWithInvalidLines(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/metrics/ingest", "./testdata/test_metricsingestclient_ingest_invalid.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	lines := []*MetricLine{
+		NewMetricLine("keptn.quality_gate.score", 100),
+		NewMetricLine("keptn quality gate", 1),
+	}
+	err := NewMetricsIngestClient(dtClient).Ingest(context.TODO(), lines)
+
+	assert.Error(t, err)
+}
@@ -4,27 +4,65 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
-	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 )
 
-const keptnProblemNotificationName = "Keptn Problem Notification"
+// KeptnProblemNotificationName is the default name of the problem notification created for Keptn
+const KeptnProblemNotificationName = "Keptn Problem Notification"
 
-const problemNotificationPayload string = `{ 
-      "type": "WEBHOOK", 
-      "name": "$KEPTN_PROBLEM_NOTIFICATION_NAME", 
-      "alertingProfile": "$ALERTING_PROFILE_ID", 
-      "active": true, 
-      "url": "$KEPTN_DNS/v1/event", 
-      "acceptAnyCertificate": true, 
-      "headers": [ 
-        { "name": "x-token", "value": "$KEPTN_TOKEN" },
-        { "name": "Content-Type", "value": "application/cloudevents+json" }
-      ],
-      "payload": "{\n    \"specversion\":\"1.0\",\n    \"type\":\"sh.keptn.events.problem\",\n    \"shkeptncontext\":\"{PID}\",\n    \"source\":\"dynatrace\",\n    \"id\":\"{PID}\",\n    \"time\":\"\",\n    \"contenttype\":\"application/json\",\n    \"data\": {\n        \"State\":\"{State}\",\n        \"ProblemID\":\"{ProblemID}\",\n        \"PID\":\"{PID}\",\n        \"ProblemTitle\":\"{ProblemTitle}\",\n        \"ProblemURL\":\"{ProblemURL}\",\n        \"ProblemDetails\":{ProblemDetailsJSON},\n        \"Tags\":\"{Tags}\",\n        \"ImpactedEntities\":{ImpactedEntities},\n        \"ImpactedEntity\":\"{ImpactedEntity}\",\n        \"KeptnProject\":\"$KEPTN_PROJECT\"\n    }\n}\n" 
+// KeptnProjectPlaceholder is replaced by the Keptn project in the payload template of a problem notification.
+const KeptnProjectPlaceholder = "$KEPTN_PROJECT"
 
-      }`
+// DefaultProblemNotificationPayloadTemplate is the payload template of problem notifications sending Dynatrace problems to Keptn as sh.keptn.events.problem events.
+// Placeholders in braces, e.g. {PID}, are replaced by Dynatrace when sending the notification.
+const DefaultProblemNotificationPayloadTemplate = `{
+    "specversion":"1.0",
+    "type":"sh.keptn.events.problem",
+    "shkeptncontext":"{PID}",
+    "source":"dynatrace",
+    "id":"{PID}",
+    "time":"",
+    "contenttype":"application/json",
+    "data": {
+        "State":"{State}",
+        "ProblemID":"{ProblemID}",
+        "PID":"{PID}",
+        "ProblemTitle":"{ProblemTitle}",
+        "ProblemURL":"{ProblemURL}",
+        "ProblemDetails":{ProblemDetailsJSON},
+        "Tags":"{Tags}",
+        "ImpactedEntities":{ImpactedEntities},
+        "ImpactedEntity":"{ImpactedEntity}",
+        "KeptnProject":"` + KeptnProjectPlaceholder + `"
+    }
+}
+`
+
+// TestProblemNotificationField is the field added to the data of test problem notifications sent to check that problem notifications reach Keptn.
+const TestProblemNotificationField = "DynatraceServiceTestNotification"
+
+var keptnProjectFieldRegexp = regexp.MustCompile(`"KeptnProject"\s*:\s*"[^"]*"`)
+
+// ProblemNotificationHeader is an HTTP header sent with a problem notification.
+// Values of secret headers, e.g. the Keptn API token, are masked by Dynatrace.
+type ProblemNotificationHeader struct {
+	Name   string
+	Value  string
+	Secret bool
+}
+
+// ProblemNotification is a webhook problem notification sending problems to Keptn.
+type ProblemNotification struct {
+	Name                 string
+	AlertingProfileID    string
+	URL                  string
+	AcceptAnyCertificate bool
+	Headers              []ProblemNotificationHeader
+	Payload              string
+}
 
 type NotificationsError struct {
 	errors []error
@@ -47,17 +85,130 @@ func (ne *NotificationsError) Error() string {
 
 const notificationsPath = "/api/config/v1/notifications"
 
+// notificationsTestPath is the Configuration API v1 endpoint asking Dynatrace to send a test notification for a notification configuration
+const notificationsTestPath = notificationsPath + "/test"
+
+// problemNotificationsSchemaID is the Settings 2.0 schema of problem notifications
+const problemNotificationsSchemaID = "builtin:problem.notifications"
+
+// webhookNotification is a webhook problem notification of the Configuration API v1
+type webhookNotification struct {
+	Type                 string                      `json:"type"`
+	Name                 string                      `json:"name"`
+	AlertingProfile      string                      `json:"alertingProfile"`
+	Active               bool                        `json:"active"`
+	URL                  string                      `json:"url"`
+	AcceptAnyCertificate bool                        `json:"acceptAnyCertificate"`
+	Headers              []webhookNotificationHeader `json:"headers"`
+	Payload              string                      `json:"payload"`
+}
+
+type webhookNotificationHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// problemNotificationSettingsValue is the value of a problem notification settings object
+type problemNotificationSettingsValue struct {
+	Enabled             bool                               `json:"enabled"`
+	NotificationType    string                             `json:"notificationType"`
+	DisplayName         string                             `json:"displayName"`
+	AlertingProfile     string                             `json:"alertingProfile"`
+	WebHookNotification problemNotificationSettingsWebhook `json:"webHookNotification"`
+}
+
+type problemNotificationSettingsWebhook struct {
+	URL                      string                                     `json:"url"`
+	AcceptAnyCertificate     bool                                       `json:"acceptAnyCertificate"`
+	NotifyEventMergesEnabled bool                                       `json:"notifyEventMergesEnabled"`
+	NotifyClosedProblems     bool                                       `json:"notifyClosedProblems"`
+	Headers                  []problemNotificationSettingsWebhookHeader `json:"headers"`
+	Payload                  string                                     `json:"payload"`
+}
+
+type problemNotificationSettingsWebhookHeader struct {
+	Name   string `json:"name"`
+	Secret bool   `json:"secret"`
+	Value  string `json:"value,omitempty"`
+
+	// SecretValue is used instead of Value for secret headers, e.g. the Keptn API token
+	SecretValue string `json:"secretValue,omitempty"`
+}
+
+// newWebhookNotification converts a problem notification into a webhook notification of the Configuration API v1.
+// The Configuration API v1 cannot mask header values, so secret headers are sent like any other header.
+func newWebhookNotification(notification ProblemNotification) webhookNotification {
+	headers := make([]webhookNotificationHeader, 0, len(notification.Headers))
+	for _, header := range notification.Headers {
+		headers = append(headers, webhookNotificationHeader{Name: header.Name, Value: header.Value})
+	}
+
+	return webhookNotification{
+		Type:                 "WEBHOOK",
+		Name:                 notification.Name,
+		AlertingProfile:      notification.AlertingProfileID,
+		Active:               true,
+		URL:                  notification.URL,
+		AcceptAnyCertificate: notification.AcceptAnyCertificate,
+		Headers:              headers,
+		Payload:              notification.Payload,
+	}
+}
+
+// newProblemNotificationSettingsValue converts a problem notification into a settings value.
+func newProblemNotificationSettingsValue(notification ProblemNotification) problemNotificationSettingsValue {
+	headers := make([]problemNotificationSettingsWebhookHeader, 0, len(notification.Headers))
+	for _, header := range notification.Headers {
+		if header.Secret {
+			headers = append(headers, problemNotificationSettingsWebhookHeader{Name: header.Name, Secret: true, SecretValue: header.Value})
+			continue
+		}
+		headers = append(headers, problemNotificationSettingsWebhookHeader{Name: header.Name, Value: header.Value})
+	}
+
+	return problemNotificationSettingsValue{
+		Enabled:          true,
+		NotificationType: "WEBHOOK",
+		DisplayName:      notification.Name,
+		AlertingProfile:  notification.AlertingProfileID,
+		WebHookNotification: problemNotificationSettingsWebhook{
+			URL:                  notification.URL,
+			AcceptAnyCertificate: notification.AcceptAnyCertificate,
+			NotifyClosedProblems: true,
+			Headers:              headers,
+			Payload:              notification.Payload,
+		},
+	}
+}
+
+// NotificationsClient is a client for managing problem notifications using the Settings 2.0 API or, if not available, the Configuration API v1.
 type NotificationsClient struct {
-	client ClientInterface
+	client           ClientInterface
+	settingsSelector *settingsAPISelector
 }
 
 func NewNotificationsClient(client ClientInterface) *NotificationsClient {
 	return &NotificationsClient{
-		client: client,
+		client:           client,
+		settingsSelector: newSettingsAPISelector(client, alertingProfileAndNotificationSchemaIDs...),
 	}
 }
 
 func (nc *NotificationsClient) getAll(ctx context.Context) (*listResponse, error) {
+	useSettingsAPI, err := nc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useSettingsAPI {
+		existingNotifications, err := getSettingsObjectsAsListResponse(ctx, NewSettingsClient(nc.client), problemNotificationsSchemaID, "displayName")
+		if err != nil {
+			return nil, fmt.Errorf("could not retrieve notifications: %v", err)
+		}
+
+		return existingNotifications, nil
+	}
+
 	response, err := nc.client.Get(ctx, notificationsPath)
 	if err != nil {
 		return nil, fmt.Errorf("could not retrieve notifications: %v", err)
@@ -72,23 +223,120 @@ func (nc *NotificationsClient) getAll(ctx context.Context) (*listResponse, error
 	return existingNotifications, nil
 }
 
-// DeleteExistingKeptnProblemNotifications deletes all existing Keptn problem notifications.
-func (nc *NotificationsClient) DeleteExistingKeptnProblemNotifications(ctx context.Context) error {
+// GetKeptnProblemNotificationIDs returns the IDs of all existing Keptn problem notifications with one of the specified names.
+func (nc *NotificationsClient) GetKeptnProblemNotificationIDs(ctx context.Context, names ...string) ([]string, error) {
 	existingNotifications, err := nc.getAll(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve notifications: %v", err)
+		return nil, fmt.Errorf("failed to retrieve notifications: %v", err)
 	}
 
-	notificationError := &NotificationsError{}
+	var ids []string
 	for _, notification := range existingNotifications.Values {
-		if notification.Name == keptnProblemNotificationName {
-			err := nc.deleteBy(ctx, notification.ID)
-			if err != nil {
-				// Error occurred but continue
-				notificationError.errors = append(
-					notificationError.errors,
-					fmt.Errorf("failed to delete notification with ID: %s", notification.ID))
-			}
+		if containsName(names, notification.Name) {
+			ids = append(ids, notification.ID)
+		}
+	}
+
+	return ids, nil
+}
+
+// GetKeptnProblemNotificationIDsForProject returns the IDs of the existing Keptn problem notifications with one of the specified names that send problems to the specified Keptn project.
+func (nc *NotificationsClient) GetKeptnProblemNotificationIDsForProject(ctx context.Context, project string, names ...string) ([]string, error) {
+	ids, err := nc.GetKeptnProblemNotificationIDs(ctx, names...)
+	if err != nil {
+		return nil, err
+	}
+
+	var projectIDs []string
+	for _, id := range ids {
+		payload, err := nc.getPayload(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if isProblemNotificationPayloadForProject(payload, project) {
+			projectIDs = append(projectIDs, id)
+		}
+	}
+
+	return projectIDs, nil
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isProblemNotificationPayloadForProject returns whether the payload of a Keptn problem notification contains the specified Keptn project.
+func isProblemNotificationPayloadForProject(payload string, project string) bool {
+	return strings.Contains(strings.Join(strings.Fields(payload), ""), `"KeptnProject":"`+project+`"`)
+}
+
+func (nc *NotificationsClient) getPayload(ctx context.Context, id string) (string, error) {
+	useSettingsAPI, err := nc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if useSettingsAPI {
+		response, err := nc.client.Get(ctx, settingsObjectsPath+"/"+id)
+		if err != nil {
+			return "", fmt.Errorf("could not retrieve notification with ID: %s, %v", id, err)
+		}
+
+		object := &SettingsObject{}
+		err = json.Unmarshal(response, object)
+		if err != nil {
+			return "", common.NewUnmarshalJSONError("problem notification settings object", err)
+		}
+
+		value := problemNotificationSettingsValue{}
+		err = json.Unmarshal(object.Value, &value)
+		if err != nil {
+			return "", common.NewUnmarshalJSONError("problem notification settings value", err)
+		}
+
+		return value.WebHookNotification.Payload, nil
+	}
+
+	response, err := nc.client.Get(ctx, notificationsPath+"/"+id)
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve notification with ID: %s, %v", id, err)
+	}
+
+	notification := webhookNotification{}
+	err = json.Unmarshal(response, &notification)
+	if err != nil {
+		return "", common.NewUnmarshalJSONError("problem notification", err)
+	}
+
+	return notification.Payload, nil
+}
+
+// DeleteExistingKeptnProblemNotifications deletes all existing Keptn problem notifications with one of the specified names.
+func (nc *NotificationsClient) DeleteExistingKeptnProblemNotifications(ctx context.Context, names ...string) error {
+	ids, err := nc.GetKeptnProblemNotificationIDs(ctx, names...)
+	if err != nil {
+		return err
+	}
+
+	return nc.DeleteKeptnProblemNotifications(ctx, ids...)
+}
+
+// DeleteKeptnProblemNotifications deletes the Keptn problem notifications with the specified IDs.
+func (nc *NotificationsClient) DeleteKeptnProblemNotifications(ctx context.Context, ids ...string) error {
+	notificationError := &NotificationsError{}
+	for _, id := range ids {
+		err := nc.DeleteByID(ctx, id)
+		if err != nil {
+			// Error occurred but continue
+			notificationError.errors = append(
+				notificationError.errors,
+				fmt.Errorf("failed to delete notification with ID: %s", id))
 		}
 	}
 
@@ -99,16 +347,24 @@ func (nc *NotificationsClient) DeleteExistingKeptnProblemNotifications(ctx conte
 	return nil
 }
 
-// Create creates a new default notification for the given KeptnAPICredentials and the alertingProfileID.
-func (nc *NotificationsClient) Create(ctx context.Context, credentials *credentials.KeptnCredentials, alertingProfileID string, project string) error {
-	notification := problemNotificationPayload
-	notification = strings.ReplaceAll(notification, "$KEPTN_DNS", credentials.GetAPIURL())
-	notification = strings.ReplaceAll(notification, "$KEPTN_TOKEN", credentials.GetAPIToken())
-	notification = strings.ReplaceAll(notification, "$ALERTING_PROFILE_ID", alertingProfileID)
-	notification = strings.ReplaceAll(notification, "$KEPTN_PROBLEM_NOTIFICATION_NAME", keptnProblemNotificationName)
-	notification = strings.ReplaceAll(notification, "$KEPTN_PROJECT", project)
+// Create creates the problem notification.
+func (nc *NotificationsClient) Create(ctx context.Context, notification ProblemNotification) error {
+	useSettingsAPI, err := nc.settingsSelector.isSettingsAPIAvailable(ctx)
+	if err != nil {
+		return err
+	}
+
+	if useSettingsAPI {
+		_, err := NewSettingsClient(nc.client).Create(ctx, problemNotificationsSchemaID, newProblemNotificationSettingsValue(notification))
+		return err
+	}
+
+	payload, err := json.Marshal(newWebhookNotification(notification))
+	if err != nil {
+		return common.NewMarshalJSONError("problem notification", err)
+	}
 
-	_, err := nc.client.Post(ctx, notificationsPath, []byte(notification))
+	_, err = nc.client.Post(ctx, notificationsPath, payload)
 	if err != nil {
 		return err
 	}
@@ -116,10 +372,45 @@ func (nc *NotificationsClient) Create(ctx context.Context, credentials *credenti
 	return nil
 }
 
-func (nc *NotificationsClient) deleteBy(ctx context.Context, id string) error {
-	_, err := nc.client.Delete(ctx, notificationsPath+"/"+id)
+// SendTest asks Dynatrace to send a test notification using the problem notification, with the {PID} placeholder of its payload replaced by the specified PID.
+// The test notification is marked by the TestProblemNotificationField, which is added next to the KeptnProject field every problem notification payload must contain.
+// This is only supported by the Configuration API v1, so it is used regardless of whether the Settings 2.0 API is available.
+func (nc *NotificationsClient) SendTest(ctx context.Context, notification ProblemNotification, pid string) error {
+	notification.Payload = getTestNotificationPayload(notification.Payload, pid)
+
+	payload, err := json.Marshal(newWebhookNotification(notification))
+	if err != nil {
+		return common.NewMarshalJSONError("test problem notification", err)
+	}
+
+	_, err = nc.client.Post(ctx, notificationsTestPath, payload)
+	if err != nil {
+		return fmt.Errorf("could not send test problem notification: %w", err)
+	}
+
+	return nil
+}
+
+// getTestNotificationPayload returns the payload with the {PID} placeholder replaced by the PID and the TestProblemNotificationField added next to the KeptnProject field.
+func getTestNotificationPayload(payload string, pid string) string {
+	payload = strings.ReplaceAll(payload, "{PID}", pid)
+	return keptnProjectFieldRegexp.ReplaceAllString(payload, `$0,"`+TestProblemNotificationField+`":true`)
+}
+
+// DeleteByID deletes the notification with the specified ID.
+func (nc *NotificationsClient) DeleteByID(ctx context.Context, id string) error {
+	useSettingsAPI, err := nc.settingsSelector.isSettingsAPIAvailable(ctx)
 	if err != nil {
-		return nil
+		return err
+	}
+
+	if useSettingsAPI {
+		return NewSettingsClient(nc.client).Delete(ctx, id)
+	}
+
+	_, err = nc.client.Delete(ctx, notificationsPath+"/"+id)
+	if err != nil {
+		return err
 	}
 
 	return nil
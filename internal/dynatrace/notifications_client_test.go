@@ -0,0 +1,36 @@
+package dynatrace
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getTestNotificationPayload(t *testing.T) {
+	payload := strings.ReplaceAll(DefaultProblemNotificationPayloadTemplate, KeptnProjectPlaceholder, "sockshop")
+	payload = strings.ReplaceAll(payload, "{ProblemDetailsJSON}", "{}")
+	payload = strings.ReplaceAll(payload, "{ImpactedEntities}", "[]")
+
+	testPayload := getTestNotificationPayload(payload, "4f3e1b2a-9c8d-4e7f-a6b5-c4d3e2f1a0b9")
+
+	event := struct {
+		ShKeptnContext string                 `json:"shkeptncontext"`
+		Data           map[string]interface{} `json:"data"`
+	}{}
+	err := json.Unmarshal([]byte(testPayload), &event)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "4f3e1b2a-9c8d-4e7f-a6b5-c4d3e2f1a0b9", event.ShKeptnContext)
+	assert.Equal(t, "4f3e1b2a-9c8d-4e7f-a6b5-c4d3e2f1a0b9", event.Data["PID"])
+	assert.Equal(t, "sockshop", event.Data["KeptnProject"])
+	assert.Equal(t, true, event.Data[TestProblemNotificationField])
+}
+
+func Test_getTestNotificationPayload_whitespace(t *testing.T) {
+	testPayload := getTestNotificationPayload(`{"data": {"PID": "{PID}", "KeptnProject" : "sockshop"}}`, "1234")
+	assert.Equal(t, `{"data": {"PID": "1234", "KeptnProject" : "sockshop","DynatraceServiceTestNotification":true}}`, testPayload)
+}
@@ -25,6 +25,9 @@ const (
 	problemSelectorKey = "problemSelector"
 )
 
+// problemEvidenceDetailsField is the field that must be requested to include evidence details in a problem
+const problemEvidenceDetailsField = "+evidenceDetails"
+
 // ProblemsV2ClientQueryParameters encapsulates the query parameters for the ProblemsV2Client's GetTotalCountByQuery method.
 type ProblemsV2ClientQueryParameters struct {
 	query     problems.Query
@@ -41,6 +44,10 @@ func NewProblemsV2ClientQueryParameters(query problems.Query, timeframe common.T
 
 // encode encodes ProblemsV2ClientQueryParameters into a URL-encoded string.
 func (q *ProblemsV2ClientQueryParameters) encode() string {
+	return q.toQueryParameters().encode()
+}
+
+func (q *ProblemsV2ClientQueryParameters) toQueryParameters() *queryParameters {
 	queryParameters := newQueryParameters()
 	if q.query.GetProblemSelector() != "" {
 		queryParameters.add(problemSelectorKey, q.query.GetProblemSelector())
@@ -51,19 +58,87 @@ func (q *ProblemsV2ClientQueryParameters) encode() string {
 
 	queryParameters.add(fromKey, common.TimestampToUnixMillisecondsString(q.timeframe.Start()))
 	queryParameters.add(toKey, common.TimestampToUnixMillisecondsString(q.timeframe.End()))
-	return queryParameters.encode()
+	return queryParameters
 }
 
 // ProblemQueryResult result of query to /api/v2/problems
-// Here only totalCount is considered as that is the only field that is used
 type problemQueryResult struct {
-	TotalCount int `json:"totalCount"`
+	TotalCount  int       `json:"totalCount"`
+	NextPageKey string    `json:"nextPageKey"`
+	Problems    []Problem `json:"problems"`
 }
 
 // Problem problem details returned by /api/v2/problems/{PROBLEM-ID}
-// Here only status is considered as that is the only field that is used
-type problem struct {
-	Status string `json:"status"`
+// Here only the fields used by the dynatrace-service are considered
+type Problem struct {
+	ProblemID        string                  `json:"problemId"`
+	DisplayID        string                  `json:"displayId"`
+	Title            string                  `json:"title"`
+	Status           string                  `json:"status"`
+	ImpactLevel      string                  `json:"impactLevel"`
+	SeverityLevel    string                  `json:"severityLevel"`
+	StartTime        int64                   `json:"startTime"`
+	EndTime          int64                   `json:"endTime"`
+	RootCauseEntity  *ProblemEntity          `json:"rootCauseEntity"`
+	AffectedEntities []ProblemEntity         `json:"affectedEntities"`
+	ImpactedEntities []ProblemEntity         `json:"impactedEntities"`
+	ManagementZones  []ProblemManagementZone `json:"managementZones"`
+	EntityTags       []Tag                   `json:"entityTags"`
+	EvidenceDetails  *ProblemEvidenceDetails `json:"evidenceDetails"`
+}
+
+// ProblemManagementZone is a management zone a problem belongs to
+type ProblemManagementZone struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProblemEvidenceDetails are the evidence details of a problem
+type ProblemEvidenceDetails struct {
+	TotalCount int               `json:"totalCount"`
+	Details    []ProblemEvidence `json:"details"`
+}
+
+// ProblemEvidence is a single piece of evidence of a problem, e.g. an event or a metric
+type ProblemEvidence struct {
+	EvidenceType      string        `json:"evidenceType"`
+	DisplayName       string        `json:"displayName"`
+	Entity            ProblemEntity `json:"entity"`
+	RootCauseRelevant bool          `json:"rootCauseRelevant"`
+	StartTime         int64         `json:"startTime"`
+}
+
+// ProblemEntity is an entity referenced by a problem, e.g. its root cause entity
+type ProblemEntity struct {
+	EntityID EntityID `json:"entityId"`
+	Name     string   `json:"name"`
+}
+
+// problemCloseRequest is the request body used to close a problem via /api/v2/problems/{PROBLEM-ID}/close
+type problemCloseRequest struct {
+	Message string `json:"message"`
+}
+
+// ProblemComment is a comment on a problem returned by /api/v2/problems/{PROBLEM-ID}/comments
+type ProblemComment struct {
+	ID                 string `json:"id"`
+	CreatedAtTimestamp int64  `json:"createdAtTimestamp"`
+	Content            string `json:"content"`
+	AuthorName         string `json:"authorName"`
+	Context            string `json:"context"`
+}
+
+// problemCommentsQueryResult is the result of a query to /api/v2/problems/{PROBLEM-ID}/comments
+type problemCommentsQueryResult struct {
+	TotalCount  int              `json:"totalCount"`
+	NextPageKey string           `json:"nextPageKey"`
+	Comments    []ProblemComment `json:"comments"`
+}
+
+// problemCommentRequest is the request body used to add or update a comment via /api/v2/problems/{PROBLEM-ID}/comments
+type problemCommentRequest struct {
+	Message string `json:"message"`
+	Context string `json:"context"`
 }
 
 // ProblemsV2Client is a client for interacting with the Dynatrace problems endpoints
@@ -99,19 +174,148 @@ func (pc *ProblemsV2Client) GetTotalCountByQuery(ctx context.Context, parameters
 	return result.TotalCount, nil
 }
 
+// GetByQuery calls the Dynatrace V2 API to retrieve all problems for a given query and timeframe, following next page keys.
+// Unlike GetTotalCountByQuery, it does not wait for the data of the timeframe to be complete.
+func (pc *ProblemsV2Client) GetByQuery(ctx context.Context, parameters ProblemsV2ClientQueryParameters) ([]Problem, error) {
+	queryParameters := parameters.toQueryParameters()
+	queryParameters.add(pageSizeKey, "500")
+
+	problems := []Problem{}
+	path := ProblemsV2Path + "?" + queryParameters.encode()
+	for {
+		body, err := pc.client.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		var result problemQueryResult
+		err = json.Unmarshal(body, &result)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("problems", err)
+		}
+
+		problems = append(problems, result.Problems...)
+
+		if result.NextPageKey == "" {
+			break
+		}
+
+		nextPageQueryParameters := newQueryParameters()
+		nextPageQueryParameters.add(nextPageKeyKey, result.NextPageKey)
+		path = ProblemsV2Path + "?" + nextPageQueryParameters.encode()
+	}
+
+	return problems, nil
+}
+
 // GetStatusByID calls the Dynatrace API to retrieve the status of a given problemID.
 func (pc *ProblemsV2Client) GetStatusByID(ctx context.Context, problemID string) (string, error) {
-	body, err := pc.client.Get(ctx, ProblemsV2Path+"/"+problemID)
+	problem, err := pc.GetByID(ctx, problemID)
 	if err != nil {
 		return "", err
 	}
 
+	return problem.Status, nil
+}
+
+// GetByID calls the Dynatrace API to retrieve the details of a given problemID.
+func (pc *ProblemsV2Client) GetByID(ctx context.Context, problemID string) (*Problem, error) {
+	return pc.getByID(ctx, ProblemsV2Path+"/"+problemID)
+}
+
+// GetWithEvidenceByID calls the Dynatrace API to retrieve the details of a given problemID including its evidence details.
+func (pc *ProblemsV2Client) GetWithEvidenceByID(ctx context.Context, problemID string) (*Problem, error) {
+	queryParameters := newQueryParameters()
+	queryParameters.add(fieldsKey, problemEvidenceDetailsField)
+	return pc.getByID(ctx, ProblemsV2Path+"/"+problemID+"?"+queryParameters.encode())
+}
+
+func (pc *ProblemsV2Client) getByID(ctx context.Context, path string) (*Problem, error) {
+	body, err := pc.client.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
 	// parse response json
-	var result problem
+	var result Problem
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		return "", err
+		return nil, common.NewUnmarshalJSONError("problem", err)
 	}
 
-	return result.Status, nil
+	return &result, nil
+}
+
+// Close calls the Dynatrace API to close the problem with the given problemID, adding the specified closing comment.
+func (pc *ProblemsV2Client) Close(ctx context.Context, problemID string, closingComment string) error {
+	payload, err := json.Marshal(problemCloseRequest{Message: closingComment})
+	if err != nil {
+		return common.NewMarshalJSONError("problem close request", err)
+	}
+
+	_, err = pc.client.Post(ctx, ProblemsV2Path+"/"+problemID+"/close", payload)
+	return err
+}
+
+// GetComments calls the Dynatrace API to retrieve all comments of the problem with the given problemID, following next page keys.
+func (pc *ProblemsV2Client) GetComments(ctx context.Context, problemID string) ([]ProblemComment, error) {
+	commentsPath := ProblemsV2Path + "/" + problemID + "/comments"
+
+	queryParameters := newQueryParameters()
+	queryParameters.add(pageSizeKey, "500")
+
+	comments := []ProblemComment{}
+	path := commentsPath + "?" + queryParameters.encode()
+	for {
+		body, err := pc.client.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		var result problemCommentsQueryResult
+		err = json.Unmarshal(body, &result)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("problem comments", err)
+		}
+
+		comments = append(comments, result.Comments...)
+
+		if result.NextPageKey == "" {
+			break
+		}
+
+		nextPageQueryParameters := newQueryParameters()
+		nextPageQueryParameters.add(nextPageKeyKey, result.NextPageKey)
+		path = commentsPath + "?" + nextPageQueryParameters.encode()
+	}
+
+	return comments, nil
+}
+
+// AddComment calls the Dynatrace API to add a comment with the specified message and context to the problem with the given problemID.
+func (pc *ProblemsV2Client) AddComment(ctx context.Context, problemID string, message string, commentContext string) error {
+	payload, err := json.Marshal(problemCommentRequest{Message: message, Context: commentContext})
+	if err != nil {
+		return common.NewMarshalJSONError("problem comment request", err)
+	}
+
+	_, err = pc.client.Post(ctx, ProblemsV2Path+"/"+problemID+"/comments", payload)
+	return err
+}
+
+// UpdateComment calls the Dynatrace API to replace the message and context of the comment with the given commentID on the problem with the given problemID.
+func (pc *ProblemsV2Client) UpdateComment(ctx context.Context, problemID string, commentID string, message string, commentContext string) error {
+	payload, err := json.Marshal(problemCommentRequest{Message: message, Context: commentContext})
+	if err != nil {
+		return common.NewMarshalJSONError("problem comment request", err)
+	}
+
+	_, err = pc.client.Put(ctx, ProblemsV2Path+"/"+problemID+"/comments/"+commentID, payload)
+	return err
+}
+
+// DeleteComment calls the Dynatrace API to delete the comment with the given commentID from the problem with the given problemID.
+func (pc *ProblemsV2Client) DeleteComment(ctx context.Context, problemID string, commentID string) error {
+	_, err := pc.client.Delete(ctx, ProblemsV2Path+"/"+problemID+"/comments/"+commentID)
+	return err
 }
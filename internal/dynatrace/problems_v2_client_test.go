@@ -27,6 +27,28 @@ func TestProblemsV2Client_GetTotalCountByQuery(t *testing.T) {
 	assert.EqualValues(t, 1, totalProblemCount)
 }
 
+func TestProblemsV2Client_GetByQuery(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems?from=1571649084000&pageSize=500&problemSelector=status%28%22open%22%29&to=1571649085000", "./testdata/test_problemsv2client_getbyquery_page1.json")
+	handler.AddExact("/api/v2/problems?nextPageKey=___page2___", "./testdata/test_problemsv2client_getbyquery_page2.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	timeframe, err := common.NewTimeframeParser("2019-10-21T09:11:24Z", "2019-10-21T09:11:25Z").Parse()
+	assert.NoError(t, err)
+
+	problemQuery := problems.NewQuery("status(\"open\")", "")
+	result, err := NewProblemsV2Client(dtClient).GetByQuery(context.TODO(), NewProblemsV2ClientQueryParameters(problemQuery, *timeframe))
+
+	assert.NoError(t, err)
+	if assert.EqualValues(t, 2, len(result)) {
+		assert.EqualValues(t, "-4511147584335281453_1637590620000V2", result[0].ProblemID)
+		assert.EqualValues(t, "CLOSED", result[1].Status)
+		assert.EqualValues(t, 1638272040000, result[1].EndTime)
+	}
+}
+
 func TestProblemsV2Client_GetStatusById(t *testing.T) {
 	handler := test.NewFileBasedURLHandler(t)
 	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2", "./testdata/test_problemsv2client_getstatusbyid.json")
@@ -39,3 +61,68 @@ func TestProblemsV2Client_GetStatusById(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, ProblemStatusOpen, status)
 }
+
+func TestProblemsV2Client_Close(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/close", "./testdata/test_problemsv2client_close.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	err := NewProblemsV2Client(dtClient).Close(context.TODO(), "-6004362228644432354_1638271020000V2", "Closed by Keptn")
+
+	assert.NoError(t, err)
+}
+
+func TestProblemsV2Client_GetComments(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/comments?pageSize=500", "./testdata/test_problemsv2client_getcomments_page1.json")
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/comments?nextPageKey=___page2___", "./testdata/test_problemsv2client_getcomments_page2.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	comments, err := NewProblemsV2Client(dtClient).GetComments(context.TODO(), "-6004362228644432354_1638271020000V2")
+
+	assert.NoError(t, err)
+	if assert.EqualValues(t, 2, len(comments)) {
+		assert.EqualValues(t, "7843212345678901234", comments[0].ID)
+		assert.EqualValues(t, "keptn-remediation-status", comments[1].Context)
+	}
+}
+
+func TestProblemsV2Client_AddComment(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/comments", "./testdata/test_problemsv2client_comment.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	err := NewProblemsV2Client(dtClient).AddComment(context.TODO(), "-6004362228644432354_1638271020000V2", "Remediation started", "keptn-remediation-status")
+
+	assert.NoError(t, err)
+}
+
+func TestProblemsV2Client_UpdateComment(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/comments/7843212345678901235", "./testdata/test_problemsv2client_comment.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	err := NewProblemsV2Client(dtClient).UpdateComment(context.TODO(), "-6004362228644432354_1638271020000V2", "7843212345678901235", "Remediation finished", "keptn-remediation-status")
+
+	assert.NoError(t, err)
+}
+
+func TestProblemsV2Client_DeleteComment(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/-6004362228644432354_1638271020000V2/comments/7843212345678901235", "./testdata/test_problemsv2client_comment.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	err := NewProblemsV2Client(dtClient).DeleteComment(context.TODO(), "-6004362228644432354_1638271020000V2", "7843212345678901235")
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,91 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+)
+
+const requestAttributesPath = "/api/config/v1/service/requestAttributes"
+
+// RequestAttribute is a request attribute of the Configuration API v1 capturing a value of each request to a service.
+type RequestAttribute struct {
+	Name                    string                       `json:"name"`
+	Enabled                 bool                         `json:"enabled"`
+	DataType                string                       `json:"dataType"`
+	DataSources             []RequestAttributeDataSource `json:"dataSources"`
+	Normalization           string                       `json:"normalization"`
+	Aggregation             string                       `json:"aggregation"`
+	Confidential            bool                         `json:"confidential"`
+	SkipPersonalDataMasking bool                         `json:"skipPersonalDataMasking"`
+}
+
+// RequestAttributeDataSource defines where the value of a request attribute is captured.
+type RequestAttributeDataSource struct {
+	Enabled                     bool                             `json:"enabled"`
+	Source                      string                           `json:"source"`
+	ParameterName               string                           `json:"parameterName"`
+	CapturingAndStorageLocation string                           `json:"capturingAndStorageLocation"`
+	ValueProcessing             *RequestAttributeValueProcessing `json:"valueProcessing,omitempty"`
+}
+
+// RequestAttributeValueProcessing defines how the captured value of a request attribute is processed.
+type RequestAttributeValueProcessing struct {
+	ExtractSubstring *RequestAttributeExtractSubstring `json:"extractSubstring,omitempty"`
+	Trim             bool                              `json:"trim"`
+}
+
+// RequestAttributeExtractSubstring extracts the part of a captured value at the position relative to the delimiters, e.g. BETWEEN TSN= and ;.
+type RequestAttributeExtractSubstring struct {
+	Position     string `json:"position"`
+	Delimiter    string `json:"delimiter"`
+	EndDelimiter string `json:"endDelimiter,omitempty"`
+}
+
+// RequestAttributeNames are the names of the existing request attributes.
+type RequestAttributeNames struct {
+	*StringSet
+}
+
+// RequestAttributesClient is a client for managing request attributes using the Configuration API v1.
+type RequestAttributesClient struct {
+	client ClientInterface
+}
+
+// NewRequestAttributesClient creates a new RequestAttributesClient.
+func NewRequestAttributesClient(client ClientInterface) *RequestAttributesClient {
+	return &RequestAttributesClient{
+		client: client,
+	}
+}
+
+// GetAllNames gets the names of all request attributes or returns an error.
+func (c *RequestAttributesClient) GetAllNames(ctx context.Context) (*RequestAttributeNames, error) {
+	response, err := c.client.Get(ctx, requestAttributesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	requestAttributes := &listResponse{}
+	err = json.Unmarshal(response, requestAttributes)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("Dynatrace request attributes", err)
+	}
+
+	return &RequestAttributeNames{
+		requestAttributes.ToStringSetWith(
+			func(values values) string { return values.Name }),
+	}, nil
+}
+
+// Create creates the request attribute or returns an error.
+func (c *RequestAttributesClient) Create(ctx context.Context, requestAttribute *RequestAttribute) error {
+	payload, err := json.Marshal(requestAttribute)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace request attribute", err)
+	}
+
+	_, err = c.client.Post(ctx, requestAttributesPath, payload)
+	return err
+}
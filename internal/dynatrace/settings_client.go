@@ -0,0 +1,229 @@
+package dynatrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	log "github.com/sirupsen/logrus"
+)
+
+const settingsObjectsPath = "/api/v2/settings/objects"
+const settingsSchemasPath = "/api/v2/settings/schemas"
+
+// settingsEnvironmentScope is the scope of settings objects that apply to the whole environment
+const settingsEnvironmentScope = "environment"
+
+const (
+	schemaIDsKey = "schemaIds"
+	scopesKey    = "scopes"
+)
+
+// SettingsObject is a settings object returned by /api/v2/settings/objects
+type SettingsObject struct {
+	ObjectID string          `json:"objectId"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// settingsObjectsQueryResult is the result of a query to /api/v2/settings/objects
+type settingsObjectsQueryResult struct {
+	Items       []SettingsObject `json:"items"`
+	NextPageKey string           `json:"nextPageKey"`
+}
+
+// settingsObjectCreate is a single settings object to be created via /api/v2/settings/objects
+type settingsObjectCreate struct {
+	SchemaID string      `json:"schemaId"`
+	Scope    string      `json:"scope"`
+	Value    interface{} `json:"value"`
+}
+
+// settingsObjectUpdate is the request body used to update a settings object via /api/v2/settings/objects/{OBJECT-ID}
+type settingsObjectUpdate struct {
+	Value interface{} `json:"value"`
+}
+
+// settingsObjectResponse is the response for a single settings object created via /api/v2/settings/objects
+type settingsObjectResponse struct {
+	Code     int    `json:"code"`
+	ObjectID string `json:"objectId"`
+}
+
+// SettingsClient is a client for interacting with the Dynatrace Settings 2.0 API
+type SettingsClient struct {
+	client ClientInterface
+}
+
+// NewSettingsClient creates a new SettingsClient
+func NewSettingsClient(client ClientInterface) *SettingsClient {
+	return &SettingsClient{
+		client: client,
+	}
+}
+
+// AreSchemasAvailable returns whether all of the specified schemas are available in the tenant and can be read by the API token.
+// A schema is only considered unavailable if it is not found or the API token is not allowed to read it, any other error is returned.
+func (sc *SettingsClient) AreSchemasAvailable(ctx context.Context, schemaIDs ...string) (bool, error) {
+	for _, schemaID := range schemaIDs {
+		_, err := sc.client.Get(ctx, settingsSchemasPath+"/"+schemaID)
+		if err == nil {
+			continue
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.Code() == http.StatusNotFound || apiErr.Code() == http.StatusForbidden) {
+			log.WithError(err).WithField("schemaId", schemaID).Debug("Settings 2.0 schema is not available")
+			return false, nil
+		}
+
+		return false, fmt.Errorf("could not check availability of Settings 2.0 schema %s: %w", schemaID, err)
+	}
+
+	return true, nil
+}
+
+// GetObjects returns all settings objects of the specified schema in the environment scope, following next page keys.
+func (sc *SettingsClient) GetObjects(ctx context.Context, schemaID string) ([]SettingsObject, error) {
+	queryParameters := newQueryParameters()
+	queryParameters.add(schemaIDsKey, schemaID)
+	queryParameters.add(scopesKey, settingsEnvironmentScope)
+	queryParameters.add(fieldsKey, "objectId,value")
+	queryParameters.add(pageSizeKey, "500")
+
+	objects := []SettingsObject{}
+	path := settingsObjectsPath + "?" + queryParameters.encode()
+	for {
+		body, err := sc.client.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		var result settingsObjectsQueryResult
+		err = json.Unmarshal(body, &result)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("settings objects", err)
+		}
+
+		objects = append(objects, result.Items...)
+
+		if result.NextPageKey == "" {
+			break
+		}
+
+		nextPageQueryParameters := newQueryParameters()
+		nextPageQueryParameters.add(nextPageKeyKey, result.NextPageKey)
+		path = settingsObjectsPath + "?" + nextPageQueryParameters.encode()
+	}
+
+	return objects, nil
+}
+
+// Create creates a settings object of the specified schema in the environment scope and returns its object ID.
+func (sc *SettingsClient) Create(ctx context.Context, schemaID string, value interface{}) (string, error) {
+	payload, err := json.Marshal([]settingsObjectCreate{{SchemaID: schemaID, Scope: settingsEnvironmentScope, Value: value}})
+	if err != nil {
+		return "", common.NewMarshalJSONError("settings object", err)
+	}
+
+	body, err := sc.client.Post(ctx, settingsObjectsPath, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var responses []settingsObjectResponse
+	err = json.Unmarshal(body, &responses)
+	if err != nil {
+		return "", common.NewUnmarshalJSONError("settings object response", err)
+	}
+
+	if len(responses) != 1 {
+		return "", fmt.Errorf("expected a single settings object response but got %d", len(responses))
+	}
+
+	return responses[0].ObjectID, nil
+}
+
+// Update replaces the value of the settings object with the specified object ID.
+func (sc *SettingsClient) Update(ctx context.Context, objectID string, value interface{}) error {
+	payload, err := json.Marshal(settingsObjectUpdate{Value: value})
+	if err != nil {
+		return common.NewMarshalJSONError("settings object", err)
+	}
+
+	_, err = sc.client.Put(ctx, settingsObjectsPath+"/"+objectID, payload)
+	return err
+}
+
+// Delete deletes the settings object with the specified object ID.
+func (sc *SettingsClient) Delete(ctx context.Context, objectID string) error {
+	_, err := sc.client.Delete(ctx, settingsObjectsPath+"/"+objectID)
+	return err
+}
+
+// settingsAPIAvailability caches whether the Settings 2.0 API can be used, by tenant, API token and required schemas, so that each tenant is only probed once.
+var settingsAPIAvailability sync.Map
+
+// settingsAPISelector decides whether the Settings 2.0 API or the Configuration API v1 is used to manage objects.
+// The tenant is probed for the required schemas until the result is known, which is then shared by all selectors for the same tenant and API token.
+type settingsAPISelector struct {
+	settingsClient *SettingsClient
+	cacheKey       string
+	schemaIDs      []string
+}
+
+func newSettingsAPISelector(client ClientInterface, schemaIDs ...string) *settingsAPISelector {
+	cacheKey := strings.Join(schemaIDs, ",")
+	if credentials := client.Credentials(); credentials != nil {
+		cacheKey = credentials.GetTenant() + "|" + credentials.GetAPIToken() + "|" + cacheKey
+	}
+
+	return &settingsAPISelector{
+		settingsClient: NewSettingsClient(client),
+		cacheKey:       cacheKey,
+		schemaIDs:      schemaIDs,
+	}
+}
+
+// isSettingsAPIAvailable returns true if all required schemas are available, otherwise the Configuration API v1 should be used as a fallback.
+// An error is returned if the availability of the schemas could not be determined, e.g. due to a transient error, in which case the tenant is probed again next time.
+func (s *settingsAPISelector) isSettingsAPIAvailable(ctx context.Context) (bool, error) {
+	if useSettings, ok := settingsAPIAvailability.Load(s.cacheKey); ok {
+		return useSettings.(bool), nil
+	}
+
+	useSettings, err := s.settingsClient.AreSchemasAvailable(ctx, s.schemaIDs...)
+	if err != nil {
+		return false, err
+	}
+
+	settingsAPIAvailability.Store(s.cacheKey, useSettings)
+	log.WithFields(log.Fields{"schemaIds": s.schemaIDs, "useSettings": useSettings}).Debug("Probed tenant for Settings 2.0 schemas")
+	return useSettings, nil
+}
+
+// getSettingsObjectsAsListResponse returns the objects of the specified schema as a listResponse using their object ID and the specified name field of their value.
+func getSettingsObjectsAsListResponse(ctx context.Context, settingsClient *SettingsClient, schemaID string, nameField string) (*listResponse, error) {
+	objects, err := settingsClient.GetObjects(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &listResponse{Values: make([]values, 0, len(objects))}
+	for _, object := range objects {
+		var value map[string]interface{}
+		err = json.Unmarshal(object.Value, &value)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("settings object value", err)
+		}
+
+		name, _ := value[nameField].(string)
+		response.Values = append(response.Values, values{ID: object.ObjectID, Name: name})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,351 @@
+package dynatrace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const testSettingsObjectsURL = "/api/v2/settings/objects?fields=objectId%2Cvalue&pageSize=500&schemaIds=builtin%3Amanagement-zones&scopes=environment"
+
+func TestSettingsClient_AreSchemasAvailable(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/settings/schemas/builtin:management-zones", "./testdata/test_settingsclient_schema.json")
+	handler.AddExactError("/api/v2/settings/schemas/builtin:anomaly-detection.metric-events", 404, "./testdata/test_settingsclient_schema_not_found.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	settingsClient := NewSettingsClient(dtClient)
+
+	available, err := settingsClient.AreSchemasAvailable(context.TODO(), managementZonesSchemaID)
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	available, err = settingsClient.AreSchemasAvailable(context.TODO(), managementZonesSchemaID, metricEventsSchemaID)
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestSettingsClient_AreSchemasAvailable_errors(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExactError("/api/v2/settings/schemas/builtin:management-zones", 403, "./testdata/test_settingsclient_schema_forbidden.json")
+	handler.AddExactError("/api/v2/settings/schemas/builtin:anomaly-detection.metric-events", 503, "./testdata/test_settingsclient_schema_unavailable.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	settingsClient := NewSettingsClient(dtClient)
+
+	available, err := settingsClient.AreSchemasAvailable(context.TODO(), managementZonesSchemaID)
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	_, err = settingsClient.AreSchemasAvailable(context.TODO(), metricEventsSchemaID)
+	assert.Error(t, err)
+}
+
+// requestCountingHandler counts the requests passed on to the wrapped handler.
+type requestCountingHandler struct {
+	handler  http.Handler
+	requests int
+}
+
+func (h *requestCountingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.requests++
+	h.handler.ServeHTTP(w, r)
+}
+
+func TestSettingsAPISelector_isSettingsAPIAvailable(t *testing.T) {
+	t.Run("result is shared by selectors of the same tenant", func(t *testing.T) {
+		fileBasedHandler := test.NewFileBasedURLHandler(t)
+		fileBasedHandler.AddStartsWith("/api/v2/settings/schemas/", "./testdata/test_settingsclient_schema.json")
+		handler := &requestCountingHandler{handler: fileBasedHandler}
+
+		dtClient, _, teardown := createDynatraceClient(t, handler)
+		defer teardown()
+
+		for i := 0; i < 3; i++ {
+			useSettings, err := newSettingsAPISelector(dtClient, managementZoneAndMetricEventSchemaIDs...).isSettingsAPIAvailable(context.TODO())
+			assert.NoError(t, err)
+			assert.True(t, useSettings)
+		}
+		assert.Equal(t, len(managementZoneAndMetricEventSchemaIDs), handler.requests)
+	})
+
+	t.Run("transient errors are returned and not cached", func(t *testing.T) {
+		handler := test.NewFileBasedURLHandler(t)
+		handler.AddStartsWithError("/api/v2/settings/schemas/", 503, "./testdata/test_settingsclient_schema_unavailable.json")
+
+		dtClient, _, teardown := createDynatraceClient(t, handler)
+		defer teardown()
+
+		selector := newSettingsAPISelector(dtClient, managementZoneAndMetricEventSchemaIDs...)
+		_, err := selector.isSettingsAPIAvailable(context.TODO())
+		assert.Error(t, err)
+
+		_, ok := settingsAPIAvailability.Load(selector.cacheKey)
+		assert.False(t, ok)
+	})
+}
+
+func TestSettingsClient_GetObjects(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact(testSettingsObjectsURL, "./testdata/test_settingsclient_getobjects_page1.json")
+	handler.AddExact("/api/v2/settings/objects?nextPageKey=___page2___", "./testdata/test_settingsclient_getobjects_page2.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	objects, err := NewSettingsClient(dtClient).GetObjects(context.TODO(), managementZonesSchemaID)
+
+	assert.NoError(t, err)
+	if assert.EqualValues(t, 2, len(objects)) {
+		assert.EqualValues(t, "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACQ1YjZmMjdjMC0yYjllLTNjNzEtOWY0ZS0zYThhMjFjNmYwZDK-71TeFdrerQ", objects[0].ObjectID)
+		assert.JSONEq(t, `{"name": "Keptn: sockshop production", "rules": []}`, string(objects[1].Value))
+	}
+}
+
+func TestSettingsClient_Create(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/settings/objects", "./testdata/test_settingsclient_create.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	objectID, err := NewSettingsClient(dtClient).Create(context.TODO(), managementZonesSchemaID, managementZoneSettingsValue{Name: "Keptn: sockshop staging"})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACQ3", objectID)
+}
+
+func TestManagementZonesClient_GetAll(t *testing.T) {
+	t.Run("settings 2.0", func(t *testing.T) {
+		handler := test.NewFileBasedURLHandler(t)
+		handler.AddStartsWith("/api/v2/settings/schemas/", "./testdata/test_settingsclient_schema.json")
+		handler.AddExact(testSettingsObjectsURL, "./testdata/test_settingsclient_getobjects_page1.json")
+		handler.AddExact("/api/v2/settings/objects?nextPageKey=___page2___", "./testdata/test_settingsclient_getobjects_page2.json")
+
+		dtClient, _, teardown := createDynatraceClient(t, handler)
+		defer teardown()
+
+		managementZones, err := NewManagementZonesClient(dtClient).GetAll(context.TODO())
+
+		assert.NoError(t, err)
+		// the object ID is used to update or delete the management zone, references to it use the numeric ID
+		managementZone, ok := managementZones.GetByName("Keptn: sockshop production")
+		assert.True(t, ok)
+		assert.EqualValues(t, "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACRjZjBkNmNmYy1hYjY4LTM4ZWEtODRhOS0zMzU3NDNlNTE3YjC-71TeFdrerQ", managementZone.ID)
+
+		numericID, ok := managementZones.GetNumericIDByName("Keptn: sockshop production")
+		assert.True(t, ok)
+		assert.EqualValues(t, "5450586640970755930", numericID)
+	})
+
+	t.Run("configuration API v1 fallback", func(t *testing.T) {
+		handler := test.NewFileBasedURLHandler(t)
+		handler.AddExact("/api/v2/settings/schemas/builtin:management-zones", "./testdata/test_settingsclient_schema.json")
+		handler.AddExactError("/api/v2/settings/schemas/builtin:anomaly-detection.metric-events", 404, "./testdata/test_settingsclient_schema_not_found.json")
+		handler.AddExact("/api/config/v1/managementZones", "./testdata/test_managementzonesclient_getall.json")
+
+		dtClient, _, teardown := createDynatraceClient(t, handler)
+		defer teardown()
+
+		managementZones, err := NewManagementZonesClient(dtClient).GetAll(context.TODO())
+
+		assert.NoError(t, err)
+		managementZone, ok := managementZones.GetByName("Keptn: sockshop production")
+		assert.True(t, ok)
+		assert.EqualValues(t, "2311420533206603714", managementZone.ID)
+
+		numericID, ok := managementZones.GetNumericIDByName("Keptn: sockshop production")
+		assert.True(t, ok)
+		assert.EqualValues(t, "2311420533206603714", numericID)
+	})
+}
+
+func TestNewManagementZoneSettingsValue(t *testing.T) {
+	managementZone := &ManagementZone{
+		Name: "Keptn: sockshop production",
+		Rules: []MZRules{
+			{
+				Type:    ServiceEntityType,
+				Enabled: true,
+				Conditions: []MZConditions{
+					{
+						Key:            MZKey{Attribute: "SERVICE_TAGS"},
+						ComparisonInfo: MZComparisonInfo{Type: "TAG", Operator: "EQUALS", Value: MZValue{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"}},
+					},
+					{
+						Key:            MZKey{Attribute: "SERVICE_TAGS"},
+						ComparisonInfo: MZComparisonInfo{Type: "TAG", Operator: "EQUALS", Value: MZValue{Context: "ENVIRONMENT", Key: "keptn_stage", Value: "production"}, Negate: true},
+					},
+				},
+			},
+		},
+	}
+
+	assert.EqualValues(t, managementZoneSettingsValue{
+		Name: "Keptn: sockshop production",
+		Rules: []managementZoneSettingsValueRule{
+			{
+				Enabled: true,
+				Type:    "ME",
+				AttributeRule: managementZoneSettingsAttributeRule{
+					EntityType: ServiceEntityType,
+					Conditions: []managementZoneSettingsCondition{
+						{Key: "SERVICE_TAGS", Operator: "EQUALS", Tag: "keptn_project:sockshop"},
+						{Key: "SERVICE_TAGS", Operator: "NOT_EQUALS", Tag: "[ENVIRONMENT]keptn_stage:production"},
+					},
+				},
+			},
+		},
+	}, newManagementZoneSettingsValue(managementZone))
+}
+
+func TestManagementZoneSettingsValue_RoundTrip(t *testing.T) {
+	managementZone := &ManagementZone{
+		Name: "Keptn: sockshop production",
+		Rules: []MZRules{
+			{
+				Type:             ServiceEntityType,
+				Enabled:          true,
+				PropagationTypes: []string{},
+				Conditions: []MZConditions{
+					{
+						Key:            MZKey{Attribute: "SERVICE_TAGS"},
+						ComparisonInfo: MZComparisonInfo{Type: "TAG", Operator: "EQUALS", Value: MZValue{Context: "CONTEXTLESS", Key: "keptn_project", Value: "sockshop"}},
+					},
+					{
+						Key:            MZKey{Attribute: "SERVICE_TAGS"},
+						ComparisonInfo: MZComparisonInfo{Type: "TAG", Operator: "EQUALS", Value: MZValue{Context: "ENVIRONMENT", Key: "keptn_stage", Value: "production"}, Negate: true},
+					},
+					{
+						Key:            MZKey{Attribute: "SERVICE_TAGS"},
+						ComparisonInfo: MZComparisonInfo{Type: "TAG", Operator: "EQUALS", Value: MZValue{Context: "CONTEXTLESS", Key: "monitored"}},
+					},
+				},
+			},
+		},
+	}
+
+	assert.EqualValues(t, managementZone, newManagementZoneFromSettingsValue(newManagementZoneSettingsValue(managementZone)))
+}
+
+func TestMetricEventSettingsValue_RoundTrip(t *testing.T) {
+	metricEvent := &MetricEvent{
+		MetricID:          "builtin:service.response.time",
+		Name:              "response_time_p90 (Keptn.sockshop.production.carts)",
+		Description:       "Keptn SLI violated",
+		AggregationType:   "P90",
+		EventType:         "CUSTOM_ALERT",
+		Severity:          "CUSTOM_ALERT",
+		AlertCondition:    "ABOVE",
+		Samples:           5,
+		ViolatingSamples:  3,
+		DealertingSamples: 5,
+		Threshold:         600,
+		AlertingScope: []MEAlertingScope{
+			{FilterType: "MANAGEMENT_ZONE", ManagementZoneID: "5450586640970755930"},
+			{FilterType: "TAG", TagFilter: &METagFilter{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"}},
+			{FilterType: "TAG", TagFilter: &METagFilter{Context: "ENVIRONMENT", Key: "owner", Value: "team-a"}},
+			{FilterType: "TAG", TagFilter: &METagFilter{Context: "CONTEXTLESS", Key: "keptn_managed"}},
+			{FilterType: "ENTITY_ID", EntityID: "SERVICE-1234567890ABCDEF"},
+		},
+	}
+
+	value := newMetricEventSettingsValue(metricEvent)
+	assert.EqualValues(t, "PERCENTILE90", value.QueryDefinition.Aggregation)
+	assert.EqualValues(t, "5450586640970755930", value.QueryDefinition.ManagementZone)
+	assert.EqualValues(t,
+		[]metricEventSettingsEntityFilterCondition{
+			{Type: "TAG", Operator: "EQUALS", Value: "keptn_service:carts"},
+			{Type: "TAG", Operator: "EQUALS", Value: "[ENVIRONMENT]owner:team-a"},
+			{Type: "TAG", Operator: "EQUALS", Value: "keptn_managed"},
+			{Type: "ENTITY_ID", Operator: "EQUALS", Value: "SERVICE-1234567890ABCDEF"},
+		},
+		value.QueryDefinition.EntityFilter.Conditions)
+
+	metricEvent.ID = "object-id"
+	assert.EqualValues(t, metricEvent, newMetricEventFromSettingsValue("object-id", value))
+}
+
+func TestMetricEventSettingsValue_RoundTripBaseline(t *testing.T) {
+	metricEvent := &MetricEvent{
+		MetricID:          "builtin:service.response.time",
+		Name:              "response_time_p90 baseline (Keptn.sockshop.production.carts)",
+		Description:       "Keptn SLI violated",
+		EventType:         "CUSTOM_ALERT",
+		Severity:          "CUSTOM_ALERT",
+		AlertCondition:    "ABOVE",
+		Samples:           5,
+		ViolatingSamples:  3,
+		DealertingSamples: 5,
+		MonitoringStrategy: &MEMonitoringStrategy{
+			Type:              MonitoringStrategySeasonalBaseline,
+			AlertCondition:    "ABOVE",
+			Samples:           5,
+			ViolatingSamples:  3,
+			DealertingSamples: 5,
+			Tolerance:         4,
+		},
+	}
+
+	value := newMetricEventSettingsValue(metricEvent)
+	assert.EqualValues(t, "SEASONAL_BASELINE", value.ModelProperties.Type)
+	assert.EqualValues(t, 4, value.ModelProperties.Tolerance)
+
+	metricEvent.ID = "object-id"
+	assert.EqualValues(t, metricEvent, newMetricEventFromSettingsValue("object-id", value))
+}
+
+func Test_getManagementZoneNumericID(t *testing.T) {
+	tests := []struct {
+		name      string
+		objectID  string
+		want      string
+		wantError bool
+	}{
+		{
+			name:     "positive numeric ID",
+			objectID: "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACRjZjBkNmNmYy1hYjY4LTM4ZWEtODRhOS0zMzU3NDNlNTE3YjC-71TeFdrerQ",
+			want:     "5450586640970755930",
+		},
+		{
+			name:     "negative numeric ID",
+			objectID: "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACQ1YjZmMjdjMC0yYjllLTNjNzEtOWY0ZS0zYThhMjFjNmYwZDK-71TeFdrerQ",
+			want:     "-4314134764205847389",
+		},
+		{
+			name:      "truncated object ID",
+			objectID:  "vu9U3hXa3q0AAAABABhidWlsdGluOm1hbmFnZW1lbnQtem9uZXMABnRlbmFudAAGdGVuYW50ACQ2",
+			wantError: true,
+		},
+		{
+			name:      "object ID of another schema",
+			objectID:  "vu9U3hXa3q0AAAABACdidWlsdGluOmFub21hbHktZGV0ZWN0aW9uLm1ldHJpYy1ldmVudHMABnRlbmFudAAGdGVuYW50ACRjZjBkNmNmYy1hYjY4LTM4ZWEtODRhOS0zMzU3NDNlNTE3YjC-71TeFdrerQ",
+			wantError: true,
+		},
+		{
+			name:      "no base64",
+			objectID:  "not an object ID",
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			numericID, err := getManagementZoneNumericID(tt.objectID)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, tt.want, numericID)
+		})
+	}
+}
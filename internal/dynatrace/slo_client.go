@@ -19,6 +19,7 @@ const SLOMaximumWait = 4 * time.Minute
 
 const (
 	timeFrameKey = "timeFrame"
+	evaluateKey  = "evaluate"
 )
 
 // SLOClientGetParameters encapsulates the parameters for the SLOClient's Get method.
@@ -91,3 +92,85 @@ func (c *SLOClient) Get(ctx context.Context, parameters SLOClientGetParameters)
 
 	return &result, nil
 }
+
+// SLO is the definition of a Dynatrace SLO as used by the SLO API v2.
+type SLO struct {
+	ID               string  `json:"id,omitempty"`
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Enabled          bool    `json:"enabled"`
+	MetricExpression string  `json:"metricExpression"`
+	EvaluationType   string  `json:"evaluationType"`
+	Filter           string  `json:"filter"`
+	Target           float64 `json:"target"`
+	Warning          float64 `json:"warning"`
+	Timeframe        string  `json:"timeframe"`
+}
+
+// SLOList is a page of Dynatrace SLO definitions.
+type SLOList struct {
+	SLOs        []SLO  `json:"slo"`
+	NextPageKey string `json:"nextPageKey"`
+}
+
+// GetAll gets the definitions of all Dynatrace SLOs, following next page keys, without evaluating them.
+func (c *SLOClient) GetAll(ctx context.Context) ([]SLO, error) {
+	queryParameters := newQueryParameters()
+	queryParameters.add(pageSizeKey, "500")
+	queryParameters.add(evaluateKey, "false")
+
+	slos := []SLO{}
+	path := SLOPath + "?" + queryParameters.encode()
+	for {
+		body, err := c.client.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+
+		sloList := &SLOList{}
+		err = json.Unmarshal(body, sloList)
+		if err != nil {
+			return nil, common.NewUnmarshalJSONError("Dynatrace SLOs", err)
+		}
+
+		slos = append(slos, sloList.SLOs...)
+
+		if sloList.NextPageKey == "" {
+			break
+		}
+
+		nextPageQueryParameters := newQueryParameters()
+		nextPageQueryParameters.add(nextPageKeyKey, sloList.NextPageKey)
+		path = SLOPath + "?" + nextPageQueryParameters.encode()
+	}
+
+	return slos, nil
+}
+
+// Create creates the specified Dynatrace SLO or returns an error.
+func (c *SLOClient) Create(ctx context.Context, slo *SLO) error {
+	payload, err := json.Marshal(slo)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace SLO", err)
+	}
+
+	_, err = c.client.Post(ctx, SLOPath, payload)
+	return err
+}
+
+// Update updates the specified Dynatrace SLO, which must have an ID, or returns an error.
+func (c *SLOClient) Update(ctx context.Context, slo *SLO) error {
+	payload, err := json.Marshal(slo)
+	if err != nil {
+		return common.NewMarshalJSONError("Dynatrace SLO", err)
+	}
+
+	_, err = c.client.Put(ctx, SLOPath+"/"+slo.ID, payload)
+	return err
+}
+
+// DeleteByID deletes the Dynatrace SLO with the specified ID or returns an error.
+func (c *SLOClient) DeleteByID(ctx context.Context, id string) error {
+	_, err := c.client.Delete(ctx, SLOPath+"/"+id)
+	return err
+}
@@ -25,3 +25,20 @@ func TestExecuteGetDynatraceSLO(t *testing.T) {
 	assert.NotNil(t, sloResult, "No SLO Result returned for "+sloID)
 	assert.EqualValues(t, 95.66405076939219, sloResult.EvaluatedPercentage, "Not returning expected value for SLO")
 }
+
+func TestSLOClient_GetAll(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact(SLOPath+"?evaluate=false&pageSize=500", "./testdata/test_get_slos_page_1.json")
+	handler.AddExact(SLOPath+"?nextPageKey=AQAAABQBAAAABQ%3D%3D", "./testdata/test_get_slos_page_2.json")
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	slos, err := NewSLOClient(dtClient).GetAll(context.TODO())
+	assert.NoError(t, err)
+	if assert.Len(t, slos, 2) {
+		assert.Equal(t, "success_rate (Keptn.sockshop.production.carts)", slos[0].Name)
+		assert.EqualValues(t, 95, slos[0].Target)
+		assert.EqualValues(t, 99, slos[0].Warning)
+		assert.Equal(t, "Availability of easytravel", slos[1].Name)
+	}
+}
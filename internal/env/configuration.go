@@ -3,6 +3,7 @@ package env
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -75,11 +76,158 @@ func IsDashboardsGenerationEnabled() bool {
 	return readEnvAsBool("GENERATE_DASHBOARDS", false)
 }
 
+// IsKQGDashboardsGenerationEnabled returns whether a quality gate dashboard should be generated for each service and stage when configuring the monitoring
+func IsKQGDashboardsGenerationEnabled() bool {
+	return readEnvAsBool("GENERATE_KQG_DASHBOARDS", false)
+}
+
 // IsMetricEventsGenerationEnabled returns whether metric events should be generated when configuring the monitoring
 func IsMetricEventsGenerationEnabled() bool {
 	return readEnvAsBool("GENERATE_METRIC_EVENTS", false)
 }
 
+// IsSLOsGenerationEnabled returns whether Dynatrace SLOs should be generated for the ratio SLIs of each service and stage when configuring the monitoring
+func IsSLOsGenerationEnabled() bool {
+	return readEnvAsBool("GENERATE_SLOS", false)
+}
+
+// IsTestStepMetricsGenerationEnabled returns whether the request attributes and calculated service metrics for Keptn test steps should be generated when configuring the monitoring
+func IsTestStepMetricsGenerationEnabled() bool {
+	return readEnvAsBool("GENERATE_TEST_STEP_METRICS", false)
+}
+
+// GetMetricEventsBaselineModel returns the baseline model, auto-adaptive or seasonal, of metric events generated for comparison-based SLO criteria
+func GetMetricEventsBaselineModel() string {
+	model := os.Getenv("METRIC_EVENTS_BASELINE_MODEL")
+	if model == "" {
+		return "auto-adaptive"
+	}
+	return model
+}
+
+// GetProjectManagementZoneNameTemplate returns the Go template naming the management zone of a project, or an empty string to use the default name
+func GetProjectManagementZoneNameTemplate() string {
+	return os.Getenv("PROJECT_MANAGEMENT_ZONE_NAME_TEMPLATE")
+}
+
+// GetStageManagementZoneNameTemplate returns the Go template naming the management zone of a stage, or an empty string to use the default name
+func GetStageManagementZoneNameTemplate() string {
+	return os.Getenv("STAGE_MANAGEMENT_ZONE_NAME_TEMPLATE")
+}
+
+// GetDashboardNameTemplate returns the Go template naming the dashboard of a project, or an empty string to use the default name
+func GetDashboardNameTemplate() string {
+	return os.Getenv("DASHBOARD_NAME_TEMPLATE")
+}
+
+// GetMetricEventNameTemplate returns the Go template naming the metric events of an SLI, or an empty string to use the default name
+func GetMetricEventNameTemplate() string {
+	return os.Getenv("METRIC_EVENT_NAME_TEMPLATE")
+}
+
+// GetProblemNotificationNameTemplate returns the Go template naming the problem notification, or an empty string to use the default name
+func GetProblemNotificationNameTemplate() string {
+	return os.Getenv("PROBLEM_NOTIFICATION_NAME_TEMPLATE")
+}
+
+// GetSLONameTemplate returns the Go template naming the Dynatrace SLO of an SLI, or an empty string to use the default name
+func GetSLONameTemplate() string {
+	return os.Getenv("SLO_NAME_TEMPLATE")
+}
+
+// IsConfigureMonitoringDryRunEnabled returns whether configuring the monitoring should only report the planned changes without writing to Dynatrace
+func IsConfigureMonitoringDryRunEnabled() bool {
+	return readEnvAsBool("CONFIGURE_MONITORING_DRY_RUN", false)
+}
+
+// IsMonitoringCleanupEnabled returns whether the Dynatrace configuration created for a Keptn project or service should be deleted once the project or service has been deleted
+func IsMonitoringCleanupEnabled() bool {
+	return readEnvAsBool("CLEAN_UP_MONITORING_ON_DELETION", false)
+}
+
+// IsProblemClosingAfterSuccessfulRemediationEnabled returns whether Dynatrace problems should be closed after a remediation has been successfully evaluated
+func IsProblemClosingAfterSuccessfulRemediationEnabled() bool {
+	return readEnvAsBool("CLOSE_PROBLEMS_AFTER_SUCCESSFUL_REMEDIATION", false)
+}
+
+// IsRemediationStatusCommentEnabled returns whether the progress of a remediation should be kept in a single, consolidated problem comment
+func IsRemediationStatusCommentEnabled() bool {
+	return readEnvAsBool("CONSOLIDATE_REMEDIATION_COMMENTS", false)
+}
+
+// IsQualityGateMetricsSendingEnabled returns whether quality gate results should be sent to Dynatrace as metrics
+func IsQualityGateMetricsSendingEnabled() bool {
+	return readEnvAsBool("SEND_QUALITY_GATE_METRICS", false)
+}
+
+// GetProblemDebounceWindow returns the duration within which problems that are reopened after being closed do not trigger a new remediation sequence
+func GetProblemDebounceWindow() time.Duration {
+	return time.Duration(readEnvAsInt("PROBLEM_DEBOUNCE_WINDOW_SECONDS", 0)) * time.Second
+}
+
+// IsProblemStatePersistenceEnabled returns whether the state of problems used to deduplicate problem notifications should be kept in a ConfigMap, so that it survives restarts
+func IsProblemStatePersistenceEnabled() bool {
+	return readEnvAsBool("PERSIST_PROBLEM_STATES", true)
+}
+
+// IsProblemKeptnServiceResolutionEnabled returns whether the Keptn project, stage and service of problems without keptn tags should be resolved via their entities
+func IsProblemKeptnServiceResolutionEnabled() bool {
+	return readEnvAsBool("RESOLVE_PROBLEM_KEPTN_SERVICES", false)
+}
+
+// GetProblemKeptnServiceTieBreakRules returns the rules used in order to decide between several Keptn services resolved for a problem
+func GetProblemKeptnServiceTieBreakRules() []string {
+	return readEnvAsStringList("PROBLEM_KEPTN_SERVICE_TIE_BREAK_RULES", []string{"rootCause", "impacted", "mostEntities"})
+}
+
+// IsProblemPollingEnabled returns whether problems should be polled via the Problems API v2 in addition to being received via problem notifications
+func IsProblemPollingEnabled() bool {
+	return readEnvAsBool("POLL_PROBLEMS", false)
+}
+
+// GetProblemPollingInterval returns the number of seconds the problem poller should sleep between polling runs.
+// If the environment variable is empty or cannot be parsed, a default polling interval is used.
+func GetProblemPollingInterval() int {
+	return readEnvAsInt("POLL_PROBLEMS_INTERVAL_SECONDS", 60)
+}
+
+// GetProblemPollingSelector returns the problem selector used to poll problems, or an empty string to poll all problems
+func GetProblemPollingSelector() string {
+	return os.Getenv("POLL_PROBLEMS_SELECTOR")
+}
+
+// GetProblemPollingLookback returns the number of seconds each polling run looks back before the end of the previous one, so that problems reported late are not missed.
+// If the environment variable is empty or cannot be parsed, a default lookback is used.
+func GetProblemPollingLookback() int {
+	return readEnvAsInt("POLL_PROBLEMS_LOOKBACK_SECONDS", 3600)
+}
+
+// GetProblemPollingKeptnProject returns the Keptn project used for polled problems that are not tagged with keptn_project
+func GetProblemPollingKeptnProject() string {
+	return os.Getenv("POLL_PROBLEMS_KEPTN_PROJECT")
+}
+
+// IsMonitoringReconciliationEnabled returns whether the Dynatrace configuration of Keptn projects should be periodically checked for drift
+func IsMonitoringReconciliationEnabled() bool {
+	return readEnvAsBool("RECONCILE_MONITORING", false)
+}
+
+// GetMonitoringReconciliationInterval returns the number of seconds the monitoring reconciler should sleep between reconciliation runs.
+// If the environment variable is empty or cannot be parsed, a default reconciliation interval is used.
+func GetMonitoringReconciliationInterval() int {
+	return readEnvAsInt("RECONCILE_MONITORING_INTERVAL_SECONDS", 3600)
+}
+
+// IsMonitoringReconciliationApplyEnabled returns whether drifted Dynatrace configuration should be re-applied by the monitoring reconciler
+func IsMonitoringReconciliationApplyEnabled() bool {
+	return readEnvAsBool("RECONCILE_MONITORING_APPLY", false)
+}
+
+// GetMonitoringReconciliationProjects returns the Keptn projects checked by the monitoring reconciler, or an empty list to check all projects
+func GetMonitoringReconciliationProjects() []string {
+	return readEnvAsStringList("RECONCILE_MONITORING_PROJECTS", []string{})
+}
+
 // IsHttpSSLVerificationEnabled returns whether the SSL verification is enabled or disabled
 func IsHttpSSLVerificationEnabled() bool {
 	return readEnvAsBool("HTTP_SSL_VERIFY", true)
@@ -145,3 +293,25 @@ func readEnvAsInt(env string, defaultValue int) int {
 
 	return int(parseInt)
 }
+
+func readEnvAsStringList(env string, defaultValue []string) []string {
+	envValue := os.Getenv(env)
+	if envValue == "" {
+		log.WithFields(
+			log.Fields{
+				"name":    env,
+				"default": defaultValue,
+			}).Info("Environment variable not set or empty. Using default value.")
+		return defaultValue
+	}
+
+	values := []string{}
+	for _, value := range strings.Split(envValue, ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
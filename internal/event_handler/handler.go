@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	keptnevents "github.com/keptn/go-utils/pkg/lib"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	keptnkubeutils "github.com/keptn/kubernetes-utils/pkg"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/keptn-contrib/dynatrace-service/internal/action"
@@ -15,12 +18,67 @@ import (
 	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	"github.com/keptn-contrib/dynatrace-service/internal/monitoring"
 	"github.com/keptn-contrib/dynatrace-service/internal/problem"
 	"github.com/keptn-contrib/dynatrace-service/internal/sli"
 )
 
+// problemStateRetention is how long the state of problems is kept after they last changed.
+const problemStateRetention = 24 * time.Hour
+
+// problemStateConfigMapName is the name of the ConfigMap the state of problems is kept in if it is persisted.
+const problemStateConfigMapName = "dynatrace-service-problem-states"
+
+// problemDeduplicator is shared by all problem event handlers so that repeated notifications for the same problem can be detected.
+var problemDeduplicator *problem.ProblemDeduplicator
+var problemDeduplicatorOnce sync.Once
+
+// getProblemDeduplicator returns the shared problem deduplicator, which keeps the state of problems in a ConfigMap if enabled and possible, or otherwise in memory.
+func getProblemDeduplicator() *problem.ProblemDeduplicator {
+	problemDeduplicatorOnce.Do(func() {
+		problemDeduplicator = problem.NewProblemDeduplicator(getProblemStateStore(), env.GetProblemDebounceWindow())
+	})
+	return problemDeduplicator
+}
+
+func getProblemStateStore() problem.ProblemStateStore {
+	if !env.IsProblemStatePersistenceEnabled() {
+		return problem.NewInMemoryProblemStateStore(problemStateRetention)
+	}
+
+	k8sClient, err := keptnkubeutils.GetClientset(env.GetKubernetesServiceHost() != "")
+	if err != nil {
+		log.WithError(err).Warn("Could not create Kubernetes client, keeping state of problems in memory")
+		return problem.NewInMemoryProblemStateStore(problemStateRetention)
+	}
+
+	return problem.NewConfigMapProblemStateStore(k8sClient, env.GetPodNamespace(), problemStateConfigMapName, problemStateRetention)
+}
+
+// dynatraceCredentialsNameConfigMapName is the name of the ConfigMap the names of the secrets containing the Dynatrace credentials of the projects, stages and services are kept in.
+const dynatraceCredentialsNameConfigMapName = "dynatrace-service-credentials"
+
+// dynatraceCredentialsNameStore is shared by all event handlers so that the Dynatrace credentials of a project are known once it has been deleted.
+var dynatraceCredentialsNameStore credentials.DynatraceCredentialsNameStore
+var dynatraceCredentialsNameStoreOnce sync.Once
+
+// getDynatraceCredentialsNameStore returns the shared store of the names of the secrets containing the Dynatrace credentials of projects, which keeps them in a ConfigMap if possible, or otherwise in memory.
+func getDynatraceCredentialsNameStore() credentials.DynatraceCredentialsNameStore {
+	dynatraceCredentialsNameStoreOnce.Do(func() {
+		k8sClient, err := keptnkubeutils.GetClientset(env.GetKubernetesServiceHost() != "")
+		if err != nil {
+			log.WithError(err).Warn("Could not create Kubernetes client, keeping names of Dynatrace credentials secrets in memory")
+			dynatraceCredentialsNameStore = credentials.NewInMemoryDynatraceCredentialsNameStore()
+			return
+		}
+
+		dynatraceCredentialsNameStore = credentials.NewConfigMapDynatraceCredentialsNameStore(k8sClient, env.GetPodNamespace(), dynatraceCredentialsNameConfigMapName)
+	})
+	return dynatraceCredentialsNameStore
+}
+
 // DynatraceEventHandler is the common interface for all event handlers.
 type DynatraceEventHandler interface {
 	// HandleEvent handles an event.
@@ -55,6 +113,13 @@ func getEventHandler(ctx context.Context, event cloudevents.Event, clientFactory
 		return NoOpHandler{}, nil
 	}
 
+	if problemAdapter, ok := keptnEvent.(*problem.ProblemAdapter); ok && needsKeptnServiceResolution(problemAdapter) {
+		err = resolveKeptnServiceOfProblem(ctx, problemAdapter)
+		if err != nil {
+			log.WithError(err).WithField("PID", problemAdapter.GetPID()).Warn("Could not resolve Keptn service of problem")
+		}
+	}
+
 	if keptnEvent.GetProject() == "" {
 		return nil, errors.New("event has no project")
 	}
@@ -62,7 +127,21 @@ func getEventHandler(ctx context.Context, event cloudevents.Event, clientFactory
 	dynatraceConfigGetter := config.NewDynatraceConfigGetter(keptn.NewConfigClient(clientFactory.CreateResourceClient()))
 	dynatraceConfig, err := dynatraceConfigGetter.GetDynatraceConfig(keptnEvent)
 	if err != nil {
-		return nil, fmt.Errorf("could not get configuration: %w", err)
+		if _, ok := keptnEvent.(*monitoring.DeleteFinishedAdapter); !ok {
+			return nil, fmt.Errorf("could not get configuration: %w", err)
+		}
+
+		// the configuration of a deleted project is no longer available, so the credentials remembered while it existed are used
+		dynatraceConfig, err = getDynatraceConfigOfDeletedProject(keptnEvent)
+		if err != nil {
+			log.WithError(err).WithField("project", keptnEvent.GetProject()).Warn("Skipping clean-up of Dynatrace configuration of deleted project")
+			return NoOpHandler{}, nil
+		}
+	} else {
+		err = getDynatraceCredentialsNameStore().Put(keptnEvent.GetProject(), keptnEvent.GetStage(), keptnEvent.GetService(), dynatraceConfig.DtCreds)
+		if err != nil {
+			log.WithError(err).WithField("project", keptnEvent.GetProject()).Warn("Could not remember name of Dynatrace credentials secret of project")
+		}
 	}
 
 	dynatraceCredentialsProvider, err := credentials.NewDefaultDynatraceK8sSecretReader()
@@ -84,38 +163,102 @@ func getEventHandler(ctx context.Context, event cloudevents.Event, clientFactory
 
 	switch aType := keptnEvent.(type) {
 	case *monitoring.ConfigureMonitoringAdapter:
-		return monitoring.NewConfigureMonitoringEventHandler(keptnEvent.(*monitoring.ConfigureMonitoringAdapter), dtClient, kClient, keptn.NewConfigClient(clientFactory.CreateResourceClient()), clientFactory.CreateServiceClient(), keptn.NewDefaultCredentialsChecker()), nil
+		return monitoring.NewConfigureMonitoringEventHandler(keptnEvent.(*monitoring.ConfigureMonitoringAdapter), dtClient, kClient, keptn.NewConfigClient(clientFactory.CreateResourceClient()), clientFactory.CreateServiceClient(), clientFactory.CreateEventClient(), dynatraceConfigGetter, keptn.NewDefaultCredentialsChecker()), nil
+	case *monitoring.DeleteFinishedAdapter:
+		return monitoring.NewDeleteFinishedEventHandler(keptnEvent.(*monitoring.DeleteFinishedAdapter), dtClient, getDynatraceCredentialsNameStore()), nil
 	case *problem.ProblemAdapter:
-		return problem.NewProblemEventHandler(keptnEvent.(*problem.ProblemAdapter), kClient), nil
+		return problem.NewProblemEventHandler(keptnEvent.(*problem.ProblemAdapter), dtClient, kClient, dynatraceConfig.ProblemFilter, getProblemDeduplicator()), nil
 	case *action.ActionTriggeredAdapter:
-		return action.NewActionTriggeredEventHandler(keptnEvent.(*action.ActionTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewActionTriggeredEventHandler(keptnEvent.(*action.ActionTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	case *action.ActionStartedAdapter:
 		return action.NewActionStartedEventHandler(keptnEvent.(*action.ActionStartedAdapter), dtClient, clientFactory.CreateEventClient()), nil
 	case *action.ActionFinishedAdapter:
-		return action.NewActionFinishedEventHandler(keptnEvent.(*action.ActionFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewActionFinishedEventHandler(keptnEvent.(*action.ActionFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	case *sli.GetSLITriggeredAdapter:
 		return sli.NewGetSLITriggeredHandler(keptnEvent.(*sli.GetSLITriggeredAdapter), dtClient, kClient, keptn.NewConfigClient(clientFactory.CreateResourceClient()), dynatraceConfig.DtCreds, dynatraceConfig.Dashboard), nil
 	case *action.DeploymentFinishedAdapter:
-		return action.NewDeploymentFinishedEventHandler(keptnEvent.(*action.DeploymentFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewDeploymentFinishedEventHandler(keptnEvent.(*action.DeploymentFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation, dynatraceConfig.DeploymentMaintenanceWindow), nil
+	case *action.DeploymentTriggeredAdapter:
+		return action.NewDeploymentTriggeredEventHandler(keptnEvent.(*action.DeploymentTriggeredAdapter), dtClient, dynatraceConfig.AttachRules, dynatraceConfig.DeploymentMaintenanceWindow), nil
 	case *action.TestTriggeredAdapter:
-		return action.NewTestTriggeredEventHandler(keptnEvent.(*action.TestTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewTestTriggeredEventHandler(keptnEvent.(*action.TestTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	case *action.TestFinishedAdapter:
-		return action.NewTestFinishedEventHandler(keptnEvent.(*action.TestFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewTestFinishedEventHandler(keptnEvent.(*action.TestFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	case *action.EvaluationFinishedAdapter:
-		return action.NewEvaluationFinishedEventHandler(keptnEvent.(*action.EvaluationFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewEvaluationFinishedEventHandler(keptnEvent.(*action.EvaluationFinishedAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	case *action.ReleaseTriggeredAdapter:
-		return action.NewReleaseTriggeredEventHandler(keptnEvent.(*action.ReleaseTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules), nil
+		return action.NewReleaseTriggeredEventHandler(keptnEvent.(*action.ReleaseTriggeredAdapter), dtClient, clientFactory.CreateEventClient(), dynatraceConfig.AttachRules, dynatraceConfig.AttachRulesValidation), nil
 	default:
 		return NewErrorHandler(fmt.Errorf("this should not have happened, we are missing an implementation for: %T", aType), event, clientFactory.CreateUniformClient()), nil
 	}
 }
 
+// getDynatraceConfigOfDeletedProject returns the default configuration using the Dynatrace credentials remembered for the deleted project, stage or service of the event.
+// An error is returned if the credentials are not known, as cleaning up with other credentials would affect the wrong Dynatrace tenant.
+func getDynatraceConfigOfDeletedProject(event adapter.EventContentAdapter) (*config.DynatraceConfig, error) {
+	secretName, err := getDynatraceCredentialsNameStore().Get(event.GetProject(), event.GetStage(), event.GetService())
+	if err != nil {
+		return nil, fmt.Errorf("could not get name of Dynatrace credentials secret: %w", err)
+	}
+
+	if secretName == "" {
+		return nil, errors.New("the Dynatrace credentials used by the project are not known, as it was not configured since the dynatrace-service started remembering them")
+	}
+
+	dynatraceConfig := config.NewDynatraceConfigWithDefaults()
+	dynatraceConfig.DtCreds = secretName
+	return dynatraceConfig, nil
+}
+
+// needsKeptnServiceResolution returns true if the problem should be resolved to a Keptn service via its entities as its project or stage could not be determined from its tags.
+func needsKeptnServiceResolution(problemAdapter *problem.ProblemAdapter) bool {
+	if !env.IsProblemKeptnServiceResolutionEnabled() || problemAdapter.IsNotFromDynatrace() || problemAdapter.IsTestNotification() {
+		return false
+	}
+
+	return problemAdapter.GetProject() == "" || problemAdapter.GetStage() == ""
+}
+
+// resolveKeptnServiceOfProblem sets the project, stage and service of the problem based on the services related to its entities.
+// As the project is not yet known, the Dynatrace credentials are read from the default secret.
+func resolveKeptnServiceOfProblem(ctx context.Context, problemAdapter *problem.ProblemAdapter) error {
+	dynatraceCredentialsProvider, err := credentials.NewDefaultDynatraceK8sSecretReader()
+	if err != nil {
+		return fmt.Errorf("could not create Kubernetes secret reader: %w", err)
+	}
+
+	dynatraceCredentials, err := dynatraceCredentialsProvider.GetDynatraceCredentials(ctx, config.NewDynatraceConfigWithDefaults().DtCreds)
+	if err != nil {
+		return fmt.Errorf("could not get Dynatrace credentials: %w", err)
+	}
+
+	resolver := problem.NewKeptnServiceResolver(dynatrace.NewClient(dynatraceCredentials), env.GetProblemKeptnServiceTieBreakRules())
+	keptnService, err := resolver.Resolve(ctx, problemAdapter.GetPID())
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"PID": problemAdapter.GetPID(), "keptnService": keptnService.String()}).Info("Resolved Keptn service of problem via its entities")
+	problemAdapter.SetKeptnService(*keptnService)
+	return nil
+}
+
 func getEventAdapter(e cloudevents.Event) (adapter.EventContentAdapter, error) {
 	switch e.Type() {
 	case keptnevents.ConfigureMonitoringEventType:
 		return monitoring.NewConfigureMonitoringAdapterFromEvent(e)
 	case keptnevents.ProblemEventType:
 		return problem.NewProblemAdapterFromEvent(e)
+	case keptnv2.GetFinishedEventType(keptnv2.ProjectDeleteTaskName):
+		if !env.IsMonitoringCleanupEnabled() {
+			return nil, nil
+		}
+		return monitoring.NewProjectDeleteFinishedAdapterFromEvent(e)
+	case keptnv2.GetFinishedEventType(keptnv2.ServiceDeleteTaskName):
+		if !env.IsMonitoringCleanupEnabled() {
+			return nil, nil
+		}
+		return monitoring.NewServiceDeleteFinishedAdapterFromEvent(e)
 	case keptnv2.GetTriggeredEventType(keptnv2.ActionTaskName):
 		return action.NewActionTriggeredAdapterFromEvent(e)
 	case keptnv2.GetStartedEventType(keptnv2.ActionTaskName):
@@ -124,6 +267,8 @@ func getEventAdapter(e cloudevents.Event) (adapter.EventContentAdapter, error) {
 		return action.NewActionFinishedAdapterFromEvent(e)
 	case keptnv2.GetTriggeredEventType(keptnv2.GetSLITaskName):
 		return sli.NewGetSLITriggeredAdapterFromEvent(e)
+	case keptnv2.GetTriggeredEventType(keptnv2.DeploymentTaskName):
+		return action.NewDeploymentTriggeredAdapterFromEvent(e)
 	case keptnv2.GetFinishedEventType(keptnv2.DeploymentTaskName):
 		return action.NewDeploymentFinishedAdapterFromEvent(e)
 	case keptnv2.GetTriggeredEventType(keptnv2.TestTaskName):
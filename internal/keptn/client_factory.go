@@ -9,6 +9,7 @@ import (
 // ClientFactoryInterface provides a factories for clients.
 type ClientFactoryInterface interface {
 	CreateEventClient() EventClientInterface
+	CreateProjectClient() ProjectClientInterface
 	CreateResourceClient() ResourceClientInterface
 	CreateServiceClient() ServiceClientInterface
 	CreateUniformClient() UniformClientInterface
@@ -28,6 +29,11 @@ func (c *ClientFactory) CreateEventClient() EventClientInterface {
 	return NewEventClient(api.NewEventHandler(getDatastoreURL()))
 }
 
+// CreateProjectClient creates a ProjectClientInterface.
+func (c *ClientFactory) CreateProjectClient() ProjectClientInterface {
+	return NewProjectClient(api.NewProjectHandler(getShipyardControllerURL()))
+}
+
 // CreateResourceClient creates a ResourceClientInterface.
 func (c *ClientFactory) CreateResourceClient() ResourceClientInterface {
 	return NewResourceClient(api.NewResourceHandler(getConfigurationServiceURL()))
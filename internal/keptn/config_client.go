@@ -16,6 +16,12 @@ type SLOReaderInterface interface {
 	GetSLOs(project string, stage string, service string) (*keptn.ServiceLevelObjectives, error)
 }
 
+// SLIReaderInterface provides functionality for getting SLIs.
+type SLIReaderInterface interface {
+	// GetSLIs gets the SLIs stored for exactly the specified project, stage and service.
+	GetSLIs(project string, stage string, service string) (*dynatrace.SLI, error)
+}
+
 // SLIAndSLOWriterInterface provides functionality for uploading SLIs and SLOs.
 type SLIAndSLOWriterInterface interface {
 	// UploadSLIs uploads the SLIs for the specified project, stage and service.
@@ -31,6 +37,12 @@ type SLOAndSLIClientInterface interface {
 	SLIAndSLOWriterInterface
 }
 
+// SLIAndSLOResourcesClientInterface provides functionality for getting SLIs and uploading SLIs and SLOs.
+type SLIAndSLOResourcesClientInterface interface {
+	SLIReaderInterface
+	SLIAndSLOWriterInterface
+}
+
 // DynatraceConfigReaderInterface provides functionality for getting a Dynatrace config.
 type DynatraceConfigReaderInterface interface {
 	// GetDynatraceConfig gets the Dynatrace config for the specified project, stage and service, checking first on the service, then stage and then project level.
@@ -69,6 +81,22 @@ func (rc *ConfigClient) GetSLOs(project string, stage string, service string) (*
 	return slos, nil
 }
 
+// GetSLIs gets the SLIs stored for exactly the specified project, stage and service.
+func (rc *ConfigClient) GetSLIs(project string, stage string, service string) (*dynatrace.SLI, error) {
+	resource, err := rc.client.GetServiceResource(project, stage, service, sliFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	slis := &dynatrace.SLI{}
+	err = yaml.Unmarshal([]byte(resource), slis)
+	if err != nil {
+		return nil, errors.New("invalid SLI file format")
+	}
+
+	return slis, nil
+}
+
 // UploadSLOs uploads the SLOs for the specified project, stage and service.
 func (rc *ConfigClient) UploadSLOs(project string, stage string, service string, slos *keptn.ServiceLevelObjectives) error {
 	yamlAsByteArray, err := yaml.Marshal(slos)
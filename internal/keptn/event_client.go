@@ -3,10 +3,13 @@ package keptn
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
 	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn/go-utils/pkg/api/models"
 	api "github.com/keptn/go-utils/pkg/api/utils"
 	keptncommon "github.com/keptn/go-utils/pkg/lib"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
@@ -23,6 +26,38 @@ type EventClientInterface interface {
 
 	// GetImageAndTag extracts the image and tag associated with a deployment triggered as part of the sequence.
 	GetImageAndTag(keptnEvent adapter.EventContentAdapter) common.ImageAndTag
+
+	// GetDeploymentConfiguration extracts the configuration change associated with a deployment triggered as part of the sequence.
+	GetDeploymentConfiguration(keptnEvent adapter.EventContentAdapter) DeploymentConfiguration
+
+	// GetRemediationTimeline returns the progress of the actions and evaluations of the remediation sequence the event belongs to or returns an error.
+	GetRemediationTimeline(keptnEvent adapter.EventContentAdapter) (*RemediationTimeline, error)
+
+	// HasProblemEvent checks whether a problem event with the specified Keptn context has been received by Keptn or returns an error.
+	HasProblemEvent(keptnContext string) (bool, error)
+}
+
+// DeploymentConfiguration describes the configuration change of a deployment triggered as part of a sequence.
+type DeploymentConfiguration struct {
+	// ImagesAndTags contains all images found in the configuration change values, ordered by the key of the value.
+	ImagesAndTags []common.ImageAndTag
+
+	// HelmChartVersion is the version of the Helm chart, if included in the configuration change values.
+	HelmChartVersion string
+}
+
+// GetImageAndTag returns the first image and tag of the deployment or a not available ImageAndTag if there is none.
+func (c DeploymentConfiguration) GetImageAndTag() common.ImageAndTag {
+	if len(c.ImagesAndTags) == 0 {
+		return common.NewNotAvailableImageAndTag()
+	}
+
+	return c.ImagesAndTags[0]
+}
+
+// keptnEventConverter is implemented by event adapters that can provide the underlying Keptn event.
+type keptnEventConverter interface {
+	ToKeptnEvent() (*models.KeptnContextExtendedCE, error)
 }
 
 // EventClient implements offers EventClientInterface using api.EventsV1Interface.
@@ -97,9 +132,57 @@ func (c *EventClient) FindProblemID(keptnEvent adapter.EventContentAdapter) (str
 	return problemOpenEvent.PID, nil
 }
 
+// HasProblemEvent checks whether a problem event with the specified Keptn context has been received by Keptn or returns an error.
+func (c *EventClient) HasProblemEvent(keptnContext string) (bool, error) {
+	events, err := c.client.GetEvents(
+		&api.EventFilter{
+			EventType:    keptncommon.ProblemEventType,
+			KeptnContext: keptnContext,
+		})
+
+	if err != nil {
+		return false, errors.New(err.GetMessage())
+	}
+
+	return len(events) > 0, nil
+}
+
+// GetRemediationTimeline returns the progress of the actions and evaluations of the remediation sequence the event belongs to or returns an error.
+func (c *EventClient) GetRemediationTimeline(keptnEvent adapter.EventContentAdapter) (*RemediationTimeline, error) {
+	events, mErr := c.client.GetEvents(
+		&api.EventFilter{
+			Project:      keptnEvent.GetProject(),
+			KeptnContext: keptnEvent.GetShKeptnContext(),
+		})
+
+	if mErr != nil {
+		return nil, fmt.Errorf("could not retrieve events of remediation sequence: %s", mErr.GetMessage())
+	}
+
+	// the event being handled may not have been stored yet, so include it explicitly if possible
+	if converter, ok := keptnEvent.(keptnEventConverter); ok {
+		currentEvent, err := converter.ToKeptnEvent()
+		if err != nil {
+			log.WithError(err).Warn("Could not convert event to include it in remediation timeline")
+		} else {
+			if currentEvent.Time.IsZero() {
+				currentEvent.Time = time.Now()
+			}
+			events = append(events, currentEvent)
+		}
+	}
+
+	timeline := NewRemediationTimeline(keptnEvent.GetShKeptnContext(), events)
+	return &timeline, nil
+}
+
 // GetImageAndTag extracts the image and tag associated with a deployment triggered as part of the sequence.
 func (c *EventClient) GetImageAndTag(event adapter.EventContentAdapter) common.ImageAndTag {
+	return c.GetDeploymentConfiguration(event).GetImageAndTag()
+}
 
+// GetDeploymentConfiguration extracts the configuration change associated with a deployment triggered as part of the sequence.
+func (c *EventClient) GetDeploymentConfiguration(event adapter.EventContentAdapter) DeploymentConfiguration {
 	events, mErr := c.client.GetEvents(
 		&api.EventFilter{
 			Project:      event.GetProject(),
@@ -110,31 +193,75 @@ func (c *EventClient) GetImageAndTag(event adapter.EventContentAdapter) common.I
 		})
 
 	if mErr != nil {
-		log.WithError(errors.New(mErr.GetMessage())).Error("Could not retrieve image and tag for event")
-		return common.NewNotAvailableImageAndTag()
+		log.WithError(errors.New(mErr.GetMessage())).Error("Could not retrieve deployment configuration for event")
+		return DeploymentConfiguration{}
 	}
 
 	if len(events) == 0 {
-		return common.NewNotAvailableImageAndTag()
+		return DeploymentConfiguration{}
 	}
 
 	triggeredData := &keptnv2.DeploymentTriggeredEventData{}
 	err := keptnv2.Decode(events[0].Data, triggeredData)
 	if err != nil {
 		log.WithError(err).Error("Could not decode event data")
-		return common.NewNotAvailableImageAndTag()
+		return DeploymentConfiguration{}
 	}
 
-	for key, value := range triggeredData.ConfigurationChange.Values {
+	return newDeploymentConfigurationFromValues(triggeredData.ConfigurationChange.Values)
+}
+
+// newDeploymentConfigurationFromValues creates a DeploymentConfiguration from configuration change values.
+// Nested values are supported, e.g. {"image": "carts:0.13.1"}, {"carts": {"image": "carts:0.13.1"}} and {"image": {"repository": "carts", "tag": "0.13.1"}} are all recognized.
+func newDeploymentConfigurationFromValues(values map[string]interface{}) DeploymentConfiguration {
+	flattenedValues := make(map[string]string)
+	flattenValues("", values, flattenedValues)
+
+	keys := make([]string, 0, len(flattenedValues))
+	for key := range flattenedValues {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	configuration := DeploymentConfiguration{}
+	for _, key := range keys {
+		value := flattenedValues[key]
 		if strings.HasSuffix(key, "image") {
-			imageAndTag := value.(string)
-			return common.NewImageAndTag(
-				getImage(imageAndTag),
-				getTag(imageAndTag))
+			configuration.ImagesAndTags = append(configuration.ImagesAndTags, common.NewImageAndTag(getImage(value), getTag(value)))
+		} else if imageKey := strings.TrimSuffix(key, ".repository"); imageKey != key && strings.HasSuffix(imageKey, "image") {
+			tag, ok := flattenedValues[imageKey+".tag"]
+			if !ok {
+				tag = common.NotAvailable
+			}
+			configuration.ImagesAndTags = append(configuration.ImagesAndTags, common.NewImageAndTag(value, tag))
+		} else if isHelmChartVersionKey(key) && configuration.HelmChartVersion == "" {
+			configuration.HelmChartVersion = value
 		}
 	}
 
-	return common.NewNotAvailableImageAndTag()
+	return configuration
+}
+
+// flattenValues adds all string values to result using their dot-separated path as key.
+func flattenValues(prefix string, values map[string]interface{}, result map[string]string) {
+	for key, value := range values {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		switch v := value.(type) {
+		case string:
+			result[key] = v
+		case map[string]interface{}:
+			flattenValues(key, v, result)
+		}
+	}
+}
+
+// isHelmChartVersionKey returns true if the key refers to a Helm chart version, e.g. chartVersion or chart.version.
+func isHelmChartVersionKey(key string) bool {
+	lowerCaseKey := strings.ToLower(key)
+	return strings.HasSuffix(lowerCaseKey, "chartversion") || strings.HasSuffix(lowerCaseKey, "chart.version")
 }
 
 // getImage returns the deployed image
@@ -0,0 +1,91 @@
+package keptn
+
+import (
+	"testing"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDeploymentConfigurationFromValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   DeploymentConfiguration
+	}{
+		{
+			name: "single image",
+			values: map[string]interface{}{
+				"image": "docker.io/keptnexamples/carts:0.13.1",
+			},
+			want: DeploymentConfiguration{
+				ImagesAndTags: []common.ImageAndTag{common.NewImageAndTag("docker.io/keptnexamples/carts", "0.13.1")},
+			},
+		},
+		{
+			name: "image without tag",
+			values: map[string]interface{}{
+				"image": "docker.io/keptnexamples/carts",
+			},
+			want: DeploymentConfiguration{
+				ImagesAndTags: []common.ImageAndTag{common.NewImageAndTag("docker.io/keptnexamples/carts", common.NotAvailable)},
+			},
+		},
+		{
+			name: "multiple nested images and chart version",
+			values: map[string]interface{}{
+				"chartVersion": "1.2.3",
+				"orders": map[string]interface{}{
+					"image": "docker.io/keptnexamples/orders:0.2.0",
+				},
+				"carts": map[string]interface{}{
+					"image": map[string]interface{}{
+						"repository": "docker.io/keptnexamples/carts",
+						"tag":        "0.13.1",
+					},
+					"replicas": 2,
+				},
+			},
+			want: DeploymentConfiguration{
+				ImagesAndTags: []common.ImageAndTag{
+					common.NewImageAndTag("docker.io/keptnexamples/carts", "0.13.1"),
+					common.NewImageAndTag("docker.io/keptnexamples/orders", "0.2.0"),
+				},
+				HelmChartVersion: "1.2.3",
+			},
+		},
+		{
+			name: "nested chart version",
+			values: map[string]interface{}{
+				"chart": map[string]interface{}{
+					"version": "0.1.0",
+				},
+			},
+			want: DeploymentConfiguration{
+				HelmChartVersion: "0.1.0",
+			},
+		},
+		{
+			name:   "no values",
+			values: nil,
+			want:   DeploymentConfiguration{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.want, newDeploymentConfigurationFromValues(tt.values))
+		})
+	}
+}
+
+func TestDeploymentConfiguration_GetImageAndTag(t *testing.T) {
+	assert.EqualValues(t, common.NewNotAvailableImageAndTag(), DeploymentConfiguration{}.GetImageAndTag())
+
+	configuration := DeploymentConfiguration{
+		ImagesAndTags: []common.ImageAndTag{
+			common.NewImageAndTag("carts", "0.13.1"),
+			common.NewImageAndTag("orders", "0.2.0"),
+		},
+	}
+	assert.EqualValues(t, common.NewImageAndTag("carts", "0.13.1"), configuration.GetImageAndTag())
+}
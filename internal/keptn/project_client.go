@@ -0,0 +1,42 @@
+package keptn
+
+import (
+	"fmt"
+
+	api "github.com/keptn/go-utils/pkg/api/utils"
+)
+
+// ProjectClientInterface provides access to Keptn projects.
+type ProjectClientInterface interface {
+	// GetProjectNames gets the names of all Keptn projects or returns an error.
+	GetProjectNames() ([]string, error)
+}
+
+// ProjectClient is an implementation of ProjectClientInterface using api.ProjectsV1Interface.
+type ProjectClient struct {
+	projectsClient api.ProjectsV1Interface
+}
+
+// NewProjectClient creates a new ProjectClient using the specified client.
+func NewProjectClient(projectsClient api.ProjectsV1Interface) *ProjectClient {
+	return &ProjectClient{
+		projectsClient: projectsClient,
+	}
+}
+
+// GetProjectNames gets the names of all Keptn projects or returns an error.
+func (c *ProjectClient) GetProjectNames() ([]string, error) {
+	projects, err := c.projectsClient.GetAllProjects()
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch Keptn projects: %s", err.Error())
+	}
+
+	projectNames := make([]string, 0, len(projects))
+	for _, project := range projects {
+		if project != nil {
+			projectNames = append(projectNames, project.ProjectName)
+		}
+	}
+
+	return projectNames, nil
+}
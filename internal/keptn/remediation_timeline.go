@@ -0,0 +1,177 @@
+package keptn
+
+import (
+	"sort"
+	"time"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	log "github.com/sirupsen/logrus"
+)
+
+// RemediationVerdict is the overall state of a remediation sequence.
+type RemediationVerdict string
+
+const (
+	// RemediationVerdictInProgress indicates that an action is still pending or that its evaluation has not finished yet.
+	RemediationVerdictInProgress RemediationVerdict = "in progress"
+
+	// RemediationVerdictSuccessful indicates that the last evaluation resulted in pass or warning.
+	RemediationVerdictSuccessful RemediationVerdict = "successful"
+
+	// RemediationVerdictNotSuccessful indicates that the last evaluation resulted in fail.
+	RemediationVerdictNotSuccessful RemediationVerdict = "not successful"
+)
+
+// RemediationAction is the progress of a single remediation action.
+type RemediationAction struct {
+	// Action is the type of the action, e.g. scaling.
+	Action string
+
+	// Description is the description of the action, if any.
+	Description string
+
+	TriggeredAt time.Time
+	StartedAt   time.Time
+
+	// StartedBy is the source of the action.started event, i.e. the service executing the action.
+	StartedBy string
+
+	FinishedAt time.Time
+	Status     keptnv2.StatusType
+	Result     keptnv2.ResultType
+}
+
+// IsFinished returns true if an action.finished event has been received for the action.
+func (a RemediationAction) IsFinished() bool {
+	return !a.FinishedAt.IsZero()
+}
+
+// RemediationEvaluation is the result of an evaluation carried out as part of a remediation sequence.
+type RemediationEvaluation struct {
+	FinishedAt time.Time
+	Result     keptnv2.ResultType
+	Score      float64
+}
+
+// RemediationTimeline is the progress of all actions and evaluations of a remediation sequence, ordered by time.
+type RemediationTimeline struct {
+	KeptnContext string
+	Actions      []RemediationAction
+	Evaluations  []RemediationEvaluation
+}
+
+// NewRemediationTimeline creates a RemediationTimeline from the action.triggered, action.started, action.finished and evaluation.finished events of a Keptn context.
+// Other events are ignored, as are duplicate events with the same ID.
+func NewRemediationTimeline(keptnContext string, events []*models.KeptnContextExtendedCE) RemediationTimeline {
+	sortedEvents := make([]*models.KeptnContextExtendedCE, 0, len(events))
+	seenIDs := make(map[string]bool)
+	for _, event := range events {
+		if event == nil || event.Type == nil {
+			continue
+		}
+
+		if event.ID != "" {
+			if seenIDs[event.ID] {
+				continue
+			}
+			seenIDs[event.ID] = true
+		}
+		sortedEvents = append(sortedEvents, event)
+	}
+
+	sort.SliceStable(sortedEvents, func(i, j int) bool {
+		return sortedEvents[i].Time.Before(sortedEvents[j].Time)
+	})
+
+	timeline := RemediationTimeline{KeptnContext: keptnContext}
+	actionIndicesByTriggeredID := make(map[string]int)
+	getAction := func(triggeredID string) *RemediationAction {
+		index, ok := actionIndicesByTriggeredID[triggeredID]
+		if !ok {
+			index = len(timeline.Actions)
+			timeline.Actions = append(timeline.Actions, RemediationAction{})
+			if triggeredID != "" {
+				actionIndicesByTriggeredID[triggeredID] = index
+			}
+		}
+		return &timeline.Actions[index]
+	}
+
+	for _, event := range sortedEvents {
+		switch *event.Type {
+		case keptnv2.GetTriggeredEventType(keptnv2.ActionTaskName):
+			data := &keptnv2.ActionTriggeredEventData{}
+			if !decodeRemediationEventData(event, data) {
+				continue
+			}
+
+			action := getAction(event.ID)
+			action.Action = data.Action.Action
+			action.Description = data.Action.Description
+			action.TriggeredAt = event.Time
+
+		case keptnv2.GetStartedEventType(keptnv2.ActionTaskName):
+			action := getAction(event.Triggeredid)
+			action.StartedAt = event.Time
+			if event.Source != nil {
+				action.StartedBy = *event.Source
+			}
+
+		case keptnv2.GetFinishedEventType(keptnv2.ActionTaskName):
+			data := &keptnv2.ActionFinishedEventData{}
+			if !decodeRemediationEventData(event, data) {
+				continue
+			}
+
+			action := getAction(event.Triggeredid)
+			action.FinishedAt = event.Time
+			action.Status = data.Status
+			action.Result = data.Result
+
+		case keptnv2.GetFinishedEventType(keptnv2.EvaluationTaskName):
+			data := &keptnv2.EvaluationFinishedEventData{}
+			if !decodeRemediationEventData(event, data) {
+				continue
+			}
+
+			timeline.Evaluations = append(timeline.Evaluations, RemediationEvaluation{
+				FinishedAt: event.Time,
+				Result:     data.Result,
+				Score:      data.Evaluation.Score,
+			})
+		}
+	}
+
+	return timeline
+}
+
+func decodeRemediationEventData(event *models.KeptnContextExtendedCE, data interface{}) bool {
+	err := keptnv2.Decode(event.Data, data)
+	if err != nil {
+		log.WithError(err).WithField("eventID", event.ID).Warn("Could not decode remediation event data")
+		return false
+	}
+	return true
+}
+
+// GetVerdict returns the overall state of the remediation sequence.
+// The remediation is in progress until the last evaluation has finished after all actions have finished.
+func (t RemediationTimeline) GetVerdict() RemediationVerdict {
+	if len(t.Evaluations) == 0 {
+		return RemediationVerdictInProgress
+	}
+
+	lastEvaluation := t.Evaluations[len(t.Evaluations)-1]
+	for _, action := range t.Actions {
+		if !action.IsFinished() || action.TriggeredAt.After(lastEvaluation.FinishedAt) {
+			return RemediationVerdictInProgress
+		}
+	}
+
+	if lastEvaluation.Result == keptnv2.ResultPass || lastEvaluation.Result == keptnv2.ResultWarning {
+		return RemediationVerdictSuccessful
+	}
+
+	return RemediationVerdictNotSuccessful
+}
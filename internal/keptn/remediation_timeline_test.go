@@ -0,0 +1,143 @@
+package keptn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keptn/go-utils/pkg/api/models"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRemediationKeptnContext = "7c2c890f-b3ac-4caa-8922-f44d2aa54ec9"
+
+var testRemediationStartTime = time.Date(2021, 11, 22, 14, 17, 0, 0, time.UTC)
+
+func newTestRemediationEvent(id string, eventType string, triggeredID string, source string, offset time.Duration, data interface{}) *models.KeptnContextExtendedCE {
+	return &models.KeptnContextExtendedCE{
+		ID:             id,
+		Type:           &eventType,
+		Source:         &source,
+		Shkeptncontext: testRemediationKeptnContext,
+		Triggeredid:    triggeredID,
+		Time:           testRemediationStartTime.Add(offset),
+		Data:           data,
+	}
+}
+
+func newTestActionTriggeredEvent(id string, offset time.Duration, action string) *models.KeptnContextExtendedCE {
+	return newTestRemediationEvent(id, keptnv2.GetTriggeredEventType(keptnv2.ActionTaskName), "", "shipyard-controller", offset, keptnv2.ActionTriggeredEventData{
+		Action: keptnv2.ActionInfo{Action: action, Description: "Toggle feature " + action},
+	})
+}
+
+func newTestActionStartedEvent(id string, triggeredID string, offset time.Duration) *models.KeptnContextExtendedCE {
+	return newTestRemediationEvent(id, keptnv2.GetStartedEventType(keptnv2.ActionTaskName), triggeredID, "unleash-service", offset, keptnv2.ActionStartedEventData{})
+}
+
+func newTestActionFinishedEvent(id string, triggeredID string, offset time.Duration, result keptnv2.ResultType) *models.KeptnContextExtendedCE {
+	return newTestRemediationEvent(id, keptnv2.GetFinishedEventType(keptnv2.ActionTaskName), triggeredID, "unleash-service", offset, keptnv2.ActionFinishedEventData{
+		EventData: keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: result},
+	})
+}
+
+func newTestEvaluationFinishedEvent(id string, offset time.Duration, result keptnv2.ResultType, score float64) *models.KeptnContextExtendedCE {
+	return newTestRemediationEvent(id, keptnv2.GetFinishedEventType(keptnv2.EvaluationTaskName), "", "lighthouse-service", offset, keptnv2.EvaluationFinishedEventData{
+		EventData:  keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: result},
+		Evaluation: keptnv2.EvaluationDetails{Score: score},
+	})
+}
+
+func TestNewRemediationTimeline(t *testing.T) {
+	// events are deliberately out of order and include a duplicate
+	events := []*models.KeptnContextExtendedCE{
+		newTestActionFinishedEvent("a1-finished", "a1", 2*time.Minute, keptnv2.ResultPass),
+		newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+		newTestActionStartedEvent("a1-started", "a1", time.Minute),
+		newTestActionStartedEvent("a1-started", "a1", time.Minute),
+		newTestEvaluationFinishedEvent("e1", 5*time.Minute, keptnv2.ResultPass, 100),
+		newTestRemediationEvent("other", "sh.keptn.event.remediation.triggered", "", "shipyard-controller", 0, map[string]interface{}{}),
+	}
+
+	timeline := NewRemediationTimeline(testRemediationKeptnContext, events)
+
+	assert.EqualValues(t, RemediationTimeline{
+		KeptnContext: testRemediationKeptnContext,
+		Actions: []RemediationAction{
+			{
+				Action:      "toggle-feature",
+				Description: "Toggle feature toggle-feature",
+				TriggeredAt: testRemediationStartTime,
+				StartedAt:   testRemediationStartTime.Add(time.Minute),
+				StartedBy:   "unleash-service",
+				FinishedAt:  testRemediationStartTime.Add(2 * time.Minute),
+				Status:      keptnv2.StatusSucceeded,
+				Result:      keptnv2.ResultPass,
+			},
+		},
+		Evaluations: []RemediationEvaluation{
+			{
+				FinishedAt: testRemediationStartTime.Add(5 * time.Minute),
+				Result:     keptnv2.ResultPass,
+				Score:      100,
+			},
+		},
+	}, timeline)
+}
+
+func TestRemediationTimeline_GetVerdict(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*models.KeptnContextExtendedCE
+		want   RemediationVerdict
+	}{
+		{
+			name: "action pending",
+			events: []*models.KeptnContextExtendedCE{
+				newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+			},
+			want: RemediationVerdictInProgress,
+		},
+		{
+			name: "action finished but not evaluated",
+			events: []*models.KeptnContextExtendedCE{
+				newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+				newTestActionFinishedEvent("a1-finished", "a1", time.Minute, keptnv2.ResultPass),
+			},
+			want: RemediationVerdictInProgress,
+		},
+		{
+			name: "evaluation passed",
+			events: []*models.KeptnContextExtendedCE{
+				newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+				newTestActionFinishedEvent("a1-finished", "a1", time.Minute, keptnv2.ResultPass),
+				newTestEvaluationFinishedEvent("e1", 2*time.Minute, keptnv2.ResultWarning, 75),
+			},
+			want: RemediationVerdictSuccessful,
+		},
+		{
+			name: "evaluation failed",
+			events: []*models.KeptnContextExtendedCE{
+				newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+				newTestActionFinishedEvent("a1-finished", "a1", time.Minute, keptnv2.ResultPass),
+				newTestEvaluationFinishedEvent("e1", 2*time.Minute, keptnv2.ResultFailed, 0),
+			},
+			want: RemediationVerdictNotSuccessful,
+		},
+		{
+			name: "further action triggered after failed evaluation",
+			events: []*models.KeptnContextExtendedCE{
+				newTestActionTriggeredEvent("a1", 0, "toggle-feature"),
+				newTestActionFinishedEvent("a1-finished", "a1", time.Minute, keptnv2.ResultPass),
+				newTestEvaluationFinishedEvent("e1", 2*time.Minute, keptnv2.ResultFailed, 0),
+				newTestActionTriggeredEvent("a2", 3*time.Minute, "scaling"),
+			},
+			want: RemediationVerdictInProgress,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.want, NewRemediationTimeline(testRemediationKeptnContext, tt.events).GetVerdict())
+		})
+	}
+}
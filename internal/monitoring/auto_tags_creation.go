@@ -8,6 +8,9 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// autoTaggingRuleNames are the names of the auto-tagging rules created in Dynatrace
+var autoTaggingRuleNames = []string{"keptn_service", "keptn_stage", "keptn_project", "keptn_deployment"}
+
 type AutoTagCreation struct {
 	client dynatrace.ClientInterface
 }
@@ -18,12 +21,12 @@ func NewAutoTagCreation(client dynatrace.ClientInterface) *AutoTagCreation {
 	}
 }
 
-// Create creates auto-tags in Dynatrace and returns the tagging rules.
+// Create creates auto-tags in Dynatrace, updates existing ones that differ from the generated rules and returns the tagging rules.
 func (at *AutoTagCreation) Create(ctx context.Context) []ConfigResult {
 	log.Info("Setting up auto-tagging rules in Dynatrace Tenant")
 
 	autoTagsClient := dynatrace.NewAutoTagClient(at.client)
-	existingDTRuleNames, err := autoTagsClient.GetAllTagNames(ctx)
+	existingDTRules, err := autoTagsClient.GetAll(ctx)
 	if err != nil {
 		// Error occurred but continue
 		// TODO 2021-08-18: should this error just be ignored?
@@ -31,18 +34,54 @@ func (at *AutoTagCreation) Create(ctx context.Context) []ConfigResult {
 	}
 
 	var taggingRulesResults []ConfigResult
-	for _, ruleName := range []string{"keptn_service", "keptn_stage", "keptn_project", "keptn_deployment"} {
+	for _, ruleName := range autoTaggingRuleNames {
 		taggingRulesResults = append(
 			taggingRulesResults,
-			createAutoTaggingRuleForRuleName(ctx, autoTagsClient, existingDTRuleNames, ruleName))
+			createOrUpdateAutoTaggingRuleForRuleName(ctx, autoTagsClient, existingDTRules, ruleName))
 	}
 	return taggingRulesResults
 }
 
-func createAutoTaggingRuleForRuleName(ctx context.Context, client *dynatrace.AutoTagsClient, existingTagNames *dynatrace.TagNames, ruleName string) ConfigResult {
-	if !existingTagNames.Contains(ruleName) {
-		rule := createAutoTaggingRuleDTO(ruleName)
+// Plan returns the changes Create would make to the auto-tagging rules without creating or updating them.
+func (at *AutoTagCreation) Plan(ctx context.Context) []PlannedChange {
+	autoTagsClient := dynatrace.NewAutoTagClient(at.client)
+	existingDTRules, err := autoTagsClient.GetAll(ctx)
+
+	var plannedChanges []PlannedChange
+	for _, ruleName := range autoTaggingRuleNames {
+		if err != nil {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(ruleName, err))
+			continue
+		}
+
+		existingRuleID, exists := existingDTRules.GetID(ruleName)
+		if !exists {
+			plannedChanges = append(plannedChanges, PlannedChange{Name: ruleName, Action: PlannedChangeActionCreate})
+			continue
+		}
+
+		currentRule, getErr := autoTagsClient.GetByID(ctx, existingRuleID)
+		if getErr != nil {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(ruleName, getErr))
+			continue
+		}
+
+		plannedChanges = append(plannedChanges, newUpdatePlannedChange(ruleName, currentRule, createAutoTaggingRuleDTO(ruleName)))
+	}
+	return plannedChanges
+}
+
+// createOrUpdateAutoTaggingRuleForRuleName creates the auto-tagging rule unless it exists, in which case it is updated if it differs from the generated rule.
+func createOrUpdateAutoTaggingRuleForRuleName(ctx context.Context, client *dynatrace.AutoTagsClient, existingRules *dynatrace.AutoTags, ruleName string) ConfigResult {
+	rule := createAutoTaggingRuleDTO(ruleName)
+
+	existingRuleID := ""
+	exists := false
+	if existingRules != nil {
+		existingRuleID, exists = existingRules.GetID(ruleName)
+	}
 
+	if !exists {
 		err := client.Create(ctx, rule)
 		if err != nil {
 			// Error occurred but continue
@@ -60,10 +99,47 @@ func createAutoTaggingRuleForRuleName(ctx context.Context, client *dynatrace.Aut
 		}
 	}
 
-	log.WithField("ruleName", ruleName).Info("Tagging rule already exists")
+	currentRule, err := client.GetByID(ctx, existingRuleID)
+	if err != nil {
+		log.WithError(err).Error("Could not retrieve auto tagging rule")
+		return ConfigResult{
+			Name:    ruleName,
+			Success: false,
+			Message: "Could not retrieve auto tagging rule: " + err.Error(),
+		}
+	}
+
+	fieldChanges, err := diffJSON(currentRule, rule)
+	if err != nil {
+		return ConfigResult{
+			Name:    ruleName,
+			Success: false,
+			Message: "Could not compare auto tagging rule: " + err.Error(),
+		}
+	}
+
+	if len(fieldChanges) == 0 {
+		log.WithField("ruleName", ruleName).Info("Tagging rule already exists")
+		return ConfigResult{
+			Name:    ruleName,
+			Message: "Tagging rule " + ruleName + " already exists",
+			Success: true,
+		}
+	}
+
+	err = client.Update(ctx, existingRuleID, rule)
+	if err != nil {
+		log.WithError(err).Error("Could not update auto tagging rule")
+		return ConfigResult{
+			Name:    ruleName,
+			Success: false,
+			Message: "Could not update auto tagging rule: " + err.Error(),
+		}
+	}
+
 	return ConfigResult{
 		Name:    ruleName,
-		Message: "Tagging rule " + ruleName + " already exists",
+		Message: "Tagging rule " + ruleName + " updated",
 		Success: true,
 	}
 }
@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const autoTagsCreationTestDataFolder = "./testdata/auto_tags_creation/"
+
+func createAutoTagCreation(t *testing.T, handler http.Handler) (*AutoTagCreation, func()) {
+	httpClient, url, teardown := test.CreateHTTPSClient(handler)
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	if !assert.NoError(t, err) {
+		teardown()
+		t.FailNow()
+	}
+
+	return NewAutoTagCreation(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient)), teardown
+}
+
+// newAutoTagsHandler returns a handler for a tenant without Settings 2.0 API, containing unchanged keptn_service and keptn_project rules, a drifted keptn_stage rule and no keptn_deployment rule.
+func newAutoTagsHandler(t *testing.T) *writeRecordingHandler {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddStartsWithError("/api/v2/settings/schemas/", 404, autoTagsCreationTestDataFolder+"schema_not_found.json")
+	handler.AddExact("/api/config/v1/autoTags", autoTagsCreationTestDataFolder+"auto_tags.json")
+	handler.AddExact("/api/config/v1/autoTags/11", autoTagsCreationTestDataFolder+"auto_tag_service.json")
+	handler.AddExact("/api/config/v1/autoTags/12", autoTagsCreationTestDataFolder+"auto_tag_stage_drifted.json")
+	handler.AddExact("/api/config/v1/autoTags/13", autoTagsCreationTestDataFolder+"auto_tag_project.json")
+	return &writeRecordingHandler{handler: handler}
+}
+
+// TestAutoTagCreation_Plan tests that drifted auto-tagging rules are planned as updates listing the changed fields.
+func TestAutoTagCreation_Plan(t *testing.T) {
+	handler := newAutoTagsHandler(t)
+	creation, teardown := createAutoTagCreation(t, handler)
+	defer teardown()
+
+	plannedChanges := creation.Plan(context.Background())
+
+	assert.Empty(t, handler.postPaths)
+	assert.Empty(t, handler.putPaths)
+	assert.EqualValues(t,
+		[]PlannedChange{
+			{Name: "keptn_service", Action: PlannedChangeActionNone},
+			{
+				Name:   "keptn_stage",
+				Action: PlannedChangeActionUpdate,
+				FieldChanges: []FieldChange{
+					{Path: "rules[0].enabled", Current: false, Desired: true},
+				},
+			},
+			{Name: "keptn_project", Action: PlannedChangeActionNone},
+			{Name: "keptn_deployment", Action: PlannedChangeActionCreate},
+		},
+		plannedChanges)
+}
+
+// TestAutoTagCreation_Create tests that missing auto-tagging rules are created and drifted ones are updated.
+func TestAutoTagCreation_Create(t *testing.T) {
+	handler := newAutoTagsHandler(t)
+	creation, teardown := createAutoTagCreation(t, handler)
+	defer teardown()
+
+	results := creation.Create(context.Background())
+
+	assert.EqualValues(t, []string{"/api/config/v1/autoTags"}, handler.postPaths)
+	assert.EqualValues(t, []string{"/api/config/v1/autoTags/12"}, handler.putPaths)
+	assert.EqualValues(t,
+		[]ConfigResult{
+			{Name: "keptn_service", Success: true, Message: "Tagging rule keptn_service already exists"},
+			{Name: "keptn_stage", Success: true, Message: "Tagging rule keptn_stage updated"},
+			{Name: "keptn_project", Success: true, Message: "Tagging rule keptn_project already exists"},
+			{Name: "keptn_deployment", Success: true},
+		},
+		results)
+}
@@ -2,21 +2,28 @@ package monitoring
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
-	"github.com/keptn-contrib/dynatrace-service/internal/env"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 )
 
 // ConfiguredEntities contains information about the entities configures in Dynatrace
 type ConfiguredEntities struct {
-	TaggingRules         []ConfigResult
-	ProblemNotifications *ConfigResult
-	ManagementZones      []ConfigResult
-	Dashboard            *ConfigResult
-	MetricEvents         []ConfigResult
+	TaggingRules             []ConfigResult
+	ProblemNotifications     *ConfigResult
+	ProblemNotificationCheck *ConfigResult
+	ManagementZones          []ConfigResult
+	Dashboard                *ConfigResult
+	MetricEvents             []ConfigResult
+	KQGDashboards            []ConfigResult
+	SLOs                     []ConfigResult
+	TestStepMetrics          []ConfigResult
 }
 
 type ConfigResult struct {
@@ -26,55 +33,233 @@ type ConfigResult struct {
 }
 
 type Configuration struct {
-	dtClient      dynatrace.ClientInterface
-	kClient       keptn.ClientInterface
-	sloReader     keptn.SLOReaderInterface
-	serviceClient keptn.ServiceClientInterface
+	dtClient       dynatrace.ClientInterface
+	kClient        keptn.ClientInterface
+	sloReader      keptn.SLOReaderInterface
+	serviceClient  keptn.ServiceClientInterface
+	configProvider config.DynatraceConfigProvider
 }
 
-func NewConfiguration(dynatraceClient dynatrace.ClientInterface, keptnClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, serviceClient keptn.ServiceClientInterface) *Configuration {
+func NewConfiguration(dynatraceClient dynatrace.ClientInterface, keptnClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, serviceClient keptn.ServiceClientInterface, configProvider config.DynatraceConfigProvider) *Configuration {
 	return &Configuration{
-		dtClient:      dynatraceClient,
-		kClient:       keptnClient,
-		sloReader:     sloReader,
-		serviceClient: serviceClient,
+		dtClient:       dynatraceClient,
+		kClient:        keptnClient,
+		sloReader:      sloReader,
+		serviceClient:  serviceClient,
+		configProvider: configProvider,
 	}
 }
 
 // ConfigureMonitoring configures Dynatrace for a Keptn project
 func (mc *Configuration) ConfigureMonitoring(ctx context.Context, project string, shipyard keptnv2.Shipyard) (*ConfiguredEntities, error) {
+	settings, err := mc.getMonitoringSettings(project, shipyard)
+	if err != nil {
+		return nil, err
+	}
 
 	configuredEntities := &ConfiguredEntities{}
 
-	if env.IsTaggingRulesGenerationEnabled() {
+	if settings.project.taggingRules {
 		configuredEntities.TaggingRules = NewAutoTagCreation(mc.dtClient).Create(ctx)
 	}
 
-	if env.IsProblemNotificationsGenerationEnabled() {
-		configuredEntities.ProblemNotifications = NewProblemNotificationCreation(mc.dtClient).Create(ctx, project)
+	if settings.project.testStepMetrics {
+		configuredEntities.TestStepMetrics = NewTestStepMetricsCreation(mc.dtClient).Create(ctx)
 	}
 
-	if env.IsManagementZonesGenerationEnabled() {
-		configuredEntities.ManagementZones = NewManagementZoneCreation(mc.dtClient).Create(ctx, project, shipyard)
+	if settings.project.problemNotifications {
+		configuredEntities.ProblemNotifications = NewProblemNotificationCreation(mc.dtClient, settings.naming, settings.problemNotification).Create(ctx, project)
 	}
 
-	if env.IsDashboardsGenerationEnabled() {
-		configuredEntities.Dashboard = NewDashboardCreation(mc.dtClient).Create(ctx, project, shipyard)
+	if settings.isManagementZonesGenerationEnabled() {
+		configuredEntities.ManagementZones = NewManagementZoneCreation(mc.dtClient, settings.naming).Create(ctx, project, settings.getManagementZoneShipyard(shipyard))
 	}
 
-	if env.IsMetricEventsGenerationEnabled() {
+	if settings.project.dashboards {
+		configuredEntities.Dashboard = NewDashboardCreation(mc.dtClient, settings.naming).Create(ctx, project, shipyard)
+	}
+
+	if settings.isMetricEventsGenerationEnabled() {
 		var metricEvents []ConfigResult
 		for _, stage := range shipyard.Spec.Stages {
-			metricEvents = append(metricEvents, mc.createMetricEventsForStage(ctx, project, stage)...)
+			metricEvents = append(metricEvents, mc.createMetricEventsForStage(ctx, settings, project, stage)...)
 		}
 		configuredEntities.MetricEvents = metricEvents
 	}
 
+	if settings.isKQGDashboardsGenerationEnabled() {
+		var kqgDashboards []ConfigResult
+		for _, stage := range shipyard.Spec.Stages {
+			kqgDashboards = append(kqgDashboards, mc.createKQGDashboardsForStage(ctx, settings, project, stage)...)
+		}
+		configuredEntities.KQGDashboards = kqgDashboards
+	}
+
+	if settings.isSLOsGenerationEnabled() {
+		var slos []ConfigResult
+		for _, stage := range shipyard.Spec.Stages {
+			slos = append(slos, mc.createSLOsForStage(ctx, settings, project, stage)...)
+		}
+		configuredEntities.SLOs = slos
+	}
+
 	return configuredEntities, nil
 }
 
-func (mc *Configuration) createMetricEventsForStage(ctx context.Context, project string, stage keptnv2.Stage) []ConfigResult {
-	if isStageMissingRemediationSequence(stage) {
+// CheckProblemNotification asks Dynatrace to send a test problem notification for the Keptn project and checks that it arrives in Keptn.
+func (mc *Configuration) CheckProblemNotification(ctx context.Context, project string, shipyard keptnv2.Shipyard, eventClient keptn.EventClientInterface) (*ConfigResult, error) {
+	settings, err := mc.getMonitoringSettings(project, shipyard)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProblemNotificationCreation(mc.dtClient, settings.naming, settings.problemNotification).Check(ctx, project, eventClient), nil
+}
+
+// PlanMonitoring returns the changes ConfigureMonitoring would make in Dynatrace for a Keptn project without writing them
+func (mc *Configuration) PlanMonitoring(ctx context.Context, project string, shipyard keptnv2.Shipyard) (*ConfigurationPlan, error) {
+	settings, err := mc.getMonitoringSettings(project, shipyard)
+	if err != nil {
+		return nil, err
+	}
+
+	return mc.planMonitoring(ctx, settings, project, shipyard), nil
+}
+
+func (mc *Configuration) planMonitoring(ctx context.Context, settings *monitoringSettings, project string, shipyard keptnv2.Shipyard) *ConfigurationPlan {
+	plan := &ConfigurationPlan{}
+
+	if settings.project.taggingRules {
+		plan.TaggingRules = NewAutoTagCreation(mc.dtClient).Plan(ctx)
+	}
+
+	if settings.project.testStepMetrics {
+		plan.TestStepMetrics = NewTestStepMetricsCreation(mc.dtClient).Plan(ctx)
+	}
+
+	if settings.project.problemNotifications {
+		plan.ProblemNotifications = NewProblemNotificationCreation(mc.dtClient, settings.naming, settings.problemNotification).Plan(ctx, project)
+	}
+
+	if settings.isManagementZonesGenerationEnabled() {
+		plan.ManagementZones = NewManagementZoneCreation(mc.dtClient, settings.naming).Plan(ctx, project, settings.getManagementZoneShipyard(shipyard))
+	}
+
+	if settings.project.dashboards {
+		plan.Dashboard = NewDashboardCreation(mc.dtClient, settings.naming).Plan(ctx, project, shipyard)
+	}
+
+	if settings.isMetricEventsGenerationEnabled() {
+		var metricEvents []PlannedChange
+		for _, stage := range shipyard.Spec.Stages {
+			metricEvents = append(metricEvents, mc.planMetricEventsForStage(ctx, settings, project, stage)...)
+		}
+		plan.MetricEvents = metricEvents
+	}
+
+	if settings.isKQGDashboardsGenerationEnabled() {
+		var kqgDashboards []PlannedChange
+		for _, stage := range shipyard.Spec.Stages {
+			kqgDashboards = append(kqgDashboards, mc.planKQGDashboardsForStage(ctx, settings, project, stage)...)
+		}
+		plan.KQGDashboards = kqgDashboards
+	}
+
+	if settings.isSLOsGenerationEnabled() {
+		var slos []PlannedChange
+		for _, stage := range shipyard.Spec.Stages {
+			slos = append(slos, mc.planSLOsForStage(ctx, settings, project, stage)...)
+		}
+		plan.SLOs = slos
+	}
+
+	return plan
+}
+
+// getMonitoringSettings returns the naming and generation settings of the project and its stages.
+// Naming templates and the problem notification setup are only taken from the dynatrace.conf.yaml of the project, generation settings also from those of the stages.
+func (mc *Configuration) getMonitoringSettings(project string, shipyard keptnv2.Shipyard) (*monitoringSettings, error) {
+	var namingConfig *config.NamingConfig
+	var monitoringConfig *config.MonitoringConfig
+	var problemNotificationConfig *config.ProblemNotificationConfig
+	projectConfig, err := mc.getDynatraceConfig(project, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if projectConfig != nil {
+		namingConfig = projectConfig.Naming
+		monitoringConfig = projectConfig.Monitoring
+		problemNotificationConfig = projectConfig.ProblemNotification
+	}
+
+	naming, err := NewNaming(namingConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &monitoringSettings{
+		naming:              naming,
+		problemNotification: problemNotificationConfig,
+		project:             newGenerationSettings(monitoringConfig, newDefaultGenerationSettings()),
+		stages:              make(map[string]generationSettings, len(shipyard.Spec.Stages)),
+	}
+
+	for _, stage := range shipyard.Spec.Stages {
+		var stageMonitoringConfig *config.MonitoringConfig
+		stageConfig, err := mc.getDynatraceConfig(project, stage.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if stageConfig != nil {
+			stageMonitoringConfig = stageConfig.Monitoring
+		}
+		settings.stages[stage.Name] = newGenerationSettings(stageMonitoringConfig, settings.project)
+	}
+
+	return settings, nil
+}
+
+// getDynatraceConfig returns the dynatrace.conf.yaml of the stage or, if stage is empty, of the project, or nil if there is none.
+// Any other error, e.g. if the file cannot be retrieved or parsed, is returned, as configuring Dynatrace using the default settings instead could undo the configured ones.
+func (mc *Configuration) getDynatraceConfig(project string, stage string) (*config.DynatraceConfig, error) {
+	dynatraceConfig, err := mc.configProvider.GetDynatraceConfig(newServiceEventContentAdapter(project, stage, ""))
+	var resourceNotFoundError *keptn.ResourceNotFoundError
+	if errors.As(err, &resourceNotFoundError) {
+		log.WithFields(log.Fields{"project": project, "stage": stage}).Debug("No Dynatrace config found, using default monitoring settings")
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not load Dynatrace config of project %s and stage %s: %w", project, stage, err)
+	}
+	return dynatraceConfig, nil
+}
+
+func (mc *Configuration) planMetricEventsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []PlannedChange {
+	stageSettings := settings.getStageSettings(stage.Name)
+	if !stageSettings.metricEvents || isStageMissingRemediationSequence(stage) {
+		return nil
+	}
+
+	serviceNames, err := mc.serviceClient.GetServiceNames(project, stage.Name)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(stage.Name, err)}
+	}
+
+	var metricEvents []PlannedChange
+	for _, serviceName := range serviceNames {
+		metricEvents = append(
+			metricEvents,
+			NewMetricEventCreation(mc.dtClient, mc.kClient, mc.sloReader, mc.configProvider, settings.naming, stageSettings.metricEventsBaselineModel).Plan(ctx, project, stage.Name, serviceName)...)
+	}
+	return metricEvents
+}
+
+func (mc *Configuration) createMetricEventsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []ConfigResult {
+	stageSettings := settings.getStageSettings(stage.Name)
+	if !stageSettings.metricEvents || isStageMissingRemediationSequence(stage) {
 		return nil
 	}
 
@@ -90,11 +275,94 @@ func (mc *Configuration) createMetricEventsForStage(ctx context.Context, project
 	for _, serviceName := range serviceNames {
 		metricEvents = append(
 			metricEvents,
-			NewMetricEventCreation(mc.dtClient, mc.kClient, mc.sloReader).Create(ctx, project, stage.Name, serviceName)...)
+			NewMetricEventCreation(mc.dtClient, mc.kClient, mc.sloReader, mc.configProvider, settings.naming, stageSettings.metricEventsBaselineModel).Create(ctx, project, stage.Name, serviceName)...)
 	}
 	return metricEvents
 }
 
+func (mc *Configuration) planKQGDashboardsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []PlannedChange {
+	if !settings.getStageSettings(stage.Name).kqgDashboards {
+		return nil
+	}
+
+	serviceNames, err := mc.serviceClient.GetServiceNames(project, stage.Name)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(stage.Name, err)}
+	}
+
+	var kqgDashboards []PlannedChange
+	for _, serviceName := range serviceNames {
+		kqgDashboards = append(
+			kqgDashboards,
+			NewKQGDashboardCreation(mc.dtClient, mc.kClient, mc.sloReader).Plan(ctx, project, stage.Name, serviceName)...)
+	}
+	return kqgDashboards
+}
+
+func (mc *Configuration) createKQGDashboardsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []ConfigResult {
+	if !settings.getStageSettings(stage.Name).kqgDashboards {
+		return nil
+	}
+
+	serviceNames, err := mc.serviceClient.GetServiceNames(project, stage.Name)
+	if err != nil {
+		return []ConfigResult{{
+			Success: false,
+			Message: err.Error(),
+		}}
+	}
+
+	var kqgDashboards []ConfigResult
+	for _, serviceName := range serviceNames {
+		kqgDashboard := NewKQGDashboardCreation(mc.dtClient, mc.kClient, mc.sloReader).Create(ctx, project, stage.Name, serviceName)
+		if kqgDashboard != nil {
+			kqgDashboards = append(kqgDashboards, *kqgDashboard)
+		}
+	}
+	return kqgDashboards
+}
+
+func (mc *Configuration) planSLOsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []PlannedChange {
+	if !settings.getStageSettings(stage.Name).slos {
+		return nil
+	}
+
+	serviceNames, err := mc.serviceClient.GetServiceNames(project, stage.Name)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(stage.Name, err)}
+	}
+
+	var slos []PlannedChange
+	for _, serviceName := range serviceNames {
+		slos = append(
+			slos,
+			NewSLOCreation(mc.dtClient, mc.kClient, mc.sloReader, settings.naming).Plan(ctx, project, stage.Name, serviceName)...)
+	}
+	return slos
+}
+
+func (mc *Configuration) createSLOsForStage(ctx context.Context, settings *monitoringSettings, project string, stage keptnv2.Stage) []ConfigResult {
+	if !settings.getStageSettings(stage.Name).slos {
+		return nil
+	}
+
+	serviceNames, err := mc.serviceClient.GetServiceNames(project, stage.Name)
+	if err != nil {
+		return []ConfigResult{{
+			Success: false,
+			Message: err.Error(),
+		}}
+	}
+
+	var slos []ConfigResult
+	for _, serviceName := range serviceNames {
+		slos = append(
+			slos,
+			NewSLOCreation(mc.dtClient, mc.kClient, mc.sloReader, settings.naming).Create(ctx, project, stage.Name, serviceName)...)
+	}
+	return slos
+}
+
 func isStageMissingRemediationSequence(stage keptnv2.Stage) bool {
 	for _, taskSequence := range stage.Sequences {
 		if taskSequence.Name == "remediation" {
@@ -0,0 +1,323 @@
+package monitoring
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+// CleanupStatus is the outcome of cleaning up a single object in Dynatrace.
+type CleanupStatus string
+
+const (
+	// CleanupStatusDeleted indicates that the object has been deleted.
+	CleanupStatusDeleted CleanupStatus = "deleted"
+
+	// CleanupStatusSkipped indicates that the object matched by name but was not deleted as it was not created by the dynatrace-service.
+	CleanupStatusSkipped CleanupStatus = "skipped"
+
+	// CleanupStatusFailed indicates that the object could not be retrieved or deleted.
+	CleanupStatusFailed CleanupStatus = "failed"
+)
+
+// CleanupResult is the result of cleaning up a single object in Dynatrace.
+type CleanupResult struct {
+	EntityType string
+	Name       string
+	Status     CleanupStatus
+	Message    string
+}
+
+func newDeletedCleanupResult(entityType string, name string) CleanupResult {
+	return CleanupResult{
+		EntityType: entityType,
+		Name:       name,
+		Status:     CleanupStatusDeleted,
+	}
+}
+
+func newSkippedCleanupResult(entityType string, name string, reason string) CleanupResult {
+	return CleanupResult{
+		EntityType: entityType,
+		Name:       name,
+		Status:     CleanupStatusSkipped,
+		Message:    reason,
+	}
+}
+
+func newFailedCleanupResult(entityType string, name string, err error) CleanupResult {
+	return CleanupResult{
+		EntityType: entityType,
+		Name:       name,
+		Status:     CleanupStatusFailed,
+		Message:    err.Error(),
+	}
+}
+
+// ConfigurationCleanup deletes the configuration created in Dynatrace for Keptn projects and services.
+// Objects are found by the names used when they were created and are only deleted if their content shows that they were created by the dynatrace-service.
+// As the dynatrace.conf.yaml of a deleted project is no longer available, names given by the naming templates of the Helm chart values or by the default templates are matched.
+type ConfigurationCleanup struct {
+	dtClient dynatrace.ClientInterface
+	naming   *Naming
+}
+
+// NewConfigurationCleanup creates a new ConfigurationCleanup.
+func NewConfigurationCleanup(dtClient dynatrace.ClientInterface) *ConfigurationCleanup {
+	naming, err := NewNaming(nil)
+	if err != nil {
+		log.WithError(err).Error("Invalid naming template, only objects with default names will be cleaned up")
+		naming = defaultNaming
+	}
+
+	return &ConfigurationCleanup{
+		dtClient: dtClient,
+		naming:   naming,
+	}
+}
+
+// CleanUpProject deletes the metric events, SLOs, dashboard, problem notification and management zones created for the project.
+// Tagging rules and the alerting profile are shared by all projects and are therefore never deleted.
+func (c *ConfigurationCleanup) CleanUpProject(ctx context.Context, project string) []CleanupResult {
+	var results []CleanupResult
+	results = append(results, c.cleanUpMetricEvents(ctx, project, "")...)
+	results = append(results, c.cleanUpSLOs(ctx, project, "")...)
+	results = append(results, c.cleanUpDashboards(ctx, project)...)
+	results = append(results, c.cleanUpProblemNotifications(ctx, project)...)
+	results = append(results, c.cleanUpManagementZones(ctx, project)...)
+	return results
+}
+
+// CleanUpService deletes the metric events and SLOs created for the service in all stages of the project.
+func (c *ConfigurationCleanup) CleanUpService(ctx context.Context, project string, service string) []CleanupResult {
+	results := c.cleanUpMetricEvents(ctx, project, service)
+	return append(results, c.cleanUpSLOs(ctx, project, service)...)
+}
+
+// cleanUpMetricEvents deletes the metric events of the service or, if service is empty, of all services of the project.
+// Metric events are deleted before management zones, as they reference the management zones of the stages.
+func (c *ConfigurationCleanup) cleanUpMetricEvents(ctx context.Context, project string, service string) []CleanupResult {
+	metricEventsClient := dynatrace.NewMetricEventsClient(c.dtClient)
+	metricEvents, err := metricEventsClient.GetMetricEventsByName(ctx, func(name string) bool {
+		metricEventProject, _, metricEventService, ok := c.parseMetricEventName(name)
+		return ok && metricEventProject == project && (service == "" || metricEventService == service)
+	})
+	if err != nil {
+		return []CleanupResult{newFailedCleanupResult(metricEventEntityType, "", err)}
+	}
+
+	results := make([]CleanupResult, 0, len(metricEvents))
+	for _, metricEvent := range metricEvents {
+		_, _, metricEventService, _ := c.parseMetricEventName(metricEvent.Name)
+		if !isMetricEventOwnedByService(metricEvent, metricEventService) {
+			results = append(results, newSkippedCleanupResult(metricEventEntityType, metricEvent.Name, "metric event does not have the description and alerting scope of a Keptn metric event"))
+			continue
+		}
+
+		err = metricEventsClient.DeleteByID(ctx, metricEvent.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(metricEventEntityType, metricEvent.Name, err))
+			continue
+		}
+		results = append(results, newDeletedCleanupResult(metricEventEntityType, metricEvent.Name))
+	}
+	return results
+}
+
+// cleanUpSLOs deletes the Dynatrace SLOs of the service or, if service is empty, of all services of the project.
+func (c *ConfigurationCleanup) cleanUpSLOs(ctx context.Context, project string, service string) []CleanupResult {
+	sloClient := dynatrace.NewSLOClient(c.dtClient)
+	slos, err := sloClient.GetAll(ctx)
+	if err != nil {
+		return []CleanupResult{newFailedCleanupResult(sloEntityType, "", err)}
+	}
+
+	var results []CleanupResult
+	for _, slo := range slos {
+		sloProject, _, sloService, ok := parseSLOName(c.naming, slo.Name)
+		if !ok || sloProject != project || (service != "" && sloService != service) {
+			continue
+		}
+
+		if slo.Description != sloFileMarker {
+			results = append(results, newSkippedCleanupResult(sloEntityType, slo.Name, "SLO does not have the description of a Keptn SLO"))
+			continue
+		}
+
+		err = sloClient.DeleteByID(ctx, slo.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(sloEntityType, slo.Name, err))
+			continue
+		}
+		results = append(results, newDeletedCleanupResult(sloEntityType, slo.Name))
+	}
+	return results
+}
+
+func (c *ConfigurationCleanup) cleanUpDashboards(ctx context.Context, project string) []CleanupResult {
+	dashboardsClient := dynatrace.NewDashboardsClient(c.dtClient)
+	dashboardName := c.naming.getDashboardName(project)
+	dashboards, err := dashboardsClient.GetAll(ctx)
+	if err != nil {
+		return []CleanupResult{newFailedCleanupResult(dashboardEntityType, dashboardName, err)}
+	}
+
+	names := getNamesIncludingDefault(dashboardName, defaultNaming.getDashboardName(project))
+	var results []CleanupResult
+	for _, dashboardStub := range dashboards.Dashboards {
+		if !containsString(names, dashboardStub.Name) {
+			continue
+		}
+
+		dashboard, err := dashboardsClient.GetByID(ctx, dashboardStub.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(dashboardEntityType, dashboardStub.Name, err))
+			continue
+		}
+
+		if !isDashboardOwnedByProject(dashboard, project) {
+			results = append(results, newSkippedCleanupResult(dashboardEntityType, dashboardStub.Name, "dashboard does not contain tiles filtered by the Keptn project tag"))
+			continue
+		}
+
+		err = dashboardsClient.Delete(ctx, dashboardStub.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(dashboardEntityType, dashboardStub.Name, err))
+			continue
+		}
+		results = append(results, newDeletedCleanupResult(dashboardEntityType, dashboardStub.Name))
+	}
+	return results
+}
+
+// cleanUpProblemNotifications deletes the Keptn problem notification if it sends problems to the project.
+// As only a single Keptn problem notification exists, it is left in place if it has since been configured for another project.
+func (c *ConfigurationCleanup) cleanUpProblemNotifications(ctx context.Context, project string) []CleanupResult {
+	notificationName := c.naming.getProblemNotificationName(project)
+	notificationsClient := dynatrace.NewNotificationsClient(c.dtClient)
+	ids, err := notificationsClient.GetKeptnProblemNotificationIDsForProject(ctx, project, getNamesIncludingDefault(notificationName, defaultNaming.getProblemNotificationName(project))...)
+	if err != nil {
+		return []CleanupResult{newFailedCleanupResult(problemNotificationEntityType, notificationName, err)}
+	}
+
+	results := make([]CleanupResult, 0, len(ids))
+	for _, id := range ids {
+		err = notificationsClient.DeleteByID(ctx, id)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(problemNotificationEntityType, notificationName, err))
+			continue
+		}
+		results = append(results, newDeletedCleanupResult(problemNotificationEntityType, notificationName))
+	}
+	return results
+}
+
+// cleanUpManagementZones deletes the management zone of the project and those of its stages.
+// Stages are not taken from the shipyard, as it is no longer available once the project has been deleted.
+func (c *ConfigurationCleanup) cleanUpManagementZones(ctx context.Context, project string) []CleanupResult {
+	managementZonesClient := dynatrace.NewManagementZonesClient(c.dtClient)
+	managementZones, err := managementZonesClient.GetAll(ctx)
+	if err != nil {
+		return []CleanupResult{newFailedCleanupResult(managementZoneEntityType, c.naming.getProjectManagementZoneName(project), err)}
+	}
+
+	var results []CleanupResult
+	for _, name := range managementZones.GetNames() {
+		if !c.naming.isManagementZoneNameOfProject(name, project) && !defaultNaming.isManagementZoneNameOfProject(name, project) {
+			continue
+		}
+
+		value, _ := managementZones.GetByName(name)
+		managementZone, err := managementZonesClient.GetByID(ctx, value.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(managementZoneEntityType, name, err))
+			continue
+		}
+
+		if !isManagementZoneOwnedByProject(managementZone, project) {
+			results = append(results, newSkippedCleanupResult(managementZoneEntityType, name, "management zone does not have a rule for the Keptn project tag"))
+			continue
+		}
+
+		err = managementZonesClient.Delete(ctx, value.ID)
+		if err != nil {
+			results = append(results, newFailedCleanupResult(managementZoneEntityType, name, err))
+			continue
+		}
+		results = append(results, newDeletedCleanupResult(managementZoneEntityType, name))
+	}
+	return results
+}
+
+// parseMetricEventName returns the project, stage and service of a metric event named using the naming templates or the default naming.
+func (c *ConfigurationCleanup) parseMetricEventName(name string) (string, string, string, bool) {
+	project, stage, service, ok := c.naming.parseMetricEventName(name)
+	if ok {
+		return project, stage, service, true
+	}
+	return defaultNaming.parseMetricEventName(name)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isMetricEventOwnedByService returns true if the metric event has the description and the service tag filter of a metric event created for the service.
+func isMetricEventOwnedByService(metricEvent *dynatrace.MetricEvent, service string) bool {
+	if metricEvent.Description != keptnMetricEventDescription && metricEvent.Description != keptnBaselineMetricEventDescription {
+		return false
+	}
+
+	for _, scope := range metricEvent.AlertingScope {
+		if scope.FilterType == "TAG" && scope.TagFilter != nil && scope.TagFilter.Key == keptnService && scope.TagFilter.Value == service {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagementZoneOwnedByProject returns true if the management zone has a rule matching services tagged with the Keptn project.
+func isManagementZoneOwnedByProject(managementZone *dynatrace.ManagementZone, project string) bool {
+	for _, rule := range managementZone.Rules {
+		for _, condition := range rule.Conditions {
+			comparison := condition.ComparisonInfo
+			if condition.Key.Attribute == "SERVICE_TAGS" && comparison.Operator == "EQUALS" && !comparison.Negate &&
+				comparison.Value.Key == dynatrace.KeptnProject && comparison.Value.Value == project {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDashboardOwnedByProject returns true if the dashboard contains a tile filtered by services tagged with the Keptn project.
+func isDashboardOwnedByProject(dashboard *dynatrace.Dashboard, project string) bool {
+	for _, tile := range dashboard.Tiles {
+		if tile.FilterConfig == nil {
+			continue
+		}
+
+		for _, tag := range tile.FilterConfig.FiltersPerEntityType[dynatrace.ServiceEntityType]["AUTO_TAGS"] {
+			if tag == getKeptnProjectTag(project) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSLOName returns the project, stage and service of a Dynatrace SLO named using the naming templates or the default naming.
+func parseSLOName(naming *Naming, name string) (string, string, string, bool) {
+	project, stage, service, ok := naming.parseSLOName(name)
+	if ok {
+		return project, stage, service, true
+	}
+	return defaultNaming.parseSLOName(name)
+}
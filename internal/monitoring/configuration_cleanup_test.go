@@ -0,0 +1,97 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseMetricEventName(t *testing.T) {
+	tests := []struct {
+		name        string
+		wantProject string
+		wantStage   string
+		wantService string
+		wantOK      bool
+	}{
+		{
+			name:        defaultNaming.getMetricEventName("response_time_p95", "sockshop", "production", "carts"),
+			wantProject: "sockshop",
+			wantStage:   "production",
+			wantService: "carts",
+			wantOK:      true,
+		},
+		{
+			name:        "response (time) (Keptn.sockshop.production.carts)",
+			wantProject: "sockshop",
+			wantStage:   "production",
+			wantService: "carts",
+			wantOK:      true,
+		},
+		{
+			name: "response_time_p95 (Keptn.sockshop.production)",
+		},
+		{
+			name: "response_time_p95 (Keptn.sockshop.production.carts",
+		},
+		{
+			name: "High response time",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project, stage, service, ok := defaultNaming.parseMetricEventName(tt.name)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantProject, project)
+			assert.Equal(t, tt.wantStage, stage)
+			assert.Equal(t, tt.wantService, service)
+		})
+	}
+}
+
+func Test_isMetricEventOwnedByService(t *testing.T) {
+	metricEvent, err := createKeptnMetricEventDTO("sockshop", "production", "carts", "response_time_p95", defaultNaming.getMetricEventName("response_time_p95", "sockshop", "production", "carts"), "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(95)", "<=600", 600, "1234")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.True(t, isMetricEventOwnedByService(metricEvent, "carts"))
+	assert.False(t, isMetricEventOwnedByService(metricEvent, "orders"))
+
+	metricEvent.Description = "Custom description"
+	assert.False(t, isMetricEventOwnedByService(metricEvent, "carts"))
+}
+
+func Test_isManagementZoneNameOfProject(t *testing.T) {
+	assert.True(t, defaultNaming.isManagementZoneNameOfProject("Keptn: sockshop", "sockshop"))
+	assert.True(t, defaultNaming.isManagementZoneNameOfProject("Keptn: sockshop production", "sockshop"))
+	assert.False(t, defaultNaming.isManagementZoneNameOfProject("Keptn: sockshop-v2", "sockshop"))
+	assert.False(t, defaultNaming.isManagementZoneNameOfProject("Keptn: sockshop-v2 production", "sockshop"))
+	assert.False(t, defaultNaming.isManagementZoneNameOfProject("sockshop", "sockshop"))
+}
+
+func Test_isManagementZoneOwnedByProject(t *testing.T) {
+	assert.True(t, isManagementZoneOwnedByProject(createManagementZoneForProject(defaultNaming.getProjectManagementZoneName("sockshop"), "sockshop"), "sockshop"))
+	assert.True(t, isManagementZoneOwnedByProject(createManagementZoneForStage(defaultNaming.getStageManagementZoneName("sockshop", "production"), "sockshop", "production"), "sockshop"))
+	assert.False(t, isManagementZoneOwnedByProject(createManagementZoneForStage(defaultNaming.getStageManagementZoneName("sockshop", "production"), "sockshop", "production"), "orders"))
+
+	negatedManagementZone := createManagementZoneForProject(defaultNaming.getProjectManagementZoneName("sockshop"), "sockshop")
+	negatedManagementZone.Rules[0].Conditions[0].ComparisonInfo.Negate = true
+	assert.False(t, isManagementZoneOwnedByProject(negatedManagementZone, "sockshop"))
+
+	assert.False(t, isManagementZoneOwnedByProject(&dynatrace.ManagementZone{Name: "Keptn: sockshop"}, "sockshop"))
+}
+
+func Test_isDashboardOwnedByProject(t *testing.T) {
+	shipyard := keptnv2.Shipyard{
+		Spec: keptnv2.ShipyardSpec{
+			Stages: []keptnv2.Stage{{Name: "production"}},
+		},
+	}
+
+	assert.True(t, isDashboardOwnedByProject(createDynatraceDashboard(defaultNaming.getDashboardName("sockshop"), "sockshop", shipyard), "sockshop"))
+	assert.False(t, isDashboardOwnedByProject(createDynatraceDashboard(defaultNaming.getDashboardName("sockshop"), "sockshop", shipyard), "orders"))
+	assert.False(t, isDashboardOwnedByProject(&dynatrace.Dashboard{Tiles: []dynatrace.Tile{createHostCPULoadTile()}}, "sockshop"))
+}
@@ -0,0 +1,204 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+)
+
+// PlannedChangeAction is the action that would be carried out on an object in Dynatrace.
+type PlannedChangeAction string
+
+const (
+	// PlannedChangeActionCreate indicates that the object does not exist yet and would be created.
+	PlannedChangeActionCreate PlannedChangeAction = "create"
+
+	// PlannedChangeActionUpdate indicates that the existing object would be updated in place.
+	PlannedChangeActionUpdate PlannedChangeAction = "update"
+
+	// PlannedChangeActionReplace indicates that the existing object would be deleted and created again.
+	PlannedChangeActionReplace PlannedChangeAction = "replace"
+
+	// PlannedChangeActionDelete indicates that the existing object would be deleted.
+	PlannedChangeActionDelete PlannedChangeAction = "delete"
+
+	// PlannedChangeActionNone indicates that the existing object would be left unchanged.
+	PlannedChangeActionNone PlannedChangeAction = "unchanged"
+)
+
+// FieldChange is a change of a single field of an object, identified by its JSON path, e.g. tiles[3].bounds.top.
+type FieldChange struct {
+	Path    string
+	Current interface{}
+	Desired interface{}
+}
+
+// PlannedChange is a change that would be made to a single object in Dynatrace.
+// If the change could not be determined, Error is set instead of Action.
+type PlannedChange struct {
+	Name         string
+	Action       PlannedChangeAction
+	FieldChanges []FieldChange
+	Error        string
+}
+
+func newFailedPlannedChange(name string, err error) PlannedChange {
+	return PlannedChange{
+		Name:  name,
+		Error: err.Error(),
+	}
+}
+
+// ConfigurationPlan contains the changes configuring the monitoring would make to the entities in Dynatrace
+type ConfigurationPlan struct {
+	TaggingRules         []PlannedChange
+	ProblemNotifications []PlannedChange
+	ManagementZones      []PlannedChange
+	Dashboard            []PlannedChange
+	MetricEvents         []PlannedChange
+	KQGDashboards        []PlannedChange
+	SLOs                 []PlannedChange
+	TestStepMetrics      []PlannedChange
+}
+
+// newUpdatePlannedChange compares the current and desired states of an object and returns an update or, if the states match, an unchanged planned change.
+func newUpdatePlannedChange(name string, current interface{}, desired interface{}) PlannedChange {
+	fieldChanges, err := diffJSON(current, desired)
+	if err != nil {
+		return newFailedPlannedChange(name, err)
+	}
+
+	if len(fieldChanges) == 0 {
+		return PlannedChange{
+			Name:   name,
+			Action: PlannedChangeActionNone,
+		}
+	}
+
+	return PlannedChange{
+		Name:         name,
+		Action:       PlannedChangeActionUpdate,
+		FieldChanges: fieldChanges,
+	}
+}
+
+// diffJSON returns the changes required to turn the JSON representation of current into that of desired.
+// Only fields present in desired are compared, so fields managed by Dynatrace such as IDs or metadata are not reported.
+// Elements of arrays are compared by index and elements missing in desired are reported as removed.
+func diffJSON(current interface{}, desired interface{}) ([]FieldChange, error) {
+	currentValue, err := toJSONValue(current)
+	if err != nil {
+		return nil, err
+	}
+
+	desiredValue, err := toJSONValue(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffJSONValues("", currentValue, desiredValue), nil
+}
+
+func toJSONValue(object interface{}) (interface{}, error) {
+	payload, err := json.Marshal(object)
+	if err != nil {
+		return nil, common.NewMarshalJSONError("object", err)
+	}
+
+	var value interface{}
+	err = json.Unmarshal(payload, &value)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("object", err)
+	}
+
+	return value, nil
+}
+
+func diffJSONValues(path string, current interface{}, desired interface{}) []FieldChange {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		currentValue, ok := current.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(desiredValue))
+		for key := range desiredValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var changes []FieldChange
+		for _, key := range keys {
+			changes = append(changes, diffJSONValues(joinJSONPath(path, key), currentValue[key], desiredValue[key])...)
+		}
+		return changes
+
+	case []interface{}:
+		currentValue, ok := current.([]interface{})
+		if !ok {
+			break
+		}
+
+		length := len(desiredValue)
+		if len(currentValue) > length {
+			length = len(currentValue)
+		}
+
+		var changes []FieldChange
+		for i := 0; i < length; i++ {
+			elementPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(desiredValue):
+				changes = append(changes, FieldChange{Path: elementPath, Current: currentValue[i]})
+			case i >= len(currentValue):
+				changes = append(changes, FieldChange{Path: elementPath, Desired: desiredValue[i]})
+			default:
+				changes = append(changes, diffJSONValues(elementPath, currentValue[i], desiredValue[i])...)
+			}
+		}
+		return changes
+	}
+
+	if isEqualJSONValue(current, desired) {
+		return nil
+	}
+
+	return []FieldChange{{Path: path, Current: current, Desired: desired}}
+}
+
+func isEqualJSONValue(current interface{}, desired interface{}) bool {
+	currentPayload, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+
+	desiredPayload, err := json.Marshal(desired)
+	if err != nil {
+		return false
+	}
+
+	return string(currentPayload) == string(desiredPayload)
+}
+
+func joinJSONPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// formatJSONValue formats a value of a field change for display, using <none> for absent values.
+func formatJSONValue(value interface{}) string {
+	if value == nil {
+		return "<none>"
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(payload)
+}
@@ -0,0 +1,184 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_diffJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		current interface{}
+		desired interface{}
+		want    []FieldChange
+	}{
+		{
+			name:    "equal objects",
+			current: map[string]interface{}{"name": "a", "threshold": 500},
+			desired: map[string]interface{}{"name": "a", "threshold": 500},
+			want:    nil,
+		},
+		{
+			name:    "changed field",
+			current: map[string]interface{}{"name": "a", "threshold": 500},
+			desired: map[string]interface{}{"name": "a", "threshold": 600},
+			want:    []FieldChange{{Path: "threshold", Current: float64(500), Desired: float64(600)}},
+		},
+		{
+			name:    "fields only present in current are ignored",
+			current: map[string]interface{}{"id": "1234", "name": "a"},
+			desired: map[string]interface{}{"name": "a"},
+			want:    nil,
+		},
+		{
+			name:    "added field",
+			current: map[string]interface{}{"name": "a"},
+			desired: map[string]interface{}{"name": "a", "unit": "MILLI_SECOND"},
+			want:    []FieldChange{{Path: "unit", Current: nil, Desired: "MILLI_SECOND"}},
+		},
+		{
+			name:    "nested field",
+			current: map[string]interface{}{"tiles": []interface{}{map[string]interface{}{"bounds": map[string]interface{}{"top": 0, "left": 0}}}},
+			desired: map[string]interface{}{"tiles": []interface{}{map[string]interface{}{"bounds": map[string]interface{}{"top": 38, "left": 0}}}},
+			want:    []FieldChange{{Path: "tiles[0].bounds.top", Current: float64(0), Desired: float64(38)}},
+		},
+		{
+			name:    "added and removed array elements",
+			current: map[string]interface{}{"tags": []interface{}{"a", "b", "c"}},
+			desired: map[string]interface{}{"tags": []interface{}{"a", "d"}},
+			want: []FieldChange{
+				{Path: "tags[1]", Current: "b", Desired: "d"},
+				{Path: "tags[2]", Current: "c", Desired: nil},
+			},
+		},
+		{
+			name:    "changed type",
+			current: map[string]interface{}{"filter": "none"},
+			desired: map[string]interface{}{"filter": map[string]interface{}{"timeframe": "l_7_DAYS"}},
+			want:    []FieldChange{{Path: "filter", Current: "none", Desired: map[string]interface{}{"timeframe": "l_7_DAYS"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffJSON(tt.current, tt.desired)
+			assert.NoError(t, err)
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func Test_newUpdatePlannedChange_MetricEvent(t *testing.T) {
+	existingMetricEvent := &dynatrace.MetricEvent{
+		Metadata:       dynatrace.MEMetadata{ClusterVersion: "1.240"},
+		ID:             "1234",
+		MetricID:       "builtin:service.response.time",
+		Name:           "response_time_p95 (Keptn.sockshop.production.carts)",
+		AlertCondition: "ABOVE",
+		Samples:        10,
+		Threshold:      600,
+		Enabled:        true,
+	}
+	newMetricEvent := &dynatrace.MetricEvent{
+		MetricID:       "builtin:service.response.time",
+		Name:           "response_time_p95 (Keptn.sockshop.production.carts)",
+		AlertCondition: "ABOVE",
+		Samples:        5,
+		Threshold:      500,
+	}
+
+	updatedMetricEvent := getUpdatedMetricEvent(existingMetricEvent, newMetricEvent)
+	assert.Equal(t, "1234", updatedMetricEvent.ID)
+	assert.Equal(t, "1.240", updatedMetricEvent.Metadata.ClusterVersion)
+	assert.True(t, updatedMetricEvent.Enabled)
+
+	plannedChange := newUpdatePlannedChange(newMetricEvent.Name, existingMetricEvent, updatedMetricEvent)
+	assert.Equal(t, PlannedChangeActionUpdate, plannedChange.Action)
+	assert.Empty(t, plannedChange.Error)
+	assert.EqualValues(t,
+		[]FieldChange{
+			{Path: "samples", Current: float64(10), Desired: float64(5)},
+			{Path: "threshold", Current: float64(600), Desired: float64(500)},
+		},
+		plannedChange.FieldChanges)
+
+	plannedChange = newUpdatePlannedChange(newMetricEvent.Name, updatedMetricEvent, getUpdatedMetricEvent(updatedMetricEvent, newMetricEvent))
+	assert.Equal(t, PlannedChangeActionNone, plannedChange.Action)
+	assert.Empty(t, plannedChange.FieldChanges)
+}
+
+func Test_getUpdatedMetricEvent_detectsDrift(t *testing.T) {
+	newMetricEvent := &dynatrace.MetricEvent{
+		MetricID:       "builtin:service.response.time",
+		Name:           "response_time_p95 (Keptn.sockshop.production.carts)",
+		AlertCondition: "ABOVE",
+		Samples:        5,
+		Threshold:      500,
+		AlertingScope: []dynatrace.MEAlertingScope{
+			{FilterType: "MANAGEMENT_ZONE", ManagementZoneID: "42"},
+			{FilterType: "TAG", TagFilter: &dynatrace.METagFilter{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		modify   func(metricEvent *dynatrace.MetricEvent)
+		wantPath string
+	}{
+		{
+			name:     "alert condition",
+			modify:   func(metricEvent *dynatrace.MetricEvent) { metricEvent.AlertCondition = "BELOW" },
+			wantPath: "alertCondition",
+		},
+		{
+			name:     "metric",
+			modify:   func(metricEvent *dynatrace.MetricEvent) { metricEvent.MetricID = "builtin:service.errors.total.rate" },
+			wantPath: "metricId",
+		},
+		{
+			name:     "management zone",
+			modify:   func(metricEvent *dynatrace.MetricEvent) { metricEvent.AlertingScope[0].ManagementZoneID = "43" },
+			wantPath: "alertingScope[0].managementZoneId",
+		},
+		{
+			name:     "tag",
+			modify:   func(metricEvent *dynatrace.MetricEvent) { metricEvent.AlertingScope[1].TagFilter.Value = "orders" },
+			wantPath: "alertingScope[1].tagFilter.value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existingMetricEvent := getUpdatedMetricEvent(&dynatrace.MetricEvent{ID: "1234"}, newMetricEvent)
+			existingMetricEvent.AlertingScope = []dynatrace.MEAlertingScope{newMetricEvent.AlertingScope[0], newMetricEvent.AlertingScope[1]}
+			existingMetricEvent.AlertingScope[1].TagFilter = &dynatrace.METagFilter{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"}
+			tt.modify(existingMetricEvent)
+
+			plannedChange := newUpdatePlannedChange(newMetricEvent.Name, existingMetricEvent, getUpdatedMetricEvent(existingMetricEvent, newMetricEvent))
+			assert.Equal(t, PlannedChangeActionUpdate, plannedChange.Action)
+			if assert.Len(t, plannedChange.FieldChanges, 1) {
+				assert.Equal(t, tt.wantPath, plannedChange.FieldChanges[0].Path)
+			}
+		})
+	}
+}
+
+func Test_formatPlannedChanges(t *testing.T) {
+	plannedChanges := []PlannedChange{
+		{Name: "Keptn: sockshop", Action: PlannedChangeActionNone},
+		{Name: "Keptn: sockshop production", Action: PlannedChangeActionCreate},
+		{Name: "response_time_p95", Action: PlannedChangeActionUpdate, FieldChanges: []FieldChange{{Path: "threshold", Current: float64(600), Desired: float64(500)}}},
+		{Name: "error_rate", Error: "could not retrieve metric events"},
+	}
+
+	assert.Equal(t,
+		"---Metric Events:--- \n"+
+			"  - Keptn: sockshop: unchanged\n"+
+			"  - Keptn: sockshop production: create\n"+
+			"  - response_time_p95: update\n"+
+			"      threshold: 600 -> 500\n"+
+			"  - error_rate: Error: could not retrieve metric events\n"+
+			"\n\n",
+		formatPlannedChanges("Metric Events", plannedChanges))
+	assert.Empty(t, formatPlannedChanges("Dashboard", nil))
+}
@@ -3,6 +3,7 @@ package monitoring
 import (
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
 	keptn "github.com/keptn/go-utils/pkg/lib"
 )
 
@@ -11,11 +12,18 @@ type ConfigureMonitoringAdapterInterface interface {
 	adapter.TriggeredCloudEventContentAdapter
 
 	IsNotForDynatrace() bool
+	IsDryRun() bool
+}
+
+// configureMonitoringDryRunData is the part of the event payload requesting a dry run
+type configureMonitoringDryRunData struct {
+	DryRun *bool `json:"dryRun,omitempty"`
 }
 
 // ConfigureMonitoringAdapter encapsulates a cloud event and its parsed payload
 type ConfigureMonitoringAdapter struct {
 	event      keptn.ConfigureMonitoringEventData
+	dryRun     *bool
 	cloudEvent adapter.CloudEventAdapter
 }
 
@@ -29,8 +37,15 @@ func NewConfigureMonitoringAdapterFromEvent(e cloudevents.Event) (*ConfigureMoni
 		return nil, err
 	}
 
+	dryRunData := &configureMonitoringDryRunData{}
+	err = ceAdapter.PayloadAs(dryRunData)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ConfigureMonitoringAdapter{
 		event:      *cmData,
+		dryRun:     dryRunData.DryRun,
 		cloudEvent: ceAdapter,
 	}, nil
 }
@@ -89,6 +104,14 @@ func (a ConfigureMonitoringAdapter) IsNotForDynatrace() bool {
 	return a.event.Type != "dynatrace"
 }
 
+// IsDryRun returns whether only the planned changes should be reported, as requested by the event or, if not specified, by the CONFIGURE_MONITORING_DRY_RUN environment variable.
+func (a ConfigureMonitoringAdapter) IsDryRun() bool {
+	if a.dryRun != nil {
+		return *a.dryRun
+	}
+	return env.IsConfigureMonitoringDryRunEnabled()
+}
+
 func (a ConfigureMonitoringAdapter) GetEventID() string {
 	return a.cloudEvent.GetEventID()
 }
@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestConfigureMonitoringAdapter_IsDryRun tests that the dry run flag of the event takes precedence over the CONFIGURE_MONITORING_DRY_RUN environment variable.
+func TestConfigureMonitoringAdapter_IsDryRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		envValue  string
+		eventData string
+		want      bool
+	}{
+		{
+			name:      "not set in event, disabled by default",
+			eventData: `{"type":"dynatrace","project":"sockshop"}`,
+			want:      false,
+		},
+		{
+			name:      "not set in event, enabled by environment variable",
+			envValue:  "true",
+			eventData: `{"type":"dynatrace","project":"sockshop"}`,
+			want:      true,
+		},
+		{
+			name:      "enabled by event",
+			eventData: `{"type":"dynatrace","project":"sockshop","dryRun":true}`,
+			want:      true,
+		},
+		{
+			name:      "disabled by event despite environment variable",
+			envValue:  "true",
+			eventData: `{"type":"dynatrace","project":"sockshop","dryRun":false}`,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIGURE_MONITORING_DRY_RUN", tt.envValue)
+
+			event := cloudevents.NewEvent()
+			if !assert.NoError(t, event.SetData(cloudevents.ApplicationJSON, []byte(tt.eventData))) {
+				return
+			}
+
+			adapter, err := NewConfigureMonitoringAdapterFromEvent(event)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.want, adapter.IsDryRun())
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
@@ -24,17 +25,21 @@ type ConfigureMonitoringEventHandler struct {
 	kClient            keptn.ClientInterface
 	sloReader          keptn.SLOReaderInterface
 	serviceClient      keptn.ServiceClientInterface
+	eventClient        keptn.EventClientInterface
+	configProvider     config.DynatraceConfigProvider
 	credentialsChecker keptn.CredentialsCheckerInterface
 }
 
 // NewConfigureMonitoringEventHandler returns a new ConfigureMonitoringEventHandler
-func NewConfigureMonitoringEventHandler(event ConfigureMonitoringAdapterInterface, dtClient dynatrace.ClientInterface, kClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, serviceClient keptn.ServiceClientInterface, credentialsChecker keptn.CredentialsCheckerInterface) ConfigureMonitoringEventHandler {
+func NewConfigureMonitoringEventHandler(event ConfigureMonitoringAdapterInterface, dtClient dynatrace.ClientInterface, kClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, serviceClient keptn.ServiceClientInterface, eventClient keptn.EventClientInterface, configProvider config.DynatraceConfigProvider, credentialsChecker keptn.CredentialsCheckerInterface) ConfigureMonitoringEventHandler {
 	return ConfigureMonitoringEventHandler{
 		event:              event,
 		dtClient:           dtClient,
 		kClient:            kClient,
 		sloReader:          sloReader,
 		serviceClient:      serviceClient,
+		eventClient:        eventClient,
+		configProvider:     configProvider,
 		credentialsChecker: credentialsChecker,
 	}
 }
@@ -62,13 +67,30 @@ func (eh *ConfigureMonitoringEventHandler) configureMonitoring(ctx context.Conte
 		return eh.handleError(err)
 	}
 
-	cfg := NewConfiguration(eh.dtClient, eh.kClient, eh.sloReader, eh.serviceClient)
+	cfg := NewConfiguration(eh.dtClient, eh.kClient, eh.sloReader, eh.serviceClient, eh.configProvider)
+
+	if eh.event.IsDryRun() {
+		plan, err := cfg.PlanMonitoring(ctx, eh.event.GetProject(), *shipyard)
+		if err != nil {
+			return eh.handleError(err)
+		}
+
+		log.Info("Dynatrace monitoring dry run done")
+		return eh.handleSuccess(getConfigureMonitoringPlanMessage(keptnCredentialsCheckResult, plan))
+	}
 
 	configuredEntities, err := cfg.ConfigureMonitoring(ctx, eh.event.GetProject(), *shipyard)
 	if err != nil {
 		return eh.handleError(err)
 	}
 
+	if configuredEntities.ProblemNotifications != nil && configuredEntities.ProblemNotifications.Success {
+		configuredEntities.ProblemNotificationCheck, err = cfg.CheckProblemNotification(ctx, eh.event.GetProject(), *shipyard, eh.eventClient)
+		if err != nil {
+			return eh.handleError(err)
+		}
+	}
+
 	log.Info("Dynatrace Monitoring setup done")
 	return eh.handleSuccess(getConfigureMonitoringResultMessage(keptnCredentialsCheckResult, configuredEntities))
 }
@@ -129,12 +151,30 @@ func getConfigureMonitoringResultMessage(keptnCredentialsCheckResult keptnCreden
 		msg = msg + "\n\n"
 	}
 
+	if len(entities.TestStepMetrics) > 0 {
+		msg = msg + "---Test Step Metrics:--- \n"
+		for _, metric := range entities.TestStepMetrics {
+			if metric.Success {
+				msg = msg + "  - " + metric.Name + ": Created successfully \n"
+			} else {
+				msg = msg + "  - " + metric.Name + ": Error: " + metric.Message + "\n"
+			}
+		}
+		msg = msg + "\n\n"
+	}
+
 	if entities.ProblemNotifications != nil {
 		msg = msg + "---Problem Notification:--- \n"
 		msg = msg + "  - " + entities.ProblemNotifications.Message
 		msg = msg + "\n\n"
 	}
 
+	if entities.ProblemNotificationCheck != nil {
+		msg = msg + "---Problem Notification Check:--- \n"
+		msg = msg + fmt.Sprintf("  - Test Notification Successful: %v. %s\n", entities.ProblemNotificationCheck.Success, entities.ProblemNotificationCheck.Message)
+		msg = msg + "\n\n"
+	}
+
 	if len(entities.MetricEvents) > 0 {
 		msg = msg + "---Metric Events:--- \n"
 		for _, mz := range entities.MetricEvents {
@@ -153,6 +193,56 @@ func getConfigureMonitoringResultMessage(keptnCredentialsCheckResult keptnCreden
 		msg = msg + "\n\n"
 	}
 
+	if len(entities.KQGDashboards) > 0 {
+		msg = msg + "---Quality Gate Dashboards:--- \n"
+		for _, dashboard := range entities.KQGDashboards {
+			if !dashboard.Success {
+				msg = msg + "  - " + dashboard.Name + ": Error: " + dashboard.Message + "\n"
+			} else if dashboard.Message != "" {
+				msg = msg + "  - " + dashboard.Name + ": Created successfully. " + dashboard.Message + "\n"
+			} else {
+				msg = msg + "  - " + dashboard.Name + ": Created successfully \n"
+			}
+		}
+		msg = msg + "\n\n"
+	}
+
+	if len(entities.SLOs) > 0 {
+		msg = msg + "---SLOs:--- \n"
+		for _, slo := range entities.SLOs {
+			if slo.Success {
+				msg = msg + "  - " + slo.Name + ": " + slo.Message + " \n"
+			} else {
+				msg = msg + "  - " + slo.Name + ": Error: " + slo.Message + "\n"
+			}
+		}
+		msg = msg + "\n\n"
+	}
+
+	msg = msg + "---Keptn API Connection Check:--- \n"
+	msg = msg + "  - Keptn API URL: " + keptnCredentialsCheckResult.apiURL + "\n"
+	msg = msg + fmt.Sprintf("  - Connection Successful: %v. %s\n", keptnCredentialsCheckResult.success, keptnCredentialsCheckResult.message)
+	msg = msg + "\n"
+
+	return msg
+}
+
+// getConfigureMonitoringPlanMessage formats the changes of a dry run, listing the field changes of objects that would be updated or replaced.
+func getConfigureMonitoringPlanMessage(keptnCredentialsCheckResult keptnCredentialsCheckResult, plan *ConfigurationPlan) string {
+	if plan == nil {
+		return ""
+	}
+	msg := "Dynatrace monitoring dry run done. No changes have been made.\nThe following changes would be made:\n\n"
+
+	msg = msg + formatPlannedChanges("Management Zones", plan.ManagementZones)
+	msg = msg + formatPlannedChanges("Automatic Tagging Rules", plan.TaggingRules)
+	msg = msg + formatPlannedChanges("Test Step Metrics", plan.TestStepMetrics)
+	msg = msg + formatPlannedChanges("Problem Notification", plan.ProblemNotifications)
+	msg = msg + formatPlannedChanges("Metric Events", plan.MetricEvents)
+	msg = msg + formatPlannedChanges("Dashboard", plan.Dashboard)
+	msg = msg + formatPlannedChanges("Quality Gate Dashboards", plan.KQGDashboards)
+	msg = msg + formatPlannedChanges("SLOs", plan.SLOs)
+
 	msg = msg + "---Keptn API Connection Check:--- \n"
 	msg = msg + "  - Keptn API URL: " + keptnCredentialsCheckResult.apiURL + "\n"
 	msg = msg + fmt.Sprintf("  - Connection Successful: %v. %s\n", keptnCredentialsCheckResult.success, keptnCredentialsCheckResult.message)
@@ -161,6 +251,26 @@ func getConfigureMonitoringResultMessage(keptnCredentialsCheckResult keptnCreden
 	return msg
 }
 
+func formatPlannedChanges(title string, plannedChanges []PlannedChange) string {
+	if len(plannedChanges) == 0 {
+		return ""
+	}
+
+	msg := "---" + title + ":--- \n"
+	for _, plannedChange := range plannedChanges {
+		if plannedChange.Error != "" {
+			msg = msg + "  - " + plannedChange.Name + ": Error: " + plannedChange.Error + "\n"
+			continue
+		}
+
+		msg = msg + "  - " + plannedChange.Name + ": " + string(plannedChange.Action) + "\n"
+		for _, fieldChange := range plannedChange.FieldChanges {
+			msg = msg + "      " + fieldChange.Path + ": " + formatJSONValue(fieldChange.Current) + " -> " + formatJSONValue(fieldChange.Desired) + "\n"
+		}
+	}
+	return msg + "\n\n"
+}
+
 func (eh *ConfigureMonitoringEventHandler) handleError(err error) error {
 	log.Error(err)
 	return eh.sendConfigureMonitoringFinishedEvent(NewErroredConfigureMonitoringFinishedEventFactory(eh.event, err))
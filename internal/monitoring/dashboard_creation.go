@@ -2,88 +2,279 @@ package monitoring
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
+	"strings"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	log "github.com/sirupsen/logrus"
 )
 
-const dashboardNameSuffix = "@keptn: Digital Delivery & Operations Dashboard"
-
 const customChartingTileType = "CUSTOM_CHARTING"
 const customChartName = "Custom Chart"
 const timeSeriesChartType = "TIMESERIES"
 const dashboardStageWidth int = 456
 
+// managedDashboardTileMarker is appended to the name of each tile managed by the dynatrace-service.
+// Only tiles with this marker are replaced when the dashboard is updated, removing it from a tile hands the tile over to the user.
+const managedDashboardTileMarker = "[Keptn]"
+
 type DashboardCreation struct {
 	client dynatrace.ClientInterface
+	naming *Naming
 }
 
-func NewDashboardCreation(client dynatrace.ClientInterface) *DashboardCreation {
+func NewDashboardCreation(client dynatrace.ClientInterface, naming *Naming) *DashboardCreation {
 	return &DashboardCreation{
 		client: client,
+		naming: naming,
 	}
 }
 
-// Create creates a new dashboard for the provided project.
+// Create creates the dashboard for the provided project or updates it in place if it already exists.
+// Only the tiles managed by the dynatrace-service are replaced, tiles added by users as well as the ID and owner of the dashboard are retained.
 func (dc *DashboardCreation) Create(ctx context.Context, project string, shipyard keptnv2.Shipyard) *ConfigResult {
-	// first, check if dashboard for this project already exists and delete that
 	dashboardClient := dynatrace.NewDashboardsClient(dc.client)
-	err := deleteExistingDashboard(ctx, project, dashboardClient)
+	dashboardIDs, err := dc.getExistingDashboardIDs(ctx, project, dashboardClient)
+	if err != nil {
+		log.WithError(err).Error("Could not retrieve existing dashboards")
+		return &ConfigResult{
+			Success: false,
+			Message: "Could not retrieve existing dashboards: " + err.Error(),
+		}
+	}
+
+	dashboard := createDynatraceDashboard(dc.naming.getDashboardName(project), project, shipyard)
+	if len(dashboardIDs) == 0 {
+		log.WithField("project", project).Info("Creating Dashboard for project")
+		err = dashboardClient.Create(ctx, dashboard)
+		if err != nil {
+			log.WithError(err).Error("Failed to create Dynatrace dashboards")
+			return &ConfigResult{
+				Success: false,
+				Message: err.Error(),
+			}
+		}
+		log.WithField("dashboardUrl", dc.client.Credentials().GetTenant()+"/#dashboards").Info("Dynatrace dashboard created successfully")
+		return &ConfigResult{
+			Success: true,
+			Message: "Dynatrace dashboard created successfully. You can view it here: " + dc.client.Credentials().GetTenant() + "/#dashboards",
+		}
+	}
+
+	log.WithFields(log.Fields{"project": project, "dashboardID": dashboardIDs[0]}).Info("Updating Dashboard for project")
+	existingDashboard, err := dashboardClient.GetJSONByID(ctx, dashboardIDs[0])
+	if err != nil {
+		log.WithError(err).Error("Could not retrieve existing dashboard")
+		return &ConfigResult{
+			Success: false,
+			Message: "Could not retrieve existing dashboard: " + err.Error(),
+		}
+	}
+
+	updatedDashboard, err := mergeDashboard(existingDashboard, dashboard)
 	if err != nil {
-		log.WithError(err).Error("Could not delete existing dashboard")
+		log.WithError(err).Error("Could not merge existing dashboard")
 		return &ConfigResult{
 			Success: false,
-			Message: "Could not delete existing dashboard: " + err.Error(),
+			Message: "Could not merge existing dashboard: " + err.Error(),
 		}
 	}
 
-	log.WithField("project", project).Info("Creating Dashboard for project")
-	dashboard := createDynatraceDashboard(project, shipyard)
-	err = dashboardClient.Create(ctx, dashboard)
+	err = dashboardClient.UpdateJSON(ctx, dashboardIDs[0], updatedDashboard)
 	if err != nil {
-		log.WithError(err).Error("Failed to create Dynatrace dashboards")
+		log.WithError(err).Error("Failed to update Dynatrace dashboard")
 		return &ConfigResult{
 			Success: false,
 			Message: err.Error(),
 		}
 	}
-	log.WithField("dashboardUrl", dc.client.Credentials().GetTenant()+"/#dashboards").Info("Dynatrace dashboard created successfully")
+
+	// further dashboards with the same name have been created by earlier versions and are removed
+	for _, dashboardID := range dashboardIDs[1:] {
+		err = dashboardClient.Delete(ctx, dashboardID)
+		if err != nil {
+			log.WithError(err).WithField("dashboardID", dashboardID).Warn("Could not delete duplicate dashboard")
+		}
+	}
+
+	dashboardURL := dc.client.Credentials().GetTenant() + "/#dashboard;id=" + dashboardIDs[0]
+	log.WithField("dashboardUrl", dashboardURL).Info("Dynatrace dashboard updated successfully")
 	return &ConfigResult{
-		Success: true, // I guess this should be true not false?
-		Message: "Dynatrace dashboard created successfully. You can view it here: " + dc.client.Credentials().GetTenant() + "/#dashboards",
+		Success: true,
+		Message: "Dynatrace dashboard updated successfully. You can view it here: " + dashboardURL,
 	}
 }
 
-// deleteExistingDashboard deletes an existing dashboard for the provided project
-func deleteExistingDashboard(ctx context.Context, project string, dashboardClient *dynatrace.DashboardsClient) error {
+// Plan returns the changes Create would make to the dashboard of the project without writing it.
+func (dc *DashboardCreation) Plan(ctx context.Context, project string, shipyard keptnv2.Shipyard) []PlannedChange {
+	dashboardName := dc.naming.getDashboardName(project)
+	dashboardClient := dynatrace.NewDashboardsClient(dc.client)
+	dashboardIDs, err := dc.getExistingDashboardIDs(ctx, project, dashboardClient)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(dashboardName, err)}
+	}
+
+	if len(dashboardIDs) == 0 {
+		return []PlannedChange{{Name: dashboardName, Action: PlannedChangeActionCreate}}
+	}
+
+	var plannedChanges []PlannedChange
+	name := dashboardName + " (" + dashboardIDs[0] + ")"
+	existingDashboard, err := dashboardClient.GetJSONByID(ctx, dashboardIDs[0])
+	if err != nil {
+		plannedChanges = append(plannedChanges, newFailedPlannedChange(name, err))
+	} else {
+		updatedDashboard, err := mergeDashboard(existingDashboard, createDynatraceDashboard(dashboardName, project, shipyard))
+		if err != nil {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(name, err))
+		} else {
+			plannedChanges = append(plannedChanges, newUpdatePlannedChange(name, existingDashboard, updatedDashboard))
+		}
+	}
+
+	// all further dashboards with the same name are only deleted
+	for _, dashboardID := range dashboardIDs[1:] {
+		plannedChanges = append(plannedChanges, PlannedChange{Name: dashboardName + " (" + dashboardID + ")", Action: PlannedChangeActionDelete})
+	}
+	return plannedChanges
+}
+
+// getExistingDashboardIDs returns the IDs of all dashboards named like the dashboard of the provided project.
+// If none exists, the IDs of the dashboards named by the default naming whose tiles are filtered by the Keptn project tag are returned, so that they are updated and renamed.
+// Dashboards with the default name but without such tiles are left alone, as they may belong to another installation on the same tenant.
+func (dc *DashboardCreation) getExistingDashboardIDs(ctx context.Context, project string, dashboardClient *dynatrace.DashboardsClient) ([]string, error) {
 	response, err := dashboardClient.GetAll(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, dashboardItem := range response.Dashboards {
-		if dashboardItem.Name == getDashboardName(project) {
-			err = dashboardClient.Delete(ctx, dashboardItem.ID)
-			if err != nil {
-				return fmt.Errorf("could not delete dashboard for project %s: %v", project, err)
-			}
+	dashboardName := dc.naming.getDashboardName(project)
+	dashboardIDs := getDashboardIDsWithName(response, dashboardName)
+	defaultDashboardName := defaultNaming.getDashboardName(project)
+	if len(dashboardIDs) > 0 || dashboardName == defaultDashboardName {
+		return dashboardIDs, nil
+	}
+
+	var ownedDashboardIDs []string
+	for _, dashboardID := range getDashboardIDsWithName(response, defaultDashboardName) {
+		dashboard, err := dashboardClient.GetByID(ctx, dashboardID)
+		if err != nil {
+			return nil, err
+		}
+
+		if isDashboardOwnedByProject(dashboard, project) {
+			ownedDashboardIDs = append(ownedDashboardIDs, dashboardID)
+		}
+	}
+	return ownedDashboardIDs, nil
+}
+
+func getDashboardIDsWithName(dashboards *dynatrace.DashboardList, name string) []string {
+	var dashboardIDs []string
+	for _, dashboardItem := range dashboards.Dashboards {
+		if dashboardItem.Name == name {
+			dashboardIDs = append(dashboardIDs, dashboardItem.ID)
+		}
+	}
+	return dashboardIDs
+}
+
+// mergeDashboard returns the existing dashboard with the metadata and managed tiles of the desired dashboard.
+// The ID, owner and any other fields of the existing dashboard as well as all tiles not managed by the dynatrace-service are retained.
+// If the existing dashboard does not contain any managed tiles, it was created by an earlier version and tiles matching the type and name of a desired tile are replaced instead.
+func mergeDashboard(existingDashboard map[string]interface{}, desiredDashboard *dynatrace.Dashboard) (map[string]interface{}, error) {
+	desiredValue, err := toJSONValue(desiredDashboard)
+	if err != nil {
+		return nil, err
+	}
+	desired, _ := desiredValue.(map[string]interface{})
+
+	mergedDashboard := make(map[string]interface{}, len(existingDashboard))
+	for key, value := range existingDashboard {
+		mergedDashboard[key] = value
+	}
+
+	existingMetadata, _ := existingDashboard["dashboardMetadata"].(map[string]interface{})
+	mergedMetadata := make(map[string]interface{}, len(existingMetadata))
+	for key, value := range existingMetadata {
+		mergedMetadata[key] = value
+	}
+	desiredMetadata, _ := desired["dashboardMetadata"].(map[string]interface{})
+	for key, value := range desiredMetadata {
+		if key == "owner" {
+			continue
+		}
+		mergedMetadata[key] = value
+	}
+	mergedDashboard["dashboardMetadata"] = mergedMetadata
+
+	existingTiles, _ := existingDashboard["tiles"].([]interface{})
+	tiles := make([]dynatrace.Tile, 0, len(existingTiles))
+	hasManagedTiles := false
+	for _, existingTile := range existingTiles {
+		tile, err := toDashboardTile(existingTile)
+		if err != nil {
+			return nil, err
 		}
+		tiles = append(tiles, *tile)
+		hasManagedTiles = hasManagedTiles || isManagedDashboardTile(*tile)
 	}
-	return nil
+
+	desiredTileKeys := make(map[string]bool, len(desiredDashboard.Tiles))
+	for _, desiredTile := range desiredDashboard.Tiles {
+		desiredTileKeys[getUnmanagedDashboardTileKey(desiredTile)] = true
+	}
+
+	mergedTiles, _ := desired["tiles"].([]interface{})
+	for i, tile := range tiles {
+		if isManagedDashboardTile(tile) || (!hasManagedTiles && desiredTileKeys[getUnmanagedDashboardTileKey(tile)]) {
+			continue
+		}
+		mergedTiles = append(mergedTiles, existingTiles[i])
+	}
+	mergedDashboard["tiles"] = mergedTiles
+
+	return mergedDashboard, nil
 }
 
-func getDashboardName(projectName string) string {
-	return projectName + dashboardNameSuffix
+func toDashboardTile(value interface{}) (*dynatrace.Tile, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return nil, common.NewMarshalJSONError("dashboard tile", err)
+	}
+
+	tile := &dynatrace.Tile{}
+	err = json.Unmarshal(payload, tile)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("dashboard tile", err)
+	}
+	return tile, nil
+}
+
+// markAsManagedDashboardTile adds the marker identifying tiles managed by the dynatrace-service to the name of the tile.
+func markAsManagedDashboardTile(tile dynatrace.Tile) dynatrace.Tile {
+	tile.Name = strings.TrimSpace(tile.Name + " " + managedDashboardTileMarker)
+	return tile
+}
+
+// isManagedDashboardTile returns true if the tile is managed by the dynatrace-service and may be replaced when the dashboard is updated.
+func isManagedDashboardTile(tile dynatrace.Tile) bool {
+	return strings.HasSuffix(tile.Name, managedDashboardTileMarker)
+}
+
+// getUnmanagedDashboardTileKey returns the type and name of the tile without the marker, used to identify tiles created by earlier versions.
+func getUnmanagedDashboardTileKey(tile dynatrace.Tile) string {
+	return tile.TileType + "/" + strings.TrimSpace(strings.TrimSuffix(tile.Name, managedDashboardTileMarker))
 }
 
 // Dashboard creation stuff below
 
-func createDynatraceDashboard(projectName string, shipyard keptnv2.Shipyard) *dynatrace.Dashboard {
+func createDynatraceDashboard(dashboardName string, projectName string, shipyard keptnv2.Shipyard) *dynatrace.Dashboard {
 	dtDashboard := &dynatrace.Dashboard{
 		DashboardMetadata: dynatrace.DashboardMetadata{
-			Name:   getDashboardName(projectName),
+			Name:   dashboardName,
 			Shared: true,
 			Owner:  "",
 			SharingDetails: dynatrace.SharingDetails{
@@ -159,6 +350,10 @@ func createDynatraceDashboard(projectName string, shipyard keptnv2.Shipyard) *dy
 		dtDashboard.Tiles = append(dtDashboard.Tiles, headerTile, servicesTile, throughputTile, errorRateTile, responseTimeTile)
 	}
 
+	for i, tile := range dtDashboard.Tiles {
+		dtDashboard.Tiles[i] = markAsManagedDashboardTile(tile)
+	}
+
 	return dtDashboard
 }
 
@@ -0,0 +1,138 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+)
+
+const existingDashboardWithUserTile = `{
+	"metadata": {"configurationVersions": [5], "clusterVersion": "1.240.0"},
+	"id": "a1b2c3",
+	"dashboardMetadata": {
+		"name": "sockshop@keptn: Digital Delivery & Operations Dashboard",
+		"shared": true,
+		"owner": "jane.doe@example.com",
+		"popularity": 3,
+		"dashboardFilter": {"timeframe": "l_24_HOURS"}
+	},
+	"tiles": [
+		{"name": "Infrastructure [Keptn]", "tileType": "HEADER", "configured": true, "bounds": {"top": 0, "left": 0, "width": 494, "height": 38}, "tileFilter": {}},
+		{"name": "Services: staging [Keptn]", "tileType": "SERVICES", "configured": true, "bounds": {"top": 304, "left": 456, "width": 456, "height": 152}, "tileFilter": {}},
+		{"name": "My notes", "tileType": "MARKDOWN", "configured": true, "markdown": "Hello", "visualConfig": {"rules": []}, "bounds": {"top": 988, "left": 0, "width": 304, "height": 152}, "tileFilter": {}}
+	]
+}`
+
+const existingDashboardOfEarlierVersion = `{
+	"id": "a1b2c3",
+	"dashboardMetadata": {"name": "sockshop@keptn: Digital Delivery & Operations Dashboard", "shared": true, "owner": "jane.doe@example.com"},
+	"tiles": [
+		{"name": "Infrastructure", "tileType": "HEADER", "configured": true, "bounds": {"top": 0, "left": 0, "width": 494, "height": 38}, "tileFilter": {}},
+		{"name": "", "tileType": "HOSTS", "configured": true, "bounds": {"top": 38, "left": 0, "width": 456, "height": 152}, "tileFilter": {}},
+		{"name": "Infrastructure", "tileType": "MARKDOWN", "configured": true, "markdown": "Hello", "bounds": {"top": 988, "left": 0, "width": 304, "height": 152}, "tileFilter": {}}
+	]
+}`
+
+// existingDashboardWithUserTilesMatchingGeneratedTiles contains user tiles with the same type and name as the generated header tile of the production stage and the generated hosts tile
+const existingDashboardWithUserTilesMatchingGeneratedTiles = `{
+	"id": "a1b2c3",
+	"dashboardMetadata": {"name": "sockshop@keptn: Digital Delivery & Operations Dashboard", "shared": true, "owner": "jane.doe@example.com"},
+	"tiles": [
+		{"name": "Infrastructure [Keptn]", "tileType": "HEADER", "configured": true, "bounds": {"top": 0, "left": 0, "width": 494, "height": 38}, "tileFilter": {}},
+		{"name": "production", "tileType": "HEADER", "configured": true, "bounds": {"top": 1140, "left": 0, "width": 456, "height": 38}, "tileFilter": {}},
+		{"name": "", "tileType": "HOSTS", "configured": true, "bounds": {"top": 1178, "left": 0, "width": 456, "height": 152}, "tileFilter": {}}
+	]
+}`
+
+func Test_mergeDashboard(t *testing.T) {
+	shipyard := keptnv2.Shipyard{Spec: keptnv2.ShipyardSpec{Stages: []keptnv2.Stage{{Name: "production"}}}}
+	desiredDashboard := createDynatraceDashboard(defaultNaming.getDashboardName("sockshop"), "sockshop", shipyard)
+
+	tests := []struct {
+		name              string
+		existingDashboard string
+		expectedUserTiles []string
+		expectedUserTypes []string
+	}{
+		{
+			name:              "tiles without marker are retained and marked tiles of removed stages are removed",
+			existingDashboard: existingDashboardWithUserTile,
+			expectedUserTiles: []string{"My notes"},
+			expectedUserTypes: []string{"MARKDOWN"},
+		},
+		{
+			name:              "tiles without marker matching type and name of a generated tile are retained",
+			existingDashboard: existingDashboardWithUserTilesMatchingGeneratedTiles,
+			expectedUserTiles: []string{"production", ""},
+			expectedUserTypes: []string{"HEADER", "HOSTS"},
+		},
+		{
+			name:              "tiles of earlier versions are replaced",
+			existingDashboard: existingDashboardOfEarlierVersion,
+			expectedUserTiles: []string{"Infrastructure"},
+			expectedUserTypes: []string{"MARKDOWN"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var existingDashboard map[string]interface{}
+			if !assert.NoError(t, json.Unmarshal([]byte(tt.existingDashboard), &existingDashboard)) {
+				return
+			}
+
+			mergedDashboard, err := mergeDashboard(existingDashboard, desiredDashboard)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, "a1b2c3", mergedDashboard["id"])
+
+			metadata := mergedDashboard["dashboardMetadata"].(map[string]interface{})
+			assert.Equal(t, "jane.doe@example.com", metadata["owner"])
+			assert.Equal(t, "l_7_DAYS", metadata["dashboardFilter"].(map[string]interface{})["timeframe"])
+
+			tiles := mergedDashboard["tiles"].([]interface{})
+			if !assert.Equal(t, len(desiredDashboard.Tiles)+len(tt.expectedUserTiles), len(tiles)) {
+				return
+			}
+
+			for i, desiredTile := range desiredDashboard.Tiles {
+				assert.Equal(t, desiredTile.Name, tiles[i].(map[string]interface{})["name"])
+			}
+
+			for i, expectedUserTile := range tt.expectedUserTiles {
+				userTile := tiles[len(desiredDashboard.Tiles)+i].(map[string]interface{})
+				assert.Equal(t, expectedUserTile, userTile["name"])
+				assert.Equal(t, tt.expectedUserTypes[i], userTile["tileType"])
+			}
+
+			// applying the same desired dashboard again does not result in any changes
+			mergedAgain, err := mergeDashboard(mergedDashboard, desiredDashboard)
+			if assert.NoError(t, err) {
+				fieldChanges, err := diffJSON(mergedDashboard, mergedAgain)
+				assert.NoError(t, err)
+				assert.Empty(t, fieldChanges)
+			}
+		})
+	}
+}
+
+func Test_mergeDashboard_retainsFieldsOfUserTiles(t *testing.T) {
+	var existingDashboard map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal([]byte(existingDashboardWithUserTile), &existingDashboard)) {
+		return
+	}
+
+	mergedDashboard, err := mergeDashboard(existingDashboard, createDynatraceDashboard(defaultNaming.getDashboardName("sockshop"), "sockshop", keptnv2.Shipyard{}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, existingDashboard["metadata"], mergedDashboard["metadata"])
+	assert.Equal(t, float64(3), mergedDashboard["dashboardMetadata"].(map[string]interface{})["popularity"])
+
+	tiles := mergedDashboard["tiles"].([]interface{})
+	assert.Equal(t, existingDashboard["tiles"].([]interface{})[2], tiles[len(tiles)-1])
+}
@@ -0,0 +1,107 @@
+package monitoring
+
+import (
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+type DeleteFinishedAdapterInterface interface {
+	adapter.EventContentAdapter
+
+	IsProjectDeletion() bool
+	IsSuccessful() bool
+}
+
+// DeleteFinishedAdapter is a content adaptor for events of type sh.keptn.event.project.delete.finished and sh.keptn.event.service.delete.finished
+type DeleteFinishedAdapter struct {
+	taskName   string
+	event      keptnv2.EventData
+	cloudEvent adapter.CloudEventAdapter
+}
+
+// NewProjectDeleteFinishedAdapterFromEvent creates a new DeleteFinishedAdapter from a sh.keptn.event.project.delete.finished cloudevents Event
+func NewProjectDeleteFinishedAdapterFromEvent(e cloudevents.Event) (*DeleteFinishedAdapter, error) {
+	return newDeleteFinishedAdapterFromEvent(keptnv2.ProjectDeleteTaskName, e)
+}
+
+// NewServiceDeleteFinishedAdapterFromEvent creates a new DeleteFinishedAdapter from a sh.keptn.event.service.delete.finished cloudevents Event
+func NewServiceDeleteFinishedAdapterFromEvent(e cloudevents.Event) (*DeleteFinishedAdapter, error) {
+	return newDeleteFinishedAdapterFromEvent(keptnv2.ServiceDeleteTaskName, e)
+}
+
+func newDeleteFinishedAdapterFromEvent(taskName string, e cloudevents.Event) (*DeleteFinishedAdapter, error) {
+	ceAdapter := adapter.NewCloudEventAdapter(e)
+
+	dfData := &keptnv2.EventData{}
+	err := ceAdapter.PayloadAs(dfData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeleteFinishedAdapter{
+		taskName:   taskName,
+		event:      *dfData,
+		cloudEvent: ceAdapter,
+	}, nil
+}
+
+// GetShKeptnContext returns the shkeptncontext
+func (a DeleteFinishedAdapter) GetShKeptnContext() string {
+	return a.cloudEvent.GetShKeptnContext()
+}
+
+// GetSource returns the source specified in the CloudEvent context
+func (a DeleteFinishedAdapter) GetSource() string {
+	return a.cloudEvent.GetSource()
+}
+
+// GetEvent returns the event type
+func (a DeleteFinishedAdapter) GetEvent() string {
+	return keptnv2.GetFinishedEventType(a.taskName)
+}
+
+// GetProject returns the project
+func (a DeleteFinishedAdapter) GetProject() string {
+	return a.event.Project
+}
+
+// GetStage returns the stage
+func (a DeleteFinishedAdapter) GetStage() string {
+	return a.event.Stage
+}
+
+// GetService returns the service
+func (a DeleteFinishedAdapter) GetService() string {
+	return a.event.Service
+}
+
+// GetDeployment returns the name of the deployment
+func (a DeleteFinishedAdapter) GetDeployment() string {
+	return ""
+}
+
+// GetTestStrategy returns the used test strategy
+func (a DeleteFinishedAdapter) GetTestStrategy() string {
+	return ""
+}
+
+// GetDeploymentStrategy returns the used deployment strategy
+func (a DeleteFinishedAdapter) GetDeploymentStrategy() string {
+	return ""
+}
+
+// GetLabels returns a map of labels
+func (a DeleteFinishedAdapter) GetLabels() map[string]string {
+	return a.event.Labels
+}
+
+// IsProjectDeletion returns true if the project was deleted, otherwise only the service was deleted
+func (a DeleteFinishedAdapter) IsProjectDeletion() bool {
+	return a.taskName == keptnv2.ProjectDeleteTaskName
+}
+
+// IsSuccessful returns true if the project or service has been deleted successfully
+func (a DeleteFinishedAdapter) IsSuccessful() bool {
+	return a.event.Status != keptnv2.StatusErrored && a.event.Result != keptnv2.ResultFailed
+}
@@ -0,0 +1,73 @@
+package monitoring
+
+import (
+	"context"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeleteFinishedEventHandler cleans up the configuration created in Dynatrace once a Keptn project or service has been deleted.
+// The names of the Dynatrace credentials secrets remembered for a deleted project are forgotten once its configuration has been cleaned up.
+type DeleteFinishedEventHandler struct {
+	event                DeleteFinishedAdapterInterface
+	dtClient             dynatrace.ClientInterface
+	credentialsNameStore credentials.DynatraceCredentialsNameStore
+}
+
+// NewDeleteFinishedEventHandler returns a new DeleteFinishedEventHandler
+func NewDeleteFinishedEventHandler(event DeleteFinishedAdapterInterface, dtClient dynatrace.ClientInterface, credentialsNameStore credentials.DynatraceCredentialsNameStore) DeleteFinishedEventHandler {
+	return DeleteFinishedEventHandler{
+		event:                event,
+		dtClient:             dtClient,
+		credentialsNameStore: credentialsNameStore,
+	}
+}
+
+// HandleEvent handles a project or service delete finished event.
+func (eh DeleteFinishedEventHandler) HandleEvent(workCtx context.Context, replyCtx context.Context) error {
+	logger := log.WithFields(log.Fields{"project": eh.event.GetProject(), "service": eh.event.GetService()})
+	if !eh.event.IsSuccessful() {
+		logger.Info("Deletion was not successful, keeping Dynatrace configuration")
+		return nil
+	}
+
+	cleanup := NewConfigurationCleanup(eh.dtClient)
+
+	var results []CleanupResult
+	if eh.event.IsProjectDeletion() {
+		logger.Info("Cleaning up Dynatrace configuration of deleted project")
+		results = cleanup.CleanUpProject(workCtx, eh.event.GetProject())
+
+		err := eh.credentialsNameStore.Delete(eh.event.GetProject())
+		if err != nil {
+			logger.WithError(err).Warn("Could not forget names of Dynatrace credentials secrets of deleted project")
+		}
+	} else {
+		logger.Info("Cleaning up Dynatrace configuration of deleted service")
+		results = cleanup.CleanUpService(workCtx, eh.event.GetProject(), eh.event.GetService())
+	}
+
+	logCleanupResults(logger, results)
+	return nil
+}
+
+func logCleanupResults(logger *log.Entry, results []CleanupResult) {
+	deletedCount := 0
+	for _, result := range results {
+		resultLogger := logger.WithFields(log.Fields{"entityType": result.EntityType, "name": result.Name})
+		switch result.Status {
+		case CleanupStatusDeleted:
+			deletedCount++
+			resultLogger.Info("Deleted Dynatrace configuration")
+		case CleanupStatusSkipped:
+			resultLogger.WithField("reason", result.Message).Warn("Skipped deleting Dynatrace configuration not created by the dynatrace-service")
+		case CleanupStatusFailed:
+			resultLogger.WithField("message", result.Message).Error("Could not delete Dynatrace configuration")
+		}
+	}
+
+	logger.WithField("deletedCount", deletedCount).Info("Cleaned up Dynatrace configuration")
+}
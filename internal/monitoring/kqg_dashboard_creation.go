@@ -0,0 +1,426 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/metrics"
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	kqgMarkdownTileHeight     = 76
+	kqgDataExplorerTileWidth  = 380
+	kqgDataExplorerTileHeight = 304
+	kqgDataExplorerColumns    = 3
+)
+
+// kqgTransformations are the transformations of SLI metric selectors, besides the aggregation, that do not change the value of a quality gate dashboard tile
+var kqgTransformations = map[string]bool{
+	"auto":  true,
+	"limit": true,
+	"merge": true,
+	"names": true,
+	"sort":  true,
+}
+
+// errNoSLOs indicates that no quality gate dashboard is generated for a service as it has no SLOs.
+var errNoSLOs = errors.New("no SLOs defined for service")
+
+// KQGDashboardCreation creates a quality gate dashboard for each service and stage based on its slo.yaml and sli.yaml.
+// The dashboards can be found by the dynatrace-service if dashboard is set to query in the dynatrace.conf.yaml.
+type KQGDashboardCreation struct {
+	client    dynatrace.ClientInterface
+	kClient   keptn.ClientInterface
+	sloReader keptn.SLOReaderInterface
+}
+
+// NewKQGDashboardCreation creates a new KQGDashboardCreation.
+func NewKQGDashboardCreation(client dynatrace.ClientInterface, kClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface) *KQGDashboardCreation {
+	return &KQGDashboardCreation{
+		client:    client,
+		kClient:   kClient,
+		sloReader: sloReader,
+	}
+}
+
+// Create creates or updates the quality gate dashboard of the service in the stage.
+// It returns nil if the service has no SLOs.
+func (dc *KQGDashboardCreation) Create(ctx context.Context, project string, stage string, service string) *ConfigResult {
+	dashboardName := getKQGDashboardName(project, stage, service)
+	dashboard, skippedSLIs, err := dc.getDesiredDashboard(project, stage, service)
+	if errors.Is(err, errNoSLOs) {
+		log.WithFields(log.Fields{"stage": stage, "service": service}).Info("No SLOs defined for service. Skipping creation of quality gate dashboard.")
+		return nil
+	}
+	if err != nil {
+		return &ConfigResult{Name: dashboardName, Message: err.Error()}
+	}
+
+	dashboardsClient := dynatrace.NewDashboardsClient(dc.client)
+	existingDashboard, err := getExistingKQGDashboard(ctx, dashboardsClient, dashboardName)
+	if err != nil {
+		return &ConfigResult{Name: dashboardName, Message: err.Error()}
+	}
+
+	if existingDashboard == nil {
+		err = dashboardsClient.Create(ctx, dashboard)
+	} else {
+		err = dashboardsClient.Update(ctx, getUpdatedKQGDashboard(existingDashboard, dashboard))
+	}
+	if err != nil {
+		log.WithError(err).WithField("name", dashboardName).Error("Could not create quality gate dashboard")
+		return &ConfigResult{Name: dashboardName, Message: err.Error()}
+	}
+
+	log.WithField("name", dashboardName).Info("Created quality gate dashboard")
+	result := &ConfigResult{Name: dashboardName, Success: true}
+	if len(skippedSLIs) > 0 {
+		result.Message = "Skipped SLIs: " + strings.Join(skippedSLIs, ", ")
+	}
+	return result
+}
+
+// Plan returns the changes Create would make to the quality gate dashboard of the service in the stage without writing it.
+func (dc *KQGDashboardCreation) Plan(ctx context.Context, project string, stage string, service string) []PlannedChange {
+	dashboardName := getKQGDashboardName(project, stage, service)
+	dashboard, _, err := dc.getDesiredDashboard(project, stage, service)
+	if errors.Is(err, errNoSLOs) {
+		return nil
+	}
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(dashboardName, err)}
+	}
+
+	existingDashboard, err := getExistingKQGDashboard(ctx, dynatrace.NewDashboardsClient(dc.client), dashboardName)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(dashboardName, err)}
+	}
+
+	if existingDashboard == nil {
+		return []PlannedChange{{Name: dashboardName, Action: PlannedChangeActionCreate}}
+	}
+	return []PlannedChange{newUpdatePlannedChange(dashboardName, existingDashboard, getUpdatedKQGDashboard(existingDashboard, dashboard))}
+}
+
+// getDesiredDashboard returns the quality gate dashboard for the SLOs of the service and the names of the SLIs which could not be added to it.
+func (dc *KQGDashboardCreation) getDesiredDashboard(project string, stage string, service string) (*dynatrace.Dashboard, []string, error) {
+	slos, err := dc.sloReader.GetSLOs(project, stage, service)
+	if err != nil || len(slos.Objectives) == 0 {
+		return nil, nil, errNoSLOs
+	}
+
+	customQueries, err := dc.kClient.GetCustomQueries(project, stage, service)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get custom queries: %w", err)
+	}
+
+	dashboard, skippedSLIs := createKQGDashboard(project, stage, service, slos, customQueries)
+	return dashboard, skippedSLIs, nil
+}
+
+// getExistingKQGDashboard returns the existing quality gate dashboard with the name or nil if it does not exist.
+// An error is returned if several dashboards have the name or if the dashboard was not generated by the dynatrace-service.
+func getExistingKQGDashboard(ctx context.Context, dashboardsClient *dynatrace.DashboardsClient, dashboardName string) (*dynatrace.Dashboard, error) {
+	dashboards, err := dashboardsClient.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dashboardIDs []string
+	for _, dashboardStub := range dashboards.Dashboards {
+		if dashboardStub.Name == dashboardName {
+			dashboardIDs = append(dashboardIDs, dashboardStub.ID)
+		}
+	}
+
+	switch len(dashboardIDs) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d dashboards named '%s', leaving them unchanged", len(dashboardIDs), dashboardName)
+	}
+
+	dashboard, err := dashboardsClient.GetByID(ctx, dashboardIDs[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !isKQGDashboardGeneratedByService(dashboard) {
+		return nil, fmt.Errorf("dashboard '%s' was not generated by the dynatrace-service, leaving it unchanged", dashboardName)
+	}
+	return dashboard, nil
+}
+
+// getUpdatedKQGDashboard returns the desired dashboard with the ID and owner of the existing dashboard so that links to it remain valid.
+func getUpdatedKQGDashboard(existingDashboard *dynatrace.Dashboard, desiredDashboard *dynatrace.Dashboard) *dynatrace.Dashboard {
+	updatedDashboard := *desiredDashboard
+	updatedDashboard.ID = existingDashboard.ID
+	updatedDashboard.DashboardMetadata.Owner = existingDashboard.DashboardMetadata.Owner
+	return &updatedDashboard
+}
+
+// isKQGDashboardGeneratedByService returns true if the dashboard contains the markdown tile of a generated quality gate dashboard.
+func isKQGDashboardGeneratedByService(dashboard *dynatrace.Dashboard) bool {
+	for _, tile := range dashboard.Tiles {
+		if tile.TileType == dynatrace.MarkdownTileType && strings.Contains(tile.Markdown, sloFileMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// getKQGDashboardName returns the name of the quality gate dashboard of the service, which is found if dashboard is set to query in the dynatrace.conf.yaml.
+func getKQGDashboardName(project string, stage string, service string) string {
+	return "KQG;project=" + project + ";stage=" + stage + ";service=" + service
+}
+
+// createKQGDashboard creates a quality gate dashboard containing a markdown tile with the total score and comparison and a Data Explorer tile for each objective.
+// The names of SLIs whose objective or query cannot be represented by a tile are returned.
+func createKQGDashboard(project string, stage string, service string, slos *keptnlib.ServiceLevelObjectives, customQueries *keptn.CustomQueries) (*dynatrace.Dashboard, []string) {
+	dashboard := &dynatrace.Dashboard{
+		DashboardMetadata: dynatrace.DashboardMetadata{
+			Name:   getKQGDashboardName(project, stage, service),
+			Shared: true,
+			Owner:  "",
+			SharingDetails: dynatrace.SharingDetails{
+				LinkShared: true,
+				Published:  false,
+			},
+			DashboardFilter: &dynatrace.DashboardFilter{
+				Timeframe: "l_2_HOURS",
+			},
+		},
+		Tiles: []dynatrace.Tile{},
+	}
+
+	markdownTile := createTileWith("Markdown", dynatrace.MarkdownTileType, nil)
+	markdownTile.Markdown = createKQGMarkdown(slos)
+	markdownTile.ChartVisible = false
+	markdownTile.Bounds = dynatrace.Bounds{
+		Top:    0,
+		Left:   0,
+		Width:  kqgDataExplorerColumns * kqgDataExplorerTileWidth,
+		Height: kqgMarkdownTileHeight,
+	}
+	dashboard.Tiles = append(dashboard.Tiles, markdownTile)
+
+	var skippedSLIs []string
+	for _, objective := range slos.Objectives {
+		query, err := customQueries.GetQueryByNameOrDefault(objective.SLI)
+		if err != nil {
+			log.WithError(err).WithField("sli", objective.SLI).Warn("Could not find query for SLI, skipping it in quality gate dashboard")
+			skippedSLIs = append(skippedSLIs, objective.SLI)
+			continue
+		}
+
+		tile, err := createKQGDataExplorerTile(project, stage, service, objective, query)
+		if err != nil {
+			log.WithError(err).WithField("sli", objective.SLI).Warn("Could not create Data Explorer tile for SLI, skipping it in quality gate dashboard")
+			skippedSLIs = append(skippedSLIs, objective.SLI)
+			continue
+		}
+
+		index := len(dashboard.Tiles) - 1
+		tile.Bounds = dynatrace.Bounds{
+			Top:    kqgMarkdownTileHeight + (index/kqgDataExplorerColumns)*kqgDataExplorerTileHeight,
+			Left:   (index % kqgDataExplorerColumns) * kqgDataExplorerTileWidth,
+			Width:  kqgDataExplorerTileWidth,
+			Height: kqgDataExplorerTileHeight,
+		}
+		dashboard.Tiles = append(dashboard.Tiles, *tile)
+	}
+
+	return dashboard, skippedSLIs
+}
+
+// createKQGMarkdown creates the markdown specifying the total score and comparison of the SLOs, e.g. KQG.Total.Pass=90%;KQG.Total.Warning=75%.
+// Values that are not set are omitted, so that the defaults are used when the dashboard is processed.
+func createKQGMarkdown(slos *keptnlib.ServiceLevelObjectives) string {
+	var keyValuePairs []string
+	if slos.TotalScore != nil {
+		if slos.TotalScore.Pass != "" {
+			keyValuePairs = append(keyValuePairs, "KQG.Total.Pass="+slos.TotalScore.Pass)
+		}
+		if slos.TotalScore.Warning != "" {
+			keyValuePairs = append(keyValuePairs, "KQG.Total.Warning="+slos.TotalScore.Warning)
+		}
+	}
+
+	if slos.Comparison != nil {
+		if slos.Comparison.IncludeResultWithScore != "" {
+			keyValuePairs = append(keyValuePairs, "KQG.Compare.WithScore="+slos.Comparison.IncludeResultWithScore)
+		}
+		if slos.Comparison.NumberOfComparisonResults > 0 {
+			keyValuePairs = append(keyValuePairs, "KQG.Compare.Results="+strconv.Itoa(slos.Comparison.NumberOfComparisonResults))
+		}
+		if slos.Comparison.AggregateFunction != "" {
+			keyValuePairs = append(keyValuePairs, "KQG.Compare.Function="+slos.Comparison.AggregateFunction)
+		}
+	}
+
+	// the markdown tile must contain "KQG." to be processed, the marker is separated by a ';' so it is not part of the last value
+	if len(keyValuePairs) == 0 {
+		keyValuePairs = append(keyValuePairs, "KQG.Total.Pass=90%", "KQG.Total.Warning=75%")
+	}
+	return strings.Join(keyValuePairs, ";") + ";\n\n" + sloFileMarker
+}
+
+// createKQGDataExplorerTile creates a Data Explorer tile for the objective titled in the format read by common.ParseSLOFromString.
+func createKQGDataExplorerTile(project string, stage string, service string, objective *keptnlib.SLO, query string) (*dynatrace.Tile, error) {
+	title := common.FormatSLOToString(objective)
+	parsedObjective, err := common.ParseSLOFromString(title)
+	if err != nil {
+		return nil, fmt.Errorf("objective cannot be represented as tile title: %w", err)
+	}
+
+	if parsedObjective.SLI != objective.SLI {
+		return nil, fmt.Errorf("SLI name '%s' cannot be represented as tile title", objective.SLI)
+	}
+
+	dataExplorerQuery, err := createKQGDataExplorerQuery(project, stage, service, query)
+	if err != nil {
+		return nil, err
+	}
+
+	tile := createTileWith(title, dynatrace.DataExplorerTileType, nil)
+	tile.Queries = []dynatrace.DataExplorerQuery{*dataExplorerQuery}
+	return &tile, nil
+}
+
+// createKQGDataExplorerQuery creates a Data Explorer query for the metric, aggregation and entity selector of a Metrics API v2 SLI query.
+func createKQGDataExplorerQuery(project string, stage string, service string, query string) (*dynatrace.DataExplorerQuery, error) {
+	metricsQuery, _, err := parseMetricsSLIQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	metricSelector, err := metrics.ParseMetricSelector(metricsQuery.GetMetricSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transformation := range metricSelector.GetTransformations() {
+		if transformation.IsAggregation() || kqgTransformations[transformation.GetName()] {
+			continue
+		}
+
+		if transformation.GetName() == "splitBy" && transformation.GetArguments() == "" {
+			continue
+		}
+
+		return nil, fmt.Errorf("transformation %s cannot be represented by a Data Explorer tile", transformation)
+	}
+
+	aggregation, ok := metricSelector.GetAggregation()
+	if !ok {
+		return nil, fmt.Errorf("metric selector %s does not specify an aggregation", metricsQuery.GetMetricSelector())
+	}
+
+	spaceAggregation, err := getDataExplorerSpaceAggregation(aggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := createKQGDataExplorerFilter(project, stage, service, metricsQuery.GetEntitySelector())
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynatrace.DataExplorerQuery{
+		ID:               "A",
+		Metric:           metricSelector.GetMetricKey(),
+		SpaceAggregation: spaceAggregation,
+		TimeAggregation:  "DEFAULT",
+		SplitBy:          []string{},
+		FilterBy:         filter,
+	}, nil
+}
+
+// createKQGDataExplorerFilter creates the filter of a Data Explorer query for the entity selector of an SLI query.
+// Keptn placeholders are replaced in the same way as for metric events, i.e. $DEPLOYMENT refers to the primary deployment.
+// Data Explorer tiles are limited to a single filter, so the entity selector may either select entities by tags, which must all match, or by a single entity ID.
+func createKQGDataExplorerFilter(project string, stage string, service string, entitySelector string) (*dynatrace.DataExplorerFilter, error) {
+	entitySelector = common.ReplaceKeptnPlaceholders(entitySelector, newServiceEventContentAdapter(project, stage, service))
+	predicates, err := metrics.ParseEntitySelector(entitySelector)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse entity selector: %w", err)
+	}
+
+	entityType := dynatrace.ServiceEntityType
+	var tags []string
+	var entityIDs []string
+	for _, predicate := range predicates {
+		arguments := predicate.GetArguments()
+		switch {
+		case predicate.GetName() == "type" && len(arguments) == 1:
+			entityType = arguments[0]
+		case predicate.GetName() == "tag" && len(arguments) == 1:
+			tags = append(tags, arguments[0])
+		case predicate.GetName() == "entityId" && len(arguments) == 1:
+			entityIDs = append(entityIDs, arguments[0])
+		default:
+			return nil, fmt.Errorf("entity selector predicate %s cannot be represented by a Data Explorer tile", predicate.GetName())
+		}
+	}
+
+	filter := &dynatrace.DataExplorerFilter{
+		FilterOperator: "AND",
+		NestedFilters:  []dynatrace.DataExplorerFilter{},
+		Criteria:       []dynatrace.DataExplorerCriterion{},
+	}
+
+	entityFilter := dynatrace.DataExplorerFilter{
+		Filter:        "dt.entity." + strings.ToLower(entityType),
+		NestedFilters: []dynatrace.DataExplorerFilter{},
+	}
+
+	switch {
+	case len(entityIDs) > 0 && (len(entityIDs) > 1 || len(tags) > 0):
+		return nil, errors.New("entity selector combining an entity ID with further entity IDs or tags cannot be represented by a Data Explorer tile")
+
+	case len(entityIDs) == 1:
+		entityFilter.FilterType = "ID"
+		entityFilter.FilterOperator = "OR"
+		entityFilter.Criteria = []dynatrace.DataExplorerCriterion{{Value: entityIDs[0], Evaluator: "IN"}}
+		filter.NestedFilters = append(filter.NestedFilters, entityFilter)
+
+	case len(tags) > 0:
+		entityFilter.FilterType = "TAG"
+		entityFilter.FilterOperator = "AND"
+		for _, tag := range tags {
+			entityFilter.Criteria = append(entityFilter.Criteria, dynatrace.DataExplorerCriterion{Value: tag, Evaluator: "IN"})
+		}
+		filter.NestedFilters = append(filter.NestedFilters, entityFilter)
+	}
+
+	return filter, nil
+}
+
+// getDataExplorerSpaceAggregation returns the space aggregation of a Data Explorer query for the aggregation transformation of a metric selector.
+func getDataExplorerSpaceAggregation(aggregation metrics.Transformation) (string, error) {
+	if aggregation.GetName() != "percentile" {
+		return strings.ToUpper(aggregation.GetName()), nil
+	}
+
+	switch strings.TrimSpace(aggregation.GetArguments()) {
+	case "10":
+		return "PERCENTILE_10", nil
+	case "50":
+		return "MEDIAN", nil
+	case "75":
+		return "PERCENTILE_75", nil
+	case "90":
+		return "PERCENTILE_90", nil
+	default:
+		return "", fmt.Errorf("aggregation %s is not supported by Data Explorer tiles", aggregation)
+	}
+}
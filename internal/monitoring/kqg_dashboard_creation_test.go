@@ -0,0 +1,329 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	sli "github.com/keptn-contrib/dynatrace-service/internal/sli/dashboard"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+func Test_createKQGDashboard(t *testing.T) {
+	slos := &keptnlib.ServiceLevelObjectives{
+		Objectives: []*keptnlib.SLO{
+			{
+				SLI:     "response_time_p90",
+				Pass:    []*keptnlib.SLOCriteria{{Criteria: []string{"<600"}}, {Criteria: []string{"<+10%"}}},
+				Warning: []*keptnlib.SLOCriteria{{Criteria: []string{"<800"}}},
+				Weight:  2,
+				KeySLI:  true,
+			},
+			{
+				SLI:  "error_rate",
+				Pass: []*keptnlib.SLOCriteria{{Criteria: []string{"<= 1"}}},
+			},
+			{
+				SLI: "throughput_by_service",
+			},
+			{
+				SLI: "custom_usql",
+			},
+		},
+		TotalScore: &keptnlib.SLOScore{Pass: "90%", Warning: "75%"},
+		Comparison: &keptnlib.SLOComparison{
+			CompareWith:               "several_results",
+			IncludeResultWithScore:    "pass",
+			NumberOfComparisonResults: 3,
+			AggregateFunction:         "avg",
+		},
+	}
+
+	customQueries := keptn.NewCustomQueries(map[string]string{
+		"response_time_p90":     "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)&entitySelector=type(SERVICE),tag(keptn_service:carts)",
+		"error_rate":            "MV2;Byte;metricSelector=builtin:service.errors.total.rate:avg&entitySelector=type(SERVICE)",
+		"throughput_by_service": "metricSelector=builtin:service.requestCount.total:splitBy(\"dt.entity.service\"):sum",
+		"custom_usql":           "USQL;COLUMN_CHART;iOS;SELECT osVersion FROM usersession",
+	})
+
+	dashboard, skippedSLIs := createKQGDashboard("sockshop", "production", "carts", slos, customQueries)
+
+	assert.Equal(t, "KQG;project=sockshop;stage=production;service=carts", dashboard.DashboardMetadata.Name)
+	assert.ElementsMatch(t, []string{"throughput_by_service", "custom_usql"}, skippedSLIs)
+
+	if !assert.Equal(t, 3, len(dashboard.Tiles)) {
+		return
+	}
+
+	markdownTile := dashboard.Tiles[0]
+	assert.Equal(t, dynatrace.MarkdownTileType, markdownTile.TileType)
+	assert.Equal(t, "KQG.Total.Pass=90%;KQG.Total.Warning=75%;KQG.Compare.WithScore=pass;KQG.Compare.Results=3;KQG.Compare.Function=avg;\n\n"+sloFileMarker, markdownTile.Markdown)
+	assert.True(t, isKQGDashboardGeneratedByService(dashboard))
+
+	expectedQueries := []struct {
+		metric           string
+		spaceAggregation string
+		filter           *dynatrace.DataExplorerFilter
+	}{
+		{
+			metric:           "builtin:service.response.time",
+			spaceAggregation: "PERCENTILE_90",
+			filter: &dynatrace.DataExplorerFilter{
+				FilterOperator: "AND",
+				NestedFilters: []dynatrace.DataExplorerFilter{
+					{
+						Filter:         "dt.entity.service",
+						FilterType:     "TAG",
+						FilterOperator: "AND",
+						NestedFilters:  []dynatrace.DataExplorerFilter{},
+						Criteria: []dynatrace.DataExplorerCriterion{
+							{Value: "keptn_service:carts", Evaluator: "IN"},
+						},
+					},
+				},
+				Criteria: []dynatrace.DataExplorerCriterion{},
+			},
+		},
+		{
+			metric:           "builtin:service.errors.total.rate",
+			spaceAggregation: "AVG",
+			filter: &dynatrace.DataExplorerFilter{
+				FilterOperator: "AND",
+				NestedFilters:  []dynatrace.DataExplorerFilter{},
+				Criteria:       []dynatrace.DataExplorerCriterion{},
+			},
+		},
+	}
+
+	for i, tile := range dashboard.Tiles[1:] {
+		assert.Equal(t, dynatrace.DataExplorerTileType, tile.TileType)
+
+		// tile titles must be read back as the original objectives
+		objective, err := common.ParseSLOFromString(tile.Name)
+		if assert.NoError(t, err) {
+			assert.Equal(t, slos.Objectives[i].SLI, objective.SLI)
+			assert.Equal(t, len(slos.Objectives[i].Pass), len(objective.Pass))
+			assert.Equal(t, len(slos.Objectives[i].Warning), len(objective.Warning))
+		}
+
+		if assert.Equal(t, 1, len(tile.Queries)) {
+			assert.Equal(t, expectedQueries[i].metric, tile.Queries[0].Metric)
+			assert.Equal(t, expectedQueries[i].spaceAggregation, tile.Queries[0].SpaceAggregation)
+			assert.EqualValues(t, expectedQueries[i].filter, tile.Queries[0].FilterBy)
+		}
+	}
+
+	assert.Equal(t, dynatrace.Bounds{Top: 76, Left: 0, Width: 380, Height: 304}, dashboard.Tiles[1].Bounds)
+	assert.Equal(t, dynatrace.Bounds{Top: 76, Left: 380, Width: 380, Height: 304}, dashboard.Tiles[2].Bounds)
+}
+
+func Test_createKQGMarkdown_withoutTotalScoreAndComparison(t *testing.T) {
+	markdown := createKQGMarkdown(&keptnlib.ServiceLevelObjectives{})
+	assert.Equal(t, "KQG.Total.Pass=90%;KQG.Total.Warning=75%;\n\n"+sloFileMarker, markdown)
+}
+
+func Test_createKQGDataExplorerQuery_unsupportedQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{
+			name:  "no aggregation",
+			query: "metricSelector=builtin:service.response.time",
+		},
+		{
+			name:  "unsupported percentile",
+			query: "metricSelector=builtin:service.response.time:percentile(95)",
+		},
+		{
+			name:  "filter transformation",
+			query: "metricSelector=builtin:service.response.time:filter(eq(\"dt.entity.service\",\"SERVICE-1\")):avg",
+		},
+		{
+			name:  "split by dimension",
+			query: "metricSelector=builtin:service.response.time:splitBy(\"dt.entity.service\"):avg",
+		},
+		{
+			name:  "Problems API query",
+			query: "PV2;problemSelector=status(open)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := createKQGDataExplorerQuery("sockshop", "production", "carts", tt.query)
+			assert.Error(t, err)
+			assert.Nil(t, query)
+		})
+	}
+}
+
+func Test_createKQGDataExplorerFilter(t *testing.T) {
+	tests := []struct {
+		name           string
+		entitySelector string
+		want           []dynatrace.DataExplorerFilter
+		wantErr        bool
+	}{
+		{
+			name: "no entity selector",
+			want: []dynatrace.DataExplorerFilter{},
+		},
+		{
+			name:           "only entity type",
+			entitySelector: "type(SERVICE)",
+			want:           []dynatrace.DataExplorerFilter{},
+		},
+		{
+			name:           "tags with Keptn placeholders",
+			entitySelector: "type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT),tag(\"[Environment]owner:team-a\")",
+			want: []dynatrace.DataExplorerFilter{
+				{
+					Filter:         "dt.entity.service",
+					FilterType:     "TAG",
+					FilterOperator: "AND",
+					NestedFilters:  []dynatrace.DataExplorerFilter{},
+					Criteria: []dynatrace.DataExplorerCriterion{
+						{Value: "keptn_project:sockshop", Evaluator: "IN"},
+						{Value: "keptn_stage:production", Evaluator: "IN"},
+						{Value: "keptn_service:carts", Evaluator: "IN"},
+						{Value: "keptn_deployment:primary", Evaluator: "IN"},
+						{Value: "[Environment]owner:team-a", Evaluator: "IN"},
+					},
+				},
+			},
+		},
+		{
+			name:           "entity ID of other entity type",
+			entitySelector: "type(PROCESS_GROUP_INSTANCE),entityId(PROCESS_GROUP_INSTANCE-1234)",
+			want: []dynatrace.DataExplorerFilter{
+				{
+					Filter:         "dt.entity.process_group_instance",
+					FilterType:     "ID",
+					FilterOperator: "OR",
+					NestedFilters:  []dynatrace.DataExplorerFilter{},
+					Criteria: []dynatrace.DataExplorerCriterion{
+						{Value: "PROCESS_GROUP_INSTANCE-1234", Evaluator: "IN"},
+					},
+				},
+			},
+		},
+		{
+			name:           "entity ID and tag",
+			entitySelector: "type(SERVICE),entityId(SERVICE-1234),tag(keptn_service:$SERVICE)",
+			wantErr:        true,
+		},
+		{
+			name:           "several entity IDs",
+			entitySelector: "entityId(SERVICE-1234),entityId(SERVICE-5678)",
+			wantErr:        true,
+		},
+		{
+			name:           "unsupported predicate",
+			entitySelector: "type(SERVICE),entityName(carts)",
+			wantErr:        true,
+		},
+		{
+			name:           "invalid entity selector",
+			entitySelector: "type(SERVICE",
+			wantErr:        true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := createKQGDataExplorerFilter("sockshop", "production", "carts", tt.entitySelector)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, filter)
+				return
+			}
+
+			if assert.NoError(t, err) {
+				assert.Equal(t, "AND", filter.FilterOperator)
+				assert.EqualValues(t, tt.want, filter.NestedFilters)
+			}
+		})
+	}
+}
+
+// dashboardServingHandler serves a single dashboard and passes all other requests on to the wrapped handler.
+type dashboardServingHandler struct {
+	dashboard *dynatrace.Dashboard
+	handler   http.Handler
+}
+
+func (h *dashboardServingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != dynatrace.DashboardsPath+"/"+h.dashboard.ID {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	payload, err := json.Marshal(h.dashboard)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, _ = w.Write(payload)
+}
+
+// Test_createKQGDashboard_roundTrip tests that the SLIs read from a generated quality gate dashboard during an SLI evaluation select the same entities as the SLIs it was generated from.
+func Test_createKQGDashboard_roundTrip(t *testing.T) {
+	slos := &keptnlib.ServiceLevelObjectives{
+		Objectives: []*keptnlib.SLO{
+			{SLI: "response_time_p90", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{"<600"}}}, Weight: 1},
+			{SLI: "error_rate", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{"<=1"}}}, Weight: 1},
+			{SLI: "throughput", Weight: 1},
+		},
+	}
+
+	customQueries := keptn.NewCustomQueries(map[string]string{
+		"response_time_p90": "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)",
+		"error_rate":        "metricSelector=builtin:service.errors.total.rate:avg&entitySelector=type(SERVICE),entityId(SERVICE-1234)",
+		"throughput":        "metricSelector=builtin:service.requestCount.total:sum",
+	})
+
+	dashboard, skippedSLIs := createKQGDashboard("sockshop", "production", "carts", slos, customQueries)
+	if !assert.Empty(t, skippedSLIs) {
+		return
+	}
+	dashboard.ID = "12345678-1111-4444-8888-123456789012"
+
+	fileHandler := test.NewFileBasedURLHandler(t)
+	fileHandler.AddExact(dynatrace.MetricsPath+"/builtin:service.response.time", "./testdata/kqg_dashboard_creation/metric_service_response_time.json")
+	fileHandler.AddExact(dynatrace.MetricsPath+"/builtin:service.errors.total.rate", "./testdata/kqg_dashboard_creation/metric_service_errors_total_rate.json")
+	fileHandler.AddExact(dynatrace.MetricsPath+"/builtin:service.requestCount.total", "./testdata/kqg_dashboard_creation/metric_service_request_count_total.json")
+
+	httpClient, url, teardown := test.CreateHTTPSClient(&dashboardServingHandler{dashboard: dashboard, handler: fileHandler})
+	defer teardown()
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	dashboardSLOs, dashboardSLIs, err := sli.NewDefinitionParsing(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), newServiceEventContentAdapter("sockshop", "production", "carts")).GetSLIDefinitions(context.TODO(), dashboard.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.EqualValues(t,
+		map[string]string{
+			"response_time_p90": "MV2;MicroSecond;entitySelector=type(SERVICE),tag(\"keptn_project:sockshop\"),tag(\"keptn_stage:production\"),tag(\"keptn_service:carts\"),tag(\"keptn_deployment:primary\")&metricSelector=builtin:service.response.time:splitBy():percentile(90):names",
+			"error_rate":        "entitySelector=entityId(SERVICE-1234)&metricSelector=builtin:service.errors.total.rate:splitBy():avg:names",
+			"throughput":        "metricSelector=builtin:service.requestCount.total:splitBy():sum:names",
+		},
+		dashboardSLIs.Indicators)
+
+	if assert.Equal(t, len(slos.Objectives), len(dashboardSLOs.Objectives)) {
+		for i, objective := range dashboardSLOs.Objectives {
+			assert.EqualValues(t, slos.Objectives[i], objective)
+		}
+	}
+}
@@ -11,15 +11,17 @@ import (
 
 type ManagementZoneCreation struct {
 	client dynatrace.ClientInterface
+	naming *Naming
 }
 
-func NewManagementZoneCreation(client dynatrace.ClientInterface) *ManagementZoneCreation {
+func NewManagementZoneCreation(client dynatrace.ClientInterface, naming *Naming) *ManagementZoneCreation {
 	return &ManagementZoneCreation{
 		client: client,
+		naming: naming,
 	}
 }
 
-// Create creates a new management zone for the project.
+// Create creates the management zones of the project and its stages, and updates existing ones that differ from the generated management zones.
 func (mzc *ManagementZoneCreation) Create(ctx context.Context, project string, shipyard keptnv2.Shipyard) []ConfigResult {
 	// get existing management zones
 	managementZoneClient := dynatrace.NewManagementZonesClient(mzc.client)
@@ -30,23 +32,27 @@ func (mzc *ManagementZoneCreation) Create(ctx context.Context, project string, s
 	}
 
 	var managementZonesResults []ConfigResult
-	managementZoneResult := getOrCreateManagementZone(
+	managementZoneResult := getCreateOrUpdateManagementZone(
 		ctx,
 		managementZoneClient,
-		GetManagementZoneNameForProject(project),
-		func() *dynatrace.ManagementZone {
-			return createManagementZoneForProject(project)
+		mzc.naming.getProjectManagementZoneName(project),
+		defaultNaming.getProjectManagementZoneName(project),
+		project,
+		func(name string) *dynatrace.ManagementZone {
+			return createManagementZoneForProject(name, project)
 		},
 		managementZoneNames)
 	managementZonesResults = append(managementZonesResults, managementZoneResult)
 
 	for _, stage := range shipyard.Spec.Stages {
-		managementZone := getOrCreateManagementZone(
+		managementZone := getCreateOrUpdateManagementZone(
 			ctx,
 			managementZoneClient,
-			GetManagementZoneNameForProjectAndStage(project, stage.Name),
-			func() *dynatrace.ManagementZone {
-				return createManagementZoneForStage(project, stage.Name)
+			mzc.naming.getStageManagementZoneName(project, stage.Name),
+			defaultNaming.getStageManagementZoneName(project, stage.Name),
+			project,
+			func(name string) *dynatrace.ManagementZone {
+				return createManagementZoneForStage(name, project, stage.Name)
 			},
 			managementZoneNames)
 		managementZonesResults = append(managementZonesResults, managementZone)
@@ -55,21 +61,94 @@ func (mzc *ManagementZoneCreation) Create(ctx context.Context, project string, s
 	return managementZonesResults
 }
 
-func getOrCreateManagementZone(
+// Plan returns the changes Create would make to the management zones of the project without creating or updating them.
+func (mzc *ManagementZoneCreation) Plan(ctx context.Context, project string, shipyard keptnv2.Shipyard) []PlannedChange {
+	type plannedManagementZone struct {
+		name               string
+		defaultName        string
+		managementZoneFunc func(name string) *dynatrace.ManagementZone
+	}
+
+	managementZones := []plannedManagementZone{
+		{
+			name:        mzc.naming.getProjectManagementZoneName(project),
+			defaultName: defaultNaming.getProjectManagementZoneName(project),
+			managementZoneFunc: func(name string) *dynatrace.ManagementZone {
+				return createManagementZoneForProject(name, project)
+			},
+		},
+	}
+	for _, stage := range shipyard.Spec.Stages {
+		stageName := stage.Name
+		managementZones = append(managementZones, plannedManagementZone{
+			name:        mzc.naming.getStageManagementZoneName(project, stageName),
+			defaultName: defaultNaming.getStageManagementZoneName(project, stageName),
+			managementZoneFunc: func(name string) *dynatrace.ManagementZone {
+				return createManagementZoneForStage(name, project, stageName)
+			},
+		})
+	}
+
+	managementZoneClient := dynatrace.NewManagementZonesClient(mzc.client)
+	existingManagementZones, err := managementZoneClient.GetAll(ctx)
+	if err != nil {
+		var plannedChanges []PlannedChange
+		for _, managementZone := range managementZones {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(managementZone.name, err))
+		}
+		return plannedChanges
+	}
+
+	var plannedChanges []PlannedChange
+	for _, managementZone := range managementZones {
+		existingName, err := findManagementZoneName(ctx, managementZoneClient, existingManagementZones, managementZone.name, managementZone.defaultName, project)
+		if err != nil {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(managementZone.name, err))
+			continue
+		}
+
+		if existingName == "" {
+			plannedChanges = append(plannedChanges, PlannedChange{Name: managementZone.name, Action: PlannedChangeActionCreate})
+			continue
+		}
+
+		existingValue, _ := existingManagementZones.GetByName(existingName)
+		currentManagementZone, err := managementZoneClient.GetByID(ctx, existingValue.ID)
+		if err != nil {
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(existingName, err))
+			continue
+		}
+
+		plannedChanges = append(plannedChanges, newUpdatePlannedChange(existingName, currentManagementZone, managementZone.managementZoneFunc(existingName)))
+	}
+	return plannedChanges
+}
+
+// getCreateOrUpdateManagementZone creates the management zone with the name unless it already exists under the name or, if owned by the project, under the default name.
+// An existing management zone is updated if it differs from the generated one, keeping its name.
+func getCreateOrUpdateManagementZone(
 	ctx context.Context,
 	managementZoneClient *dynatrace.ManagementZonesClient,
 	managementZoneName string,
-	managementZoneFunc func() *dynatrace.ManagementZone,
+	defaultManagementZoneName string,
+	project string,
+	managementZoneFunc func(name string) *dynatrace.ManagementZone,
 	managementZoneNames *dynatrace.ManagementZones) ConfigResult {
-	if managementZoneNames != nil && managementZoneNames.Contains(managementZoneName) {
+	existingName, err := findManagementZoneName(ctx, managementZoneClient, managementZoneNames, managementZoneName, defaultManagementZoneName, project)
+	if err != nil {
+		log.WithError(err).Error("Failed to retrieve management zone")
 		return ConfigResult{
 			Name:    managementZoneName,
-			Success: true,
-			Message: "Management Zone '" + managementZoneName + "' was already available in your Tenant",
+			Success: false,
+			Message: "failed to retrieve management zone: " + err.Error(),
 		}
 	}
 
-	err := managementZoneClient.Create(ctx, managementZoneFunc())
+	if existingName != "" {
+		return updateManagementZoneIfChanged(ctx, managementZoneClient, existingName, managementZoneFunc(existingName), managementZoneNames)
+	}
+
+	err = managementZoneClient.Create(ctx, managementZoneFunc(managementZoneName))
 	if err != nil {
 		log.WithError(err).Error("Failed to create management zone")
 		return ConfigResult{
@@ -85,17 +164,83 @@ func getOrCreateManagementZone(
 	}
 }
 
-func GetManagementZoneNameForProjectAndStage(project string, stage string) string {
-	return GetManagementZoneNameForProject(project) + " " + stage
+// updateManagementZoneIfChanged updates the existing management zone with the name if it differs from the desired one.
+func updateManagementZoneIfChanged(ctx context.Context, managementZoneClient *dynatrace.ManagementZonesClient, name string, desiredManagementZone *dynatrace.ManagementZone, managementZones *dynatrace.ManagementZones) ConfigResult {
+	existingValue, _ := managementZones.GetByName(name)
+	currentManagementZone, err := managementZoneClient.GetByID(ctx, existingValue.ID)
+	if err != nil {
+		log.WithError(err).Error("Failed to retrieve management zone")
+		return ConfigResult{
+			Name:    name,
+			Success: false,
+			Message: "failed to retrieve management zone: " + err.Error(),
+		}
+	}
+
+	fieldChanges, err := diffJSON(currentManagementZone, desiredManagementZone)
+	if err != nil {
+		return ConfigResult{
+			Name:    name,
+			Success: false,
+			Message: "failed to compare management zone: " + err.Error(),
+		}
+	}
+
+	if len(fieldChanges) == 0 {
+		return ConfigResult{
+			Name:    name,
+			Success: true,
+			Message: "Management Zone '" + name + "' was already available in your Tenant",
+		}
+	}
+
+	err = managementZoneClient.Update(ctx, existingValue.ID, desiredManagementZone)
+	if err != nil {
+		log.WithError(err).Error("Failed to update management zone")
+		return ConfigResult{
+			Name:    name,
+			Success: false,
+			Message: "failed to update management zone: " + err.Error(),
+		}
+	}
+
+	return ConfigResult{
+		Name:    name,
+		Success: true,
+		Message: "Management Zone '" + name + "' was updated",
+	}
 }
 
-func GetManagementZoneNameForProject(project string) string {
-	return "Keptn: " + project
+// findManagementZoneName returns the name if a management zone with it exists, otherwise the default name if a management zone with it exists and has a rule for the Keptn project tag, otherwise an empty string.
+// A management zone with the default name but without such a rule is left alone, as it may belong to another installation on the same tenant.
+func findManagementZoneName(ctx context.Context, managementZoneClient *dynatrace.ManagementZonesClient, managementZones *dynatrace.ManagementZones, name string, defaultName string, project string) (string, error) {
+	if managementZones == nil {
+		return "", nil
+	}
+
+	if managementZones.Contains(name) {
+		return name, nil
+	}
+
+	value, exists := managementZones.GetByName(defaultName)
+	if name == defaultName || !exists {
+		return "", nil
+	}
+
+	managementZone, err := managementZoneClient.GetByID(ctx, value.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if !isManagementZoneOwnedByProject(managementZone, project) {
+		return "", nil
+	}
+	return defaultName, nil
 }
 
-func createManagementZoneForProject(project string) *dynatrace.ManagementZone {
+func createManagementZoneForProject(name string, project string) *dynatrace.ManagementZone {
 	managementZone := &dynatrace.ManagementZone{
-		Name: GetManagementZoneNameForProject(project),
+		Name: name,
 		Rules: []dynatrace.MZRules{
 			{
 				Type:             dynatrace.ServiceEntityType,
@@ -111,9 +256,9 @@ func createManagementZoneForProject(project string) *dynatrace.ManagementZone {
 	return managementZone
 }
 
-func createManagementZoneForStage(project string, stage string) *dynatrace.ManagementZone {
+func createManagementZoneForStage(name string, project string, stage string) *dynatrace.ManagementZone {
 	managementZone := &dynatrace.ManagementZone{
-		Name: GetManagementZoneNameForProjectAndStage(project, stage),
+		Name: name,
 		Rules: []dynatrace.MZRules{
 			{
 				Type:             dynatrace.ServiceEntityType,
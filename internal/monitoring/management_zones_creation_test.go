@@ -0,0 +1,88 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const managementZonesCreationTestDataFolder = "./testdata/management_zones_creation/"
+
+func createManagementZoneCreation(t *testing.T, handler http.Handler) (*ManagementZoneCreation, func()) {
+	httpClient, url, teardown := test.CreateHTTPSClient(handler)
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	if !assert.NoError(t, err) {
+		teardown()
+		t.FailNow()
+	}
+
+	return NewManagementZoneCreation(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), defaultNaming), teardown
+}
+
+// newManagementZonesHandler returns a handler for a tenant without Settings 2.0 API, containing an unchanged project management zone and a drifted management zone for stage dev.
+func newManagementZonesHandler(t *testing.T) *writeRecordingHandler {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddStartsWithError("/api/v2/settings/schemas/", 404, managementZonesCreationTestDataFolder+"schema_not_found.json")
+	handler.AddExact("/api/config/v1/managementZones", managementZonesCreationTestDataFolder+"management_zones.json")
+	handler.AddExact("/api/config/v1/managementZones/1", managementZonesCreationTestDataFolder+"management_zone_project.json")
+	handler.AddExact("/api/config/v1/managementZones/2", managementZonesCreationTestDataFolder+"management_zone_stage_drifted.json")
+	return &writeRecordingHandler{handler: handler}
+}
+
+var managementZonesCreationShipyard = keptnv2.Shipyard{
+	Spec: keptnv2.ShipyardSpec{
+		Stages: []keptnv2.Stage{{Name: "dev"}, {Name: "production"}},
+	},
+}
+
+// TestManagementZoneCreation_Plan tests that drifted management zones are planned as updates listing the changed fields.
+func TestManagementZoneCreation_Plan(t *testing.T) {
+	handler := newManagementZonesHandler(t)
+	creation, teardown := createManagementZoneCreation(t, handler)
+	defer teardown()
+
+	plannedChanges := creation.Plan(context.Background(), "sockshop", managementZonesCreationShipyard)
+
+	assert.Empty(t, handler.postPaths)
+	assert.Empty(t, handler.putPaths)
+	assert.EqualValues(t,
+		[]PlannedChange{
+			{Name: "Keptn: sockshop", Action: PlannedChangeActionNone},
+			{
+				Name:   "Keptn: sockshop dev",
+				Action: PlannedChangeActionUpdate,
+				FieldChanges: []FieldChange{
+					{Path: "rules[0].conditions[1].comparisonInfo.value.value", Current: "staging", Desired: "dev"},
+				},
+			},
+			{Name: "Keptn: sockshop production", Action: PlannedChangeActionCreate},
+		},
+		plannedChanges)
+}
+
+// TestManagementZoneCreation_Create tests that missing management zones are created and drifted ones are updated.
+func TestManagementZoneCreation_Create(t *testing.T) {
+	handler := newManagementZonesHandler(t)
+	creation, teardown := createManagementZoneCreation(t, handler)
+	defer teardown()
+
+	results := creation.Create(context.Background(), "sockshop", managementZonesCreationShipyard)
+
+	assert.EqualValues(t, []string{"/api/config/v1/managementZones"}, handler.postPaths)
+	assert.EqualValues(t, []string{"/api/config/v1/managementZones/2"}, handler.putPaths)
+	assert.EqualValues(t,
+		[]ConfigResult{
+			{Name: "Keptn: sockshop", Success: true, Message: "Management Zone 'Keptn: sockshop' was already available in your Tenant"},
+			{Name: "Keptn: sockshop dev", Success: true, Message: "Management Zone 'Keptn: sockshop dev' was updated"},
+			{Name: "Keptn: sockshop production", Success: true},
+		},
+		results)
+}
@@ -2,14 +2,20 @@ package monitoring
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/metrics"
+	v1metrics "github.com/keptn-contrib/dynatrace-service/internal/sli/v1/metrics"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/v1/mv2"
 	keptnlib "github.com/keptn/go-utils/pkg/lib"
 
 	log "github.com/sirupsen/logrus"
@@ -17,6 +23,16 @@ import (
 
 const keptnService = "keptn_service"
 const keptnDeployment = "keptn_deployment"
+const keptnPrimaryDeployment = "primary"
+
+// maxAlertingScopeTagFilters is the maximum number of tag filters in the alerting scope of a metric event
+const maxAlertingScopeTagFilters = 3
+
+// keptnMetricEventDescription is the description of all metric events created for Keptn SLOs
+const keptnMetricEventDescription = "Keptn SLI violated: The {metricname} value of {severity} was {alert_condition} your custom threshold of {threshold}."
+
+// keptnBaselineMetricEventDescription is the description of all metric events created for comparison-based criteria of Keptn SLOs
+const keptnBaselineMetricEventDescription = "Keptn SLI violated: The {metricname} value of {severity} was {alert_condition} its baseline."
 
 type CriteriaObject struct {
 	Operator        string
@@ -27,59 +43,123 @@ type CriteriaObject struct {
 }
 
 type MetricEventCreation struct {
-	dtClient  dynatrace.ClientInterface
-	kClient   keptn.ClientInterface
-	sloReader keptn.SLOReaderInterface
+	dtClient       dynatrace.ClientInterface
+	kClient        keptn.ClientInterface
+	sloReader      keptn.SLOReaderInterface
+	configProvider config.DynatraceConfigProvider
+	naming         *Naming
+	baselineModel  string
 }
 
-func NewMetricEventCreation(dynatraceClient dynatrace.ClientInterface, keptnClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface) MetricEventCreation {
+func NewMetricEventCreation(dynatraceClient dynatrace.ClientInterface, keptnClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, configProvider config.DynatraceConfigProvider, naming *Naming, baselineModel string) MetricEventCreation {
 	return MetricEventCreation{
-		dtClient:  dynatraceClient,
-		kClient:   keptnClient,
-		sloReader: sloReader,
+		dtClient:       dynatraceClient,
+		kClient:        keptnClient,
+		sloReader:      sloReader,
+		configProvider: configProvider,
+		naming:         naming,
+		baselineModel:  baselineModel,
 	}
 }
 
 // Create creates new metric events if SLOs are specified.
 func (mec MetricEventCreation) Create(ctx context.Context, project string, stage string, service string) []ConfigResult {
 	log.Info("Creating custom metric events for project SLIs")
-	slos, err := mec.sloReader.GetSLOs(project, stage, service)
+	managementZoneID, err := mec.getManagementZoneID(ctx, project, stage)
 	if err != nil {
-		log.WithError(err).WithFields(
-			log.Fields{
-				"service": service,
-				"stage":   stage}).Info("No SLOs defined for service. Skipping creation of custom metric events.")
+		log.WithError(err).WithFields(log.Fields{"project": project, "stage": stage}).Warn("Could not find management zone")
 		return nil
 	}
-	// get custom metrics for project
 
-	projectCustomQueries, err := mec.kClient.GetCustomQueries(project, stage, service)
-	if err != nil {
-		log.WithError(err).WithField("project", project).Error("Failed to get custom queries for project")
-		return nil
+	metricEventsClient := dynatrace.NewMetricEventsClient(mec.dtClient)
+	var metricsEventResults []ConfigResult
+	// try to create metric events using best effort.
+	for _, metricEvent := range mec.getMetricEvents(ctx, project, stage, service, managementZoneID) {
+		err := mec.createOrUpdateMetricEvent(ctx, metricEventsClient, metricEvent, service)
+		if err != nil {
+			log.WithError(err).WithField("metricName", metricEvent.Name).Error("Could not create metric event")
+			continue
+		}
+
+		log.WithField("name", metricEvent.Name).Info("Created metric event")
+		metricsEventResults = append(
+			metricsEventResults,
+			ConfigResult{
+				Name:    metricEvent.Name,
+				Success: true,
+			})
+	}
+
+	if len(metricsEventResults) > 0 {
+		// TODO: improve this?
+		log.Info("To review and enable the generated custom metric events, please go to: " + mec.dtClient.Credentials().GetTenant() + "/#settings/anomalydetection/metricevents")
 	}
 
-	managementZones, err := dynatrace.NewManagementZonesClient(mec.dtClient).GetAll(ctx)
-	var mzId int64 = -1
+	return metricsEventResults
+}
+
+// Plan returns the changes Create would make to the metric events of the service without writing them.
+// If the management zone of the stage does not exist yet, metric events are planned as if it had been created.
+func (mec MetricEventCreation) Plan(ctx context.Context, project string, stage string, service string) []PlannedChange {
+	managementZoneID, err := mec.getManagementZoneID(ctx, project, stage)
 	if err != nil {
-		log.WithError(err).WithFields(log.Fields{"project": project, "stage": stage}).Error("Could not retrieve management zones")
-		return nil
+		log.WithError(err).WithFields(log.Fields{"project": project, "stage": stage}).Debug("Could not find management zone, planning metric events without it")
 	}
 
-	// TODO 2021-08-20: check the logic below - if parsing management zone id does not work, we will continue anyway?
-	if zone, wasFound := managementZones.GetByName(GetManagementZoneNameForProjectAndStage(project, stage)); wasFound {
-		mzId, err = strconv.ParseInt(zone.ID, 10, 64)
-		if err != nil {
-			log.WithError(err).WithFields(log.Fields{"project": project, "stage": stage}).Warn("Could not parse management zone ID")
+	metricEventsClient := dynatrace.NewMetricEventsClient(mec.dtClient)
+	var plannedChanges []PlannedChange
+	for _, metricEvent := range mec.getMetricEvents(ctx, project, stage, service, managementZoneID) {
+		existingMetricEvent, err := mec.getExistingMetricEvent(ctx, metricEventsClient, metricEvent.Name, service)
+		switch {
+		case err != nil:
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(metricEvent.Name, err))
+		case existingMetricEvent == nil:
+			plannedChanges = append(plannedChanges, PlannedChange{Name: metricEvent.Name, Action: PlannedChangeActionCreate})
+		default:
+			plannedChanges = append(plannedChanges, newUpdatePlannedChange(metricEvent.Name, existingMetricEvent, getUpdatedMetricEvent(existingMetricEvent, metricEvent)))
 		}
-	} else {
-		log.WithError(err).WithFields(log.Fields{"project": project, "stage": stage}).Warn("Could not find management zone")
+	}
+	return plannedChanges
+}
+
+// getManagementZoneID returns the numeric ID of the management zone of the stage, also if the Settings 2.0 API is used.
+func (mec MetricEventCreation) getManagementZoneID(ctx context.Context, project string, stage string) (json.Number, error) {
+	managementZonesClient := dynatrace.NewManagementZonesClient(mec.dtClient)
+	managementZones, err := managementZonesClient.GetAll(ctx)
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve management zones: %w", err)
+	}
+
+	managementZoneName := mec.naming.getStageManagementZoneName(project, stage)
+	existingName, err := findManagementZoneName(ctx, managementZonesClient, managementZones, managementZoneName, defaultNaming.getStageManagementZoneName(project, stage), project)
+	if err != nil {
+		return "", fmt.Errorf("could not retrieve management zone: %w", err)
+	}
+
+	if existingName == "" || !managementZones.Contains(existingName) {
+		return "", fmt.Errorf("management zone '%s' does not exist", managementZoneName)
+	}
+
+	numericID, wasFound := managementZones.GetNumericIDByName(existingName)
+	if !wasFound {
+		return "", fmt.Errorf("could not determine numeric ID of management zone '%s'", existingName)
+	}
+	return json.Number(numericID), nil
+}
+
+// getMetricEvents returns the metric events for the pass criteria of the SLOs of the service.
+// Criteria that cannot be mapped to metric events are skipped.
+func (mec MetricEventCreation) getMetricEvents(ctx context.Context, project string, stage string, service string, managementZoneID json.Number) []*dynatrace.MetricEvent {
+	slos, projectCustomQueries, err := mec.getSLOsAndQueries(ctx, project, stage, service)
+	if err != nil {
+		log.WithError(err).WithFields(
+			log.Fields{
+				"service": service,
+				"stage":   stage}).Info("No SLOs defined for service. Skipping creation of custom metric events.")
 		return nil
 	}
 
-	metricEventsClient := dynatrace.NewMetricEventsClient(mec.dtClient)
-	var metricsEventResults []ConfigResult
-	// try to create metric events using best effort.
+	var metricEvents []*dynatrace.MetricEvent
 	for _, objective := range slos.Objectives {
 		query, err := projectCustomQueries.GetQueryByNameOrDefault(objective.SLI)
 		if err != nil {
@@ -93,38 +173,30 @@ func (mec MetricEventCreation) Create(ctx context.Context, project string, stage
 			continue
 		}
 
-		metricsEventResults = append(
-			metricsEventResults,
-			setupAllMetricEvents(ctx, metricEventsClient, project, stage, service, objective, query, mzId)...)
-
+		metricEvents = append(metricEvents, getMetricEventsForSLO(mec.naming, mec.baselineModel, project, stage, service, objective, query, managementZoneID)...)
 	}
 
-	if len(metricsEventResults) > 0 {
-		// TODO: improve this?
-		log.Info("To review and enable the generated custom metric events, please go to: " + mec.dtClient.Credentials().GetTenant() + "/#settings/anomalydetection/metricevents")
-	}
-
-	return metricsEventResults
+	return metricEvents
 }
 
-func setupAllMetricEvents(ctx context.Context, client *dynatrace.MetricEventsClient, project string, stage string, service string, slo *keptnlib.SLO, query string, managementZoneID int64) []ConfigResult {
-	var metricEventsResults []ConfigResult
+func getMetricEventsForSLO(naming *Naming, baselineModel string, project string, stage string, service string, slo *keptnlib.SLO, query string, managementZoneID json.Number) []*dynatrace.MetricEvent {
+	var metricEvents []*dynatrace.MetricEvent
 	for _, criteria := range slo.Pass {
 		for _, crit := range criteria.Criteria {
 
-			metricEventsResult, err := setupSingleMetricEvent(ctx, client, project, stage, service, slo.SLI, query, crit, managementZoneID)
+			metricEvent, err := getMetricEventForCriteria(naming, baselineModel, project, stage, service, slo.SLI, query, crit, managementZoneID)
 			if err != nil {
 				continue
 			}
 
-			metricEventsResults = append(metricEventsResults, *metricEventsResult)
+			metricEvents = append(metricEvents, metricEvent)
 		}
 	}
 
-	return metricEventsResults
+	return metricEvents
 }
 
-func setupSingleMetricEvent(ctx context.Context, client *dynatrace.MetricEventsClient, project string, stage string, service string, metric string, query string, crit string, managementZoneID int64) (*ConfigResult, error) {
+func getMetricEventForCriteria(naming *Naming, baselineModel string, project string, stage string, service string, metric string, query string, crit string, managementZoneID json.Number) (*dynatrace.MetricEvent, error) {
 	// criteria.Criteria
 	criteriaObject, err := parseCriteriaString(crit)
 	if err != nil {
@@ -134,11 +206,21 @@ func setupSingleMetricEvent(ctx context.Context, client *dynatrace.MetricEventsC
 	}
 
 	if criteriaObject.IsComparison {
-		// comparison-based criteria cannot be mapped to alerts
-		return nil, fmt.Errorf("comparison-based criteria cannot be mapped to alerts")
+		// comparison-based criteria are mapped to alerts using a baseline
+		newMetricEvent, err := createKeptnBaselineMetricEventDTO(project, stage, service, metric, naming.getBaselineMetricEventName(metric, project, stage, service), query, criteriaObject, baselineModel, managementZoneID)
+		if err != nil {
+			log.WithError(err).WithFields(
+				log.Fields{
+					"sli":      metric,
+					"criteria": crit,
+				}).Error("Could not create baseline metric event definition for criteria")
+			return nil, fmt.Errorf("could not create baseline metric event definition for criteria, sli: %s, criteria: %s", metric, crit)
+		}
+
+		return newMetricEvent, nil
 	}
 
-	newMetricEvent, err := createKeptnMetricEventDTO(project, stage, service, metric, query, crit, criteriaObject.Value, managementZoneID)
+	newMetricEvent, err := createKeptnMetricEventDTO(project, stage, service, metric, naming.getMetricEventName(metric, project, stage, service), query, crit, criteriaObject.Value, managementZoneID)
 	if err != nil {
 		// Error occurred but continue
 		log.WithError(err).WithFields(
@@ -149,31 +231,17 @@ func setupSingleMetricEvent(ctx context.Context, client *dynatrace.MetricEventsC
 		return nil, fmt.Errorf("could not create metric event definition for criteria, sli: %s, criteria: %s", metric, crit)
 	}
 
-	err = createOrUpdateMetricEvent(ctx, client, newMetricEvent)
-	if err != nil {
-		log.WithError(err).WithField("metricName", newMetricEvent.Name).Error("Could not create metric event")
-		return nil, fmt.Errorf("could not create metric event: %s", newMetricEvent.Name)
-	}
-
-	log.WithFields(log.Fields{"name": newMetricEvent.Name, "criteria": crit}).Info("Created metric event")
-	return &ConfigResult{
-		Name:    newMetricEvent.Name,
-		Success: true,
-	}, nil
+	return newMetricEvent, nil
 }
 
-func createOrUpdateMetricEvent(ctx context.Context, client *dynatrace.MetricEventsClient, newMetricEvent *dynatrace.MetricEvent) error {
-	existingMetricEvent, err := client.GetMetricEventByName(ctx, newMetricEvent.Name)
+func (mec MetricEventCreation) createOrUpdateMetricEvent(ctx context.Context, client *dynatrace.MetricEventsClient, newMetricEvent *dynatrace.MetricEvent, service string) error {
+	existingMetricEvent, err := mec.getExistingMetricEvent(ctx, client, newMetricEvent.Name, service)
 	if err != nil {
 		return err
 	}
 
 	if existingMetricEvent != nil {
-		// adapt all properties that have initially been defaulted to some value from previous (potentially modified event)
-		existingMetricEvent.Threshold = newMetricEvent.Threshold
-		existingMetricEvent.TagFilters = nil
-
-		err := client.Update(ctx, existingMetricEvent)
+		err := client.Update(ctx, getUpdatedMetricEvent(existingMetricEvent, newMetricEvent))
 		if err != nil {
 			return err
 		}
@@ -189,6 +257,36 @@ func createOrUpdateMetricEvent(ctx context.Context, client *dynatrace.MetricEven
 	return nil
 }
 
+// getExistingMetricEvent returns the existing metric event with the name or, if none exists, the one named by the default naming, which is renamed when it is updated.
+// A metric event with the default name is only returned if it was created by the dynatrace-service for the service, as it may otherwise belong to another installation on the same tenant.
+func (mec MetricEventCreation) getExistingMetricEvent(ctx context.Context, client *dynatrace.MetricEventsClient, name string, service string) (*dynatrace.MetricEvent, error) {
+	existingMetricEvent, err := client.GetMetricEventByName(ctx, name)
+	if err != nil || existingMetricEvent != nil {
+		return existingMetricEvent, err
+	}
+
+	defaultName := mec.naming.getDefaultMetricEventName(name)
+	if defaultName == name {
+		return nil, nil
+	}
+
+	existingMetricEvent, err = client.GetMetricEventByName(ctx, defaultName)
+	if err != nil || existingMetricEvent == nil || !isMetricEventOwnedByService(existingMetricEvent, service) {
+		return nil, err
+	}
+	return existingMetricEvent, nil
+}
+
+// getUpdatedMetricEvent returns the new metric event with the ID and metadata of the existing metric event, so that any drift of the existing metric event, e.g. of its query, alert condition, samples or alerting scope, is detected and reverted.
+// Only whether the metric event is enabled is kept from the existing metric event, as generated metric events are created disabled and have to be enabled by the user.
+func getUpdatedMetricEvent(existingMetricEvent *dynatrace.MetricEvent, newMetricEvent *dynatrace.MetricEvent) *dynatrace.MetricEvent {
+	updatedMetricEvent := *newMetricEvent
+	updatedMetricEvent.ID = existingMetricEvent.ID
+	updatedMetricEvent.Metadata = existingMetricEvent.Metadata
+	updatedMetricEvent.Enabled = existingMetricEvent.Enabled
+	return &updatedMetricEvent
+}
+
 func parseCriteriaString(criteria string) (*CriteriaObject, error) {
 	// example values: <+15%, <500, >-8%, =0
 	// possible operators: <, <=, =, >, >=
@@ -243,79 +341,88 @@ func parseCriteriaString(criteria string) (*CriteriaObject, error) {
 	return c, nil
 }
 
-var supportedAggregations = [...]string{"avg", "max", "min", "count", "sum", "value", "percentile"}
-
-func createKeptnMetricEventDTO(project string, stage string, service string, metric string, query string, condition string, threshold float64, managementZoneID int64) (*dynatrace.MetricEvent, error) {
+func createKeptnMetricEventDTO(project string, stage string, service string, metric string, name string, query string, condition string, threshold float64, managementZoneID json.Number) (*dynatrace.MetricEvent, error) {
+	meAlertCondition, err := parseAlertCondition(condition)
+	if err != nil {
+		return nil, err
+	}
 
-	// TODO: 2021-09-20: Check what parts are still needed
-	/*
-		need to map queries used by SLI-service to metric event definition.
-		example: builtin:service.response.time:merge("dt.entity.service"):percentile(90)?scope=tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)
+	metricEvent, err := newKeptnMetricEventDTO(project, stage, service, metric, name, query, managementZoneID)
+	if err != nil {
+		return nil, err
+	}
 
-		1. split by '?' and get first part => builtin:service.response.time:merge("dt.entity.service"):percentile(90)
-		2. split by ':' => builtin:service.response.time | merge("dt.entity.service") | percentile(90) => merge("dt.entity.service") is not needed
-		3. first part is the metricId and can be used for the Metric Event API => builtin:service.response.time
-		4. Aggregation is limited to: AVG, COUNT, MAX, MEDIAN, MIN, OF_INTEREST, OF_INTEREST_RATIO, OTHER, OTHER_RATIO, P90, SUM, VALUE
-	*/
+	metricEvent.AlertCondition = meAlertCondition
+	metricEvent.Threshold = threshold
+	return metricEvent, nil
+}
 
-	if project == "" || stage == "" || service == "" || metric == "" || query == "" {
-		return nil, errors.New("missing input parameter values")
+// createKeptnBaselineMetricEventDTO creates a metric event for a comparison-based criteria, e.g. <=+10%, using a baseline with a sensitivity matching the allowed relative change.
+func createKeptnBaselineMetricEventDTO(project string, stage string, service string, metric string, name string, query string, criteria *CriteriaObject, baselineModel string, managementZoneID json.Number) (*dynatrace.MetricEvent, error) {
+	meAlertCondition, err := getBaselineAlertCondition(criteria)
+	if err != nil {
+		return nil, err
 	}
 
-	query = strings.TrimPrefix(query, "metricSelector=")
-	// 1. split by '?' and get first part => builtin:service.response.time:merge("dt.entity.service"):percentile(90)
-	split := strings.Split(query, "?")
+	metricEvent, err := newKeptnMetricEventDTO(project, stage, service, metric, name, query, managementZoneID)
+	if err != nil {
+		return nil, err
+	}
 
-	// 2. split by ':' => builtin:service.response.time | merge("dt.entity.service") | percentile(90) => merge("dt.entity.service") is not needed/supported by MetricEvent API
-	splittedQuery := strings.Split(split[0], ":")
+	metricEvent.Description = keptnBaselineMetricEventDescription
+	metricEvent.AlertCondition = meAlertCondition
+	metricEvent.MonitoringStrategy = &dynatrace.MEMonitoringStrategy{
+		Type:              dynatrace.MonitoringStrategyAutoAdaptiveBaseline,
+		AlertCondition:    meAlertCondition,
+		Samples:           metricEvent.Samples,
+		ViolatingSamples:  metricEvent.ViolatingSamples,
+		DealertingSamples: metricEvent.DealertingSamples,
+	}
 
-	if len(splittedQuery) < 2 {
-		return nil, errors.New("invalid metricId")
+	sensitivity := getBaselineSensitivity(criteria.Value)
+	if baselineModel == seasonalBaselineModel {
+		metricEvent.MonitoringStrategy.Type = dynatrace.MonitoringStrategySeasonalBaseline
+		metricEvent.MonitoringStrategy.Tolerance = sensitivity.tolerance
+	} else {
+		metricEvent.MonitoringStrategy.NumberOfSignalFluctuations = sensitivity.signalFluctuations
 	}
-	metricId := splittedQuery[0] + ":" + splittedQuery[1]
-	meAggregation := ""
-	for _, transformation := range splittedQuery {
-		isSupportedAggregation := false
-		for _, aggregationType := range supportedAggregations {
-			if strings.Contains(strings.ToLower(transformation), aggregationType) {
-				isSupportedAggregation = true
-			}
-		}
 
-		if isSupportedAggregation {
-			meAggregation = getMetricEventAggregation(transformation)
+	return metricEvent, nil
+}
 
-			/*
-				if meAggregation == "" {
-					return nil, errors.New("unsupported aggregation type: " + transformation)
-				}
+// newKeptnMetricEventDTO creates a metric event with the specified name for the query of the metric without an alert condition.
+// The metric key and aggregation are taken from the metric selector of the query, tags and entity IDs of its entity selector are added to the alerting scope.
+func newKeptnMetricEventDTO(project string, stage string, service string, metric string, name string, query string, managementZoneID json.Number) (*dynatrace.MetricEvent, error) {
+	if project == "" || stage == "" || service == "" || metric == "" || query == "" {
+		return nil, errors.New("missing input parameter values")
+	}
 
-			*/
-		}
+	metricsQuery, unit, err := parseMetricsSLIQuery(query)
+	if err != nil {
+		return nil, err
 	}
-	/*
-		if meAggregation == "" {
-			return nil, errors.New("no aggregation provided in query")
-		}
-	*/
 
-	meAlertCondition, err := parseAlertCondition(condition)
+	metricSelector, err := metrics.ParseMetricSelector(metricsQuery.GetMetricSelector())
 	if err != nil {
 		return nil, err
 	}
 
+	// Aggregation is limited to: AVG, COUNT, MAX, MEDIAN, MIN, OF_INTEREST, OF_INTEREST_RATIO, OTHER, OTHER_RATIO, P90, SUM, VALUE
+	meAggregation := ""
+	if aggregation, ok := metricSelector.GetAggregation(); ok {
+		meAggregation = getMetricEventAggregation(aggregation.String())
+	}
+
 	metricEvent := &dynatrace.MetricEvent{
 		Metadata:          dynatrace.MEMetadata{},
-		MetricID:          metricId,
-		Name:              metric + " (Keptn." + project + "." + stage + "." + service + ")",
-		Description:       "Keptn SLI violated: The {metricname} value of {severity} was {alert_condition} your custom threshold of {threshold}.",
+		MetricID:          metricSelector.GetMetricKey(),
+		Name:              name,
+		Description:       keptnMetricEventDescription,
 		EventType:         "CUSTOM_ALERT",
 		Severity:          "CUSTOM_ALERT",
-		AlertCondition:    meAlertCondition,
 		Samples:           5, // taken from default value of custom metric events
 		ViolatingSamples:  3, // taken from default value of custom metric events
 		DealertingSamples: 5, // taken from default value of custom metric events
-		Threshold:         threshold,
 		Enabled:           false,
 		TagFilters:        nil, // not used anymore by MetricEvents API, replaced by AlertingScope
 		AlertingScope: []dynatrace.MEAlertingScope{
@@ -337,14 +444,26 @@ func createKeptnMetricEventDTO(project string, stage string, service string, met
 				TagFilter: &dynatrace.METagFilter{
 					Context: "CONTEXTLESS",
 					Key:     keptnDeployment,
-					Value:   "primary",
+					Value:   keptnPrimaryDeployment,
 				},
 			},
 		},
 	}
 
-	// LIMITATION: currently we do not have the possibility of specifying units => assume MILLI_SECONDS for response time metrics
-	if strings.Contains(metric, "time") {
+	entitySelector := common.ReplaceKeptnPlaceholders(metricsQuery.GetEntitySelector(), newServiceEventContentAdapter(project, stage, service))
+	metricEvent.AlertingScope, err = appendEntitySelectorAlertingScope(metricEvent.AlertingScope, entitySelector)
+	if err != nil {
+		return nil, err
+	}
+
+	// MV2 queries specify the unit of the metric, values are compared in milliseconds and kilobytes respectively
+	// LIMITATION: otherwise we do not have the possibility of specifying units => assume MILLI_SECONDS for response time metrics
+	switch {
+	case strings.EqualFold(unit, "MicroSecond"):
+		metricEvent.Unit = "MILLI_SECOND"
+	case strings.EqualFold(unit, "Byte"):
+		metricEvent.Unit = "KILO_BYTE"
+	case strings.Contains(metric, "time"):
 		metricEvent.Unit = "MILLI_SECOND"
 	}
 
@@ -355,6 +474,147 @@ func createKeptnMetricEventDTO(project string, stage string, service string, met
 	return metricEvent, nil
 }
 
+// parseMetricsSLIQuery parses an SLI query of the Metrics API v2 into a metrics query and the unit of MV2 queries.
+// Queries may use the MV2 format, e.g. MV2;MicroSecond;metricSelector=..., the current format, e.g. metricSelector=...&entitySelector=..., or the legacy format, e.g. builtin:service.response.time:percentile(90)?scope=...
+func parseMetricsSLIQuery(query string) (*metrics.Query, string, error) {
+	if strings.HasPrefix(query, mv2.MV2Prefix+";") {
+		mv2Query, err := mv2.NewQueryParser(query).Parse()
+		if err != nil {
+			return nil, "", fmt.Errorf("could not parse MV2 query: %w", err)
+		}
+
+		metricsQuery := mv2Query.GetQuery()
+		return &metricsQuery, mv2Query.GetUnit(), nil
+	}
+
+	metricsQuery, err := v1metrics.NewQueryParser(query).Parse()
+	if err == nil {
+		return metricsQuery, "", nil
+	}
+
+	metricsQuery, legacyErr := v1metrics.NewLegacyQueryParser(query).Parse()
+	if legacyErr != nil {
+		return nil, "", fmt.Errorf("unsupported query, only Metrics API v2 queries are supported: %w", err)
+	}
+	return metricsQuery, "", nil
+}
+
+// appendEntitySelectorAlertingScope appends the tags and the entity ID of the entity selector to the alerting scope.
+// Keptn tags are skipped, as the alerting scope already contains the management zone of the stage and the tags of the service and primary deployment.
+// Other predicates, e.g. type(SERVICE), are implied by the metric or can not be expressed by an alerting scope and are skipped.
+func appendEntitySelectorAlertingScope(alertingScope []dynatrace.MEAlertingScope, entitySelector string) ([]dynatrace.MEAlertingScope, error) {
+	predicates, err := metrics.ParseEntitySelector(entitySelector)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse entity selector: %w", err)
+	}
+
+	tagFilterCount := 0
+	for _, scope := range alertingScope {
+		if scope.FilterType == "TAG" {
+			tagFilterCount++
+		}
+	}
+
+	for _, predicate := range predicates {
+		arguments := predicate.GetArguments()
+		switch {
+		case predicate.GetName() == "entityId" && len(arguments) == 1:
+			alertingScope = append(alertingScope, dynatrace.MEAlertingScope{
+				FilterType: "ENTITY_ID",
+				EntityID:   arguments[0],
+			})
+
+		case predicate.GetName() == "tag" && len(arguments) == 1:
+			tagFilter, ok := newAlertingScopeTagFilter(arguments[0])
+			if !ok {
+				log.WithField("tag", arguments[0]).Debug("Skipping tag of entity selector in alerting scope of metric event")
+				continue
+			}
+
+			if tagFilterCount >= maxAlertingScopeTagFilters {
+				log.WithField("tag", arguments[0]).Warn("Skipping tag of entity selector as the alerting scope of the metric event already contains the maximum number of tag filters")
+				continue
+			}
+
+			tagFilterCount++
+			alertingScope = append(alertingScope, dynatrace.MEAlertingScope{
+				FilterType: "TAG",
+				TagFilter:  tagFilter,
+			})
+
+		default:
+			log.WithField("predicate", predicate.GetName()).Debug("Skipping predicate of entity selector in alerting scope of metric event")
+		}
+	}
+
+	return alertingScope, nil
+}
+
+// newAlertingScopeTagFilter returns the tag filter for a contextless tag, e.g. app:carts, unless it is a Keptn tag already covered by the alerting scope.
+func newAlertingScopeTagFilter(tag string) (*dynatrace.METagFilter, bool) {
+	if strings.HasPrefix(tag, "[") {
+		return nil, false
+	}
+
+	key, value := tag, ""
+	if i := strings.Index(tag, ":"); i >= 0 {
+		key, value = tag[:i], tag[i+1:]
+	}
+
+	switch key {
+	case dynatrace.KeptnProject, dynatrace.KeptnStage, keptnService, keptnDeployment:
+		return nil, false
+	}
+
+	return &dynatrace.METagFilter{
+		Context: "CONTEXTLESS",
+		Key:     key,
+		Value:   value,
+	}, true
+}
+
+const (
+	autoAdaptiveBaselineModel = "auto-adaptive"
+	seasonalBaselineModel     = "seasonal"
+)
+
+// baselineSensitivity is the sensitivity of a baseline, given as the number of signal fluctuations of an auto-adaptive baseline or the tolerance of a seasonal baseline.
+type baselineSensitivity struct {
+	signalFluctuations float64
+	tolerance          float64
+}
+
+// getBaselineSensitivity returns the sensitivity of the baseline for the relative change in percent allowed by a criteria.
+// The smaller the allowed change, the closer to the baseline an alert is raised.
+func getBaselineSensitivity(percentage float64) baselineSensitivity {
+	switch {
+	case percentage <= 5:
+		return baselineSensitivity{signalFluctuations: 1, tolerance: 2}
+	case percentage <= 20:
+		return baselineSensitivity{signalFluctuations: 2, tolerance: 4}
+	default:
+		return baselineSensitivity{signalFluctuations: 3, tolerance: 6}
+	}
+}
+
+// getBaselineAlertCondition returns the alert condition for a relative comparison-based criteria.
+// An allowed increase, e.g. <=+10%, alerts above the baseline and an allowed decrease, e.g. >=-10%, alerts below the baseline.
+func getBaselineAlertCondition(criteria *CriteriaObject) (string, error) {
+	if !criteria.CheckPercentage {
+		return "", errors.New("unsupported condition. only relative comparisons, e.g. <=+10%, can be mapped to baselines")
+	}
+
+	if strings.HasPrefix(criteria.Operator, "<") && criteria.CheckIncrease {
+		return "ABOVE", nil
+	}
+
+	if strings.HasPrefix(criteria.Operator, ">") && !criteria.CheckIncrease {
+		return "BELOW", nil
+	}
+
+	return "", errors.New("unsupported condition. only allowed increases, e.g. <=+10%, or decreases, e.g. >=-10%, can be mapped to baselines")
+}
+
 func parseAlertCondition(condition string) (string, error) {
 	meAlertCondition := ""
 	if strings.Contains(condition, "+") || strings.Contains(condition, "-") || strings.Contains(condition, "%") {
@@ -1,6 +1,11 @@
 package monitoring
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/stretchr/testify/assert"
+)
 
 func Test_getAlertCondition(t *testing.T) {
 	type args struct {
@@ -120,3 +125,173 @@ func Test_getMetricEventAggregation(t *testing.T) {
 		})
 	}
 }
+
+func Test_getBaselineAlertCondition(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "Expect ABOVE condition for allowed increase",
+			criteria: "<=+10%",
+			want:     "ABOVE",
+		},
+		{
+			name:     "Expect BELOW condition for allowed decrease",
+			criteria: ">-5%",
+			want:     "BELOW",
+		},
+		{
+			name:     "Expect error for absolute comparison",
+			criteria: "<+100",
+			wantErr:  true,
+		},
+		{
+			name:     "Expect error for required increase",
+			criteria: ">+10%",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			criteria, err := parseCriteriaString(tt.criteria)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			got, err := getBaselineAlertCondition(criteria)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_createKeptnBaselineMetricEventDTO(t *testing.T) {
+	const query = "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(95)"
+
+	criteria, err := parseCriteriaString("<=+10%")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	metricEvent, err := createKeptnBaselineMetricEventDTO("sockshop", "production", "carts", "response_time_p95", defaultNaming.getBaselineMetricEventName("response_time_p95", "sockshop", "production", "carts"), query, criteria, autoAdaptiveBaselineModel, "1234")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "response_time_p95 baseline (Keptn.sockshop.production.carts)", metricEvent.Name)
+		assert.Equal(t, "builtin:service.response.time", metricEvent.MetricID)
+		assert.EqualValues(t, &dynatrace.MEMonitoringStrategy{
+			Type:                       dynatrace.MonitoringStrategyAutoAdaptiveBaseline,
+			AlertCondition:             "ABOVE",
+			Samples:                    5,
+			ViolatingSamples:           3,
+			DealertingSamples:          5,
+			NumberOfSignalFluctuations: 2,
+		}, metricEvent.MonitoringStrategy)
+		assert.True(t, isMetricEventOwnedByService(metricEvent, "carts"))
+	}
+
+	criteria, err = parseCriteriaString(">=-5%")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	metricEvent, err = createKeptnBaselineMetricEventDTO("sockshop", "production", "carts", "throughput", defaultNaming.getBaselineMetricEventName("throughput", "sockshop", "production", "carts"), query, criteria, seasonalBaselineModel, "1234")
+	if assert.NoError(t, err) {
+		assert.Equal(t, dynatrace.MonitoringStrategySeasonalBaseline, metricEvent.MonitoringStrategy.Type)
+		assert.Equal(t, "BELOW", metricEvent.MonitoringStrategy.AlertCondition)
+		assert.EqualValues(t, 2, metricEvent.MonitoringStrategy.Tolerance)
+		assert.Zero(t, metricEvent.MonitoringStrategy.NumberOfSignalFluctuations)
+	}
+}
+
+func Test_createKeptnMetricEventDTO_queries(t *testing.T) {
+	defaultAlertingScope := []dynatrace.MEAlertingScope{
+		{FilterType: "MANAGEMENT_ZONE", ManagementZoneID: "1234"},
+		{FilterType: "TAG", TagFilter: &dynatrace.METagFilter{Context: "CONTEXTLESS", Key: "keptn_service", Value: "carts"}},
+		{FilterType: "TAG", TagFilter: &dynatrace.METagFilter{Context: "CONTEXTLESS", Key: "keptn_deployment", Value: "primary"}},
+	}
+
+	tests := []struct {
+		name                  string
+		query                 string
+		expectedMetricID      string
+		expectedAggregation   string
+		expectedUnit          string
+		expectedAlertingScope []dynatrace.MEAlertingScope
+		expectError           bool
+	}{
+		{
+			name:                  "metrics query",
+			query:                 "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(95)&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)",
+			expectedMetricID:      "builtin:service.response.time",
+			expectedAggregation:   "P90",
+			expectedUnit:          "MILLI_SECOND",
+			expectedAlertingScope: defaultAlertingScope,
+		},
+		{
+			name:                  "legacy query",
+			query:                 "builtin:service.response.time:merge(\"dt.entity.service\"):percentile(50)?scope=tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)",
+			expectedMetricID:      "builtin:service.response.time",
+			expectedAggregation:   "MEDIAN",
+			expectedUnit:          "MILLI_SECOND",
+			expectedAlertingScope: defaultAlertingScope,
+		},
+		{
+			name:                  "MV2 query",
+			query:                 "MV2;Byte;metricSelector=builtin:service.requestSize:splitBy():avg&entitySelector=type(SERVICE),tag(keptn_service:carts)",
+			expectedMetricID:      "builtin:service.requestSize",
+			expectedUnit:          "KILO_BYTE",
+			expectedAlertingScope: defaultAlertingScope,
+		},
+		{
+			name:             "data explorer query with entity ID and custom tag",
+			query:            "metricSelector=builtin:service.errors.server.rate:splitBy():avg:auto:sort(value(avg,descending)):limit(10)&entitySelector=type(SERVICE),entityId(\"SERVICE-1234567890ABCDEF\"),tag(\"app:carts\"),tag(\"[Kubernetes]namespace:sockshop\")",
+			expectedMetricID: "builtin:service.errors.server.rate",
+			expectedUnit:     "MILLI_SECOND",
+			expectedAlertingScope: append(append([]dynatrace.MEAlertingScope{}, defaultAlertingScope...),
+				dynatrace.MEAlertingScope{FilterType: "ENTITY_ID", EntityID: "SERVICE-1234567890ABCDEF"},
+				dynatrace.MEAlertingScope{FilterType: "TAG", TagFilter: &dynatrace.METagFilter{Context: "CONTEXTLESS", Key: "app", Value: "carts"}},
+			),
+		},
+		// Error cases below:
+		{
+			name:        "metric expression",
+			query:       "metricSelector=(builtin:service.errors.server.count:avg/builtin:service.requestCount.server:avg)&entitySelector=type(SERVICE)",
+			expectError: true,
+		},
+		{
+			name:        "USQL query",
+			query:       "USQL;COLUMN_CHART;iOS 12.1.4;SELECT osVersion, AVG(duration) FROM usersession GROUP BY osVersion",
+			expectError: true,
+		},
+		{
+			name:        "SLO query",
+			query:       "SLO;7d2ebf7f-5f86-3f47-8e8b-8e1e4d8e3a8c",
+			expectError: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			metricEvent, err := createKeptnMetricEventDTO("sockshop", "production", "carts", "response_time", defaultNaming.getMetricEventName("response_time", "sockshop", "production", "carts"), tc.query, "<=600", 600, "1234")
+			if tc.expectError {
+				assert.Error(t, err)
+				assert.Nil(t, metricEvent)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tc.expectedMetricID, metricEvent.MetricID)
+			assert.Equal(t, tc.expectedAggregation, metricEvent.AggregationType)
+			assert.Equal(t, tc.expectedUnit, metricEvent.Unit)
+			assert.EqualValues(t, tc.expectedAlertingScope, metricEvent.AlertingScope)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/dashboard"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// getSLOsAndQueries returns the SLOs of the service and the queries of their SLIs.
+// If a dashboard is configured in the dynatrace.conf.yaml of the service, both are resolved from the dashboard, otherwise slo.yaml and sli.yaml are used.
+func (mec MetricEventCreation) getSLOsAndQueries(ctx context.Context, project string, stage string, service string) (*keptnlib.ServiceLevelObjectives, *keptn.CustomQueries, error) {
+	eventData := newServiceEventContentAdapter(project, stage, service)
+
+	sliDashboard := ""
+	dynatraceConfig, err := mec.configProvider.GetDynatraceConfig(eventData)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{"stage": stage, "service": service}).Debug("Could not load Dynatrace config of service, using SLIs from sli.yaml")
+	} else {
+		sliDashboard = dynatraceConfig.Dashboard
+	}
+
+	if sliDashboard != "" {
+		return mec.getDashboardSLOsAndQueries(ctx, eventData, sliDashboard)
+	}
+
+	slos, err := mec.sloReader.GetSLOs(project, stage, service)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read SLOs: %w", err)
+	}
+
+	customQueries, err := mec.kClient.GetCustomQueries(project, stage, service)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get custom queries: %w", err)
+	}
+
+	return slos, customQueries, nil
+}
+
+// getDashboardSLOsAndQueries returns the SLOs and SLI queries defined by the tiles of the configured dashboard, which may either be a dashboard ID or query.
+// Only the tile definitions are parsed, the SLI queries are not executed.
+func (mec MetricEventCreation) getDashboardSLOsAndQueries(ctx context.Context, eventData *serviceEventContentAdapter, sliDashboard string) (*keptnlib.ServiceLevelObjectives, *keptn.CustomQueries, error) {
+	slos, slis, err := dashboard.NewDefinitionParsing(mec.dtClient, eventData).GetSLIDefinitions(ctx, sliDashboard)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not get SLIs from dashboard: %w", err)
+	}
+
+	if len(slos.Objectives) == 0 || len(slis.Indicators) == 0 {
+		return nil, nil, errors.New("dashboard does not define any SLOs")
+	}
+
+	return slos, keptn.NewCustomQueries(slis.Indicators), nil
+}
+
+// serviceEventContentAdapter is an adapter.EventContentAdapter for a service in a stage, used to read its configuration and SLI dashboard outside of an event.
+type serviceEventContentAdapter struct {
+	project string
+	stage   string
+	service string
+}
+
+func newServiceEventContentAdapter(project string, stage string, service string) *serviceEventContentAdapter {
+	return &serviceEventContentAdapter{
+		project: project,
+		stage:   stage,
+		service: service,
+	}
+}
+
+// GetShKeptnContext returns the shkeptncontext
+func (a serviceEventContentAdapter) GetShKeptnContext() string {
+	return ""
+}
+
+// GetSource returns the source specified in the CloudEvent context
+func (a serviceEventContentAdapter) GetSource() string {
+	return ""
+}
+
+// GetEvent returns the event type
+func (a serviceEventContentAdapter) GetEvent() string {
+	return ""
+}
+
+// GetProject returns the project
+func (a serviceEventContentAdapter) GetProject() string {
+	return a.project
+}
+
+// GetStage returns the stage
+func (a serviceEventContentAdapter) GetStage() string {
+	return a.stage
+}
+
+// GetService returns the service
+func (a serviceEventContentAdapter) GetService() string {
+	return a.service
+}
+
+// GetDeployment returns the name of the deployment, metric events always alert on the primary deployment
+func (a serviceEventContentAdapter) GetDeployment() string {
+	return keptnPrimaryDeployment
+}
+
+// GetTestStrategy returns the used test strategy
+func (a serviceEventContentAdapter) GetTestStrategy() string {
+	return ""
+}
+
+// GetDeploymentStrategy returns the used deployment strategy
+func (a serviceEventContentAdapter) GetDeploymentStrategy() string {
+	return ""
+}
+
+// GetLabels returns a map of labels
+func (a serviceEventContentAdapter) GetLabels() map[string]string {
+	return nil
+}
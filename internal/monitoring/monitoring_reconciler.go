@@ -0,0 +1,303 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+)
+
+const monitoringDriftMetricKey = "keptn.monitoring.drift"
+
+const (
+	taggingRuleEntityType         = "tagging_rule"
+	managementZoneEntityType      = "management_zone"
+	metricEventEntityType         = "metric_event"
+	dashboardEntityType           = "dashboard"
+	problemNotificationEntityType = "problem_notification"
+	sloEntityType                 = "slo"
+)
+
+// ReconciliationClientFactory defines a factory that can create the clients used to configure the monitoring of a Keptn project.
+type ReconciliationClientFactory interface {
+	// CreateClients creates a dynatrace.ClientInterface using the credentials of the project and a keptn.ClientInterface for the project or returns an error.
+	CreateClients(ctx context.Context, project string) (dynatrace.ClientInterface, keptn.ClientInterface, error)
+}
+
+type defaultReconciliationClientFactory struct {
+	configProvider config.DynatraceConfigProvider
+}
+
+// CreateClients creates the clients for the project as if a configure monitoring event had been received for it.
+func (f defaultReconciliationClientFactory) CreateClients(ctx context.Context, project string) (dynatrace.ClientInterface, keptn.ClientInterface, error) {
+	event := cloudevents.NewEvent()
+	event.SetID("reconcile-" + project)
+	event.SetType(keptnlib.ConfigureMonitoringEventType)
+	event.SetSource("dynatrace-service")
+	event.SetExtension("shkeptncontext", "reconcile-"+project)
+	err := event.SetData(cloudevents.ApplicationJSON, keptnlib.ConfigureMonitoringEventData{Type: "dynatrace", Project: project})
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not set data: %w", err)
+	}
+
+	configureMonitoringAdapter, err := NewConfigureMonitoringAdapterFromEvent(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dynatraceConfig, err := f.configProvider.GetDynatraceConfig(configureMonitoringAdapter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load Dynatrace config: %w", err)
+	}
+
+	credentialsProvider, err := credentials.NewDefaultDynatraceK8sSecretReader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dynatraceCredentials, err := credentialsProvider.GetDynatraceCredentials(ctx, dynatraceConfig.DtCreds)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load Dynatrace credentials: %w", err)
+	}
+
+	kClient, err := keptn.NewDefaultClient(event)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dynatrace.NewClient(dynatraceCredentials), kClient, nil
+}
+
+// MonitoringReconciler periodically compares the Dynatrace configuration generated for Keptn projects with the tenant.
+// Drift is reported via logs and metrics and, if enabled, corrected by re-applying the configuration.
+type MonitoringReconciler struct {
+	projectClient  keptn.ProjectClientInterface
+	sloReader      keptn.SLOReaderInterface
+	serviceClient  keptn.ServiceClientInterface
+	configProvider config.DynatraceConfigProvider
+	clientFactory  ReconciliationClientFactory
+	projects       []string
+	apply          bool
+}
+
+// NewDefaultMonitoringReconciler creates a new default MonitoringReconciler.
+func NewDefaultMonitoringReconciler() *MonitoringReconciler {
+	clientSet := keptn.NewClientFactory()
+	configClient := keptn.NewConfigClient(clientSet.CreateResourceClient())
+	configProvider := config.NewDynatraceConfigGetter(configClient)
+
+	return &MonitoringReconciler{
+		projectClient:  clientSet.CreateProjectClient(),
+		sloReader:      configClient,
+		serviceClient:  clientSet.CreateServiceClient(),
+		configProvider: configProvider,
+		clientFactory: defaultReconciliationClientFactory{
+			configProvider: configProvider,
+		},
+		projects: env.GetMonitoringReconciliationProjects(),
+		apply:    env.IsMonitoringReconciliationApplyEnabled(),
+	}
+}
+
+// Run runs the monitoring reconciler which does not return unless cancelled.
+// Cancelling runCtx will stop any new reconciliation runs, cancelling reconciliationCtx will stop an in progress reconciliation.
+func (r *MonitoringReconciler) Run(runCtx context.Context, reconciliationCtx context.Context) {
+	reconciliationInterval := env.GetMonitoringReconciliationInterval()
+	log.WithFields(log.Fields{"reconciliationInterval": reconciliationInterval, "apply": r.apply}).Info("Monitoring Reconciler will reconcile periodically")
+	for {
+		r.reconcile(reconciliationCtx)
+
+		select {
+		case <-runCtx.Done():
+			log.Info("Monitoring Reconciler has terminated")
+			return
+
+		case <-time.After(time.Duration(reconciliationInterval) * time.Second):
+		}
+
+		log.WithField("delaySeconds", reconciliationInterval).Info("Reconciling monitoring")
+	}
+}
+
+func (r *MonitoringReconciler) reconcile(ctx context.Context) {
+	projects := r.projects
+	if len(projects) == 0 {
+		var err error
+		projects, err = r.projectClient.GetProjectNames()
+		if err != nil {
+			log.WithError(err).Error("Could not get Keptn projects to reconcile")
+			return
+		}
+	}
+
+	for _, project := range projects {
+		err := r.reconcileProject(ctx, project)
+		if err != nil {
+			log.WithError(err).WithField("project", project).Error("Could not reconcile monitoring of project")
+		}
+	}
+}
+
+// reconcileProject plans the configuration of the project, reports any drift and re-applies the configuration of drifted entity types if enabled.
+func (r *MonitoringReconciler) reconcileProject(ctx context.Context, project string) error {
+	dtClient, kClient, err := r.clientFactory.CreateClients(ctx, project)
+	if err != nil {
+		return err
+	}
+
+	shipyard, err := kClient.GetShipyard()
+	if err != nil {
+		return err
+	}
+
+	cfg := NewConfiguration(dtClient, kClient, r.sloReader, r.serviceClient, r.configProvider)
+	settings, err := cfg.getMonitoringSettings(project, *shipyard)
+	if err != nil {
+		return err
+	}
+
+	drifts := newEntityTypeDrifts(cfg.planMonitoring(ctx, settings, project, *shipyard), settings)
+	if len(drifts) == 0 {
+		log.WithField("project", project).Debug("Generation of tagging rules, management zones and metric events is disabled, nothing to reconcile")
+		return nil
+	}
+
+	logEntityTypeDrifts(project, drifts)
+
+	err = dynatrace.NewMetricsIngestClient(dtClient).Ingest(ctx, createMonitoringDriftMetricLines(project, drifts))
+	if err != nil {
+		log.WithError(err).WithField("project", project).Error("Could not send monitoring drift metrics to Dynatrace")
+	}
+
+	if !r.apply {
+		return nil
+	}
+
+	for _, drift := range drifts {
+		if len(drift.changes) == 0 {
+			continue
+		}
+
+		log.WithFields(log.Fields{"project": project, "entityType": drift.entityType}).Info("Re-applying drifted monitoring configuration")
+		logConfigResults(project, drift.entityType, reapplyEntityType(ctx, cfg, settings, project, *shipyard, drift.entityType))
+	}
+
+	return nil
+}
+
+// reapplyEntityType re-applies the configuration of all entities of the entity type using the same code as ConfigureMonitoring.
+func reapplyEntityType(ctx context.Context, cfg *Configuration, settings *monitoringSettings, project string, shipyard keptnv2.Shipyard, entityType string) []ConfigResult {
+	switch entityType {
+	case taggingRuleEntityType:
+		return NewAutoTagCreation(cfg.dtClient).Create(ctx)
+	case managementZoneEntityType:
+		return NewManagementZoneCreation(cfg.dtClient, settings.naming).Create(ctx, project, settings.getManagementZoneShipyard(shipyard))
+	case metricEventEntityType:
+		var metricEvents []ConfigResult
+		for _, stage := range shipyard.Spec.Stages {
+			metricEvents = append(metricEvents, cfg.createMetricEventsForStage(ctx, settings, project, stage)...)
+		}
+		return metricEvents
+	}
+	return nil
+}
+
+func logConfigResults(project string, entityType string, results []ConfigResult) {
+	for _, result := range results {
+		logger := log.WithFields(log.Fields{"project": project, "entityType": entityType, "name": result.Name})
+		if !result.Success {
+			logger.WithField("message", result.Message).Error("Could not re-apply monitoring configuration")
+			continue
+		}
+		logger.Debug("Re-applied monitoring configuration")
+	}
+}
+
+// entityTypeDrift contains the planned changes of the entities of an entity type that have drifted from their desired configuration.
+type entityTypeDrift struct {
+	entityType string
+	changes    []PlannedChange
+}
+
+// newEntityTypeDrifts returns the drift of each entity type whose generation is enabled, in the order the entity types are configured.
+// Entities that would be created have been deleted and entities that would be updated have been modified in Dynatrace.
+func newEntityTypeDrifts(plan *ConfigurationPlan, settings *monitoringSettings) []entityTypeDrift {
+	var drifts []entityTypeDrift
+	if settings.project.taggingRules {
+		drifts = append(drifts, entityTypeDrift{entityType: taggingRuleEntityType, changes: getDriftedChanges(plan.TaggingRules)})
+	}
+
+	if settings.isManagementZonesGenerationEnabled() {
+		drifts = append(drifts, entityTypeDrift{entityType: managementZoneEntityType, changes: getDriftedChanges(plan.ManagementZones)})
+	}
+
+	if settings.isMetricEventsGenerationEnabled() {
+		drifts = append(drifts, entityTypeDrift{entityType: metricEventEntityType, changes: getDriftedChanges(plan.MetricEvents)})
+	}
+
+	return drifts
+}
+
+func getDriftedChanges(plannedChanges []PlannedChange) []PlannedChange {
+	var driftedChanges []PlannedChange
+	for _, plannedChange := range plannedChanges {
+		if plannedChange.Error != "" {
+			log.WithFields(log.Fields{"name": plannedChange.Name, "error": plannedChange.Error}).Warn("Could not check entity for drift")
+			continue
+		}
+
+		if plannedChange.Action == PlannedChangeActionCreate || plannedChange.Action == PlannedChangeActionUpdate {
+			driftedChanges = append(driftedChanges, plannedChange)
+		}
+	}
+	return driftedChanges
+}
+
+func logEntityTypeDrifts(project string, drifts []entityTypeDrift) {
+	driftCount := 0
+	for _, drift := range drifts {
+		for _, change := range drift.changes {
+			driftCount++
+
+			logger := log.WithFields(log.Fields{"project": project, "entityType": drift.entityType, "name": change.Name})
+			if change.Action == PlannedChangeActionCreate {
+				logger.Warn("Monitoring configuration has drifted: entity is missing")
+				continue
+			}
+
+			for _, fieldChange := range change.FieldChanges {
+				logger.WithFields(log.Fields{
+					"field":   fieldChange.Path,
+					"current": formatJSONValue(fieldChange.Current),
+					"desired": formatJSONValue(fieldChange.Desired),
+				}).Warn("Monitoring configuration has drifted: field was modified")
+			}
+		}
+	}
+
+	if driftCount == 0 {
+		log.WithField("project", project).Info("Monitoring configuration has not drifted")
+	}
+}
+
+// createMonitoringDriftMetricLines creates a metric line with the number of drifted entities for each entity type.
+func createMonitoringDriftMetricLines(project string, drifts []entityTypeDrift) []*dynatrace.MetricLine {
+	lines := make([]*dynatrace.MetricLine, 0, len(drifts))
+	for _, drift := range drifts {
+		lines = append(lines,
+			dynatrace.NewMetricLine(monitoringDriftMetricKey, float64(len(drift.changes))).
+				AddDimension("project", project).
+				AddDimension("entity_type", drift.entityType))
+	}
+	return lines
+}
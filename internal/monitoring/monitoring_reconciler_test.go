@@ -0,0 +1,184 @@
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const monitoringReconcilerTestDataFolder = "./testdata/monitoring_reconciler/"
+
+// reconciliationClientFactoryMock returns the same clients for any project.
+type reconciliationClientFactoryMock struct {
+	dtClient dynatrace.ClientInterface
+	kClient  keptn.ClientInterface
+}
+
+func (f *reconciliationClientFactoryMock) CreateClients(_ context.Context, _ string) (dynatrace.ClientInterface, keptn.ClientInterface, error) {
+	return f.dtClient, f.kClient, nil
+}
+
+// shipyardKeptnClientMock only provides the shipyard of the project.
+type shipyardKeptnClientMock struct {
+	shipyard *keptnv2.Shipyard
+}
+
+func (m *shipyardKeptnClientMock) GetCustomQueries(_ string, _ string, _ string) (*keptn.CustomQueries, error) {
+	panic("GetCustomQueries() should not be needed in this mock!")
+}
+
+func (m *shipyardKeptnClientMock) GetShipyard() (*keptnv2.Shipyard, error) {
+	return m.shipyard, nil
+}
+
+func (m *shipyardKeptnClientMock) SendCloudEvent(_ adapter.CloudEventFactoryInterface) error {
+	panic("SendCloudEvent() should not be needed in this mock!")
+}
+
+// metricsIngestRecordingHandler records the payloads sent to the metrics ingest endpoint before passing requests on to the wrapped handler.
+type metricsIngestRecordingHandler struct {
+	handler         http.Handler
+	ingestedPayload []string
+}
+
+func (h *metricsIngestRecordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/v2/metrics/ingest" {
+		payload, _ := ioutil.ReadAll(r.Body)
+		h.ingestedPayload = append(h.ingestedPayload, string(payload))
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// TestMonitoringReconciler_reconcileProject tests that drifted tagging rules are reported as a metric and only re-applied if enabled.
+func TestMonitoringReconciler_reconcileProject(t *testing.T) {
+	t.Setenv("GENERATE_TAGGING_RULES", "true")
+	t.Setenv("GENERATE_MANAGEMENT_ZONES", "false")
+	t.Setenv("GENERATE_METRIC_EVENTS", "false")
+
+	tests := []struct {
+		name              string
+		apply             bool
+		expectedPostPaths []string
+		expectedPutPaths  []string
+	}{
+		{
+			name:  "apply disabled leaves drifted tagging rules unchanged",
+			apply: false,
+		},
+		{
+			name:              "apply enabled re-creates missing and updates drifted tagging rules",
+			apply:             true,
+			expectedPostPaths: []string{"/api/config/v1/autoTags"},
+			expectedPutPaths:  []string{"/api/config/v1/autoTags/12"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			autoTagsHandler := newAutoTagsHandler(t)
+			autoTagsHandler.handler.(*test.FileBasedURLHandler).AddExact("/api/v2/metrics/ingest", monitoringReconcilerTestDataFolder+"metrics_ingest.json")
+			handler := &metricsIngestRecordingHandler{handler: autoTagsHandler}
+
+			httpClient, url, teardown := test.CreateHTTPSClient(handler)
+			defer teardown()
+
+			dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			reconciler := &MonitoringReconciler{
+				configProvider: &dynatraceConfigProviderMock{},
+				clientFactory: &reconciliationClientFactoryMock{
+					dtClient: dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient),
+					kClient: &shipyardKeptnClientMock{
+						shipyard: &keptnv2.Shipyard{Spec: keptnv2.ShipyardSpec{Stages: []keptnv2.Stage{{Name: "production"}}}},
+					},
+				},
+				apply: tt.apply,
+			}
+
+			err = reconciler.reconcileProject(context.Background(), "sockshop")
+			assert.NoError(t, err)
+
+			// the missing keptn_deployment and the drifted keptn_stage tagging rules are reported
+			assert.EqualValues(t, []string{`keptn.monitoring.drift,project="sockshop",entity_type="tagging_rule" 2`}, handler.ingestedPayload)
+
+			// only the metric ingestion is sent as a POST request, unless the drifted tagging rules are re-applied
+			assert.EqualValues(t, append([]string{"/api/v2/metrics/ingest"}, tt.expectedPostPaths...), autoTagsHandler.postPaths)
+			assert.EqualValues(t, tt.expectedPutPaths, autoTagsHandler.putPaths)
+		})
+	}
+}
+
+func Test_newEntityTypeDrifts(t *testing.T) {
+	t.Setenv("GENERATE_TAGGING_RULES", "true")
+	t.Setenv("GENERATE_MANAGEMENT_ZONES", "true")
+	t.Setenv("GENERATE_METRIC_EVENTS", "false")
+
+	plan := &ConfigurationPlan{
+		TaggingRules: []PlannedChange{
+			{Name: "keptn_service", Action: PlannedChangeActionNone},
+			{Name: "keptn_stage", Action: PlannedChangeActionCreate},
+		},
+		ManagementZones: []PlannedChange{
+			{Name: "Keptn: sockshop", Action: PlannedChangeActionNone},
+			{Name: "Keptn: sockshop production", Error: "could not retrieve management zones"},
+		},
+		Dashboard: []PlannedChange{
+			{Name: "sockshop@keptn: Digital Delivery & Operations Dashboard", Action: PlannedChangeActionReplace},
+		},
+	}
+
+	settings := &monitoringSettings{
+		naming:  defaultNaming,
+		project: newDefaultGenerationSettings(),
+		stages:  map[string]generationSettings{"production": newDefaultGenerationSettings()},
+	}
+
+	drifts := newEntityTypeDrifts(plan, settings)
+	assert.EqualValues(t,
+		[]entityTypeDrift{
+			{entityType: taggingRuleEntityType, changes: []PlannedChange{{Name: "keptn_stage", Action: PlannedChangeActionCreate}}},
+			{entityType: managementZoneEntityType, changes: nil},
+		},
+		drifts)
+}
+
+func Test_getDriftedChanges(t *testing.T) {
+	update := PlannedChange{
+		Name:         "response_time_p95 (Keptn.sockshop.production.carts)",
+		Action:       PlannedChangeActionUpdate,
+		FieldChanges: []FieldChange{{Path: "threshold", Current: float64(600), Desired: float64(500)}},
+	}
+	create := PlannedChange{Name: "error_rate (Keptn.sockshop.production.carts)", Action: PlannedChangeActionCreate}
+
+	driftedChanges := getDriftedChanges([]PlannedChange{
+		update,
+		{Name: "throughput (Keptn.sockshop.production.carts)", Action: PlannedChangeActionNone},
+		create,
+		{Name: "response_time_p50 (Keptn.sockshop.production.carts)", Error: "could not retrieve metric events"},
+	})
+	assert.EqualValues(t, []PlannedChange{update, create}, driftedChanges)
+}
+
+func Test_createMonitoringDriftMetricLines(t *testing.T) {
+	lines := createMonitoringDriftMetricLines("sockshop", []entityTypeDrift{
+		{entityType: taggingRuleEntityType},
+		{entityType: metricEventEntityType, changes: []PlannedChange{{Name: "a", Action: PlannedChangeActionCreate}, {Name: "b", Action: PlannedChangeActionUpdate}}},
+	})
+
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, `keptn.monitoring.drift,project="sockshop",entity_type="tagging_rule" 0`, lines[0].String())
+		assert.Equal(t, `keptn.monitoring.drift,project="sockshop",entity_type="metric_event" 2`, lines[1].String())
+	}
+}
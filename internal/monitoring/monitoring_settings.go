@@ -0,0 +1,139 @@
+package monitoring
+
+import (
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+)
+
+// generationSettings define which Dynatrace objects are generated when configuring monitoring for a project or stage.
+type generationSettings struct {
+	taggingRules              bool
+	problemNotifications      bool
+	managementZones           bool
+	dashboards                bool
+	kqgDashboards             bool
+	metricEvents              bool
+	metricEventsBaselineModel string
+	slos                      bool
+	testStepMetrics           bool
+}
+
+// newDefaultGenerationSettings returns the generation settings set via the Helm chart.
+func newDefaultGenerationSettings() generationSettings {
+	return generationSettings{
+		taggingRules:              env.IsTaggingRulesGenerationEnabled(),
+		problemNotifications:      env.IsProblemNotificationsGenerationEnabled(),
+		managementZones:           env.IsManagementZonesGenerationEnabled(),
+		dashboards:                env.IsDashboardsGenerationEnabled(),
+		kqgDashboards:             env.IsKQGDashboardsGenerationEnabled(),
+		metricEvents:              env.IsMetricEventsGenerationEnabled(),
+		metricEventsBaselineModel: getMetricEventsBaselineModel(env.GetMetricEventsBaselineModel()),
+		slos:                      env.IsSLOsGenerationEnabled(),
+		testStepMetrics:           env.IsTestStepMetricsGenerationEnabled(),
+	}
+}
+
+// newGenerationSettings returns the generation settings of the monitoring configuration, which may be nil, using the defaults for unset settings.
+func newGenerationSettings(monitoringConfig *config.MonitoringConfig, defaults generationSettings) generationSettings {
+	if monitoringConfig == nil {
+		return defaults
+	}
+
+	settings := generationSettings{
+		taggingRules:              getGenerationSetting(monitoringConfig.GenerateTaggingRules, defaults.taggingRules),
+		problemNotifications:      getGenerationSetting(monitoringConfig.GenerateProblemNotifications, defaults.problemNotifications),
+		managementZones:           getGenerationSetting(monitoringConfig.GenerateManagementZones, defaults.managementZones),
+		dashboards:                getGenerationSetting(monitoringConfig.GenerateDashboards, defaults.dashboards),
+		kqgDashboards:             getGenerationSetting(monitoringConfig.GenerateKQGDashboards, defaults.kqgDashboards),
+		metricEvents:              getGenerationSetting(monitoringConfig.GenerateMetricEvents, defaults.metricEvents),
+		metricEventsBaselineModel: defaults.metricEventsBaselineModel,
+		slos:                      getGenerationSetting(monitoringConfig.GenerateSLOs, defaults.slos),
+		testStepMetrics:           getGenerationSetting(monitoringConfig.GenerateTestStepMetrics, defaults.testStepMetrics),
+	}
+
+	if monitoringConfig.MetricEventsBaselineModel != "" {
+		settings.metricEventsBaselineModel = getMetricEventsBaselineModel(monitoringConfig.MetricEventsBaselineModel)
+	}
+
+	return settings
+}
+
+func getGenerationSetting(configuredValue *bool, defaultValue bool) bool {
+	if configuredValue == nil {
+		return defaultValue
+	}
+	return *configuredValue
+}
+
+// getMetricEventsBaselineModel returns the baseline model, defaulting to the auto-adaptive baseline if the model is unknown.
+func getMetricEventsBaselineModel(model string) string {
+	if model != autoAdaptiveBaselineModel && model != seasonalBaselineModel {
+		log.WithField("baselineModel", model).Warn("Unknown metric events baseline model, using auto-adaptive baseline")
+		return autoAdaptiveBaselineModel
+	}
+	return model
+}
+
+// monitoringSettings are the naming and generation settings used to configure monitoring for a project and its stages.
+// Tagging rules, problem notifications, test step metrics and the dashboard are generated for the project, metric events, quality gate dashboards, SLOs and management zones for each stage.
+type monitoringSettings struct {
+	naming              *Naming
+	problemNotification *config.ProblemNotificationConfig
+	project             generationSettings
+	stages              map[string]generationSettings
+}
+
+// getStageSettings returns the generation settings of the stage or, if the stage is unknown, those of the project.
+func (s *monitoringSettings) getStageSettings(stage string) generationSettings {
+	settings, ok := s.stages[stage]
+	if !ok {
+		return s.project
+	}
+	return settings
+}
+
+// isManagementZonesGenerationEnabled returns whether the management zone of the project is generated, which is the case if management zones are enabled for the project or any of its stages.
+func (s *monitoringSettings) isManagementZonesGenerationEnabled() bool {
+	return s.project.managementZones || s.isEnabledForAnyStage(func(settings generationSettings) bool { return settings.managementZones })
+}
+
+// isMetricEventsGenerationEnabled returns whether metric events are generated for any stage.
+func (s *monitoringSettings) isMetricEventsGenerationEnabled() bool {
+	return s.isEnabledForAnyStage(func(settings generationSettings) bool { return settings.metricEvents })
+}
+
+// isKQGDashboardsGenerationEnabled returns whether quality gate dashboards are generated for any stage.
+func (s *monitoringSettings) isKQGDashboardsGenerationEnabled() bool {
+	return s.isEnabledForAnyStage(func(settings generationSettings) bool { return settings.kqgDashboards })
+}
+
+// isSLOsGenerationEnabled returns whether Dynatrace SLOs are generated for any stage.
+func (s *monitoringSettings) isSLOsGenerationEnabled() bool {
+	return s.isEnabledForAnyStage(func(settings generationSettings) bool { return settings.slos })
+}
+
+func (s *monitoringSettings) isEnabledForAnyStage(isEnabled func(settings generationSettings) bool) bool {
+	for _, settings := range s.stages {
+		if isEnabled(settings) {
+			return true
+		}
+	}
+	return false
+}
+
+// getManagementZoneShipyard returns a copy of the shipyard only containing the stages for which management zones are generated.
+func (s *monitoringSettings) getManagementZoneShipyard(shipyard keptnv2.Shipyard) keptnv2.Shipyard {
+	var stages []keptnv2.Stage
+	for _, stage := range shipyard.Spec.Stages {
+		if s.getStageSettings(stage.Name).managementZones {
+			stages = append(stages, stage)
+		}
+	}
+
+	managementZoneShipyard := shipyard
+	managementZoneShipyard.Spec.Stages = stages
+	return managementZoneShipyard
+}
@@ -0,0 +1,145 @@
+package monitoring
+
+import (
+	"errors"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+)
+
+func Test_newGenerationSettings(t *testing.T) {
+	t.Setenv("GENERATE_TAGGING_RULES", "true")
+	t.Setenv("GENERATE_MANAGEMENT_ZONES", "true")
+	t.Setenv("GENERATE_METRIC_EVENTS", "false")
+	t.Setenv("METRIC_EVENTS_BASELINE_MODEL", "seasonal")
+	t.Setenv("GENERATE_SLOS", "false")
+
+	defaults := newDefaultGenerationSettings()
+	assert.Equal(t, defaults, newGenerationSettings(nil, defaults))
+
+	enabled := true
+	disabled := false
+	projectSettings := newGenerationSettings(&config.MonitoringConfig{
+		GenerateTaggingRules: &disabled,
+		GenerateMetricEvents: &enabled,
+	}, defaults)
+	assert.Equal(t,
+		generationSettings{
+			managementZones:           true,
+			metricEvents:              true,
+			metricEventsBaselineModel: seasonalBaselineModel,
+		},
+		projectSettings)
+
+	stageSettings := newGenerationSettings(&config.MonitoringConfig{
+		GenerateManagementZones:   &disabled,
+		MetricEventsBaselineModel: "weekly",
+		GenerateSLOs:              &enabled,
+	}, projectSettings)
+	assert.Equal(t,
+		generationSettings{
+			metricEvents:              true,
+			metricEventsBaselineModel: autoAdaptiveBaselineModel,
+			slos:                      true,
+		},
+		stageSettings)
+}
+
+func Test_monitoringSettings_stages(t *testing.T) {
+	settings := &monitoringSettings{
+		naming:  defaultNaming,
+		project: generationSettings{},
+		stages: map[string]generationSettings{
+			"dev":        {},
+			"production": {managementZones: true, kqgDashboards: true},
+		},
+	}
+
+	assert.True(t, settings.isManagementZonesGenerationEnabled())
+	assert.True(t, settings.isKQGDashboardsGenerationEnabled())
+	assert.False(t, settings.isMetricEventsGenerationEnabled())
+	assert.False(t, settings.isSLOsGenerationEnabled())
+	assert.Equal(t, generationSettings{}, settings.getStageSettings("hardening"))
+
+	shipyard := keptnv2.Shipyard{
+		Spec: keptnv2.ShipyardSpec{
+			Stages: []keptnv2.Stage{{Name: "dev"}, {Name: "production"}},
+		},
+	}
+	managementZoneShipyard := settings.getManagementZoneShipyard(shipyard)
+	assert.Equal(t, []keptnv2.Stage{{Name: "production"}}, managementZoneShipyard.Spec.Stages)
+	assert.Len(t, shipyard.Spec.Stages, 2)
+}
+
+type dynatraceConfigProviderMock struct {
+	configs map[string]*config.DynatraceConfig
+	err     error
+}
+
+func (m *dynatraceConfigProviderMock) GetDynatraceConfig(event adapter.EventContentAdapter) (*config.DynatraceConfig, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	dynatraceConfig, ok := m.configs[event.GetStage()]
+	if !ok {
+		return nil, &keptn.ResourceNotFoundError{}
+	}
+	return dynatraceConfig, nil
+}
+
+func TestConfiguration_getMonitoringSettings(t *testing.T) {
+	enabled := true
+	shipyard := keptnv2.Shipyard{Spec: keptnv2.ShipyardSpec{Stages: []keptnv2.Stage{{Name: "dev"}, {Name: "production"}}}}
+
+	tests := []struct {
+		name                      string
+		configProvider            *dynatraceConfigProviderMock
+		wantError                 bool
+		wantProductionSLOs        bool
+		wantProjectManagementZone string
+	}{
+		{
+			name:                      "no dynatrace.conf.yaml uses defaults",
+			configProvider:            &dynatraceConfigProviderMock{},
+			wantProjectManagementZone: "Keptn: sockshop",
+		},
+		{
+			name: "dynatrace.conf.yaml of project and stage",
+			configProvider: &dynatraceConfigProviderMock{
+				configs: map[string]*config.DynatraceConfig{
+					"":           {Naming: &config.NamingConfig{ProjectManagementZone: "{{.Project}} (Keptn)"}},
+					"production": {Monitoring: &config.MonitoringConfig{GenerateSLOs: &enabled}},
+				},
+			},
+			wantProductionSLOs:        true,
+			wantProjectManagementZone: "sockshop (Keptn)",
+		},
+		{
+			name:           "dynatrace.conf.yaml cannot be retrieved",
+			configProvider: &dynatraceConfigProviderMock{err: errors.New("connection refused")},
+			wantError:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := NewConfiguration(nil, nil, nil, nil, tt.configProvider).getMonitoringSettings("sockshop", shipyard)
+			if tt.wantError {
+				assert.Error(t, err)
+				return
+			}
+
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.Equal(t, tt.wantProjectManagementZone, settings.naming.getProjectManagementZoneName("sockshop"))
+			assert.Equal(t, tt.wantProductionSLOs, settings.getStageSettings("production").slos)
+		})
+	}
+}
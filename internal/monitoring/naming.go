@@ -0,0 +1,346 @@
+package monitoring
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+)
+
+const (
+	defaultProjectManagementZoneNameTemplate = "Keptn: {{.Project}}"
+	defaultStageManagementZoneNameTemplate   = "Keptn: {{.Project}} {{.Stage}}"
+	defaultDashboardNameTemplate             = "{{.Project}}@keptn: Digital Delivery & Operations Dashboard"
+	defaultMetricEventNameTemplate           = "{{.SLI}} (Keptn.{{.Project}}.{{.Stage}}.{{.Service}})"
+	defaultProblemNotificationNameTemplate   = dynatrace.KeptnProblemNotificationName
+	defaultSLONameTemplate                   = "{{.SLI}} (Keptn.{{.Project}}.{{.Stage}}.{{.Service}})"
+)
+
+// namingField is a field of NamingData together with the pattern its values match when parsing names.
+type namingField struct {
+	name    string
+	pattern string
+}
+
+// namingFields are the fields of NamingData, Keptn project, stage and service names never contain dots or whitespace.
+var namingFields = []namingField{
+	{name: "Project", pattern: `[\w-]+`},
+	{name: "Stage", pattern: `[\w-]+`},
+	{name: "Service", pattern: `[\w-]+`},
+	{name: "SLI", pattern: `.+`},
+}
+
+// namingSentinelPattern matches the sentinel values used to locate the fields within a rendered name.
+var namingSentinelPattern = regexp.MustCompile("\x00(\\w+)\x00")
+
+// defaultNaming names objects as earlier versions of the dynatrace-service did, it is used to find objects created before a naming template was configured.
+var defaultNaming = newDefaultNaming()
+
+// NamingData is the data available to the Go templates naming the Dynatrace objects generated for Keptn, e.g. {{.Project}}.
+type NamingData struct {
+	Project string
+	Stage   string
+	Service string
+	SLI     string
+}
+
+func newNamingData(values map[string]string) NamingData {
+	return NamingData{
+		Project: values["Project"],
+		Stage:   values["Stage"],
+		Service: values["Service"],
+		SLI:     values["SLI"],
+	}
+}
+
+// namingTemplate is a validated Go template for the names of a type of Dynatrace object.
+type namingTemplate struct {
+	template *template.Template
+	pattern  *regexp.Regexp
+	fields   []string
+}
+
+// newNamingTemplate parses the template and checks that it results in non-empty names that contain each of the required fields unchanged, so that names of different objects cannot collide and names can be parsed again.
+func newNamingTemplate(objectType string, text string, requiredFields ...string) (*namingTemplate, error) {
+	tmpl, err := template.New(objectType).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s name template '%s': %w", objectType, text, err)
+	}
+
+	sentinelValues := make(map[string]string, len(namingFields))
+	for _, field := range namingFields {
+		sentinelValues[field.name] = "\x00" + field.name + "\x00"
+	}
+
+	var builder strings.Builder
+	err = tmpl.Execute(&builder, newNamingData(sentinelValues))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s name template '%s': %w", objectType, text, err)
+	}
+
+	rendered := builder.String()
+	for _, requiredField := range requiredFields {
+		if !strings.Contains(rendered, sentinelValues[requiredField]) {
+			return nil, fmt.Errorf("invalid %s name template '%s': must contain {{.%s}}", objectType, text, requiredField)
+		}
+	}
+
+	if strings.TrimSpace(rendered) == "" {
+		return nil, fmt.Errorf("invalid %s name template '%s': results in an empty name", objectType, text)
+	}
+
+	pattern, fields := createNamingPattern(rendered)
+	return &namingTemplate{
+		template: tmpl,
+		pattern:  pattern,
+		fields:   fields,
+	}, nil
+}
+
+// createNamingPattern creates a regular expression matching the names rendered from a template, with a group capturing the first occurrence of each field.
+func createNamingPattern(rendered string) (*regexp.Regexp, []string) {
+	fieldPatterns := make(map[string]string, len(namingFields))
+	for _, field := range namingFields {
+		fieldPatterns[field.name] = field.pattern
+	}
+
+	var builder strings.Builder
+	var fields []string
+	capturedFields := make(map[string]bool)
+	position := 0
+	for _, match := range namingSentinelPattern.FindAllStringSubmatchIndex(rendered, -1) {
+		field := rendered[match[2]:match[3]]
+		builder.WriteString(regexp.QuoteMeta(rendered[position:match[0]]))
+		if capturedFields[field] {
+			builder.WriteString("(?:" + fieldPatterns[field] + ")")
+		} else {
+			builder.WriteString("(" + fieldPatterns[field] + ")")
+			fields = append(fields, field)
+			capturedFields[field] = true
+		}
+		position = match[1]
+	}
+	builder.WriteString(regexp.QuoteMeta(rendered[position:]))
+
+	return regexp.MustCompile("^" + builder.String() + "$"), fields
+}
+
+// name returns the name for the data.
+func (t *namingTemplate) name(data NamingData) string {
+	var builder strings.Builder
+	err := t.template.Execute(&builder, data)
+	if err != nil {
+		log.WithError(err).WithField("template", t.template.Name()).Error("Could not execute name template")
+		return ""
+	}
+	return builder.String()
+}
+
+// parse returns the data a name was rendered from, it returns false if the name was not rendered by the template.
+func (t *namingTemplate) parse(name string) (NamingData, bool) {
+	matches := t.pattern.FindStringSubmatch(name)
+	if matches == nil {
+		return NamingData{}, false
+	}
+
+	values := make(map[string]string, len(t.fields))
+	for i, field := range t.fields {
+		values[field] = matches[i+1]
+	}
+
+	data := newNamingData(values)
+	return data, t.name(data) == name
+}
+
+// Naming names the Dynatrace objects generated for Keptn projects using Go templates.
+// Templates are taken from the dynatrace.conf.yaml of the project, the Helm chart values or the defaults, in that order.
+type Naming struct {
+	projectManagementZone *namingTemplate
+	stageManagementZone   *namingTemplate
+	dashboard             *namingTemplate
+	metricEvent           *namingTemplate
+	problemNotification   *namingTemplate
+	slo                   *namingTemplate
+}
+
+// NewNaming creates a new Naming using the templates of the naming configuration, which may be nil, or returns an error if a template is invalid.
+func NewNaming(namingConfig *config.NamingConfig) (*Naming, error) {
+	if namingConfig == nil {
+		namingConfig = &config.NamingConfig{}
+	}
+
+	projectManagementZone, err := newNamingTemplate(
+		"project management zone",
+		getNamingTemplateText(namingConfig.ProjectManagementZone, env.GetProjectManagementZoneNameTemplate(), defaultProjectManagementZoneNameTemplate),
+		"Project")
+	if err != nil {
+		return nil, err
+	}
+
+	stageManagementZone, err := newNamingTemplate(
+		"stage management zone",
+		getNamingTemplateText(namingConfig.StageManagementZone, env.GetStageManagementZoneNameTemplate(), defaultStageManagementZoneNameTemplate),
+		"Project", "Stage")
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard, err := newNamingTemplate(
+		"dashboard",
+		getNamingTemplateText(namingConfig.Dashboard, env.GetDashboardNameTemplate(), defaultDashboardNameTemplate),
+		"Project")
+	if err != nil {
+		return nil, err
+	}
+
+	metricEvent, err := newNamingTemplate(
+		"metric event",
+		getNamingTemplateText(namingConfig.MetricEvent, env.GetMetricEventNameTemplate(), defaultMetricEventNameTemplate),
+		"SLI", "Project", "Stage", "Service")
+	if err != nil {
+		return nil, err
+	}
+
+	problemNotification, err := newNamingTemplate(
+		"problem notification",
+		getNamingTemplateText(namingConfig.ProblemNotification, env.GetProblemNotificationNameTemplate(), defaultProblemNotificationNameTemplate))
+	if err != nil {
+		return nil, err
+	}
+
+	slo, err := newNamingTemplate(
+		"SLO",
+		getNamingTemplateText(namingConfig.SLO, env.GetSLONameTemplate(), defaultSLONameTemplate),
+		"SLI", "Project", "Stage", "Service")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Naming{
+		projectManagementZone: projectManagementZone,
+		stageManagementZone:   stageManagementZone,
+		dashboard:             dashboard,
+		metricEvent:           metricEvent,
+		problemNotification:   problemNotification,
+		slo:                   slo,
+	}, nil
+}
+
+func newDefaultNaming() *Naming {
+	return &Naming{
+		projectManagementZone: mustNewNamingTemplate("project management zone", defaultProjectManagementZoneNameTemplate),
+		stageManagementZone:   mustNewNamingTemplate("stage management zone", defaultStageManagementZoneNameTemplate),
+		dashboard:             mustNewNamingTemplate("dashboard", defaultDashboardNameTemplate),
+		metricEvent:           mustNewNamingTemplate("metric event", defaultMetricEventNameTemplate),
+		problemNotification:   mustNewNamingTemplate("problem notification", defaultProblemNotificationNameTemplate),
+		slo:                   mustNewNamingTemplate("SLO", defaultSLONameTemplate),
+	}
+}
+
+func mustNewNamingTemplate(objectType string, text string) *namingTemplate {
+	namingTemplate, err := newNamingTemplate(objectType, text)
+	if err != nil {
+		panic(err)
+	}
+	return namingTemplate
+}
+
+func getNamingTemplateText(configuredText string, envText string, defaultText string) string {
+	if configuredText != "" {
+		return configuredText
+	}
+
+	if envText != "" {
+		return envText
+	}
+
+	return defaultText
+}
+
+// getProjectManagementZoneName returns the name of the management zone of the project, by default Keptn: <project>.
+func (n *Naming) getProjectManagementZoneName(project string) string {
+	return n.projectManagementZone.name(NamingData{Project: project})
+}
+
+// getStageManagementZoneName returns the name of the management zone of the stage, by default Keptn: <project> <stage>.
+func (n *Naming) getStageManagementZoneName(project string, stage string) string {
+	return n.stageManagementZone.name(NamingData{Project: project, Stage: stage})
+}
+
+// getDashboardName returns the name of the dashboard of the project, by default <project>@keptn: Digital Delivery & Operations Dashboard.
+func (n *Naming) getDashboardName(project string) string {
+	return n.dashboard.name(NamingData{Project: project})
+}
+
+// getMetricEventName returns the name of the metric event for the metric of the SLO of the service, by default e.g. response_time_p95 (Keptn.sockshop.production.carts).
+func (n *Naming) getMetricEventName(metric string, project string, stage string, service string) string {
+	return n.metricEvent.name(NamingData{Project: project, Stage: stage, Service: service, SLI: metric})
+}
+
+// getBaselineMetricEventName returns the name of the baseline metric event for the metric of the SLO of the service, by default e.g. response_time_p95 baseline (Keptn.sockshop.production.carts).
+// It differs from the name of the static threshold metric event so that both can be created for the same SLO.
+func (n *Naming) getBaselineMetricEventName(metric string, project string, stage string, service string) string {
+	return n.getMetricEventName(metric+" baseline", project, stage, service)
+}
+
+// getDefaultMetricEventName returns the name the default naming gives to the metric event with the name, or the name itself if it cannot be parsed.
+func (n *Naming) getDefaultMetricEventName(name string) string {
+	data, ok := n.metricEvent.parse(name)
+	if !ok {
+		return name
+	}
+	return defaultNaming.metricEvent.name(data)
+}
+
+// getProblemNotificationName returns the name of the problem notification, by default Keptn Problem Notification.
+func (n *Naming) getProblemNotificationName(project string) string {
+	return n.problemNotification.name(NamingData{Project: project})
+}
+
+// getSLOName returns the name of the Dynatrace SLO for the SLI of the service, by default e.g. success_rate (Keptn.sockshop.production.carts).
+func (n *Naming) getSLOName(sli string, project string, stage string, service string) string {
+	return n.slo.name(NamingData{Project: project, Stage: stage, Service: service, SLI: sli})
+}
+
+// parseMetricEventName returns the project, stage and service of a metric event named by getMetricEventName.
+func (n *Naming) parseMetricEventName(name string) (string, string, string, bool) {
+	data, ok := n.metricEvent.parse(name)
+	if !ok {
+		return "", "", "", false
+	}
+	return data.Project, data.Stage, data.Service, true
+}
+
+// parseSLOName returns the project, stage and service of a Dynatrace SLO named by getSLOName.
+func (n *Naming) parseSLOName(name string) (string, string, string, bool) {
+	data, ok := n.slo.parse(name)
+	if !ok {
+		return "", "", "", false
+	}
+	return data.Project, data.Stage, data.Service, true
+}
+
+// isManagementZoneNameOfProject returns true if the name is that of the management zone of the project or of one of its stages.
+func (n *Naming) isManagementZoneNameOfProject(name string, project string) bool {
+	if name == n.getProjectManagementZoneName(project) {
+		return true
+	}
+
+	data, ok := n.stageManagementZone.parse(name)
+	return ok && data.Project == project
+}
+
+// getNamesIncludingDefault returns the name followed by the default name, if it differs, so that objects created before a naming template was configured are found.
+// Objects found by the default name may belong to another installation on the same tenant, so callers must check that they are owned by the project before changing them.
+func getNamesIncludingDefault(name string, defaultName string) []string {
+	if name == defaultName {
+		return []string{name}
+	}
+	return []string{name, defaultName}
+}
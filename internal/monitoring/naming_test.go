@@ -0,0 +1,126 @@
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+)
+
+func Test_newNamingTemplate_invalidTemplates(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		requiredFields []string
+	}{
+		{
+			name:           "missing required field",
+			text:           "Keptn: {{.Project}}",
+			requiredFields: []string{"Project", "Stage"},
+		},
+		{
+			name:           "required field modified by function",
+			text:           "Keptn: {{printf \"%.3s\" .Project}}",
+			requiredFields: []string{"Project"},
+		},
+		{
+			name: "parse error",
+			text: "Keptn: {{.Project",
+		},
+		{
+			name: "unknown field",
+			text: "Keptn: {{.Team}}",
+		},
+		{
+			name: "empty name",
+			text: "{{if false}}Keptn{{end}}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namingTemplate, err := newNamingTemplate("management zone", tt.text, tt.requiredFields...)
+			assert.Error(t, err)
+			assert.Nil(t, namingTemplate)
+		})
+	}
+}
+
+func TestNaming_customTemplates(t *testing.T) {
+	naming, err := NewNaming(&config.NamingConfig{
+		ProjectManagementZone: "{{.Project}} (Keptn)",
+		StageManagementZone:   "{{.Project}}/{{.Stage}} (Keptn)",
+		Dashboard:             "Keptn {{.Project}} overview",
+		MetricEvent:           "[{{.Project}}/{{.Stage}}/{{.Service}}] {{.SLI}} ({{.Project}})",
+		ProblemNotification:   "Keptn {{.Project}} problems",
+		SLO:                   "{{.Service}} {{.SLI}} ({{.Project}} {{.Stage}})",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "sockshop (Keptn)", naming.getProjectManagementZoneName("sockshop"))
+	assert.Equal(t, "sockshop/production (Keptn)", naming.getStageManagementZoneName("sockshop", "production"))
+	assert.Equal(t, "Keptn sockshop overview", naming.getDashboardName("sockshop"))
+	assert.Equal(t, "Keptn sockshop problems", naming.getProblemNotificationName("sockshop"))
+
+	sloName := naming.getSLOName("success_rate", "sockshop", "production", "carts")
+	assert.Equal(t, "carts success_rate (sockshop production)", sloName)
+
+	project, stage, service, ok := naming.parseSLOName(sloName)
+	assert.True(t, ok)
+	assert.Equal(t, "sockshop", project)
+	assert.Equal(t, "production", stage)
+	assert.Equal(t, "carts", service)
+
+	metricEventName := naming.getMetricEventName("response_time_p95", "sockshop", "production", "carts")
+	assert.Equal(t, "[sockshop/production/carts] response_time_p95 (sockshop)", metricEventName)
+
+	project, stage, service, ok = naming.parseMetricEventName(metricEventName)
+	assert.True(t, ok)
+	assert.Equal(t, "sockshop", project)
+	assert.Equal(t, "production", stage)
+	assert.Equal(t, "carts", service)
+
+	// the duplicated project field must match its first occurrence
+	_, _, _, ok = naming.parseMetricEventName("[sockshop/production/carts] response_time_p95 (orders)")
+	assert.False(t, ok)
+
+	assert.Equal(t, "response_time_p95 (Keptn.sockshop.production.carts)", naming.getDefaultMetricEventName(metricEventName))
+	assert.Equal(t, "High response time", naming.getDefaultMetricEventName("High response time"))
+
+	assert.True(t, naming.isManagementZoneNameOfProject("sockshop (Keptn)", "sockshop"))
+	assert.True(t, naming.isManagementZoneNameOfProject("sockshop/production (Keptn)", "sockshop"))
+	assert.False(t, naming.isManagementZoneNameOfProject("orders/production (Keptn)", "sockshop"))
+	assert.False(t, naming.isManagementZoneNameOfProject("Keptn: sockshop", "sockshop"))
+}
+
+func TestNewNaming_precedence(t *testing.T) {
+	t.Setenv("PROJECT_MANAGEMENT_ZONE_NAME_TEMPLATE", "Env: {{.Project}}")
+	t.Setenv("DASHBOARD_NAME_TEMPLATE", "Env dashboard {{.Project}}")
+
+	naming, err := NewNaming(&config.NamingConfig{
+		Dashboard: "Project dashboard {{.Project}}",
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "Env: sockshop", naming.getProjectManagementZoneName("sockshop"))
+	assert.Equal(t, "Project dashboard sockshop", naming.getDashboardName("sockshop"))
+	assert.Equal(t, "Keptn: sockshop production", naming.getStageManagementZoneName("sockshop", "production"))
+	assert.Equal(t, "Keptn Problem Notification", naming.getProblemNotificationName("sockshop"))
+}
+
+func TestNewNaming_invalidEnvTemplate(t *testing.T) {
+	t.Setenv("METRIC_EVENT_NAME_TEMPLATE", "{{.SLI}} ({{.Project}})")
+
+	naming, err := NewNaming(nil)
+	assert.Error(t, err)
+	assert.Nil(t, naming)
+}
+
+func Test_getNamesIncludingDefault(t *testing.T) {
+	assert.Equal(t, []string{"Keptn: sockshop"}, getNamesIncludingDefault("Keptn: sockshop", "Keptn: sockshop"))
+	assert.Equal(t, []string{"sockshop (Keptn)", "Keptn: sockshop"}, getNamesIncludingDefault("sockshop (Keptn)", "Keptn: sockshop"))
+}
@@ -2,22 +2,63 @@ package monitoring
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
 	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 
 	log "github.com/sirupsen/logrus"
 )
 
+const keptnAlertingProfileName = "Keptn"
+
+// testNotificationKeptnContextField maps the Keptn context to the PID, which allows the test notification to be found in Keptn.
+const testNotificationKeptnContextField = `"shkeptncontext":"{PID}"`
+
+// problemNotificationTestPollInterval is the interval in which Keptn is checked for the arrival of the test problem notification
+const problemNotificationTestPollInterval = 2 * time.Second
+
 type ProblemNotificationCreation struct {
 	client dynatrace.ClientInterface
+	naming *Naming
+	config *config.ProblemNotificationConfig
 }
 
-func NewProblemNotificationCreation(client dynatrace.ClientInterface) *ProblemNotificationCreation {
+// NewProblemNotificationCreation creates a new ProblemNotificationCreation. The problem notification config may be nil, in which case the defaults are used.
+func NewProblemNotificationCreation(client dynatrace.ClientInterface, naming *Naming, problemNotificationConfig *config.ProblemNotificationConfig) *ProblemNotificationCreation {
 	return &ProblemNotificationCreation{
 		client: client,
+		naming: naming,
+		config: problemNotificationConfig,
+	}
+}
+
+// getExistingNotificationIDs returns the IDs of the existing problem notifications with the name of the problem notification for the project.
+// If a naming template is configured, those with the default name are only included if they send problems to the project, as they may otherwise belong to another installation on the same tenant.
+func (pn *ProblemNotificationCreation) getExistingNotificationIDs(ctx context.Context, notificationsClient *dynatrace.NotificationsClient, project string) ([]string, error) {
+	name := pn.naming.getProblemNotificationName(project)
+	ids, err := notificationsClient.GetKeptnProblemNotificationIDs(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultName := defaultNaming.getProblemNotificationName(project)
+	if name == defaultName {
+		return ids, nil
 	}
+
+	defaultIDs, err := notificationsClient.GetKeptnProblemNotificationIDsForProject(ctx, project, defaultName)
+	if err != nil {
+		return nil, err
+	}
+	return append(ids, defaultIDs...), nil
 }
 
 // Create sets up/updates the DT problem notification and returns it.
@@ -33,12 +74,6 @@ func (pn *ProblemNotificationCreation) Create(ctx context.Context, project strin
 		}
 	}
 
-	notificationsClient := dynatrace.NewNotificationsClient(pn.client)
-	err = notificationsClient.DeleteExistingKeptnProblemNotifications(ctx)
-	if err != nil {
-		log.WithError(err).Error("failed to delete existing notifications")
-	}
-
 	keptnCredentials, err := credentials.GetKeptnCredentials(ctx)
 	if err != nil {
 		log.WithError(err).Error("Failed to retrieve Keptn API credentials")
@@ -48,7 +83,27 @@ func (pn *ProblemNotificationCreation) Create(ctx context.Context, project strin
 		}
 	}
 
-	err = notificationsClient.Create(ctx, keptnCredentials, alertingProfileID, project)
+	notification, err := pn.getProblemNotification(keptnCredentials, alertingProfileID, project)
+	if err != nil {
+		log.WithError(err).Error("Invalid problem notification configuration")
+		return &ConfigResult{
+			Success: false,
+			Message: "failed to set up problem notification: " + err.Error(),
+		}
+	}
+
+	notificationsClient := dynatrace.NewNotificationsClient(pn.client)
+	existingNotificationIDs, err := pn.getExistingNotificationIDs(ctx, notificationsClient, project)
+	if err != nil {
+		log.WithError(err).Error("failed to retrieve existing notifications")
+	}
+
+	err = notificationsClient.DeleteKeptnProblemNotifications(ctx, existingNotificationIDs...)
+	if err != nil {
+		log.WithError(err).Error("failed to delete existing notifications")
+	}
+
+	err = notificationsClient.Create(ctx, *notification)
 	if err != nil {
 		log.WithError(err).Error("Failed to create problem notification")
 		return &ConfigResult{
@@ -63,9 +118,196 @@ func (pn *ProblemNotificationCreation) Create(ctx context.Context, project strin
 	}
 }
 
+// Check asks Dynatrace to send a test notification using the problem notification of the project and waits for it to arrive in Keptn.
+// The test notification uses a unique PID, which is also its Keptn context, so that it can be found in Keptn and is not handled like a problem.
+func (pn *ProblemNotificationCreation) Check(ctx context.Context, project string, eventClient keptn.EventClientInterface) *ConfigResult {
+	log.Info("Sending test problem notification")
+
+	alertingProfileID, err := dynatrace.NewAlertingProfilesClient(pn.client).GetProfileID(ctx, keptnAlertingProfileName)
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(fmt.Errorf("could not get Keptn alerting profile: %w", err))
+	}
+
+	keptnCredentials, err := credentials.GetKeptnCredentials(ctx)
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(fmt.Errorf("could not retrieve Keptn API credentials: %w", err))
+	}
+
+	notification, err := pn.getProblemNotification(keptnCredentials, alertingProfileID, project)
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(err)
+	}
+
+	err = validateTestNotificationPayload(notification.Payload)
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(err)
+	}
+
+	// the default payload uses the PID as Keptn context, which Keptn only keeps if it is a UUID
+	pid := uuid.New().String()
+	err = dynatrace.NewNotificationsClient(pn.client).SendTest(ctx, *notification, pid)
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(err)
+	}
+
+	err = waitForProblemEvent(ctx, eventClient, pid, pn.config.GetTestTimeout())
+	if err != nil {
+		return newFailedProblemNotificationCheckResult(err)
+	}
+
+	log.WithField("PID", pid).Info("Test problem notification arrived in Keptn")
+	return &ConfigResult{
+		Name:    notification.Name,
+		Success: true,
+		Message: "Test notification sent by Dynatrace arrived in Keptn",
+	}
+}
+
+// validateTestNotificationPayload returns an error if the payload does not use the PID as Keptn context, as the test notification could then not be found in Keptn.
+func validateTestNotificationPayload(payload string) error {
+	if !strings.Contains(strings.Join(strings.Fields(payload), ""), testNotificationKeptnContextField) {
+		return fmt.Errorf("payload template does not contain %s, so the test notification cannot be found in Keptn", testNotificationKeptnContextField)
+	}
+
+	return nil
+}
+
+func newFailedProblemNotificationCheckResult(err error) *ConfigResult {
+	log.WithError(err).Error("Problem notification check failed")
+	return &ConfigResult{
+		Success: false,
+		Message: "test notification did not arrive in Keptn: " + err.Error(),
+	}
+}
+
+// waitForProblemEvent polls Keptn until a problem event with the Keptn context has been received or the timeout has elapsed.
+func waitForProblemEvent(ctx context.Context, eventClient keptn.EventClientInterface, keptnContext string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(problemNotificationTestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("no problem event received within %v", timeout)
+		case <-ticker.C:
+			received, err := eventClient.HasProblemEvent(keptnContext)
+			if err != nil {
+				log.WithError(err).Debug("Could not check for test problem event")
+				continue
+			}
+
+			if received {
+				return nil
+			}
+		}
+	}
+}
+
+// getProblemNotification returns the problem notification sending problems to the Keptn project, as defined by the problem notification config.
+func (pn *ProblemNotificationCreation) getProblemNotification(keptnCredentials *credentials.KeptnCredentials, alertingProfileID string, project string) (*dynatrace.ProblemNotification, error) {
+	payloadTemplate := dynatrace.DefaultProblemNotificationPayloadTemplate
+	baseURL := keptnCredentials.GetAPIURL()
+	var additionalHeaders []config.ProblemNotificationHeaderConfig
+	if pn.config != nil {
+		if pn.config.PayloadTemplate != "" {
+			payloadTemplate = pn.config.PayloadTemplate
+		}
+		if pn.config.URL != "" {
+			baseURL = pn.config.URL
+		}
+		additionalHeaders = pn.config.Headers
+	}
+
+	// the project is needed to handle problems and to find the problem notifications of a project when cleaning up
+	keptnProjectField := `"KeptnProject":"` + dynatrace.KeptnProjectPlaceholder + `"`
+	if !strings.Contains(strings.Join(strings.Fields(payloadTemplate), ""), keptnProjectField) {
+		return nil, fmt.Errorf("payload template does not contain %s", keptnProjectField)
+	}
+
+	headers, err := getProblemNotificationHeaders(keptnCredentials, additionalHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynatrace.ProblemNotification{
+		Name:                 pn.naming.getProblemNotificationName(project),
+		AlertingProfileID:    alertingProfileID,
+		URL:                  strings.TrimSuffix(baseURL, "/") + "/v1/event",
+		AcceptAnyCertificate: pn.config.IsAnyCertificateAccepted(),
+		Headers:              headers,
+		Payload:              strings.ReplaceAll(payloadTemplate, dynatrace.KeptnProjectPlaceholder, project),
+	}, nil
+}
+
+// getProblemNotificationHeaders returns the headers needed to send events to the Keptn API followed by the additional headers, which must not replace them.
+func getProblemNotificationHeaders(keptnCredentials *credentials.KeptnCredentials, additionalHeaders []config.ProblemNotificationHeaderConfig) ([]dynatrace.ProblemNotificationHeader, error) {
+	headers := []dynatrace.ProblemNotificationHeader{
+		{Name: "x-token", Value: keptnCredentials.GetAPIToken(), Secret: true},
+		{Name: "Content-Type", Value: "application/cloudevents+json"},
+	}
+
+	for _, additionalHeader := range additionalHeaders {
+		if additionalHeader.Name == "" {
+			return nil, errors.New("header name must not be empty")
+		}
+
+		for _, header := range headers {
+			if strings.EqualFold(header.Name, additionalHeader.Name) {
+				return nil, fmt.Errorf("header %s must not be set more than once", additionalHeader.Name)
+			}
+		}
+
+		headers = append(headers, dynatrace.ProblemNotificationHeader{Name: additionalHeader.Name, Value: additionalHeader.Value, Secret: additionalHeader.Secret})
+	}
+
+	return headers, nil
+}
+
+// Plan returns the changes Create would make to the Keptn alerting profile and problem notifications without writing them.
+// Existing Keptn problem notifications are always replaced, so no field changes are reported for them.
+func (pn *ProblemNotificationCreation) Plan(ctx context.Context, project string) []PlannedChange {
+	var plannedChanges []PlannedChange
+
+	alertingProfileName := "Keptn alerting profile"
+	alertingProfileID, err := dynatrace.NewAlertingProfilesClient(pn.client).GetProfileID(ctx, keptnAlertingProfileName)
+	switch {
+	case err != nil:
+		plannedChanges = append(plannedChanges, newFailedPlannedChange(alertingProfileName, err))
+	case alertingProfileID != "":
+		plannedChanges = append(plannedChanges, PlannedChange{Name: alertingProfileName, Action: PlannedChangeActionNone})
+	default:
+		plannedChanges = append(plannedChanges, PlannedChange{Name: alertingProfileName, Action: PlannedChangeActionCreate})
+	}
+
+	notificationName := pn.naming.getProblemNotificationName(project)
+	notificationIDs, err := pn.getExistingNotificationIDs(ctx, dynatrace.NewNotificationsClient(pn.client), project)
+	if err != nil {
+		return append(plannedChanges, newFailedPlannedChange(notificationName, err))
+	}
+
+	if len(notificationIDs) == 0 {
+		return append(plannedChanges, PlannedChange{Name: notificationName, Action: PlannedChangeActionCreate})
+	}
+
+	for i, notificationID := range notificationIDs {
+		plannedChange := PlannedChange{
+			Name:   notificationName + " (" + notificationID + ")",
+			Action: PlannedChangeActionDelete,
+		}
+		if i == 0 {
+			plannedChange.Action = PlannedChangeActionReplace
+		}
+		plannedChanges = append(plannedChanges, plannedChange)
+	}
+	return plannedChanges
+}
+
 func getOrCreateKeptnAlertingProfile(ctx context.Context, alertingProfilesClient *dynatrace.AlertingProfilesClient) (string, error) {
 	log.Info("Checking Keptn alerting profile availability")
-	alertingProfileID, err := alertingProfilesClient.GetProfileID(ctx, "Keptn")
+	alertingProfileID, err := alertingProfilesClient.GetProfileID(ctx, keptnAlertingProfileName)
 	if err != nil {
 		log.WithError(err).Error("Could not get alerting profiles")
 	}
@@ -88,7 +330,7 @@ func getOrCreateKeptnAlertingProfile(ctx context.Context, alertingProfilesClient
 func createKeptnAlertingProfile() *dynatrace.AlertingProfile {
 	return &dynatrace.AlertingProfile{
 		Metadata:    dynatrace.AlertingProfileMetadata{},
-		DisplayName: "Keptn",
+		DisplayName: keptnAlertingProfileName,
 		Rules: []dynatrace.AlertingProfileRules{
 			createAlertingProfileRule("AVAILABILITY"),
 			createAlertingProfileRule("ERROR"),
@@ -0,0 +1,139 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+func TestProblemNotificationCreation_getProblemNotification_defaults(t *testing.T) {
+	keptnCredentials, err := credentials.NewKeptnCredentials("https://keptn.example.com/api", "my-token", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	notification, err := NewProblemNotificationCreation(nil, defaultNaming, nil).getProblemNotification(keptnCredentials, "profile-id", "sockshop")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "Keptn Problem Notification", notification.Name)
+	assert.Equal(t, "profile-id", notification.AlertingProfileID)
+	assert.Equal(t, "https://keptn.example.com/api/v1/event", notification.URL)
+	assert.True(t, notification.AcceptAnyCertificate)
+	assert.EqualValues(t,
+		[]dynatrace.ProblemNotificationHeader{
+			{Name: "x-token", Value: "my-token", Secret: true},
+			{Name: "Content-Type", Value: "application/cloudevents+json"},
+		},
+		notification.Headers)
+	assert.Contains(t, notification.Payload, `"KeptnProject":"sockshop"`)
+}
+
+func TestProblemNotificationCreation_getProblemNotification_configured(t *testing.T) {
+	keptnCredentials, err := credentials.NewKeptnCredentials("https://keptn.example.com/api", "my-token", "")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	acceptAnyCertificate := false
+	problemNotificationConfig := &config.ProblemNotificationConfig{
+		AcceptAnyCertificate: &acceptAnyCertificate,
+		Headers: []config.ProblemNotificationHeaderConfig{
+			{Name: "x-tenant", Value: "team-a"},
+			{Name: "Authorization", Value: "Basic dXNlcjpwYXNz", Secret: true},
+		},
+		PayloadTemplate: `{"type":"sh.keptn.events.problem","data":{"PID":"{PID}","KeptnProject":"$KEPTN_PROJECT"}}`,
+		URL:             "https://gateway.example.com/keptn/",
+	}
+
+	notification, err := NewProblemNotificationCreation(nil, defaultNaming, problemNotificationConfig).getProblemNotification(keptnCredentials, "profile-id", "sockshop")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "https://gateway.example.com/keptn/v1/event", notification.URL)
+	assert.False(t, notification.AcceptAnyCertificate)
+	assert.EqualValues(t,
+		[]dynatrace.ProblemNotificationHeader{
+			{Name: "x-token", Value: "my-token", Secret: true},
+			{Name: "Content-Type", Value: "application/cloudevents+json"},
+			{Name: "x-tenant", Value: "team-a"},
+			{Name: "Authorization", Value: "Basic dXNlcjpwYXNz", Secret: true},
+		},
+		notification.Headers)
+	assert.Equal(t, `{"type":"sh.keptn.events.problem","data":{"PID":"{PID}","KeptnProject":"sockshop"}}`, notification.Payload)
+}
+
+func TestProblemNotificationCreation_getProblemNotification_invalidConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *config.ProblemNotificationConfig
+	}{
+		{
+			name:   "payload template without project placeholder",
+			config: &config.ProblemNotificationConfig{PayloadTemplate: `{"data":{"PID":"{PID}"}}`},
+		},
+		{
+			name:   "header replacing Keptn API token",
+			config: &config.ProblemNotificationConfig{Headers: []config.ProblemNotificationHeaderConfig{{Name: "X-Token", Value: "other-token"}}},
+		},
+		{
+			name:   "header without name",
+			config: &config.ProblemNotificationConfig{Headers: []config.ProblemNotificationHeaderConfig{{Value: "value"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keptnCredentials, err := credentials.NewKeptnCredentials("https://keptn.example.com/api", "my-token", "")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = NewProblemNotificationCreation(nil, defaultNaming, tt.config).getProblemNotification(keptnCredentials, "profile-id", "sockshop")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_validateTestNotificationPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		expectErr bool
+	}{
+		{
+			name:    "default payload",
+			payload: strings.ReplaceAll(dynatrace.DefaultProblemNotificationPayloadTemplate, dynatrace.KeptnProjectPlaceholder, "sockshop"),
+		},
+		{
+			name:    "custom payload with Keptn context mapped to PID",
+			payload: `{"type":"sh.keptn.events.problem", "shkeptncontext" : "{PID}", "data":{"PID":"{PID}","KeptnProject":"sockshop"}}`,
+		},
+		{
+			name:      "custom payload without Keptn context",
+			payload:   `{"type":"sh.keptn.events.problem","data":{"PID":"{PID}","KeptnProject":"sockshop"}}`,
+			expectErr: true,
+		},
+		{
+			name:      "custom payload with Keptn context mapped to problem ID",
+			payload:   `{"type":"sh.keptn.events.problem","shkeptncontext":"{ProblemID}","data":{"PID":"{PID}","KeptnProject":"sockshop"}}`,
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTestNotificationPayload(tt.payload)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/metrics"
+)
+
+// sloFileMarker marks the Dynatrace objects generated by the dynatrace-service from the slo.yaml of a service, so that objects created by users are never overwritten.
+// It is the description of generated SLOs and is contained in the markdown tile of generated quality gate dashboards.
+// As it contains neither ';' nor '=', it is ignored when the KQG configuration of the markdown tile is parsed.
+const sloFileMarker = "Generated by the dynatrace-service from the slo.yaml of the service, changes will be overwritten."
+
+const (
+	sloTimeframe      = "-1w"
+	sloEvaluationType = "AGGREGATE"
+)
+
+// SLOCreation creates a Dynatrace SLO for each objective of a service and stage whose SLI is the ratio of two metrics.
+// The target and warning thresholds of the Dynatrace SLO are taken from the warning and pass criteria of the objective and are updated whenever monitoring is configured.
+type SLOCreation struct {
+	client    dynatrace.ClientInterface
+	kClient   keptn.ClientInterface
+	sloReader keptn.SLOReaderInterface
+	naming    *Naming
+}
+
+// NewSLOCreation creates a new SLOCreation.
+func NewSLOCreation(client dynatrace.ClientInterface, kClient keptn.ClientInterface, sloReader keptn.SLOReaderInterface, naming *Naming) *SLOCreation {
+	return &SLOCreation{
+		client:    client,
+		kClient:   kClient,
+		sloReader: sloReader,
+		naming:    naming,
+	}
+}
+
+// Create creates or updates the Dynatrace SLOs of the service in the stage and deletes generated SLOs of the service in the stage that are no longer desired.
+// Objectives that cannot be represented by a Dynatrace SLO are skipped.
+func (sc *SLOCreation) Create(ctx context.Context, project string, stage string, service string) []ConfigResult {
+	slos, err := sc.getDesiredSLOs(project, stage, service)
+	if errors.Is(err, errNoSLOs) {
+		log.WithFields(log.Fields{"stage": stage, "service": service}).Info("No SLOs defined for service. Skipping creation of Dynatrace SLOs.")
+		return nil
+	}
+	if err != nil {
+		return []ConfigResult{{Name: service, Message: err.Error()}}
+	}
+
+	sloClient := dynatrace.NewSLOClient(sc.client)
+	existingSLOs, err := sloClient.GetAll(ctx)
+	if err != nil {
+		return []ConfigResult{{Name: service, Message: err.Error()}}
+	}
+
+	var results []ConfigResult
+	for _, slo := range slos {
+		existingSLO, err := getExistingSLO(existingSLOs, slo.Name)
+		message := "Created successfully"
+		if err == nil {
+			if existingSLO == nil {
+				err = sloClient.Create(ctx, slo)
+			} else {
+				err = sloClient.Update(ctx, getUpdatedSLO(existingSLO, slo))
+				message = "Updated successfully"
+			}
+		}
+		if err != nil {
+			log.WithError(err).WithField("name", slo.Name).Error("Could not create Dynatrace SLO")
+			results = append(results, ConfigResult{Name: slo.Name, Message: err.Error()})
+			continue
+		}
+
+		log.WithField("name", slo.Name).Info(message)
+		results = append(results, ConfigResult{Name: slo.Name, Success: true, Message: message})
+	}
+
+	for _, obsoleteSLO := range sc.getObsoleteSLOs(existingSLOs, slos, project, stage, service) {
+		err := sloClient.DeleteByID(ctx, obsoleteSLO.ID)
+		if err != nil {
+			log.WithError(err).WithField("name", obsoleteSLO.Name).Error("Could not delete Dynatrace SLO")
+			results = append(results, ConfigResult{Name: obsoleteSLO.Name, Message: err.Error()})
+			continue
+		}
+
+		log.WithField("name", obsoleteSLO.Name).Info("Deleted Dynatrace SLO")
+		results = append(results, ConfigResult{Name: obsoleteSLO.Name, Success: true, Message: "Deleted successfully"})
+	}
+	return results
+}
+
+// Plan returns the changes Create would make to the Dynatrace SLOs of the service in the stage without writing them.
+func (sc *SLOCreation) Plan(ctx context.Context, project string, stage string, service string) []PlannedChange {
+	slos, err := sc.getDesiredSLOs(project, stage, service)
+	if errors.Is(err, errNoSLOs) {
+		return nil
+	}
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(service, err)}
+	}
+
+	existingSLOs, err := dynatrace.NewSLOClient(sc.client).GetAll(ctx)
+	if err != nil {
+		return []PlannedChange{newFailedPlannedChange(service, err)}
+	}
+
+	var plannedChanges []PlannedChange
+	for _, slo := range slos {
+		existingSLO, err := getExistingSLO(existingSLOs, slo.Name)
+		switch {
+		case err != nil:
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(slo.Name, err))
+		case existingSLO == nil:
+			plannedChanges = append(plannedChanges, PlannedChange{Name: slo.Name, Action: PlannedChangeActionCreate})
+		default:
+			plannedChanges = append(plannedChanges, newUpdatePlannedChange(slo.Name, existingSLO, getUpdatedSLO(existingSLO, slo)))
+		}
+	}
+
+	for _, obsoleteSLO := range sc.getObsoleteSLOs(existingSLOs, slos, project, stage, service) {
+		plannedChanges = append(plannedChanges, PlannedChange{Name: obsoleteSLO.Name, Action: PlannedChangeActionDelete})
+	}
+	return plannedChanges
+}
+
+// getObsoleteSLOs returns the existing SLOs generated by the dynatrace-service for the service in the stage that are not desired anymore, e.g. as their objective has been removed from the slo.yaml.
+// SLOs are only returned if they have the description of a generated SLO and are named by the naming templates or the default naming for the project, stage and service.
+func (sc *SLOCreation) getObsoleteSLOs(existingSLOs []dynatrace.SLO, desiredSLOs []*dynatrace.SLO, project string, stage string, service string) []dynatrace.SLO {
+	desiredNames := make(map[string]bool, len(desiredSLOs))
+	for _, slo := range desiredSLOs {
+		desiredNames[slo.Name] = true
+	}
+
+	var obsoleteSLOs []dynatrace.SLO
+	for _, slo := range existingSLOs {
+		if slo.Description != sloFileMarker || desiredNames[slo.Name] {
+			continue
+		}
+
+		sloProject, sloStage, sloService, ok := parseSLOName(sc.naming, slo.Name)
+		if !ok || sloProject != project || sloStage != stage || sloService != service {
+			continue
+		}
+		obsoleteSLOs = append(obsoleteSLOs, slo)
+	}
+	return obsoleteSLOs
+}
+
+// getDesiredSLOs returns the Dynatrace SLOs for the objectives of the service that can be represented by one.
+// errNoSLOs is returned if the SLOs of the service cannot be read, whereas an empty slo.yaml results in no desired SLOs, so that all generated SLOs of the service are deleted.
+func (sc *SLOCreation) getDesiredSLOs(project string, stage string, service string) ([]*dynatrace.SLO, error) {
+	slos, err := sc.sloReader.GetSLOs(project, stage, service)
+	if err != nil {
+		return nil, errNoSLOs
+	}
+
+	customQueries, err := sc.kClient.GetCustomQueries(project, stage, service)
+	if err != nil {
+		return nil, fmt.Errorf("could not get custom queries: %w", err)
+	}
+
+	var dynatraceSLOs []*dynatrace.SLO
+	for _, objective := range slos.Objectives {
+		query, err := customQueries.GetQueryByNameOrDefault(objective.SLI)
+		if err != nil {
+			log.WithError(err).WithField("sli", objective.SLI).Warn("Could not find query for SLI, skipping it for Dynatrace SLOs")
+			continue
+		}
+
+		dynatraceSLO, err := createDynatraceSLO(sc.naming, project, stage, service, objective, query)
+		if err != nil {
+			log.WithError(err).WithField("sli", objective.SLI).Debug("SLI cannot be represented by a Dynatrace SLO, skipping it")
+			continue
+		}
+		dynatraceSLOs = append(dynatraceSLOs, dynatraceSLO)
+	}
+	return dynatraceSLOs, nil
+}
+
+// createDynatraceSLO creates a Dynatrace SLO for an objective whose SLI is a Metrics API v2 query of the ratio of two metrics, e.g. the percentage of successful requests.
+// The ratio is always expressed as a percentage, so thresholds of ratios not multiplied by 100 are scaled accordingly.
+func createDynatraceSLO(naming *Naming, project string, stage string, service string, objective *keptnlib.SLO, query string) (*dynatrace.SLO, error) {
+	metricsQuery, _, err := parseMetricsSLIQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	ratioMetricSelector, err := metrics.ParseRatioMetricSelector(metricsQuery.GetMetricSelector())
+	if err != nil {
+		return nil, err
+	}
+
+	target, warning, err := getSLOThresholds(objective)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ratioMetricSelector.IsPercentage() {
+		target = target * 100
+		warning = warning * 100
+	}
+
+	return &dynatrace.SLO{
+		Name:             naming.getSLOName(objective.SLI, project, stage, service),
+		Description:      sloFileMarker,
+		Enabled:          true,
+		MetricExpression: ratioMetricSelector.GetPercentageMetricExpression(),
+		EvaluationType:   sloEvaluationType,
+		Filter:           getSLOFilter(project, stage, service, metricsQuery.GetEntitySelector()),
+		Target:           target,
+		Warning:          warning,
+		Timeframe:        sloTimeframe,
+	}, nil
+}
+
+// getSLOThresholds returns the target and warning thresholds of a Dynatrace SLO from the static lower bounds of the warning and pass criteria of the objective, e.g. >=95 and >=99.
+// A Dynatrace SLO fails below its target and warns below its warning threshold, so the Keptn warning criterion becomes the target and the pass criterion the warning threshold.
+// If the objective has no warning criterion, both thresholds are set to the pass criterion. Comparison-based criteria are ignored.
+func getSLOThresholds(objective *keptnlib.SLO) (float64, float64, error) {
+	pass, err := getSLOLowerBound(objective.Pass)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid pass criteria: %w", err)
+	}
+	if pass == nil {
+		return 0, 0, errors.New("pass criteria do not contain a static lower bound")
+	}
+
+	warning, err := getSLOLowerBound(objective.Warning)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid warning criteria: %w", err)
+	}
+	if warning == nil {
+		return *pass, *pass, nil
+	}
+
+	if *warning > *pass {
+		return 0, 0, fmt.Errorf("warning criterion %v is stricter than pass criterion %v", *warning, *pass)
+	}
+	return *warning, *pass, nil
+}
+
+// getSLOLowerBound returns the single static lower bound of the criteria, e.g. 95 for >=95, or nil if there is none.
+// An error is returned if the criteria contain several lower bounds or any static criterion other than a lower bound.
+func getSLOLowerBound(sloCriteria []*keptnlib.SLOCriteria) (*float64, error) {
+	var lowerBound *float64
+	for _, criteria := range sloCriteria {
+		for _, criterion := range criteria.Criteria {
+			c, err := parseCriteriaString(criterion)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse criterion %s: %w", criterion, err)
+			}
+
+			if c.IsComparison || c.CheckPercentage {
+				continue
+			}
+
+			if c.Operator != ">=" && c.Operator != ">" {
+				return nil, fmt.Errorf("criterion %s is not a lower bound", criterion)
+			}
+
+			if lowerBound != nil {
+				return nil, errors.New("criteria contain several lower bounds")
+			}
+
+			value := c.Value
+			lowerBound = &value
+		}
+	}
+	return lowerBound, nil
+}
+
+// getSLOFilter returns the entity selector of the SLI query with Keptn placeholders replaced or, if it is empty, one selecting the services tagged with the project, stage and service.
+func getSLOFilter(project string, stage string, service string, entitySelector string) string {
+	entitySelector = strings.TrimSpace(entitySelector)
+	if entitySelector != "" {
+		return common.ReplaceKeptnPlaceholders(entitySelector, newServiceEventContentAdapter(project, stage, service))
+	}
+
+	return fmt.Sprintf(`type("SERVICE"),tag("%s"),tag("%s"),tag("%s")`, getKeptnProjectTag(project), getKeptnStageTag(stage), getTag(keptnService, service))
+}
+
+// getExistingSLO returns the existing Dynatrace SLO with the name or nil if it does not exist.
+// An error is returned if several SLOs have the name or if the SLO was not generated by the dynatrace-service.
+func getExistingSLO(existingSLOs []dynatrace.SLO, name string) (*dynatrace.SLO, error) {
+	var matchingSLOs []dynatrace.SLO
+	for _, slo := range existingSLOs {
+		if slo.Name == name {
+			matchingSLOs = append(matchingSLOs, slo)
+		}
+	}
+
+	switch len(matchingSLOs) {
+	case 0:
+		return nil, nil
+	case 1:
+	default:
+		return nil, fmt.Errorf("found %d SLOs named '%s', leaving them unchanged", len(matchingSLOs), name)
+	}
+
+	if matchingSLOs[0].Description != sloFileMarker {
+		return nil, fmt.Errorf("SLO '%s' was not generated by the dynatrace-service, leaving it unchanged", name)
+	}
+	return &matchingSLOs[0], nil
+}
+
+// getUpdatedSLO returns the desired SLO with the ID of the existing SLO so that it is updated in place.
+func getUpdatedSLO(existingSLO *dynatrace.SLO, desiredSLO *dynatrace.SLO) *dynatrace.SLO {
+	updatedSLO := *desiredSLO
+	updatedSLO.ID = existingSLO.ID
+	return &updatedSLO
+}
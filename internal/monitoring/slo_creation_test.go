@@ -0,0 +1,167 @@
+package monitoring
+
+import (
+	"testing"
+
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+func Test_createDynatraceSLO(t *testing.T) {
+	objective := &keptnlib.SLO{
+		SLI:     "success_rate",
+		Pass:    []*keptnlib.SLOCriteria{{Criteria: []string{">=99", ">-5%"}}},
+		Warning: []*keptnlib.SLOCriteria{{Criteria: []string{">= 95"}}},
+	}
+
+	slo, err := createDynatraceSLO(defaultNaming, "sockshop", "production", "carts", objective,
+		"metricSelector=(100)*(builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy())&entitySelector=type(SERVICE),tag(keptn_project:$PROJECT),tag(keptn_stage:$STAGE),tag(keptn_service:$SERVICE),tag(keptn_deployment:$DEPLOYMENT)")
+	assert.NoError(t, err)
+	assert.Equal(t,
+		&dynatrace.SLO{
+			Name:             "success_rate (Keptn.sockshop.production.carts)",
+			Description:      sloFileMarker,
+			Enabled:          true,
+			MetricExpression: "(100)*(builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy())",
+			EvaluationType:   "AGGREGATE",
+			Filter:           "type(SERVICE),tag(keptn_project:sockshop),tag(keptn_stage:production),tag(keptn_service:carts),tag(keptn_deployment:primary)",
+			Target:           95,
+			Warning:          99,
+			Timeframe:        "-1w",
+		},
+		slo)
+}
+
+func Test_createDynatraceSLO_ratioWithoutPercentage(t *testing.T) {
+	objective := &keptnlib.SLO{
+		SLI:  "success_ratio",
+		Pass: []*keptnlib.SLOCriteria{{Criteria: []string{">0.995"}}},
+	}
+
+	slo, err := createDynatraceSLO(defaultNaming, "sockshop", "production", "carts", objective,
+		"metricSelector=(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum)")
+	assert.NoError(t, err)
+	if assert.NotNil(t, slo) {
+		assert.Equal(t, "(100)*(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum)", slo.MetricExpression)
+		assert.Equal(t, `type("SERVICE"),tag("keptn_project:sockshop"),tag("keptn_stage:production"),tag("keptn_service:carts")`, slo.Filter)
+		assert.InDelta(t, 99.5, slo.Target, 0.0001)
+		assert.InDelta(t, 99.5, slo.Warning, 0.0001)
+	}
+}
+
+func Test_createDynatraceSLO_unsupportedObjectives(t *testing.T) {
+	ratioQuery := "metricSelector=(100)*(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum)"
+
+	tests := []struct {
+		name                 string
+		objective            *keptnlib.SLO
+		query                string
+		expectedErrorMessage string
+	}{
+		{
+			name:                 "single metric",
+			objective:            &keptnlib.SLO{SLI: "response_time_p90", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{"<600"}}}},
+			query:                "metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)",
+			expectedErrorMessage: "not a ratio of two metrics",
+		},
+		{
+			name:                 "USQL query",
+			objective:            &keptnlib.SLO{SLI: "custom_usql", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{">=99"}}}},
+			query:                "USQL;COLUMN_CHART;iOS;SELECT osVersion FROM usersession",
+			expectedErrorMessage: "not a ratio of two metrics",
+		},
+		{
+			name:                 "no pass criteria",
+			objective:            &keptnlib.SLO{SLI: "success_rate"},
+			query:                ratioQuery,
+			expectedErrorMessage: "pass criteria do not contain a static lower bound",
+		},
+		{
+			name:                 "only relative pass criteria",
+			objective:            &keptnlib.SLO{SLI: "success_rate", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{">-5%"}}}},
+			query:                ratioQuery,
+			expectedErrorMessage: "pass criteria do not contain a static lower bound",
+		},
+		{
+			name:                 "upper bound",
+			objective:            &keptnlib.SLO{SLI: "success_rate", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{">=99", "<=100"}}}},
+			query:                ratioQuery,
+			expectedErrorMessage: "criterion <=100 is not a lower bound",
+		},
+		{
+			name:                 "several lower bounds",
+			objective:            &keptnlib.SLO{SLI: "success_rate", Pass: []*keptnlib.SLOCriteria{{Criteria: []string{">=99"}}, {Criteria: []string{">98"}}}},
+			query:                ratioQuery,
+			expectedErrorMessage: "criteria contain several lower bounds",
+		},
+		{
+			name: "warning stricter than pass",
+			objective: &keptnlib.SLO{
+				SLI:     "success_rate",
+				Pass:    []*keptnlib.SLOCriteria{{Criteria: []string{">=95"}}},
+				Warning: []*keptnlib.SLOCriteria{{Criteria: []string{">=99"}}},
+			},
+			query:                ratioQuery,
+			expectedErrorMessage: "warning criterion 99 is stricter than pass criterion 95",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slo, err := createDynatraceSLO(defaultNaming, "sockshop", "production", "carts", tt.objective, tt.query)
+			assert.Nil(t, slo)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.expectedErrorMessage)
+			}
+		})
+	}
+}
+
+func Test_getExistingSLO(t *testing.T) {
+	generatedSLO := dynatrace.SLO{ID: "1", Name: "success_rate (Keptn.sockshop.production.carts)", Description: sloFileMarker}
+	existingSLOs := []dynatrace.SLO{
+		generatedSLO,
+		{ID: "2", Name: "Availability of easytravel"},
+		{ID: "3", Name: "error_rate (Keptn.sockshop.production.carts)"},
+		{ID: "4", Name: "error_rate (Keptn.sockshop.production.carts)", Description: sloFileMarker},
+	}
+
+	slo, err := getExistingSLO(existingSLOs, "success_rate (Keptn.sockshop.production.carts)")
+	assert.NoError(t, err)
+	assert.Equal(t, &generatedSLO, slo)
+
+	slo, err = getExistingSLO(existingSLOs, "success_rate (Keptn.sockshop.staging.carts)")
+	assert.NoError(t, err)
+	assert.Nil(t, slo)
+
+	_, err = getExistingSLO(existingSLOs, "Availability of easytravel")
+	assert.EqualError(t, err, "SLO 'Availability of easytravel' was not generated by the dynatrace-service, leaving it unchanged")
+
+	_, err = getExistingSLO(existingSLOs, "error_rate (Keptn.sockshop.production.carts)")
+	assert.EqualError(t, err, "found 2 SLOs named 'error_rate (Keptn.sockshop.production.carts)', leaving them unchanged")
+
+	updatedSLO := getUpdatedSLO(&generatedSLO, &dynatrace.SLO{Name: generatedSLO.Name, Description: sloFileMarker, Target: 95})
+	assert.Equal(t, "1", updatedSLO.ID)
+	assert.EqualValues(t, 95, updatedSLO.Target)
+}
+
+func TestSLOCreation_getObsoleteSLOs(t *testing.T) {
+	existingSLOs := []dynatrace.SLO{
+		{ID: "1", Name: "success_rate (Keptn.sockshop.production.carts)", Description: sloFileMarker},
+		{ID: "2", Name: "error_rate (Keptn.sockshop.production.carts)", Description: sloFileMarker},
+		{ID: "3", Name: "latency_ratio (Keptn.sockshop.production.carts)"},
+		{ID: "4", Name: "error_rate (Keptn.sockshop.staging.carts)", Description: sloFileMarker},
+		{ID: "5", Name: "error_rate (Keptn.sockshop.production.orders)", Description: sloFileMarker},
+		{ID: "6", Name: "Availability of easytravel", Description: sloFileMarker},
+	}
+	desiredSLOs := []*dynatrace.SLO{
+		{Name: "success_rate (Keptn.sockshop.production.carts)", Description: sloFileMarker},
+	}
+
+	obsoleteSLOs := NewSLOCreation(nil, nil, nil, defaultNaming).getObsoleteSLOs(existingSLOs, desiredSLOs, "sockshop", "production", "carts")
+	assert.EqualValues(t, []dynatrace.SLO{existingSLOs[1]}, obsoleteSLOs)
+
+	obsoleteSLOs = NewSLOCreation(nil, nil, nil, defaultNaming).getObsoleteSLOs(existingSLOs, nil, "sockshop", "production", "carts")
+	assert.EqualValues(t, []dynatrace.SLO{existingSLOs[0], existingSLOs[1]}, obsoleteSLOs)
+}
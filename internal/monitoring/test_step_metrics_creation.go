@@ -0,0 +1,232 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+// testHeaderName is the HTTP header sent by Keptn load tests, e.g. x-dynatrace-test: LSN=performance;LTN=performance_1;TSN=Basic Check;
+const testHeaderName = "x-dynatrace-test"
+
+const (
+	loadTestNameRequestAttribute = "LTN"
+	testStepNameRequestAttribute = "TSN"
+)
+
+// testStepRequestAttributeNames are the names of the request attributes created in Dynatrace, in the order they are created.
+var testStepRequestAttributeNames = []string{loadTestNameRequestAttribute, testStepNameRequestAttribute}
+
+// testStepMetric is a calculated service metric of a request metric split by test step.
+type testStepMetric struct {
+	key             string
+	name            string
+	metric          string
+	unit            string
+	topXAggregation string
+}
+
+// testStepMetrics are the calculated service metrics created in Dynatrace.
+var testStepMetrics = []testStepMetric{
+	{
+		key:             dynatrace.TestStepResponseTimeMetricKey,
+		name:            "Test Step Response Time",
+		metric:          "RESPONSE_TIME",
+		unit:            "MICRO_SECOND",
+		topXAggregation: "SUM",
+	},
+	{
+		key:             dynatrace.TestStepFailureRateMetricKey,
+		name:            "Test Step Failure Rate",
+		metric:          "FAILURE_RATE",
+		unit:            "PERCENT",
+		topXAggregation: "AVERAGE",
+	},
+}
+
+// TestStepMetricsCreation creates the request attributes capturing the load test and test step names sent by Keptn load tests and the calculated service metrics split by test step.
+// The metrics are restricted to Keptn-managed services. Existing request attributes and metrics are left unchanged.
+type TestStepMetricsCreation struct {
+	client dynatrace.ClientInterface
+}
+
+// NewTestStepMetricsCreation creates a new TestStepMetricsCreation.
+func NewTestStepMetricsCreation(client dynatrace.ClientInterface) *TestStepMetricsCreation {
+	return &TestStepMetricsCreation{
+		client: client,
+	}
+}
+
+// Create creates the request attributes and calculated service metrics that do not exist yet.
+func (tc *TestStepMetricsCreation) Create(ctx context.Context) []ConfigResult {
+	log.Info("Setting up test step request attributes and calculated service metrics in Dynatrace Tenant")
+
+	var results []ConfigResult
+
+	requestAttributesClient := dynatrace.NewRequestAttributesClient(tc.client)
+	existingRequestAttributeNames, err := requestAttributesClient.GetAllNames(ctx)
+	for _, name := range testStepRequestAttributeNames {
+		switch {
+		case err != nil:
+			results = append(results, ConfigResult{Name: name, Message: "Could not retrieve request attributes: " + err.Error()})
+		case existingRequestAttributeNames.Contains(name):
+			log.WithField("name", name).Info("Request attribute already exists")
+			results = append(results, ConfigResult{Name: name, Success: true, Message: "Request attribute " + name + " already exists"})
+		default:
+			results = append(results, createRequestAttribute(ctx, requestAttributesClient, name))
+		}
+	}
+
+	calculatedMetricsClient := dynatrace.NewCalculatedServiceMetricsClient(tc.client)
+	existingMetricKeys, err := calculatedMetricsClient.GetAllMetricKeys(ctx)
+	for _, metric := range testStepMetrics {
+		switch {
+		case err != nil:
+			results = append(results, ConfigResult{Name: metric.key, Message: "Could not retrieve calculated service metrics: " + err.Error()})
+		case existingMetricKeys.Contains(metric.key):
+			log.WithField("metricKey", metric.key).Info("Calculated service metric already exists")
+			results = append(results, ConfigResult{Name: metric.key, Success: true, Message: "Calculated service metric " + metric.key + " already exists"})
+		default:
+			results = append(results, createCalculatedServiceMetric(ctx, calculatedMetricsClient, metric))
+		}
+	}
+
+	return results
+}
+
+// Plan returns the changes Create would make to the request attributes and calculated service metrics without creating them.
+func (tc *TestStepMetricsCreation) Plan(ctx context.Context) []PlannedChange {
+	var plannedChanges []PlannedChange
+
+	existingRequestAttributeNames, err := dynatrace.NewRequestAttributesClient(tc.client).GetAllNames(ctx)
+	for _, name := range testStepRequestAttributeNames {
+		switch {
+		case err != nil:
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(name, err))
+		case existingRequestAttributeNames.Contains(name):
+			plannedChanges = append(plannedChanges, PlannedChange{Name: name, Action: PlannedChangeActionNone})
+		default:
+			plannedChanges = append(plannedChanges, PlannedChange{Name: name, Action: PlannedChangeActionCreate})
+		}
+	}
+
+	existingMetricKeys, err := dynatrace.NewCalculatedServiceMetricsClient(tc.client).GetAllMetricKeys(ctx)
+	for _, metric := range testStepMetrics {
+		switch {
+		case err != nil:
+			plannedChanges = append(plannedChanges, newFailedPlannedChange(metric.key, err))
+		case existingMetricKeys.Contains(metric.key):
+			plannedChanges = append(plannedChanges, PlannedChange{Name: metric.key, Action: PlannedChangeActionNone})
+		default:
+			plannedChanges = append(plannedChanges, PlannedChange{Name: metric.key, Action: PlannedChangeActionCreate})
+		}
+	}
+
+	return plannedChanges
+}
+
+func createRequestAttribute(ctx context.Context, client *dynatrace.RequestAttributesClient, name string) ConfigResult {
+	err := client.Create(ctx, createTestHeaderRequestAttributeDTO(name))
+	if err != nil {
+		log.WithError(err).WithField("name", name).Error("Could not create request attribute")
+		return ConfigResult{
+			Name:    name,
+			Message: "Could not create request attribute: " + err.Error(),
+		}
+	}
+
+	log.WithField("name", name).Info("Created request attribute")
+	return ConfigResult{
+		Name:    name,
+		Success: true,
+	}
+}
+
+func createCalculatedServiceMetric(ctx context.Context, client *dynatrace.CalculatedServiceMetricsClient, metric testStepMetric) ConfigResult {
+	err := client.Create(ctx, createTestStepMetricDTO(metric))
+	if err != nil {
+		log.WithError(err).WithField("metricKey", metric.key).Error("Could not create calculated service metric")
+		return ConfigResult{
+			Name:    metric.key,
+			Message: "Could not create calculated service metric: " + err.Error(),
+		}
+	}
+
+	log.WithField("metricKey", metric.key).Info("Created calculated service metric")
+	return ConfigResult{
+		Name:    metric.key,
+		Success: true,
+	}
+}
+
+// createTestHeaderRequestAttributeDTO creates a request attribute capturing the value of the field of the x-dynatrace-test header with the name, e.g. TSN.
+func createTestHeaderRequestAttributeDTO(name string) *dynatrace.RequestAttribute {
+	return &dynatrace.RequestAttribute{
+		Name:     name,
+		Enabled:  true,
+		DataType: "STRING",
+		DataSources: []dynatrace.RequestAttributeDataSource{
+			{
+				Enabled:                     true,
+				Source:                      "REQUEST_HEADER",
+				ParameterName:               testHeaderName,
+				CapturingAndStorageLocation: "CAPTURE_AND_STORE_ON_SERVER",
+				ValueProcessing: &dynatrace.RequestAttributeValueProcessing{
+					ExtractSubstring: &dynatrace.RequestAttributeExtractSubstring{
+						Position:     "BETWEEN",
+						Delimiter:    name + "=",
+						EndDelimiter: ";",
+					},
+					Trim: false,
+				},
+			},
+		},
+		Normalization: "ORIGINAL",
+		Aggregation:   "FIRST",
+	}
+}
+
+// createTestStepMetricDTO creates a calculated service metric of the requests of Keptn-managed services that are part of a test step, split by the name of the test step.
+func createTestStepMetricDTO(metric testStepMetric) *dynatrace.CalculatedServiceMetric {
+	return &dynatrace.CalculatedServiceMetric{
+		TsmMetricKey: metric.key,
+		Name:         metric.name,
+		Enabled:      true,
+		MetricDefinition: dynatrace.CalculatedServiceMetricDefinition{
+			Metric: metric.metric,
+		},
+		Unit: metric.unit,
+		Conditions: []dynatrace.CalculatedServiceMetricCondition{
+			{
+				Attribute: "SERVICE_TAG",
+				ComparisonInfo: dynatrace.CalculatedServiceMetricComparisonInfo{
+					Type:       "TAG",
+					Comparison: "TAG_KEY_EQUALS",
+					Value: dynatrace.CalculatedServiceMetricTag{
+						Context: "CONTEXTLESS",
+						Key:     "keptn_managed",
+					},
+				},
+			},
+			{
+				Attribute: "REQUEST_ATTRIBUTE",
+				ComparisonInfo: dynatrace.CalculatedServiceMetricComparisonInfo{
+					Type:             "STRING_REQUEST_ATTRIBUTE",
+					Comparison:       "EXISTS",
+					RequestAttribute: testStepNameRequestAttribute,
+				},
+			},
+		},
+		DimensionDefinition: &dynatrace.CalculatedServiceMetricDimensionDefinition{
+			Name:            dynatrace.TestStepDimensionName,
+			Dimension:       fmt.Sprintf("{RequestAttribute:%s}", testStepNameRequestAttribute),
+			Placeholders:    []string{},
+			TopX:            100,
+			TopXDirection:   "DESCENDING",
+			TopXAggregation: metric.topXAggregation,
+		},
+	}
+}
@@ -0,0 +1,252 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const testDynatraceAPIToken = "dt0c01.ST2EY72KQINMH574WMNVI7YN.G3DFPBEJYMODIDAEX454M7YWBUVEFOWKPRVMWFASS64NFH52PX6BNDVFFM572RZM"
+
+const testStepMetricsCreationTestDataFolder = "./testdata/test_step_metrics_creation/"
+
+// writeRecordingHandler records the paths of all POST and PUT requests before passing requests on to the wrapped handler.
+type writeRecordingHandler struct {
+	handler   http.Handler
+	postPaths []string
+	putPaths  []string
+}
+
+func (h *writeRecordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.postPaths = append(h.postPaths, r.URL.Path)
+	case http.MethodPut:
+		h.putPaths = append(h.putPaths, r.URL.Path)
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func createTestStepMetricsCreation(t *testing.T, handler http.Handler) (*TestStepMetricsCreation, func()) {
+	httpClient, url, teardown := test.CreateHTTPSClient(handler)
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	if !assert.NoError(t, err) {
+		teardown()
+		t.FailNow()
+	}
+
+	return NewTestStepMetricsCreation(dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient)), teardown
+}
+
+func newTestStepMetricsHandler(t *testing.T, requestAttributesFileName string, calculatedMetricsFileName string) *writeRecordingHandler {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/config/v1/service/requestAttributes", testStepMetricsCreationTestDataFolder+requestAttributesFileName)
+	handler.AddExact("/api/config/v1/calculatedMetrics/service", testStepMetricsCreationTestDataFolder+calculatedMetricsFileName)
+	return &writeRecordingHandler{handler: handler}
+}
+
+func newTestStepMetricsErrorHandler(t *testing.T) *writeRecordingHandler {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExactError("/api/config/v1/service/requestAttributes", 403, testStepMetricsCreationTestDataFolder+"error_forbidden.json")
+	handler.AddExactError("/api/config/v1/calculatedMetrics/service", 403, testStepMetricsCreationTestDataFolder+"error_forbidden.json")
+	return &writeRecordingHandler{handler: handler}
+}
+
+// TestTestStepMetricsCreation_Create_existing tests that existing request attributes and calculated service metrics are left unchanged.
+func TestTestStepMetricsCreation_Create_existing(t *testing.T) {
+	handler := newTestStepMetricsHandler(t, "request_attributes_existing.json", "calculated_metrics_existing.json")
+	creation, teardown := createTestStepMetricsCreation(t, handler)
+	defer teardown()
+
+	results := creation.Create(context.Background())
+
+	assert.Empty(t, handler.postPaths)
+	assert.EqualValues(t,
+		[]ConfigResult{
+			{Name: "LTN", Success: true, Message: "Request attribute LTN already exists"},
+			{Name: "TSN", Success: true, Message: "Request attribute TSN already exists"},
+			{Name: "calc:service.teststepresponsetime", Success: true, Message: "Calculated service metric calc:service.teststepresponsetime already exists"},
+			{Name: "calc:service.teststepfailurerate", Success: true, Message: "Calculated service metric calc:service.teststepfailurerate already exists"},
+		},
+		results)
+}
+
+// TestTestStepMetricsCreation_Create_missing tests that missing request attributes and calculated service metrics are created.
+func TestTestStepMetricsCreation_Create_missing(t *testing.T) {
+	handler := newTestStepMetricsHandler(t, "request_attributes_missing.json", "calculated_metrics_missing.json")
+	creation, teardown := createTestStepMetricsCreation(t, handler)
+	defer teardown()
+
+	results := creation.Create(context.Background())
+
+	assert.EqualValues(t,
+		[]string{
+			"/api/config/v1/service/requestAttributes",
+			"/api/config/v1/service/requestAttributes",
+			"/api/config/v1/calculatedMetrics/service",
+			"/api/config/v1/calculatedMetrics/service",
+		},
+		handler.postPaths)
+	assert.EqualValues(t,
+		[]ConfigResult{
+			{Name: "LTN", Success: true},
+			{Name: "TSN", Success: true},
+			{Name: "calc:service.teststepresponsetime", Success: true},
+			{Name: "calc:service.teststepfailurerate", Success: true},
+		},
+		results)
+}
+
+// TestTestStepMetricsCreation_Create_error tests that an error is reported for each object if the existing objects cannot be retrieved and that nothing is created.
+func TestTestStepMetricsCreation_Create_error(t *testing.T) {
+	handler := newTestStepMetricsErrorHandler(t)
+	creation, teardown := createTestStepMetricsCreation(t, handler)
+	defer teardown()
+
+	results := creation.Create(context.Background())
+
+	assert.Empty(t, handler.postPaths)
+	if assert.Len(t, results, 4) {
+		for _, result := range results {
+			assert.False(t, result.Success)
+			assert.Contains(t, result.Message, "Could not retrieve")
+		}
+	}
+}
+
+func TestTestStepMetricsCreation_Plan(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(t *testing.T) *writeRecordingHandler
+		wantActions []PlannedChangeAction
+		wantErrors  bool
+	}{
+		{
+			name: "existing",
+			handler: func(t *testing.T) *writeRecordingHandler {
+				return newTestStepMetricsHandler(t, "request_attributes_existing.json", "calculated_metrics_existing.json")
+			},
+			wantActions: []PlannedChangeAction{PlannedChangeActionNone, PlannedChangeActionNone, PlannedChangeActionNone, PlannedChangeActionNone},
+		},
+		{
+			name: "missing",
+			handler: func(t *testing.T) *writeRecordingHandler {
+				return newTestStepMetricsHandler(t, "request_attributes_missing.json", "calculated_metrics_missing.json")
+			},
+			wantActions: []PlannedChangeAction{PlannedChangeActionCreate, PlannedChangeActionCreate, PlannedChangeActionCreate, PlannedChangeActionCreate},
+		},
+		{
+			name:        "error",
+			handler:     newTestStepMetricsErrorHandler,
+			wantActions: []PlannedChangeAction{"", "", "", ""},
+			wantErrors:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handler(t)
+			creation, teardown := createTestStepMetricsCreation(t, handler)
+			defer teardown()
+
+			plannedChanges := creation.Plan(context.Background())
+
+			assert.Empty(t, handler.postPaths)
+			if !assert.Len(t, plannedChanges, len(tt.wantActions)) {
+				return
+			}
+
+			wantNames := []string{"LTN", "TSN", "calc:service.teststepresponsetime", "calc:service.teststepfailurerate"}
+			for i, plannedChange := range plannedChanges {
+				assert.Equal(t, wantNames[i], plannedChange.Name)
+				assert.Equal(t, tt.wantActions[i], plannedChange.Action)
+				assert.Equal(t, tt.wantErrors, plannedChange.Error != "")
+			}
+		})
+	}
+}
+
+func Test_createTestHeaderRequestAttributeDTO(t *testing.T) {
+	requestAttribute, err := json.Marshal(createTestHeaderRequestAttributeDTO("TSN"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "TSN",
+		"enabled": true,
+		"dataType": "STRING",
+		"dataSources": [
+			{
+				"enabled": true,
+				"source": "REQUEST_HEADER",
+				"parameterName": "x-dynatrace-test",
+				"capturingAndStorageLocation": "CAPTURE_AND_STORE_ON_SERVER",
+				"valueProcessing": {
+					"extractSubstring": {
+						"position": "BETWEEN",
+						"delimiter": "TSN=",
+						"endDelimiter": ";"
+					},
+					"trim": false
+				}
+			}
+		],
+		"normalization": "ORIGINAL",
+		"aggregation": "FIRST",
+		"confidential": false,
+		"skipPersonalDataMasking": false
+	}`, string(requestAttribute))
+}
+
+func Test_createTestStepMetricDTO(t *testing.T) {
+	metric, err := json.Marshal(createTestStepMetricDTO(testStepMetrics[0]))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"tsmMetricKey": "calc:service.teststepresponsetime",
+		"name": "Test Step Response Time",
+		"enabled": true,
+		"metricDefinition": {
+			"metric": "RESPONSE_TIME"
+		},
+		"unit": "MICRO_SECOND",
+		"conditions": [
+			{
+				"attribute": "SERVICE_TAG",
+				"comparisonInfo": {
+					"type": "TAG",
+					"comparison": "TAG_KEY_EQUALS",
+					"value": {
+						"context": "CONTEXTLESS",
+						"key": "keptn_managed"
+					},
+					"negate": false,
+					"caseSensitive": false
+				}
+			},
+			{
+				"attribute": "REQUEST_ATTRIBUTE",
+				"comparisonInfo": {
+					"type": "STRING_REQUEST_ATTRIBUTE",
+					"comparison": "EXISTS",
+					"value": null,
+					"negate": false,
+					"requestAttribute": "TSN",
+					"caseSensitive": false
+				}
+			}
+		],
+		"dimensionDefinition": {
+			"name": "Test Step",
+			"dimension": "{RequestAttribute:TSN}",
+			"placeholders": [],
+			"topX": 100,
+			"topXDirection": "DESCENDING",
+			"topXAggregation": "SUM"
+		}
+	}`, string(metric))
+}
@@ -3,8 +3,12 @@ package onboard
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
 	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	keptnlib "github.com/keptn/go-utils/pkg/lib"
@@ -13,12 +17,22 @@ import (
 
 	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
 	"github.com/keptn-contrib/dynatrace-service/internal/env"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/metrics"
 	log "github.com/sirupsen/logrus"
 )
 
 const synchronizedProject = "dynatrace"
 const synchronizedStage = "quality-gate"
 
+// testStepsLookback is how far back the test steps of a new service are looked up to add their SLIs to its sli.yaml.
+const testStepsLookback = 7 * 24 * time.Hour
+
+// invalidSLINameCharacters matches the characters of a test step name that are replaced by underscores in the names of its SLIs.
+var invalidSLINameCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// selectorStringEscaper escapes quotes and tildes in quoted strings of metric selectors.
+var selectorStringEscaper = strings.NewReplacer(`~`, `~~`, `"`, `~"`)
+
 type initSyncEventAdapter struct {
 }
 
@@ -68,18 +82,41 @@ type EntitiesClientFactory interface {
 	CreateEntitiesClient(ctx context.Context) (*dynatrace.EntitiesClient, error)
 }
 
-type defaultEntitiesClientFactory struct {
+// MetricsClientFactory defines a factory that can get MetricsClients.
+type MetricsClientFactory interface {
+	// CreateMetricsClient creates a dynatrace.MetricsClient or returns an error.
+	CreateMetricsClient(ctx context.Context) (*dynatrace.MetricsClient, error)
+}
+
+type defaultClientFactory struct {
 	configProvider config.DynatraceConfigProvider
 }
 
-func newDefaultEntitiesClientFactory(resourceClient keptn.DynatraceConfigReaderInterface) *defaultEntitiesClientFactory {
-	return &defaultEntitiesClientFactory{
+func newDefaultClientFactory(resourceClient keptn.DynatraceConfigReaderInterface) *defaultClientFactory {
+	return &defaultClientFactory{
 		configProvider: config.NewDynatraceConfigGetter(resourceClient),
 	}
 }
 
 // CreateEntitiesClient creates a dynatrace.EntitiesClient or returns an error.
-func (f defaultEntitiesClientFactory) CreateEntitiesClient(ctx context.Context) (*dynatrace.EntitiesClient, error) {
+func (f defaultClientFactory) CreateEntitiesClient(ctx context.Context) (*dynatrace.EntitiesClient, error) {
+	dynatraceClient, err := f.createDynatraceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dynatrace.NewEntitiesClient(dynatraceClient), nil
+}
+
+// CreateMetricsClient creates a dynatrace.MetricsClient or returns an error.
+func (f defaultClientFactory) CreateMetricsClient(ctx context.Context) (*dynatrace.MetricsClient, error) {
+	dynatraceClient, err := f.createDynatraceClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return dynatrace.NewMetricsClient(dynatraceClient), nil
+}
+
+func (f defaultClientFactory) createDynatraceClient(ctx context.Context) (*dynatrace.Client, error) {
 	dynatraceConfig, err := f.configProvider.GetDynatraceConfig(initSyncEventAdapter{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load Dynatrace config: %w", err)
@@ -95,15 +132,16 @@ func (f defaultEntitiesClientFactory) CreateEntitiesClient(ctx context.Context)
 		return nil, fmt.Errorf("failed to load Dynatrace credentials: %w", err)
 	}
 
-	dynatraceClient := dynatrace.NewClient(credentials)
-	return dynatrace.NewEntitiesClient(dynatraceClient), nil
+	return dynatrace.NewClient(credentials), nil
 }
 
 // ServiceSynchronizer encapsulates the service onboarder component.
 type ServiceSynchronizer struct {
 	servicesClient        keptn.ServiceClientInterface
-	resourcesClient       keptn.SLIAndSLOWriterInterface
+	resourcesClient       keptn.SLIAndSLOResourcesClientInterface
 	entitiesClientFactory EntitiesClientFactory
+	metricsClientFactory  MetricsClientFactory
+	addTestStepSLIs       bool
 }
 
 // NewDefaultServiceSynchronizer creates a new default ServiceSynchronizer.
@@ -111,10 +149,13 @@ func NewDefaultServiceSynchronizer() *ServiceSynchronizer {
 	clientSet := keptn.NewClientFactory()
 	resourceClient := keptn.NewConfigClient(clientSet.CreateResourceClient())
 
+	clientFactory := newDefaultClientFactory(resourceClient)
 	serviceSynchronizer := ServiceSynchronizer{
 		servicesClient:        clientSet.CreateServiceClient(),
 		resourcesClient:       resourceClient,
-		entitiesClientFactory: newDefaultEntitiesClientFactory(resourceClient),
+		entitiesClientFactory: clientFactory,
+		metricsClientFactory:  clientFactory,
+		addTestStepSLIs:       env.IsTestStepMetricsGenerationEnabled(),
 	}
 
 	return &serviceSynchronizer
@@ -167,10 +208,14 @@ func (s *ServiceSynchronizer) synchronizeServices(ctx context.Context) {
 				"service":  service,
 				"entityId": entity.EntityID,
 			}).Debug("Service already exists in project, skipping")
+
+			if s.addTestStepSLIs {
+				s.synchronizeTestStepIndicators(ctx, service)
+			}
 			continue
 		}
 
-		if err := s.addServiceToKeptn(service); err != nil {
+		if err := s.addServiceToKeptn(ctx, service); err != nil {
 			log.WithError(err).WithFields(log.Fields{
 				"service":  service,
 				"entityId": entity.EntityID,
@@ -226,7 +271,7 @@ func doesServiceExist(services []string, serviceName string) bool {
 	return false
 }
 
-func (s *ServiceSynchronizer) addServiceToKeptn(serviceName string) error {
+func (s *ServiceSynchronizer) addServiceToKeptn(ctx context.Context, serviceName string) error {
 	err := s.servicesClient.CreateServiceInProject(synchronizedProject, serviceName)
 	if err != nil {
 		return fmt.Errorf("could not create service %s: %s", serviceName, err)
@@ -238,7 +283,7 @@ func (s *ServiceSynchronizer) addServiceToKeptn(serviceName string) error {
 		log.WithError(err).WithField("service", serviceName).Info("Could not create SLO resource for service")
 	}
 
-	if err := s.createSLIResource(serviceName); err == nil {
+	if err := s.createSLIResource(ctx, serviceName); err == nil {
 		log.WithField("service", serviceName).Info("Uploaded sli.yaml for service")
 	} else {
 		log.WithError(err).WithField("service", serviceName).Info("Could not create SLI resource for service")
@@ -289,7 +334,7 @@ func (s *ServiceSynchronizer) createSLOResource(serviceName string) error {
 	return nil
 }
 
-func (s *ServiceSynchronizer) createSLIResource(serviceName string) error {
+func (s *ServiceSynchronizer) createSLIResource(ctx context.Context, serviceName string) error {
 	indicators := make(map[string]string)
 	indicators["throughput"] = fmt.Sprintf("metricSelector=builtin:service.requestCount.total:merge(\"dt.entity.service\"):sum&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", serviceName)
 	indicators["error_rate"] = fmt.Sprintf("metricSelector=builtin:service.errors.total.rate:merge(\"dt.entity.service\"):avg&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", serviceName)
@@ -297,6 +342,10 @@ func (s *ServiceSynchronizer) createSLIResource(serviceName string) error {
 	indicators["response_time_p90"] = fmt.Sprintf("metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", serviceName)
 	indicators["response_time_p95"] = fmt.Sprintf("metricSelector=builtin:service.response.time:merge(\"dt.entity.service\"):percentile(95)&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", serviceName)
 
+	if s.addTestStepSLIs {
+		s.addTestStepIndicators(ctx, serviceName, indicators)
+	}
+
 	defaultSLIs := &dynatrace.SLI{
 		SpecVersion: "1.0",
 		Indicators:  indicators,
@@ -309,3 +358,95 @@ func (s *ServiceSynchronizer) createSLIResource(serviceName string) error {
 
 	return nil
 }
+
+// synchronizeTestStepIndicators adds the SLIs of test steps executed against the service since its sli.yaml was uploaded, so that test steps of load tests run after onboarding are picked up.
+// Existing SLIs are never changed, so that modifications by users are retained. If the sli.yaml of the service cannot be read, no test step SLIs are added.
+func (s *ServiceSynchronizer) synchronizeTestStepIndicators(ctx context.Context, serviceName string) {
+	slis, err := s.resourcesClient.GetSLIs(synchronizedProject, synchronizedStage, serviceName)
+	if err != nil {
+		log.WithError(err).WithField("service", serviceName).Debug("Could not get SLIs of service, skipping test step SLIs")
+		return
+	}
+
+	testStepIndicators := make(map[string]string)
+	s.addTestStepIndicators(ctx, serviceName, testStepIndicators)
+
+	if slis.Indicators == nil {
+		slis.Indicators = make(map[string]string)
+	}
+
+	addedIndicators := 0
+	for name, query := range testStepIndicators {
+		if _, exists := slis.Indicators[name]; exists {
+			continue
+		}
+		slis.Indicators[name] = query
+		addedIndicators++
+	}
+
+	if addedIndicators == 0 {
+		return
+	}
+
+	err = s.resourcesClient.UploadSLIs(synchronizedProject, synchronizedStage, serviceName, slis)
+	if err != nil {
+		log.WithError(err).WithField("service", serviceName).Error("Could not upload test step SLIs of service")
+		return
+	}
+
+	log.WithFields(log.Fields{"service": serviceName, "addedSLIs": addedIndicators}).Info("Added test step SLIs to sli.yaml of service")
+}
+
+// addTestStepIndicators adds the response time and failure rate SLIs of each test step executed against the service in the lookback period, e.g. teststep_rt_Basic_Check.
+// If the test steps cannot be retrieved, no test step SLIs are added.
+func (s *ServiceSynchronizer) addTestStepIndicators(ctx context.Context, serviceName string, indicators map[string]string) {
+	testSteps, err := s.getTestSteps(ctx, serviceName)
+	if err != nil {
+		log.WithError(err).WithField("service", serviceName).Warn("Could not get test steps of service, skipping test step SLIs")
+		return
+	}
+
+	for _, testStep := range testSteps {
+		sliSuffix := invalidSLINameCharacters.ReplaceAllString(testStep, "_")
+		filter := fmt.Sprintf("filter(eq(\"%s\",\"%s\"))", dynatrace.TestStepDimensionName, selectorStringEscaper.Replace(testStep))
+		indicators["teststep_rt_"+sliSuffix] = fmt.Sprintf("MV2;MicroSecond;metricSelector=%s:%s:merge(\"dt.entity.service\"):avg&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", dynatrace.TestStepResponseTimeMetricKey, filter, serviceName)
+		indicators["teststep_fr_"+sliSuffix] = fmt.Sprintf("metricSelector=%s:%s:merge(\"dt.entity.service\"):avg&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", dynatrace.TestStepFailureRateMetricKey, filter, serviceName)
+	}
+}
+
+// getTestSteps returns the sorted names of the test steps recorded by the test step response time metric for the service.
+func (s *ServiceSynchronizer) getTestSteps(ctx context.Context, serviceName string) ([]string, error) {
+	metricsClient, err := s.metricsClientFactory.CreateMetricsClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := metrics.NewQuery(
+		fmt.Sprintf("%s:splitBy(\"%s\")", dynatrace.TestStepResponseTimeMetricKey, dynatrace.TestStepDimensionName),
+		fmt.Sprintf("type(SERVICE),tag(keptn_managed),tag(keptn_service:%s)", serviceName))
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().Add(-dynatrace.MetricsRequiredDelay)
+	timeframe, err := common.NewTimeframe(end.Add(-testStepsLookback), end)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := metricsClient.GetByQuery(ctx, dynatrace.NewMetricsClientQueryParameters(*query, *timeframe))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test step metric: %w", err)
+	}
+
+	var testSteps []string
+	for _, metricResult := range result.Result {
+		for _, data := range metricResult.Data {
+			if testStep := data.DimensionMap[dynatrace.TestStepDimensionName]; testStep != "" {
+				testSteps = append(testSteps, testStep)
+			}
+		}
+	}
+	sort.Strings(testSteps)
+	return testSteps, nil
+}
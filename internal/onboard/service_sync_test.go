@@ -68,10 +68,19 @@ type uploadedSLOs struct {
 }
 
 type mockSLIAndSLOResourceWriter struct {
+	existingSLIs map[string]*dynatrace.SLI
 	uploadedSLIs []uploadedSLIs
 	uploadedSLOs []uploadedSLOs
 }
 
+func (w *mockSLIAndSLOResourceWriter) GetSLIs(project string, stage string, service string) (*dynatrace.SLI, error) {
+	slis, ok := w.existingSLIs[service]
+	if !ok {
+		return nil, fmt.Errorf("no SLIs found for service %s", service)
+	}
+	return slis, nil
+}
+
 func (w *mockSLIAndSLOResourceWriter) UploadSLIs(project string, stage string, service string, slis *dynatrace.SLI) error {
 	w.uploadedSLIs = append(w.uploadedSLIs, uploadedSLIs{project: project, stage: stage, service: service, slis: slis})
 	return nil
@@ -151,6 +160,16 @@ func (f *mockEntitiesClientFactory) CreateEntitiesClient(ctx context.Context) (*
 	return dynatrace.NewEntitiesClient(dynatraceClient), nil
 }
 
+func (f *mockEntitiesClientFactory) CreateMetricsClient(ctx context.Context) (*dynatrace.MetricsClient, error) {
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(f.url, testDynatraceAPIToken)
+	if err != nil {
+		return nil, err
+	}
+
+	dynatraceClient := dynatrace.NewClientWithHTTP(dynatraceCredentials, f.httpClient)
+	return dynatrace.NewMetricsClient(dynatraceClient), nil
+}
+
 // Test_ServiceSynchronizer_synchronizeServices_addNew tests that new services are added.
 func Test_ServiceSynchronizer_synchronizeServices_addNew(t *testing.T) {
 	mockServicesClient := newMockServicesClient([]string{"my-already-synced-service"})
@@ -298,6 +317,91 @@ func Test_ServiceSynchronizer_synchronizeServices_addNew(t *testing.T) {
 	}
 }
 
+// Test_ServiceSynchronizer_synchronizeServices_addTestStepSLIs tests that SLIs are added for the test steps of new services if enabled.
+func Test_ServiceSynchronizer_synchronizeServices_addTestStepSLIs(t *testing.T) {
+	mockServicesClient := newMockServicesClient([]string{})
+	mockSLIAndSLOResourceWriter := &mockSLIAndSLOResourceWriter{}
+
+	mockEntitiesClientFactory, teardown := newMockEntitiesClientFactory(t)
+	defer teardown()
+
+	const testDataFolder = "./testdata/test_synchronize_services_add_test_steps/"
+	mockEntitiesClientFactory.handler.AddExact("/api/v2/entities?entitySelector=type(\"SERVICE\")%20AND%20tag(\"keptn_managed\",\"[Environment]keptn_managed\")%20AND%20tag(\"keptn_service\",\"[Environment]keptn_service\")&fields=+tags&pageSize=50", testDataFolder+"entities_response.json")
+	mockEntitiesClientFactory.handler.AddStartsWith("/api/v2/metrics/query?entitySelector=type%28SERVICE%29%2Ctag%28keptn_managed%29%2Ctag%28keptn_service%3Amy-service%29&from=", testDataFolder+"metrics_query_response.json")
+
+	s := &ServiceSynchronizer{
+		servicesClient:        mockServicesClient,
+		resourcesClient:       mockSLIAndSLOResourceWriter,
+		entitiesClientFactory: mockEntitiesClientFactory,
+		metricsClientFactory:  mockEntitiesClientFactory,
+		addTestStepSLIs:       true,
+	}
+	s.synchronizeServices(context.Background())
+
+	if assert.EqualValues(t, 1, len(mockSLIAndSLOResourceWriter.uploadedSLIs)) {
+		indicators := mockSLIAndSLOResourceWriter.uploadedSLIs[0].slis.Indicators
+		assert.Len(t, indicators, 9)
+		assert.EqualValues(t, "MV2;MicroSecond;metricSelector=calc:service.teststepresponsetime:filter(eq(\"Test Step\",\"Basic Check\")):merge(\"dt.entity.service\"):avg&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:my-service)", indicators["teststep_rt_Basic_Check"])
+		assert.EqualValues(t, "metricSelector=calc:service.teststepfailurerate:filter(eq(\"Test Step\",\"Basic Check\")):merge(\"dt.entity.service\"):avg&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:my-service)", indicators["teststep_fr_Basic_Check"])
+		assert.Contains(t, indicators, "teststep_rt_Login")
+		assert.Contains(t, indicators, "teststep_fr_Login")
+		assert.Contains(t, indicators, "throughput")
+	}
+}
+
+// Test_ServiceSynchronizer_synchronizeServices_addTestStepSLIsToExisting tests that SLIs are added for new test steps of existing services, retaining existing SLIs.
+func Test_ServiceSynchronizer_synchronizeServices_addTestStepSLIsToExisting(t *testing.T) {
+	const modifiedBasicCheckQuery = "MV2;MicroSecond;metricSelector=calc:service.teststepresponsetime:filter(eq(\"Test Step\",\"Basic Check\")):merge(\"dt.entity.service\"):max&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:my-service)"
+
+	mockServicesClient := newMockServicesClient([]string{"my-service"})
+	mockSLIAndSLOResourceWriter := &mockSLIAndSLOResourceWriter{
+		existingSLIs: map[string]*dynatrace.SLI{
+			"my-service": {
+				SpecVersion: "1.0",
+				Indicators: map[string]string{
+					"throughput":              "metricSelector=builtin:service.requestCount.total:merge(\"dt.entity.service\"):sum&entitySelector=type(SERVICE),tag(keptn_managed),tag(keptn_service:my-service)",
+					"teststep_rt_Basic_Check": modifiedBasicCheckQuery,
+				},
+			},
+		},
+	}
+
+	mockEntitiesClientFactory, teardown := newMockEntitiesClientFactory(t)
+	defer teardown()
+
+	const testDataFolder = "./testdata/test_synchronize_services_add_test_steps/"
+	mockEntitiesClientFactory.handler.AddExact("/api/v2/entities?entitySelector=type(\"SERVICE\")%20AND%20tag(\"keptn_managed\",\"[Environment]keptn_managed\")%20AND%20tag(\"keptn_service\",\"[Environment]keptn_service\")&fields=+tags&pageSize=50", testDataFolder+"entities_response.json")
+	mockEntitiesClientFactory.handler.AddStartsWith("/api/v2/metrics/query?entitySelector=type%28SERVICE%29%2Ctag%28keptn_managed%29%2Ctag%28keptn_service%3Amy-service%29&from=", testDataFolder+"metrics_query_response.json")
+
+	s := &ServiceSynchronizer{
+		servicesClient:        mockServicesClient,
+		resourcesClient:       mockSLIAndSLOResourceWriter,
+		entitiesClientFactory: mockEntitiesClientFactory,
+		metricsClientFactory:  mockEntitiesClientFactory,
+		addTestStepSLIs:       true,
+	}
+	s.synchronizeServices(context.Background())
+
+	assert.Empty(t, mockServicesClient.createdServices)
+	assert.Empty(t, mockSLIAndSLOResourceWriter.uploadedSLOs)
+	if assert.EqualValues(t, 1, len(mockSLIAndSLOResourceWriter.uploadedSLIs)) {
+		assert.Equal(t, "my-service", mockSLIAndSLOResourceWriter.uploadedSLIs[0].service)
+
+		indicators := mockSLIAndSLOResourceWriter.uploadedSLIs[0].slis.Indicators
+		assert.Len(t, indicators, 5)
+		assert.Equal(t, modifiedBasicCheckQuery, indicators["teststep_rt_Basic_Check"])
+		assert.Contains(t, indicators, "throughput")
+		assert.Contains(t, indicators, "teststep_fr_Basic_Check")
+		assert.Contains(t, indicators, "teststep_rt_Login")
+		assert.Contains(t, indicators, "teststep_fr_Login")
+	}
+
+	// a second synchronization finds no new test steps
+	mockSLIAndSLOResourceWriter.uploadedSLIs = nil
+	s.synchronizeServices(context.Background())
+	assert.Empty(t, mockSLIAndSLOResourceWriter.uploadedSLIs)
+}
+
 // Test_ServiceSynchronizer_synchronizeServices_skipExisting tests that services that have already been added are not added twice.
 func Test_ServiceSynchronizer_synchronizeServices_skipExisting(t *testing.T) {
 	mockServicesClient := newMockServicesClient([]string{"my-already-synced-service", "my-service", "my-service-2"})
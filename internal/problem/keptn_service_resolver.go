@@ -0,0 +1,303 @@
+package problem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	serviceEntityType              = "SERVICE"
+	hostEntityType                 = "HOST"
+	processGroupInstanceEntityType = "PROCESS_GROUP_INSTANCE"
+)
+
+// TieBreakRule decides which Keptn service is used if the entities of a problem lead to several of them.
+type TieBreakRule string
+
+const (
+	// TieBreakRuleRootCause prefers Keptn services derived from the root cause entity.
+	TieBreakRuleRootCause TieBreakRule = "rootCause"
+
+	// TieBreakRuleImpacted prefers Keptn services derived from impacted entities over those derived only from affected entities.
+	TieBreakRuleImpacted TieBreakRule = "impacted"
+
+	// TieBreakRuleMostEntities prefers Keptn services derived from the largest number of problem entities.
+	TieBreakRuleMostEntities TieBreakRule = "mostEntities"
+
+	// TieBreakRuleFirst uses the first Keptn service ordered by project, stage and service name.
+	TieBreakRuleFirst TieBreakRule = "first"
+)
+
+// KeptnService identifies a service in a stage of a Keptn project.
+type KeptnService struct {
+	Project string
+	Stage   string
+	Service string
+}
+
+func (s KeptnService) String() string {
+	return s.Project + "/" + s.Stage + "/" + s.Service
+}
+
+// keptnServiceCandidate is a Keptn service derived from one or more entities of a problem.
+type keptnServiceCandidate struct {
+	service   KeptnService
+	rootCause bool
+	impacted  bool
+	entityIDs map[string]bool
+}
+
+// problemEntity is an entity of a problem together with the role it plays in the problem.
+type problemEntity struct {
+	id         string
+	entityType string
+	rootCause  bool
+	impacted   bool
+}
+
+// KeptnServiceResolver resolves the Keptn project, stage and service of a problem via the services related to its root cause, affected and impacted entities.
+type KeptnServiceResolver struct {
+	problemsClient *dynatrace.ProblemsV2Client
+	entitiesClient *dynatrace.EntitiesClient
+	tieBreakRules  []TieBreakRule
+}
+
+// NewKeptnServiceResolver creates a new KeptnServiceResolver using the specified tie-break rules in order. Unknown rules are ignored.
+func NewKeptnServiceResolver(client dynatrace.ClientInterface, tieBreakRules []string) *KeptnServiceResolver {
+	rules := make([]TieBreakRule, 0, len(tieBreakRules))
+	for _, rule := range tieBreakRules {
+		switch TieBreakRule(rule) {
+		case TieBreakRuleRootCause, TieBreakRuleImpacted, TieBreakRuleMostEntities, TieBreakRuleFirst:
+			rules = append(rules, TieBreakRule(rule))
+		default:
+			log.WithField("rule", rule).Warn("Ignoring unknown tie-break rule")
+		}
+	}
+
+	return &KeptnServiceResolver{
+		problemsClient: dynatrace.NewProblemsV2Client(client),
+		entitiesClient: dynatrace.NewEntitiesClient(client),
+		tieBreakRules:  rules,
+	}
+}
+
+// Resolve returns the Keptn service of the problem with the specified ID or an error if none or several could be derived from its entities.
+func (r *KeptnServiceResolver) Resolve(ctx context.Context, problemID string) (*KeptnService, error) {
+	problem, err := r.problemsClient.GetByID(ctx, problemID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve problem %s: %w", problemID, err)
+	}
+
+	candidates, err := r.getCandidates(ctx, getProblemEntities(problem))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates = r.breakTie(candidates)
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("no entity of problem %s is related to a service tagged with keptn_project, keptn_stage and keptn_service", problemID)
+	case 1:
+		return &candidates[0].service, nil
+	default:
+		services := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			services = append(services, candidate.service.String())
+		}
+		return nil, fmt.Errorf("problem %s could not be resolved to a single Keptn service, candidates are: %s", problemID, strings.Join(services, ", "))
+	}
+}
+
+// getProblemEntities returns the distinct root cause, impacted and affected entities of a problem.
+func getProblemEntities(problem *dynatrace.Problem) []*problemEntity {
+	entities := []*problemEntity{}
+	entitiesByID := make(map[string]*problemEntity)
+	add := func(entity dynatrace.ProblemEntity, rootCause bool, impacted bool) {
+		if entity.EntityID.ID == "" {
+			return
+		}
+
+		e, ok := entitiesByID[entity.EntityID.ID]
+		if !ok {
+			e = &problemEntity{id: entity.EntityID.ID, entityType: entity.EntityID.Type}
+			entitiesByID[e.id] = e
+			entities = append(entities, e)
+		}
+		e.rootCause = e.rootCause || rootCause
+		e.impacted = e.impacted || impacted
+	}
+
+	if problem.RootCauseEntity != nil {
+		add(*problem.RootCauseEntity, true, false)
+	}
+	for _, entity := range problem.ImpactedEntities {
+		add(entity, false, true)
+	}
+	for _, entity := range problem.AffectedEntities {
+		add(entity, false, false)
+	}
+
+	return entities
+}
+
+// getCandidates returns the Keptn services derived from the services that are or are related to the specified problem entities, ordered by project, stage and service.
+func (r *KeptnServiceResolver) getCandidates(ctx context.Context, entities []*problemEntity) ([]*keptnServiceCandidate, error) {
+	problemEntitiesByServiceID := make(map[string][]*problemEntity)
+	for _, entity := range entities {
+		serviceIDs, err := r.getRelatedServiceIDs(ctx, entity)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, serviceID := range serviceIDs {
+			problemEntitiesByServiceID[serviceID] = append(problemEntitiesByServiceID[serviceID], entity)
+		}
+	}
+
+	if len(problemEntitiesByServiceID) == 0 {
+		return nil, nil
+	}
+
+	serviceIDs := make([]string, 0, len(problemEntitiesByServiceID))
+	for serviceID := range problemEntitiesByServiceID {
+		serviceIDs = append(serviceIDs, `"`+serviceID+`"`)
+	}
+	sort.Strings(serviceIDs)
+
+	services, err := r.entitiesClient.GetWithTagsBySelector(ctx, "type(\"SERVICE\"),entityId("+strings.Join(serviceIDs, ",")+")")
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve services related to problem: %w", err)
+	}
+
+	candidates := []*keptnServiceCandidate{}
+	candidatesByService := make(map[KeptnService]*keptnServiceCandidate)
+	for _, service := range services {
+		keptnService, ok := getKeptnServiceFromTags(service.Tags)
+		if !ok {
+			continue
+		}
+
+		candidate, ok := candidatesByService[keptnService]
+		if !ok {
+			candidate = &keptnServiceCandidate{service: keptnService, entityIDs: make(map[string]bool)}
+			candidatesByService[keptnService] = candidate
+			candidates = append(candidates, candidate)
+		}
+
+		for _, entity := range problemEntitiesByServiceID[service.EntityID] {
+			candidate.rootCause = candidate.rootCause || entity.rootCause
+			candidate.impacted = candidate.impacted || entity.impacted
+			candidate.entityIDs[entity.id] = true
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].service.String() < candidates[j].service.String()
+	})
+
+	return candidates, nil
+}
+
+// getRelatedServiceIDs returns the ID of the entity itself if it is a service or otherwise the IDs of all services it is related to.
+// Services are not related to hosts directly, so for hosts the services of the process group instances running on them are returned.
+func (r *KeptnServiceResolver) getRelatedServiceIDs(ctx context.Context, entity *problemEntity) ([]string, error) {
+	if entity.entityType == serviceEntityType {
+		return []string{entity.id}, nil
+	}
+
+	e, err := r.getWithRelationshipsByID(ctx, entity.id)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIDs := e.GetRelatedEntityIDs(serviceEntityType)
+	if entity.entityType != hostEntityType {
+		return serviceIDs, nil
+	}
+
+	for _, processGroupInstanceID := range e.GetRelatedEntityIDs(processGroupInstanceEntityType) {
+		processGroupInstance, err := r.getWithRelationshipsByID(ctx, processGroupInstanceID)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceIDs = append(serviceIDs, processGroupInstance.GetRelatedEntityIDs(serviceEntityType)...)
+	}
+
+	return serviceIDs, nil
+}
+
+func (r *KeptnServiceResolver) getWithRelationshipsByID(ctx context.Context, entityID string) (*dynatrace.Entity, error) {
+	e, err := r.entitiesClient.GetWithRelationshipsByID(ctx, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve relationships of entity %s: %w", entityID, err)
+	}
+
+	return e, nil
+}
+
+// getKeptnServiceFromTags returns the Keptn service specified by the keptn_project, keptn_stage and keptn_service tags, if all of them are present.
+func getKeptnServiceFromTags(tags []dynatrace.Tag) (KeptnService, bool) {
+	service := KeptnService{}
+	for _, tag := range tags {
+		switch tag.Key {
+		case "keptn_project":
+			service.Project = tag.Value
+		case "keptn_stage":
+			service.Stage = tag.Value
+		case "keptn_service":
+			service.Service = tag.Value
+		}
+	}
+
+	return service, service.Project != "" && service.Stage != "" && service.Service != ""
+}
+
+// breakTie applies the tie-break rules in order until at most one candidate remains.
+// A rule that would remove all candidates is skipped.
+func (r *KeptnServiceResolver) breakTie(candidates []*keptnServiceCandidate) []*keptnServiceCandidate {
+	for _, rule := range r.tieBreakRules {
+		if len(candidates) <= 1 {
+			break
+		}
+
+		switch rule {
+		case TieBreakRuleRootCause:
+			candidates = filterCandidates(candidates, func(c *keptnServiceCandidate) bool { return c.rootCause })
+		case TieBreakRuleImpacted:
+			candidates = filterCandidates(candidates, func(c *keptnServiceCandidate) bool { return c.impacted })
+		case TieBreakRuleMostEntities:
+			maxEntities := 0
+			for _, candidate := range candidates {
+				if len(candidate.entityIDs) > maxEntities {
+					maxEntities = len(candidate.entityIDs)
+				}
+			}
+			candidates = filterCandidates(candidates, func(c *keptnServiceCandidate) bool { return len(c.entityIDs) == maxEntities })
+		case TieBreakRuleFirst:
+			candidates = candidates[:1]
+		}
+	}
+
+	return candidates
+}
+
+// filterCandidates returns the candidates matching the predicate, or all candidates if none match.
+func filterCandidates(candidates []*keptnServiceCandidate, predicate func(c *keptnServiceCandidate) bool) []*keptnServiceCandidate {
+	filtered := []*keptnServiceCandidate{}
+	for _, candidate := range candidates {
+		if predicate(candidate) {
+			filtered = append(filtered, candidate)
+		}
+	}
+
+	if len(filtered) == 0 {
+		return candidates
+	}
+	return filtered
+}
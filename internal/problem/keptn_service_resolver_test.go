@@ -0,0 +1,97 @@
+package problem
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const testResolverProblemID = "-4511147584335281453_1637590620000V2"
+
+const testResolverServicesURL = "/api/v2/entities?entitySelector=type%28%22SERVICE%22%29%2CentityId%28%22SERVICE-4AE1EB7C5BC26D6D%22%2C%22SERVICE-FFD81F7F9D4C7A85%22%29&fields=%2Btags&pageSize=500"
+
+func TestKeptnServiceResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name                 string
+		servicesFileName     string
+		tieBreakRules        []string
+		expectedKeptnService *KeptnService
+		expectedErrorMessage string
+	}{
+		{
+			name:                 "default rules prefer impacted service related to root cause",
+			servicesFileName:     "./testdata/keptn_service_resolver/services.json",
+			tieBreakRules:        []string{"rootCause", "impacted", "mostEntities"},
+			expectedKeptnService: &KeptnService{Project: "sockshop", Stage: "production", Service: "items"},
+		},
+		{
+			name:                 "most entities",
+			servicesFileName:     "./testdata/keptn_service_resolver/services.json",
+			tieBreakRules:        []string{"mostEntities"},
+			expectedKeptnService: &KeptnService{Project: "sockshop", Stage: "production", Service: "items"},
+		},
+		{
+			name:                 "first",
+			servicesFileName:     "./testdata/keptn_service_resolver/services.json",
+			tieBreakRules:        []string{"unknown", "first"},
+			expectedKeptnService: &KeptnService{Project: "sockshop", Stage: "production", Service: "carts"},
+		},
+		{
+			name:                 "ambiguous",
+			servicesFileName:     "./testdata/keptn_service_resolver/services.json",
+			tieBreakRules:        []string{"rootCause"},
+			expectedErrorMessage: "candidates are: sockshop/production/carts, sockshop/production/items",
+		},
+		{
+			name:                 "no keptn tags",
+			servicesFileName:     "./testdata/keptn_service_resolver/services_without_keptn_tags.json",
+			tieBreakRules:        []string{"first"},
+			expectedErrorMessage: "no entity of problem",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := test.NewFileBasedURLHandler(t)
+			handler.AddExact("/api/v2/problems/"+testResolverProblemID, "./testdata/keptn_service_resolver/problem.json")
+			handler.AddExact("/api/v2/entities/PROCESS_GROUP_INSTANCE-95C5FBF859599282?fields=%2BfromRelationships%2C%2BtoRelationships", "./testdata/keptn_service_resolver/process_group_instance.json")
+			handler.AddExact(testResolverServicesURL, tt.servicesFileName)
+
+			dtClient, _, teardown := createDynatraceClient(t, handler)
+			defer teardown()
+
+			keptnService, err := NewKeptnServiceResolver(dtClient, tt.tieBreakRules).Resolve(context.TODO(), testResolverProblemID)
+			if tt.expectedErrorMessage != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMessage)
+				assert.Nil(t, keptnService)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, tt.expectedKeptnService, keptnService)
+		})
+	}
+}
+
+// TestKeptnServiceResolver_Resolve_hostRootCause tests that services are found via the process group instances running on a host, as they are not related to it directly.
+func TestKeptnServiceResolver_Resolve_hostRootCause(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems/"+testResolverProblemID, "./testdata/keptn_service_resolver/problem_host.json")
+	handler.AddExact("/api/v2/entities/HOST-B2F09E8AAF8CB8F5?fields=%2BfromRelationships%2C%2BtoRelationships", "./testdata/keptn_service_resolver/host.json")
+	handler.AddExact("/api/v2/entities/PROCESS_GROUP_INSTANCE-95C5FBF859599282?fields=%2BfromRelationships%2C%2BtoRelationships", "./testdata/keptn_service_resolver/process_group_instance.json")
+	handler.AddExact(testResolverServicesURL, "./testdata/keptn_service_resolver/services.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	keptnService, err := NewKeptnServiceResolver(dtClient, []string{"rootCause", "first"}).Resolve(context.TODO(), testResolverProblemID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.EqualValues(t, &KeptnService{Project: "sockshop", Stage: "production", Service: "carts"}, keptnService)
+}
@@ -1,10 +1,14 @@
 package problem
 
 import (
+	"encoding/json"
 	"strings"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 )
 
 const remediationTaskName = "remediation"
@@ -15,13 +19,21 @@ type RawProblem map[string]interface{}
 type ProblemAdapterInterface interface {
 	adapter.EventContentAdapter
 	IsNotFromDynatrace() bool
+	IsTestNotification() bool
 	GetState() string
 	GetPID() string
 	GetProblemID() string
 	IsOpen() bool
 	IsResolved() bool
+	IsMerged() bool
 	GetProblemURL() string
 	GetRawProblem() RawProblem
+	GetTitle() string
+	GetSeverityLevel() string
+	GetImpactLevel() string
+	GetImpactedEntityTypes() []string
+	GetTags() []string
+	GetStartTime() (time.Time, bool)
 }
 
 // ProblemAdapter is a content adaptor for events of type sh.keptn.event.action.finished
@@ -35,18 +47,35 @@ type ProblemAdapter struct {
 func NewProblemAdapterFromEvent(e cloudevents.Event) (*ProblemAdapter, error) {
 	ceAdapter := adapter.NewCloudEventAdapter(e)
 
-	pData := &DTProblemEvent{}
-	err := ceAdapter.PayloadAs(pData)
+	var payload RawProblem
+	err := ceAdapter.PayloadAs(&payload)
 	if err != nil {
 		return nil, err
 	}
 
-	var problem RawProblem
-	err = ceAdapter.PayloadAs(&problem)
+	// payloads in other formats are converted into the notification template format, so that they can be handled in the same way
+	normalizedPayload, err := normalizeProblemPayload(payload)
 	if err != nil {
 		return nil, err
 	}
 
+	encodedPayload, err := json.Marshal(normalizedPayload)
+	if err != nil {
+		return nil, common.NewMarshalJSONError("problem payload", err)
+	}
+
+	pData := &DTProblemEvent{}
+	err = json.Unmarshal(encodedPayload, pData)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("problem payload", err)
+	}
+
+	var problem RawProblem
+	err = json.Unmarshal(encodedPayload, &problem)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("problem payload", err)
+	}
+
 	// we need to set the project, stage and service names also from tags, if available
 	setProjectStageAndServiceFromTags(pData)
 
@@ -87,6 +116,13 @@ func (a ProblemAdapter) GetService() string {
 	return a.event.KeptnService
 }
 
+// SetKeptnService sets the project, stage and service of the problem, e.g. after resolving them via the entities of the problem
+func (a *ProblemAdapter) SetKeptnService(service KeptnService) {
+	a.event.KeptnProject = service.Project
+	a.event.KeptnStage = service.Stage
+	a.event.KeptnService = service.Service
+}
+
 // GetDeployment returns the name of the deployment
 func (a ProblemAdapter) GetDeployment() string {
 	return ""
@@ -112,6 +148,12 @@ func (a ProblemAdapter) IsNotFromDynatrace() bool {
 	return a.cloudEvent.GetSource() != "dynatrace"
 }
 
+// IsTestNotification returns true if the event is a test problem notification sent when configuring monitoring
+func (a ProblemAdapter) IsTestNotification() bool {
+	isTestNotification, _ := a.rawProblem[dynatrace.TestProblemNotificationField].(bool)
+	return isTestNotification
+}
+
 // GetState returns problem state as OPEN or RESOLVED
 func (a ProblemAdapter) GetState() string {
 	return a.event.State
@@ -137,6 +179,90 @@ func (a ProblemAdapter) GetRawProblem() RawProblem {
 	return a.rawProblem
 }
 
+// GetTitle returns the problem title or an empty string if it is not available.
+// The displayName of ProblemDetails is not used as a fallback, as it is the display ID of the problem, e.g. 852, rather than its title.
+func (a ProblemAdapter) GetTitle() string {
+	title := a.rawProblem.getString("ProblemTitle")
+	if title != "" {
+		return title
+	}
+	return a.rawProblem.getProblemDetails().getString("title")
+}
+
+// GetSeverityLevel returns the severity level, e.g. AVAILABILITY or PERFORMANCE
+func (a ProblemAdapter) GetSeverityLevel() string {
+	severityLevel := a.rawProblem.getString("ProblemSeverity")
+	if severityLevel != "" {
+		return severityLevel
+	}
+	return a.rawProblem.getProblemDetails().getString("severityLevel")
+}
+
+// GetImpactLevel returns the impact level, e.g. SERVICE or INFRASTRUCTURE
+func (a ProblemAdapter) GetImpactLevel() string {
+	impactLevel := a.rawProblem.getString("ProblemImpact")
+	if impactLevel != "" {
+		return impactLevel
+	}
+	return a.rawProblem.getProblemDetails().getString("impactLevel")
+}
+
+// GetImpactedEntityTypes returns the distinct types of the impacted entities
+func (a ProblemAdapter) GetImpactedEntityTypes() []string {
+	entityTypes := []string{}
+	addEntityType := func(entityType string) {
+		if entityType == "" {
+			return
+		}
+		for _, t := range entityTypes {
+			if t == entityType {
+				return
+			}
+		}
+		entityTypes = append(entityTypes, entityType)
+	}
+
+	for _, impactedEntity := range a.rawProblem.getObjects("ImpactedEntities") {
+		addEntityType(impactedEntity.getString("type"))
+	}
+
+	// ranked impacts only include entity IDs, which are prefixed by the entity type, e.g. SERVICE-1234567890ABCDEF
+	for _, rankedImpact := range a.rawProblem.getProblemDetails().getObjects("rankedImpacts") {
+		entityID := rankedImpact.getString("entityId")
+		if i := strings.LastIndex(entityID, "-"); i > 0 {
+			addEntityType(entityID[:i])
+		}
+	}
+
+	return entityTypes
+}
+
+// GetTags returns the tags of the affected entities, e.g. keptn_project:sockshop
+func (a ProblemAdapter) GetTags() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(a.event.Tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, tag := range a.rawProblem.getProblemDetails().getObjects("tagsOfAffectedEntities") {
+		tags = append(tags, formatTag(tag.getString("context"), tag.getString("key"), tag.getString("value")))
+	}
+
+	return tags
+}
+
+// GetStartTime returns the start time of the problem, if it is included in the problem details
+func (a ProblemAdapter) GetStartTime() (time.Time, bool) {
+	startTime, ok := a.rawProblem.getProblemDetails()["startTime"].(float64)
+	if !ok || startTime <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(startTime)*int64(time.Millisecond)), true
+}
+
 // IsOpen returns true if the problem is open
 func (a ProblemAdapter) IsOpen() bool {
 	return a.GetState() == "OPEN"
@@ -147,6 +273,11 @@ func (a ProblemAdapter) IsResolved() bool {
 	return a.GetState() == "RESOLVED"
 }
 
+// IsMerged returns true if the problem has been merged into another problem
+func (a ProblemAdapter) IsMerged() bool {
+	return a.GetState() == "MERGED"
+}
+
 func setProjectStageAndServiceFromTags(dtProblemEvent *DTProblemEvent) {
 	// we analyze the tag list as its possible that the problem was raised for a specific monitored service that has keptn tags
 	splittedTags := strings.Split(dtProblemEvent.Tags, ",")
@@ -167,3 +298,39 @@ func setProjectStageAndServiceFromTags(dtProblemEvent *DTProblemEvent) {
 		}
 	}
 }
+
+func (p RawProblem) getString(key string) string {
+	value, _ := p[key].(string)
+	return value
+}
+
+// getProblemDetails returns the problem details included via {ProblemDetailsJSON}, or an empty RawProblem if there are none.
+func (p RawProblem) getProblemDetails() RawProblem {
+	problemDetails, _ := p["ProblemDetails"].(map[string]interface{})
+	return problemDetails
+}
+
+// getObjects returns all objects contained in the array with the specified key.
+func (p RawProblem) getObjects(key string) []RawProblem {
+	values, _ := p[key].([]interface{})
+
+	objects := make([]RawProblem, 0, len(values))
+	for _, value := range values {
+		if object, ok := value.(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// formatTag formats a tag as it appears in the {Tags} placeholder, e.g. [Environment]key:value.
+func formatTag(context string, key string, value string) string {
+	tag := key
+	if context != "" && context != "CONTEXTLESS" {
+		tag = "[" + context + "]" + tag
+	}
+	if value != "" {
+		tag = tag + ":" + value
+	}
+	return tag
+}
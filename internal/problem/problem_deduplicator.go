@@ -0,0 +1,154 @@
+package problem
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ProblemDeduplicator uses a ProblemStateStore to collapse repeated notifications for the same problem and to debounce problems that are closed and reopened in quick succession.
+type ProblemDeduplicator struct {
+	mutex          sync.Mutex
+	store          ProblemStateStore
+	debounceWindow time.Duration
+	now            func() time.Time
+}
+
+// NewProblemDeduplicator creates a new ProblemDeduplicator.
+// Problems reopened within debounceWindow after being closed do not trigger a new remediation sequence.
+func NewProblemDeduplicator(store ProblemStateStore, debounceWindow time.Duration) *ProblemDeduplicator {
+	return &ProblemDeduplicator{
+		store:          store,
+		debounceWindow: debounceWindow,
+		now:            time.Now,
+	}
+}
+
+// deduplicationDecision describes whether a problem notification should be forwarded to Keptn and the state that has been recorded for it.
+type deduplicationDecision struct {
+	forward bool
+
+	// reason explains why the notification should not be forwarded
+	reason string
+
+	state ProblemState
+
+	// previousState is the state replaced when recording the decision, or nil if there was none
+	previousState *ProblemState
+}
+
+// decideOpened decides how to handle a notification for an open problem and records the resulting state.
+// Repeated opens are mapped to the Keptn context used for the first one.
+func (d *ProblemDeduplicator) decideOpened(event ProblemAdapterInterface) (*deduplicationDecision, error) {
+	return d.decideAndRecord(event, ProblemStatusOpen, func(existingState *ProblemState, newState ProblemState) *deduplicationDecision {
+		if existingState == nil {
+			return &deduplicationDecision{forward: true, state: newState}
+		}
+
+		switch existingState.Status {
+		case ProblemStatusOpen:
+			return &deduplicationDecision{forward: false, reason: "problem is already open", state: *existingState}
+
+		case ProblemStatusMerged:
+			return &deduplicationDecision{forward: false, reason: "problem has been merged into another problem", state: *existingState}
+		}
+
+		// the closed state is kept, as Keptn has already been informed that the problem is closed
+		if sinceClosed := d.now().Sub(existingState.LastChanged); sinceClosed < d.debounceWindow {
+			return &deduplicationDecision{
+				forward: false,
+				reason:  fmt.Sprintf("problem was reopened %v after being closed, which is within the debounce window of %v", sinceClosed.Round(time.Second), d.debounceWindow),
+				state:   *existingState,
+			}
+		}
+
+		return &deduplicationDecision{forward: true, state: newState}
+	})
+}
+
+// decideClosed decides how to handle a notification for a resolved or closed problem and records the resulting state.
+func (d *ProblemDeduplicator) decideClosed(event ProblemAdapterInterface) (*deduplicationDecision, error) {
+	return d.decideAndRecord(event, ProblemStatusClosed, func(existingState *ProblemState, newState ProblemState) *deduplicationDecision {
+		if existingState == nil || existingState.Status == ProblemStatusOpen {
+			return &deduplicationDecision{forward: true, state: newState}
+		}
+
+		if existingState.Status == ProblemStatusMerged {
+			return &deduplicationDecision{forward: false, reason: "problem has been merged into another problem", state: *existingState}
+		}
+
+		return &deduplicationDecision{forward: false, reason: "problem is already closed", state: *existingState}
+	})
+}
+
+// decideMerged decides how to handle a notification for a problem that has been merged into another problem and records the resulting state.
+// These are never forwarded, as the problem is continued by the problem it was merged into.
+func (d *ProblemDeduplicator) decideMerged(event ProblemAdapterInterface) (*deduplicationDecision, error) {
+	return d.decideAndRecord(event, ProblemStatusMerged, func(_ *ProblemState, newState ProblemState) *deduplicationDecision {
+		return &deduplicationDecision{forward: false, reason: "problem has been merged into another problem", state: newState}
+	})
+}
+
+// decideAndRecord decides how to handle a notification based on the existing state of the problem and records the resulting state before returning.
+// Deciding and recording is atomic, so that notifications for the same problem handled concurrently are only forwarded once.
+func (d *ProblemDeduplicator) decideAndRecord(event ProblemAdapterInterface, status ProblemStatus, decide func(existingState *ProblemState, newState ProblemState) *deduplicationDecision) (*deduplicationDecision, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	existingState, newState, err := d.getStates(event, status)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := decide(existingState, newState)
+	decision.previousState = existingState
+
+	err = d.store.Put(decision.state)
+	if err != nil {
+		return nil, fmt.Errorf("could not record state of problem %s: %w", decision.state.Key, err)
+	}
+
+	return decision, nil
+}
+
+// revert restores the state replaced when recording the decision, e.g. because the notification could not be forwarded to Keptn.
+func (d *ProblemDeduplicator) revert(decision *deduplicationDecision) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if decision.previousState == nil {
+		return d.store.Delete(decision.state.Key)
+	}
+	return d.store.Put(*decision.previousState)
+}
+
+// getStates returns the existing state of the problem, if any, as well as the new state with the specified status.
+// The new state keeps the Keptn context of the existing state.
+func (d *ProblemDeduplicator) getStates(event ProblemAdapterInterface, status ProblemStatus) (*ProblemState, ProblemState, error) {
+	key := getProblemKey(event)
+	existingState, err := d.store.Get(key)
+	if err != nil {
+		return nil, ProblemState{}, fmt.Errorf("could not get state of problem %s: %w", key, err)
+	}
+
+	newState := ProblemState{
+		Key:          key,
+		KeptnContext: event.GetShKeptnContext(),
+		Status:       status,
+		LastChanged:  d.now(),
+	}
+
+	if existingState != nil && existingState.KeptnContext != "" {
+		newState.KeptnContext = existingState.KeptnContext
+	}
+
+	return existingState, newState, nil
+}
+
+// getProblemKey returns the PID of the problem or, if not available, its problem ID.
+func getProblemKey(event ProblemAdapterInterface) string {
+	if event.GetPID() != "" {
+		return event.GetPID()
+	}
+	return event.GetProblemID()
+}
@@ -0,0 +1,119 @@
+package problem
+
+import (
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+// ProblemDetails are the normalized details of a problem retrieved via the Problems API v2.
+// Unlike the raw problem, their structure does not depend on the notification template used in Dynatrace.
+type ProblemDetails struct {
+	ProblemID          string                 `json:"problemId"`
+	DisplayID          string                 `json:"displayId"`
+	Title              string                 `json:"title"`
+	Status             string                 `json:"status"`
+	ImpactLevel        string                 `json:"impactLevel"`
+	SeverityLevel      string                 `json:"severityLevel"`
+	StartTime          string                 `json:"startTime,omitempty"`
+	RootCauseEntity    *ProblemDetailsEntity  `json:"rootCauseEntity,omitempty"`
+	AffectedEntities   []ProblemDetailsEntity `json:"affectedEntities"`
+	ImpactedEntities   []ProblemDetailsEntity `json:"impactedEntities"`
+	AffectedEntityTags []string               `json:"affectedEntityTags"`
+	ManagementZones    []string               `json:"managementZones"`
+	Evidence           []ProblemEvidence      `json:"evidence"`
+}
+
+// ProblemDetailsEntity is an entity referenced by a problem.
+type ProblemDetailsEntity struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// ProblemEvidence is a single piece of evidence of a problem.
+type ProblemEvidence struct {
+	Type              string               `json:"type"`
+	DisplayName       string               `json:"displayName"`
+	Entity            ProblemDetailsEntity `json:"entity"`
+	RootCauseRelevant bool                 `json:"rootCauseRelevant"`
+	StartTime         string               `json:"startTime,omitempty"`
+}
+
+// newProblemDetails creates normalized ProblemDetails from a problem retrieved via the Problems API v2.
+func newProblemDetails(problem *dynatrace.Problem) *ProblemDetails {
+	details := &ProblemDetails{
+		ProblemID:          problem.ProblemID,
+		DisplayID:          problem.DisplayID,
+		Title:              problem.Title,
+		Status:             problem.Status,
+		ImpactLevel:        problem.ImpactLevel,
+		SeverityLevel:      problem.SeverityLevel,
+		StartTime:          formatProblemTimestamp(problem.StartTime),
+		AffectedEntities:   newProblemDetailsEntities(problem.AffectedEntities),
+		ImpactedEntities:   newProblemDetailsEntities(problem.ImpactedEntities),
+		AffectedEntityTags: make([]string, 0, len(problem.EntityTags)),
+		ManagementZones:    make([]string, 0, len(problem.ManagementZones)),
+		Evidence:           []ProblemEvidence{},
+	}
+
+	if problem.RootCauseEntity != nil {
+		rootCauseEntity := newProblemDetailsEntity(*problem.RootCauseEntity)
+		details.RootCauseEntity = &rootCauseEntity
+	}
+
+	for _, tag := range problem.EntityTags {
+		if tag.StringRepresentation != "" {
+			details.AffectedEntityTags = append(details.AffectedEntityTags, tag.StringRepresentation)
+			continue
+		}
+		details.AffectedEntityTags = append(details.AffectedEntityTags, formatTag(tag.Context, tag.Key, tag.Value))
+	}
+
+	for _, managementZone := range problem.ManagementZones {
+		details.ManagementZones = append(details.ManagementZones, managementZone.Name)
+	}
+
+	if problem.EvidenceDetails != nil {
+		for _, evidence := range problem.EvidenceDetails.Details {
+			details.Evidence = append(details.Evidence, ProblemEvidence{
+				Type:              evidence.EvidenceType,
+				DisplayName:       evidence.DisplayName,
+				Entity:            newProblemDetailsEntity(evidence.Entity),
+				RootCauseRelevant: evidence.RootCauseRelevant,
+				StartTime:         formatProblemTimestamp(evidence.StartTime),
+			})
+		}
+	}
+
+	return details
+}
+
+func newProblemDetailsEntities(entities []dynatrace.ProblemEntity) []ProblemDetailsEntity {
+	detailsEntities := make([]ProblemDetailsEntity, 0, len(entities))
+	for _, entity := range entities {
+		detailsEntities = append(detailsEntities, newProblemDetailsEntity(entity))
+	}
+	return detailsEntities
+}
+
+func newProblemDetailsEntity(entity dynatrace.ProblemEntity) ProblemDetailsEntity {
+	return ProblemDetailsEntity{
+		ID:   entity.EntityID.ID,
+		Type: entity.EntityID.Type,
+		Name: entity.Name,
+	}
+}
+
+// formatProblemTimestamp formats a timestamp in UTC milliseconds as RFC 3339 or returns an empty string if it is not set.
+func formatProblemTimestamp(timestamp int64) string {
+	if timestamp <= 0 {
+		return ""
+	}
+	return problemTimestampToTime(timestamp).UTC().Format(time.RFC3339)
+}
+
+// problemTimestampToTime converts a timestamp in UTC milliseconds as used by the Problems API v2 to a time.Time.
+func problemTimestampToTime(timestamp int64) time.Time {
+	return time.Unix(0, timestamp*int64(time.Millisecond))
+}
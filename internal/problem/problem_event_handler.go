@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	log "github.com/sirupsen/logrus"
@@ -21,14 +23,20 @@ type DTProblemEvent struct {
 }
 
 type ProblemEventHandler struct {
-	event  ProblemAdapterInterface
-	client keptn.ClientInterface
+	event        ProblemAdapterInterface
+	dtClient     dynatrace.ClientInterface
+	client       keptn.ClientInterface
+	filterConfig *config.ProblemFilterConfig
+	deduplicator *ProblemDeduplicator
 }
 
-func NewProblemEventHandler(event ProblemAdapterInterface, client keptn.ClientInterface) ProblemEventHandler {
+func NewProblemEventHandler(event ProblemAdapterInterface, dtClient dynatrace.ClientInterface, client keptn.ClientInterface, filterConfig *config.ProblemFilterConfig, deduplicator *ProblemDeduplicator) ProblemEventHandler {
 	return ProblemEventHandler{
-		event:  event,
-		client: client,
+		event:        event,
+		dtClient:     dtClient,
+		client:       client,
+		filterConfig: filterConfig,
+		deduplicator: deduplicator,
 	}
 }
 
@@ -37,6 +45,9 @@ type RemediationTriggeredEventData struct {
 
 	// Problem contains details about the problem
 	Problem RawProblem `json:"problem"`
+
+	// ProblemDetails contains the normalized details of the problem retrieved via the Problems API v2, if available
+	ProblemDetails *ProblemDetails `json:"problemDetails,omitempty"`
 }
 
 // HandleEvent handles a problem event.
@@ -46,41 +57,110 @@ func (eh ProblemEventHandler) HandleEvent(workCtx context.Context, replyCtx cont
 		return nil
 	}
 
+	if eh.event.IsTestNotification() {
+		log.WithField("PID", eh.event.GetPID()).Info("Dropping test problem notification")
+		return nil
+	}
+
 	if eh.event.IsOpen() {
-		return eh.handleOpenedProblemFromDT()
+		return eh.handleOpenedProblemFromDT(workCtx)
 	}
 	if eh.event.IsResolved() {
 		return eh.handleClosedProblemFromDT()
 	}
+	if eh.event.IsMerged() {
+		return eh.handleMergedProblemFromDT()
+	}
 
 	return nil
 }
 
 func (eh ProblemEventHandler) handleClosedProblemFromDT() error {
-	err := eh.sendEvent(NewProblemClosedEventFactory(eh.event))
+	decision, err := eh.deduplicator.decideClosed(eh.event)
 	if err != nil {
 		return err
 	}
 
+	if !decision.forward {
+		log.WithFields(log.Fields{"PID": eh.event.GetPID(), "reason": decision.reason}).Info("Dropping closed problem event")
+		return nil
+	}
+
+	err = eh.sendEvent(NewProblemClosedEventFactory(eh.event, decision.state.KeptnContext))
+	if err != nil {
+		return eh.revertDecision(decision, err)
+	}
+
 	log.WithField("PID", eh.event.GetPID()).Debug("Successfully sent Keptn PROBLEM CLOSED event")
 	return nil
 }
 
-func (eh ProblemEventHandler) handleOpenedProblemFromDT() error {
+func (eh ProblemEventHandler) handleMergedProblemFromDT() error {
+	decision, err := eh.deduplicator.decideMerged(eh.event)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{"PID": eh.event.GetPID(), "reason": decision.reason}).Info("Dropping merged problem event")
+	return nil
+}
+
+func (eh ProblemEventHandler) handleOpenedProblemFromDT(ctx context.Context) error {
 	if eh.event.GetStage() == "" {
 		log.Debug("Dropping open problen event as it has no stage")
 		return nil
 	}
 
-	err := eh.sendEvent(NewRemediationTriggeredEventFactory(eh.event))
+	err := newProblemFilter(eh.filterConfig).check(eh.event)
+	if err != nil {
+		log.WithError(err).WithField("PID", eh.event.GetPID()).Info("Dropping open problem event as it does not match the problem filter")
+		return nil
+	}
+
+	decision, err := eh.deduplicator.decideOpened(eh.event)
 	if err != nil {
 		return err
 	}
 
+	if !decision.forward {
+		log.WithFields(log.Fields{"PID": eh.event.GetPID(), "reason": decision.reason}).Info("Dropping open problem event")
+		return nil
+	}
+
+	err = eh.sendEvent(NewRemediationTriggeredEventFactory(eh.event, decision.state.KeptnContext, eh.getProblemDetails(ctx)))
+	if err != nil {
+		return eh.revertDecision(decision, err)
+	}
+
 	log.WithField("PID", eh.event.GetPID()).Debug("Successfully sent Keptn PROBLEM OPEN event")
 	return nil
 }
 
+// revertDecision reverts the state recorded for a decision whose event could not be sent, so that the problem is forwarded again with its next notification.
+func (eh ProblemEventHandler) revertDecision(decision *deduplicationDecision, sendErr error) error {
+	err := eh.deduplicator.revert(decision)
+	if err != nil {
+		log.WithError(err).WithField("PID", eh.event.GetPID()).Error("Could not revert problem state after failing to send event")
+	}
+
+	return sendErr
+}
+
+// getProblemDetails retrieves the details of the problem via the Problems API v2 or returns nil if this is not possible.
+func (eh ProblemEventHandler) getProblemDetails(ctx context.Context) *ProblemDetails {
+	if eh.event.GetPID() == "" {
+		return nil
+	}
+
+	problem, err := dynatrace.NewProblemsV2Client(eh.dtClient).GetWithEvidenceByID(ctx, eh.event.GetPID())
+	if err != nil {
+		log.WithError(err).WithField("PID", eh.event.GetPID()).Warn("Could not retrieve problem details, sending remediation.triggered event without them")
+		return nil
+	}
+
+	return newProblemDetails(problem)
+}
+
 func (eh ProblemEventHandler) sendEvent(factory adapter.CloudEventFactoryInterface) error {
 	err := eh.client.SendCloudEvent(factory)
 	if err != nil {
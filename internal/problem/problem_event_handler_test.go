@@ -3,13 +3,19 @@ package problem
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
 	"github.com/keptn-contrib/dynatrace-service/internal/keptn"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
 	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
 	"github.com/stretchr/testify/assert"
 )
@@ -19,6 +25,8 @@ func TestProblemEventHandler_HandleEvent(t *testing.T) {
 	tests := []struct {
 		name                 string
 		receivedEvent        *cloudevents.Event
+		filterConfig         *config.ProblemFilterConfig
+		problemFileName      string
 		wantEmittedEvent     bool
 		expectedEmittedEvent *cloudevents.Event
 	}{
@@ -28,6 +36,13 @@ func TestProblemEventHandler_HandleEvent(t *testing.T) {
 			wantEmittedEvent:     true,
 			expectedEmittedEvent: readCloudEventFromFile("./testdata/open_problem/expected_emitted_ce.json"),
 		},
+		{
+			name:                 "open problem event with problem details",
+			receivedEvent:        readCloudEventFromFile("./testdata/open_problem/received_ce.json"),
+			problemFileName:      "./testdata/open_problem_with_problem_details/problem.json",
+			wantEmittedEvent:     true,
+			expectedEmittedEvent: readCloudEventFromFile("./testdata/open_problem_with_problem_details/expected_emitted_ce.json"),
+		},
 		{
 			name:                 "open problem event with tags",
 			receivedEvent:        readCloudEventFromFile("./testdata/open_problem_with_tags/received_ce.json"),
@@ -39,6 +54,24 @@ func TestProblemEventHandler_HandleEvent(t *testing.T) {
 			receivedEvent:    readCloudEventFromFile("./testdata/open_problem_no_stage/received_ce.json"),
 			wantEmittedEvent: false,
 		},
+		{
+			name:             "test problem notification",
+			receivedEvent:    readCloudEventFromFile("./testdata/test_notification/received_ce.json"),
+			wantEmittedEvent: false,
+		},
+		{
+			name:             "open problem event not matching filter",
+			receivedEvent:    readCloudEventFromFile("./testdata/open_problem/received_ce.json"),
+			filterConfig:     &config.ProblemFilterConfig{SeverityLevels: []string{"AVAILABILITY"}},
+			wantEmittedEvent: false,
+		},
+		{
+			name:                 "closed problem event not affected by filter",
+			receivedEvent:        readCloudEventFromFile("./testdata/closed_problem/received_ce.json"),
+			filterConfig:         &config.ProblemFilterConfig{SeverityLevels: []string{"AVAILABILITY"}},
+			wantEmittedEvent:     true,
+			expectedEmittedEvent: readCloudEventFromFile("./testdata/closed_problem/expected_emitted_ce.json"),
+		},
 		{
 			name:                 "closed problem event",
 			receivedEvent:        readCloudEventFromFile("./testdata/closed_problem/received_ce.json"),
@@ -59,8 +92,18 @@ func TestProblemEventHandler_HandleEvent(t *testing.T) {
 				return
 			}
 
+			handler := test.NewFileBasedURLHandler(t)
+			if tt.problemFileName != "" {
+				handler.AddExact("/api/v2/problems/"+adapter.GetPID()+"?fields=%2BevidenceDetails", tt.problemFileName)
+			} else {
+				handler.AddStartsWithError("/api/v2/problems/", http.StatusNotFound, "./testdata/problems_v2_not_found.json")
+			}
+
+			dtClient, _, teardown := createDynatraceClient(t, handler)
+			defer teardown()
+
 			kClient := &keptnClientMock{}
-			ph := NewProblemEventHandler(adapter, kClient)
+			ph := NewProblemEventHandler(adapter, dtClient, kClient, tt.filterConfig, NewProblemDeduplicator(NewInMemoryProblemStateStore(time.Hour), 0))
 
 			err = ph.HandleEvent(context.Background(), context.Background())
 
@@ -77,7 +120,9 @@ func TestProblemEventHandler_HandleEvent(t *testing.T) {
 }
 
 type keptnClientMock struct {
+	mutex     sync.Mutex
 	eventSink []*cloudevents.Event
+	sendErr   error
 }
 
 func (m *keptnClientMock) GetCustomQueries(project string, stage string, service string) (*keptn.CustomQueries, error) {
@@ -99,6 +144,13 @@ func (m *keptnClientMock) SendCloudEvent(factory adapter.CloudEventFactoryInterf
 		return err
 	}
 
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.sendErr != nil {
+		return m.sendErr
+	}
+
 	m.eventSink = append(m.eventSink, ce)
 	return nil
 }
@@ -116,3 +168,142 @@ func readCloudEventFromFile(fileName string) *cloudevents.Event {
 	}
 	return &ce
 }
+
+func TestProblemEventHandler_HandleEventDeduplication(t *testing.T) {
+	const originalKeptnContext = "39393939-3920-4020-a020-202020202020"
+	const otherKeptnContext = "40404040-4020-4020-a020-202020202020"
+
+	openEvent := readCloudEventFromFile("./testdata/open_problem/received_ce.json")
+	closedEvent := readCloudEventFromFileWithState(t, "./testdata/open_problem/received_ce.json", "RESOLVED")
+	reopenedEvent := readCloudEventFromFile("./testdata/open_problem/received_ce.json")
+	reopenedEvent.SetExtension("shkeptncontext", otherKeptnContext)
+
+	dtClient, teardown := createDynatraceClientWithoutProblems(t)
+	defer teardown()
+
+	now := time.Now()
+	deduplicator := NewProblemDeduplicator(NewInMemoryProblemStateStore(time.Hour), 5*time.Minute)
+	kClient := &keptnClientMock{}
+
+	handleEventAt := func(event *cloudevents.Event, at time.Time) {
+		adapter, err := NewProblemAdapterFromEvent(*event)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		deduplicator.now = func() time.Time { return at }
+		err = NewProblemEventHandler(adapter, dtClient, kClient, nil, deduplicator).HandleEvent(context.Background(), context.Background())
+		assert.NoError(t, err)
+	}
+
+	// first open is forwarded, repeated open is dropped
+	handleEventAt(openEvent, now)
+	handleEventAt(openEvent, now.Add(time.Minute))
+	if assert.EqualValues(t, 1, len(kClient.eventSink)) {
+		assert.EqualValues(t, "sh.keptn.event.production.remediation.triggered", kClient.eventSink[0].Type())
+	}
+
+	// close is forwarded, repeated close is dropped
+	handleEventAt(closedEvent, now.Add(2*time.Minute))
+	handleEventAt(closedEvent, now.Add(3*time.Minute))
+	if assert.EqualValues(t, 2, len(kClient.eventSink)) {
+		assert.EqualValues(t, "sh.keptn.events.problem", kClient.eventSink[1].Type())
+	}
+
+	// reopen within debounce window is dropped and the problem remains closed, so a further close is dropped as well
+	handleEventAt(reopenedEvent, now.Add(4*time.Minute))
+	handleEventAt(closedEvent, now.Add(5*time.Minute))
+	assert.EqualValues(t, 2, len(kClient.eventSink))
+
+	// reopen after debounce window is forwarded using the original Keptn context
+	handleEventAt(reopenedEvent, now.Add(8*time.Minute))
+	if assert.EqualValues(t, 3, len(kClient.eventSink)) {
+		assert.EqualValues(t, "sh.keptn.event.production.remediation.triggered", kClient.eventSink[2].Type())
+		assert.EqualValues(t, originalKeptnContext, kClient.eventSink[2].Extensions()["shkeptncontext"])
+	}
+}
+
+func TestProblemEventHandler_HandleEventConcurrently(t *testing.T) {
+	dtClient, teardown := createDynatraceClientWithoutProblems(t)
+	defer teardown()
+
+	deduplicator := NewProblemDeduplicator(NewInMemoryProblemStateStore(time.Hour), 0)
+	kClient := &keptnClientMock{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			adapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile("./testdata/open_problem/received_ce.json"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			err = NewProblemEventHandler(adapter, dtClient, kClient, nil, deduplicator).HandleEvent(context.Background(), context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, len(kClient.eventSink))
+}
+
+func TestProblemEventHandler_HandleEventSendingFails(t *testing.T) {
+	dtClient, teardown := createDynatraceClientWithoutProblems(t)
+	defer teardown()
+
+	deduplicator := NewProblemDeduplicator(NewInMemoryProblemStateStore(time.Hour), 0)
+	kClient := &keptnClientMock{sendErr: errors.New("Keptn API not available")}
+
+	adapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile("./testdata/open_problem/received_ce.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = NewProblemEventHandler(adapter, dtClient, kClient, nil, deduplicator).HandleEvent(context.Background(), context.Background())
+	assert.Error(t, err)
+
+	// the state is reverted, so the next notification is forwarded
+	kClient.sendErr = nil
+	err = NewProblemEventHandler(adapter, dtClient, kClient, nil, deduplicator).HandleEvent(context.Background(), context.Background())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(kClient.eventSink))
+}
+
+func TestProblemEventHandler_HandleEventMerged(t *testing.T) {
+	dtClient, teardown := createDynatraceClientWithoutProblems(t)
+	defer teardown()
+
+	mergedEvent := readCloudEventFromFileWithState(t, "./testdata/open_problem/received_ce.json", "MERGED")
+	deduplicator := NewProblemDeduplicator(NewInMemoryProblemStateStore(time.Hour), 0)
+	kClient := &keptnClientMock{}
+
+	for _, event := range []*cloudevents.Event{mergedEvent, readCloudEventFromFile("./testdata/open_problem/received_ce.json"), readCloudEventFromFileWithState(t, "./testdata/open_problem/received_ce.json", "RESOLVED")} {
+		adapter, err := NewProblemAdapterFromEvent(*event)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		err = NewProblemEventHandler(adapter, dtClient, kClient, nil, deduplicator).HandleEvent(context.Background(), context.Background())
+		assert.NoError(t, err)
+	}
+
+	assert.EqualValues(t, 0, len(kClient.eventSink))
+}
+
+// readCloudEventFromFileWithState reads a problem cloud event from a file and replaces its state.
+func readCloudEventFromFileWithState(t *testing.T, fileName string, state string) *cloudevents.Event {
+	ce := readCloudEventFromFile(fileName)
+
+	data := map[string]interface{}{}
+	err := ce.DataAs(&data)
+	assert.NoError(t, err)
+
+	data["State"] = state
+	err = ce.SetData(cloudevents.ApplicationJSON, data)
+	assert.NoError(t, err)
+
+	return ce
+}
@@ -10,12 +10,14 @@ import (
 )
 
 type ProblemClosedEventFactory struct {
-	event ProblemAdapterInterface
+	event        ProblemAdapterInterface
+	keptnContext string
 }
 
-func NewProblemClosedEventFactory(event ProblemAdapterInterface) *ProblemClosedEventFactory {
+func NewProblemClosedEventFactory(event ProblemAdapterInterface, keptnContext string) *ProblemClosedEventFactory {
 	return &ProblemClosedEventFactory{
-		event: event,
+		event:        event,
+		keptnContext: keptnContext,
 	}
 }
 
@@ -38,7 +40,7 @@ func (f *ProblemClosedEventFactory) CreateCloudEvent() (*cloudevents.Event, erro
 	// add problem URL as label so it becomes clickable
 	labels[common.ProblemURLLabel] = f.event.GetProblemURL()
 
-	return adapter.NewCloudEventFactoryBase(f.event, keptn.ProblemEventType, rawProblem).CreateCloudEvent()
+	return adapter.NewCloudEventFactoryBase(keptnContextAdapter(f.keptnContext), keptn.ProblemEventType, rawProblem).CreateCloudEvent()
 }
 
 func shallowCopyRawProblem(rawProblem RawProblem) RawProblem {
@@ -50,12 +52,17 @@ func shallowCopyRawProblem(rawProblem RawProblem) RawProblem {
 }
 
 type RemediationTriggeredEventFactory struct {
-	event ProblemAdapterInterface
+	event          ProblemAdapterInterface
+	keptnContext   string
+	problemDetails *ProblemDetails
 }
 
-func NewRemediationTriggeredEventFactory(event ProblemAdapterInterface) *RemediationTriggeredEventFactory {
+// NewRemediationTriggeredEventFactory creates a new RemediationTriggeredEventFactory. problemDetails may be nil if they could not be retrieved.
+func NewRemediationTriggeredEventFactory(event ProblemAdapterInterface, keptnContext string, problemDetails *ProblemDetails) *RemediationTriggeredEventFactory {
 	return &RemediationTriggeredEventFactory{
-		event: event,
+		event:          event,
+		keptnContext:   keptnContext,
+		problemDetails: problemDetails,
 	}
 }
 
@@ -66,7 +73,8 @@ func (f *RemediationTriggeredEventFactory) CreateCloudEvent() (*cloudevents.Even
 			Stage:   f.event.GetStage(),
 			Service: f.event.GetService(),
 		},
-		Problem: f.event.GetRawProblem(),
+		Problem:        f.event.GetRawProblem(),
+		ProblemDetails: f.problemDetails,
 	}
 
 	// https://github.com/keptn-contrib/dynatrace-service/issues/176
@@ -76,5 +84,13 @@ func (f *RemediationTriggeredEventFactory) CreateCloudEvent() (*cloudevents.Even
 
 	eventType := keptnv2.GetTriggeredEventType(f.event.GetStage() + "." + remediationTaskName)
 
-	return adapter.NewCloudEventFactoryBase(f.event, eventType, remediationEventData).CreateCloudEvent()
+	return adapter.NewCloudEventFactoryBase(keptnContextAdapter(f.keptnContext), eventType, remediationEventData).CreateCloudEvent()
+}
+
+// keptnContextAdapter allows events to be created for a Keptn context other than the one of the received event.
+type keptnContextAdapter string
+
+// GetShKeptnContext returns the shkeptncontext
+func (a keptnContextAdapter) GetShKeptnContext() string {
+	return string(a)
 }
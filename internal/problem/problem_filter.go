@@ -0,0 +1,119 @@
+package problem
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+)
+
+// problemFilter decides which open problems are forwarded to Keptn based on a ProblemFilterConfig.
+type problemFilter struct {
+	config *config.ProblemFilterConfig
+	now    func() time.Time
+}
+
+func newProblemFilter(config *config.ProblemFilterConfig) *problemFilter {
+	return &problemFilter{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// check returns nil if the problem should be forwarded or otherwise an error describing why it should be dropped.
+func (f *problemFilter) check(problem ProblemAdapterInterface) error {
+	if f.config == nil {
+		return nil
+	}
+
+	if len(f.config.SeverityLevels) > 0 && !containsIgnoringCase(f.config.SeverityLevels, problem.GetSeverityLevel()) {
+		return fmt.Errorf("severity level '%s' is not one of %v", problem.GetSeverityLevel(), f.config.SeverityLevels)
+	}
+
+	if len(f.config.ImpactLevels) > 0 && !containsIgnoringCase(f.config.ImpactLevels, problem.GetImpactLevel()) {
+		return fmt.Errorf("impact level '%s' is not one of %v", problem.GetImpactLevel(), f.config.ImpactLevels)
+	}
+
+	if len(f.config.EntityTypes) > 0 && !containsAnyIgnoringCase(f.config.EntityTypes, problem.GetImpactedEntityTypes()) {
+		return fmt.Errorf("none of the impacted entity types %v is one of %v", problem.GetImpactedEntityTypes(), f.config.EntityTypes)
+	}
+
+	for _, tag := range f.config.Tags {
+		if !hasTag(problem.GetTags(), tag) {
+			return fmt.Errorf("affected entities are not tagged with '%s'", tag)
+		}
+	}
+
+	if len(f.config.TitlePatterns) > 0 {
+		err := f.checkTitle(problem.GetTitle())
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.config.MinAgeMinutes > 0 {
+		startTime, ok := problem.GetStartTime()
+		if !ok {
+			return fmt.Errorf("a minimum age of %v is required but the problem start time is not available", f.config.GetMinAge())
+		}
+
+		// the problem is not remembered, so a polled problem is checked again once it is polled after reaching the minimum age, whereas a problem notification is not resent
+		age := f.now().Sub(startTime)
+		if age < f.config.GetMinAge() {
+			return fmt.Errorf("age of %v is less than the minimum age of %v", age.Round(time.Second), f.config.GetMinAge())
+		}
+	}
+
+	return nil
+}
+
+// checkTitle returns nil if the title matches any of the title patterns or otherwise an error.
+// A problem without a title never matches, as its title cannot be checked.
+func (f *problemFilter) checkTitle(title string) error {
+	if title == "" {
+		return fmt.Errorf("a title matching any of %v is required but the problem title is not available", f.config.TitlePatterns)
+	}
+
+	for _, pattern := range f.config.TitlePatterns {
+		titleRegexp, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("title pattern '%s' is invalid: %w", pattern, err)
+		}
+
+		if titleRegexp.MatchString(title) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("title '%s' does not match any of %v", title, f.config.TitlePatterns)
+}
+
+func containsIgnoringCase(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAnyIgnoringCase(values []string, candidates []string) bool {
+	for _, candidate := range candidates {
+		if containsIgnoringCase(values, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag returns true if tags contains the required tag. A required tag without a value, e.g. keptn_managed, also matches tags with that key and any value.
+func hasTag(tags []string, requiredTag string) bool {
+	for _, tag := range tags {
+		if tag == requiredTag || (!strings.Contains(requiredTag, ":") && strings.HasPrefix(tag, requiredTag+":")) {
+			return true
+		}
+	}
+	return false
+}
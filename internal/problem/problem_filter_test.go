@@ -0,0 +1,127 @@
+package problem
+
+import (
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+)
+
+func TestProblemFilter_Check(t *testing.T) {
+	// problem in testdata started at 2022-01-04T21:57:00Z
+	now := time.Date(2022, 1, 4, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		config      *config.ProblemFilterConfig
+		wantForward bool
+	}{
+		{
+			name:        "no filter",
+			config:      nil,
+			wantForward: true,
+		},
+		{
+			name: "all criteria match",
+			config: &config.ProblemFilterConfig{
+				SeverityLevels: []string{"AVAILABILITY", "performance"},
+				ImpactLevels:   []string{"SERVICE"},
+				EntityTypes:    []string{"PROCESS_GROUP_INSTANCE"},
+				Tags:           []string{"keptn_project:shop", "keptn_managed", "keptn_stage", "[ENVIRONMENT]team:shop"},
+				TitlePatterns:  []string{"^Failure rate", "^Response time"},
+				MinAgeMinutes:  2,
+			},
+			wantForward: true,
+		},
+		{
+			name:        "severity level does not match",
+			config:      &config.ProblemFilterConfig{SeverityLevels: []string{"AVAILABILITY"}},
+			wantForward: false,
+		},
+		{
+			name:        "impact level does not match",
+			config:      &config.ProblemFilterConfig{ImpactLevels: []string{"INFRASTRUCTURE"}},
+			wantForward: false,
+		},
+		{
+			name:        "entity type does not match",
+			config:      &config.ProblemFilterConfig{EntityTypes: []string{"HOST"}},
+			wantForward: false,
+		},
+		{
+			name:        "tag value does not match",
+			config:      &config.ProblemFilterConfig{Tags: []string{"keptn_project:shop", "keptn_stage:hardening"}},
+			wantForward: false,
+		},
+		{
+			name:        "title does not match",
+			config:      &config.ProblemFilterConfig{TitlePatterns: []string{"^Failure rate"}},
+			wantForward: false,
+		},
+		{
+			name:        "title pattern is invalid",
+			config:      &config.ProblemFilterConfig{TitlePatterns: []string{"(Response"}},
+			wantForward: false,
+		},
+		{
+			name:        "problem is too young",
+			config:      &config.ProblemFilterConfig{MinAgeMinutes: 5},
+			wantForward: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problemAdapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile("./testdata/open_problem_with_details/received_ce.json"))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			filter := newProblemFilter(tt.config)
+			filter.now = func() time.Time { return now }
+
+			err = filter.check(problemAdapter)
+			if tt.wantForward {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestProblemFilter_CheckWithoutStartTime(t *testing.T) {
+	problemAdapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile("./testdata/open_problem/received_ce.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = newProblemFilter(&config.ProblemFilterConfig{MinAgeMinutes: 1}).check(problemAdapter)
+	assert.Error(t, err)
+}
+
+func TestProblemFilter_CheckWithoutTitle(t *testing.T) {
+	event := readCloudEventFromFile("./testdata/open_problem_with_details/received_ce.json")
+
+	var data map[string]interface{}
+	if !assert.NoError(t, event.DataAs(&data)) {
+		return
+	}
+	delete(data, "ProblemTitle")
+	if !assert.NoError(t, event.SetData(cloudevents.ApplicationJSON, data)) {
+		return
+	}
+
+	problemAdapter, err := NewProblemAdapterFromEvent(*event)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the display name of the problem details must not be used as its title
+	assert.Empty(t, problemAdapter.GetTitle())
+
+	err = newProblemFilter(&config.ProblemFilterConfig{TitlePatterns: []string{".*"}}).check(problemAdapter)
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+// problemPayloadFormat is a format of the payload of problem events received from Dynatrace.
+type problemPayloadFormat string
+
+const (
+	// problemPayloadFormatNotificationTemplate is the format of the custom notification integration payload set up by the dynatrace-service, e.g. with PID and State fields.
+	problemPayloadFormatNotificationTemplate problemPayloadFormat = "notification template"
+
+	// problemPayloadFormatProblemsV2 is the format of the {ProblemDetailsJSONv2} placeholder, i.e. a problem as returned by the Problems API v2.
+	problemPayloadFormatProblemsV2 problemPayloadFormat = "Problems API v2"
+
+	// problemPayloadFormatSettings20Webhook is the format of webhooks configured via Settings 2.0, which use camel case field names, e.g. pid and state.
+	problemPayloadFormatSettings20Webhook problemPayloadFormat = "Settings 2.0 webhook"
+)
+
+// settings20WebhookFieldNames maps the field names used by Settings 2.0 webhooks to those of the notification template.
+var settings20WebhookFieldNames = map[string]string{
+	"pid":              "PID",
+	"problemId":        "ProblemID",
+	"state":            "State",
+	"problemTitle":     "ProblemTitle",
+	"problemUrl":       "ProblemURL",
+	"problemURL":       "ProblemURL",
+	"problemSeverity":  "ProblemSeverity",
+	"problemImpact":    "ProblemImpact",
+	"problemDetails":   "ProblemDetails",
+	"tags":             "Tags",
+	"impactedEntities": "ImpactedEntities",
+	"impactedEntity":   "ImpactedEntity",
+	"keptnProject":     "KeptnProject",
+	"keptnStage":       "KeptnStage",
+	"keptnService":     "KeptnService",
+}
+
+// detectProblemPayloadFormat returns the format of the payload or an error if it cannot be interpreted.
+func detectProblemPayloadFormat(payload RawProblem) (problemPayloadFormat, error) {
+	has := func(key string) bool {
+		_, ok := payload[key]
+		return ok
+	}
+
+	switch {
+	case has("State") || has("PID") || has("ProblemID"):
+		return problemPayloadFormatNotificationTemplate, nil
+	case has("problemId") && has("status"):
+		return problemPayloadFormatProblemsV2, nil
+	case has("state") && has("pid"):
+		return problemPayloadFormatSettings20Webhook, nil
+	default:
+		return "", fmt.Errorf("problem payload format could not be detected: expected either a notification template payload with 'PID' and 'State', a {ProblemDetailsJSONv2} payload with 'problemId' and 'status' or a Settings 2.0 webhook payload with 'pid' and 'state'")
+	}
+}
+
+// normalizeProblemPayload converts the payload into the notification template format or returns an error if its format cannot be detected.
+func normalizeProblemPayload(payload RawProblem) (RawProblem, error) {
+	format, err := detectProblemPayloadFormat(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case problemPayloadFormatProblemsV2:
+		return normalizeProblemsV2Payload(payload)
+	case problemPayloadFormatSettings20Webhook:
+		return normalizeSettings20WebhookPayload(payload), nil
+	default:
+		return payload, nil
+	}
+}
+
+func normalizeProblemsV2Payload(payload RawProblem) (RawProblem, error) {
+	encodedPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, common.NewMarshalJSONError("problem payload", err)
+	}
+
+	var problem dynatrace.Problem
+	err = json.Unmarshal(encodedPayload, &problem)
+	if err != nil {
+		return nil, common.NewUnmarshalJSONError("Problems API v2 problem payload", err)
+	}
+
+	state := "RESOLVED"
+	if problem.Status == dynatrace.ProblemStatusOpen {
+		state = "OPEN"
+	}
+
+	// the problem URL is not part of a Problems API v2 problem
+	return newNotificationTemplatePayload(problem, state, ""), nil
+}
+
+func normalizeSettings20WebhookPayload(payload RawProblem) RawProblem {
+	normalizedPayload := make(RawProblem, len(payload))
+	for key, value := range payload {
+		if normalizedKey, ok := settings20WebhookFieldNames[key]; ok {
+			key = normalizedKey
+		}
+		normalizedPayload[key] = value
+	}
+	return normalizedPayload
+}
+
+// newNotificationTemplatePayload creates a payload in the notification template format for a problem retrieved via the Problems API v2.
+func newNotificationTemplatePayload(problem dynatrace.Problem, state string, problemURL string) RawProblem {
+	tags := make([]string, 0, len(problem.EntityTags))
+	tagsOfAffectedEntities := make([]interface{}, 0, len(problem.EntityTags))
+	for _, tag := range problem.EntityTags {
+		tags = append(tags, tag.StringRepresentation)
+		tagsOfAffectedEntities = append(tagsOfAffectedEntities, map[string]interface{}{"context": tag.Context, "key": tag.Key, "value": tag.Value})
+	}
+
+	impactedEntities := make([]interface{}, 0, len(problem.ImpactedEntities))
+	for _, entity := range problem.ImpactedEntities {
+		impactedEntities = append(impactedEntities, map[string]interface{}{"entity": entity.EntityID.ID, "name": entity.Name, "type": entity.EntityID.Type})
+	}
+
+	return RawProblem{
+		"State":           state,
+		"ProblemID":       problem.DisplayID,
+		"PID":             problem.ProblemID,
+		"ProblemTitle":    problem.Title,
+		"ProblemURL":      problemURL,
+		"ProblemSeverity": problem.SeverityLevel,
+		"ProblemImpact":   problem.ImpactLevel,
+		"Tags":            strings.Join(tags, ", "),
+		"ProblemDetails": map[string]interface{}{
+			"id":                     problem.ProblemID,
+			"displayName":            problem.DisplayID,
+			"title":                  problem.Title,
+			"startTime":              problem.StartTime,
+			"endTime":                problem.EndTime,
+			"status":                 problem.Status,
+			"severityLevel":          problem.SeverityLevel,
+			"impactLevel":            problem.ImpactLevel,
+			"tagsOfAffectedEntities": tagsOfAffectedEntities,
+		},
+		"ImpactedEntities": impactedEntities,
+	}
+}
@@ -0,0 +1,93 @@
+package problem
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProblemAdapterFromEvent_PayloadFormats(t *testing.T) {
+	tests := []struct {
+		name                  string
+		fileName              string
+		expectedPID           string
+		expectedProblemID     string
+		expectedState         string
+		expectedProblemURL    string
+		expectedSeverityLevel string
+		expectedEntityTypes   []string
+	}{
+		{
+			name:                  "notification template",
+			fileName:              "./testdata/open_problem/received_ce.json",
+			expectedPID:           "99999",
+			expectedProblemID:     "999",
+			expectedState:         "OPEN",
+			expectedProblemURL:    "https://example.com",
+			expectedSeverityLevel: "",
+			expectedEntityTypes:   []string{"HOST", "SERVICE"},
+		},
+		{
+			name:                  "Problems API v2",
+			fileName:              "./testdata/open_problem_problems_v2_payload/received_ce.json",
+			expectedPID:           "-4511147584335281453_1637590620000V2",
+			expectedProblemID:     "P-211116443",
+			expectedState:         "OPEN",
+			expectedProblemURL:    "",
+			expectedSeverityLevel: "AVAILABILITY",
+			expectedEntityTypes:   []string{"SERVICE"},
+		},
+		{
+			name:                  "Settings 2.0 webhook",
+			fileName:              "./testdata/open_problem_settings20_webhook_payload/received_ce.json",
+			expectedPID:           "99999",
+			expectedProblemID:     "999",
+			expectedState:         "OPEN",
+			expectedProblemURL:    "https://example.com",
+			expectedSeverityLevel: "PERFORMANCE",
+			expectedEntityTypes:   []string{"SERVICE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile(tt.fileName))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			assert.EqualValues(t, tt.expectedPID, adapter.GetPID())
+			assert.EqualValues(t, tt.expectedProblemID, adapter.GetProblemID())
+			assert.EqualValues(t, tt.expectedState, adapter.GetState())
+			assert.EqualValues(t, tt.expectedProblemURL, adapter.GetProblemURL())
+			assert.EqualValues(t, tt.expectedSeverityLevel, adapter.GetSeverityLevel())
+			assert.EqualValues(t, tt.expectedEntityTypes, adapter.GetImpactedEntityTypes())
+			assert.EqualValues(t, "shop", adapter.GetProject())
+			assert.EqualValues(t, "production", adapter.GetStage())
+			assert.EqualValues(t, "carts", adapter.GetService())
+		})
+	}
+}
+
+func TestNewProblemAdapterFromEvent_ProblemsV2ClosedPayload(t *testing.T) {
+	event := readCloudEventFromFile("./testdata/open_problem_problems_v2_payload/received_ce.json")
+
+	data := map[string]interface{}{}
+	assert.NoError(t, event.DataAs(&data))
+	data["status"] = "CLOSED"
+	assert.NoError(t, event.SetData("application/json", data))
+
+	adapter, err := NewProblemAdapterFromEvent(*event)
+	if assert.NoError(t, err) {
+		assert.True(t, adapter.IsResolved())
+	}
+}
+
+func TestNewProblemAdapterFromEvent_UnknownPayload(t *testing.T) {
+	adapter, err := NewProblemAdapterFromEvent(*readCloudEventFromFile("./testdata/unknown_payload/received_ce.json"))
+
+	assert.Nil(t, adapter)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "problem payload format could not be detected")
+	}
+}
@@ -0,0 +1,225 @@
+package problem
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	keptnlib "github.com/keptn/go-utils/pkg/lib"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/config"
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+	"github.com/keptn-contrib/dynatrace-service/internal/sli/problems"
+)
+
+// DynatraceClientFactory defines a factory that can create Dynatrace clients.
+type DynatraceClientFactory interface {
+	// CreateClient creates a dynatrace.ClientInterface or returns an error.
+	CreateClient(ctx context.Context) (dynatrace.ClientInterface, error)
+}
+
+type defaultDynatraceClientFactory struct{}
+
+// CreateClient creates a dynatrace.ClientInterface using the credentials in the default secret or returns an error.
+func (defaultDynatraceClientFactory) CreateClient(ctx context.Context) (dynatrace.ClientInterface, error) {
+	credentialsProvider, err := credentials.NewDefaultDynatraceK8sSecretReader()
+	if err != nil {
+		return nil, err
+	}
+
+	dynatraceCredentials, err := credentialsProvider.GetDynatraceCredentials(ctx, config.NewDynatraceConfigWithDefaults().DtCreds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Dynatrace credentials: %w", err)
+	}
+
+	return dynatrace.NewClient(dynatraceCredentials), nil
+}
+
+// ProblemPoller periodically retrieves problems that were opened or closed via the Problems API v2 and handles them as if they had been received from a Dynatrace problem notification.
+// As problems may be reported late, each polling run looks back further than the previous one and relies on the ProblemDeduplicator to drop notifications that have already been handled.
+type ProblemPoller struct {
+	clientFactory   DynatraceClientFactory
+	watermarkStore  ProblemWatermarkStore
+	problemSelector string
+	keptnProject    string
+	lookback        time.Duration
+	handleEvent     func(event cloudevents.Event)
+	now             func() time.Time
+}
+
+// NewDefaultProblemPoller creates a new default ProblemPoller which passes the created problem events to handleEvent.
+func NewDefaultProblemPoller(handleEvent func(event cloudevents.Event)) *ProblemPoller {
+	return &ProblemPoller{
+		clientFactory:   defaultDynatraceClientFactory{},
+		watermarkStore:  newDefaultProblemWatermarkStore(),
+		problemSelector: env.GetProblemPollingSelector(),
+		keptnProject:    env.GetProblemPollingKeptnProject(),
+		lookback:        time.Duration(env.GetProblemPollingLookback()) * time.Second,
+		handleEvent:     handleEvent,
+		now:             time.Now,
+	}
+}
+
+// Run runs the problem poller which does not return unless cancelled.
+// Cancelling runCtx will stop any new polling runs, cancelling pollingCtx will stop an in progress polling run.
+func (p *ProblemPoller) Run(runCtx context.Context, pollingCtx context.Context) {
+	pollingInterval := env.GetProblemPollingInterval()
+	log.WithField("pollingInterval", pollingInterval).Info("Problem Poller will poll periodically")
+	for {
+		err := p.poll(pollingCtx)
+		if err != nil {
+			log.WithError(err).Error("Could not poll problems")
+		}
+
+		select {
+		case <-runCtx.Done():
+			log.Info("Problem Poller has terminated")
+			return
+
+		case <-time.After(time.Duration(pollingInterval) * time.Second):
+		}
+
+		log.WithField("delaySeconds", pollingInterval).Info("Polling problems")
+	}
+}
+
+// poll performs a single polling run, handling all problems that are open or were closed since the watermark.
+// As problems may only become visible in the API after a delay, the watermark lags behind the current time by dynatrace.ProblemsV2RequiredDelay.
+// The timeframe queried starts p.lookback before the watermark, so that problems reported even later are not missed.
+// If there is no watermark yet, the timeframe starts p.lookback before the new watermark, which then also serves as the watermark.
+func (p *ProblemPoller) poll(ctx context.Context) error {
+	watermark, err := p.watermarkStore.Get()
+	if err != nil {
+		return fmt.Errorf("could not get watermark: %w", err)
+	}
+
+	newWatermark := p.now().Add(-dynatrace.ProblemsV2RequiredDelay)
+	from := watermark.Add(-p.lookback)
+	if watermark.IsZero() {
+		watermark = newWatermark.Add(-p.lookback)
+		from = watermark
+		log.WithField("watermark", watermark).Info("Problem Poller will handle problems opened or closed from now on, looking back for problems reported late")
+	}
+
+	if !newWatermark.After(watermark) {
+		return nil
+	}
+
+	dtClient, err := p.clientFactory.CreateClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	timeframe, err := common.NewTimeframe(from, newWatermark)
+	if err != nil {
+		return err
+	}
+
+	polledProblems, err := dynatrace.NewProblemsV2Client(dtClient).GetByQuery(ctx, dynatrace.NewProblemsV2ClientQueryParameters(problems.NewQuery(p.problemSelector, ""), *timeframe))
+	if err != nil {
+		return fmt.Errorf("could not retrieve problems: %w", err)
+	}
+
+	events := newPolledProblemEvents(polledProblems, watermark)
+	for _, event := range events {
+		ce, err := event.toCloudEvent(dtClient.Credentials().GetTenant(), p.keptnProject)
+		if err != nil {
+			log.WithError(err).WithField("PID", event.problem.ProblemID).Error("Could not create problem event")
+			continue
+		}
+
+		p.handleEvent(*ce)
+	}
+
+	log.WithFields(log.Fields{"events": len(events), "watermark": newWatermark}).Debug("Polled problems")
+	return p.watermarkStore.Put(newWatermark)
+}
+
+// polledProblemEvent is the opening or closing of a polled problem.
+type polledProblemEvent struct {
+	problem dynatrace.Problem
+	state   string
+	time    time.Time
+}
+
+// newPolledProblemEvents returns the events of the polled problems based on their status, ordered by time.
+// Open problems are opened and closed problems are closed, regardless of when this happened, as repeated notifications are dropped by the ProblemDeduplicator.
+// Closed problems are only opened beforehand if they were opened after the watermark, as they would otherwise be reopened each time they are polled again.
+func newPolledProblemEvents(polledProblems []dynatrace.Problem, watermark time.Time) []polledProblemEvent {
+	events := []polledProblemEvent{}
+	for _, problem := range polledProblems {
+		startTime := problemTimestampToTime(problem.StartTime)
+		if problem.Status == dynatrace.ProblemStatusOpen {
+			events = append(events, polledProblemEvent{problem: problem, state: "OPEN", time: startTime})
+			continue
+		}
+
+		if problem.EndTime <= 0 {
+			continue
+		}
+
+		if startTime.After(watermark) {
+			events = append(events, polledProblemEvent{problem: problem, state: "OPEN", time: startTime})
+		}
+		events = append(events, polledProblemEvent{problem: problem, state: "RESOLVED", time: problemTimestampToTime(problem.EndTime)})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].time.Before(events[j].time)
+	})
+
+	return events
+}
+
+// toCloudEvent creates a problem event like the one sent by the problem notification set up by the dynatrace-service.
+func (e polledProblemEvent) toCloudEvent(tenant string, keptnProject string) (*cloudevents.Event, error) {
+	data := newNotificationTemplatePayload(e.problem, e.state, strings.TrimSuffix(tenant, "/")+"/#problems/problemdetails;pid="+e.problem.ProblemID)
+	if keptnProject != "" {
+		data["KeptnProject"] = keptnProject
+	}
+
+	keptnContext, err := getKeptnContextForPID(e.problem.ProblemID)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Keptn context: %w", err)
+	}
+
+	ce := cloudevents.NewEvent()
+	ce.SetID(uuid.New().String())
+	ce.SetType(keptnlib.ProblemEventType)
+	ce.SetSource("dynatrace")
+	ce.SetExtension("shkeptncontext", keptnContext)
+	err = ce.SetData(cloudevents.ApplicationJSON, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not set data: %w", err)
+	}
+
+	return &ce, nil
+}
+
+// getKeptnContextForPID returns the Keptn context that the Keptn API assigns to events of problem notifications, whose shkeptncontext is the PID.
+// As the PID is not a UUID, the Keptn API uses it as the source of randomness of a version 4 UUID, so that all events of a problem share the same Keptn context.
+func getKeptnContextForPID(pid string) (string, error) {
+	if _, err := uuid.Parse(pid); err == nil {
+		return pid, nil
+	}
+
+	// shorter PIDs are padded like the Keptn API does, as 16 bytes are needed
+	source := pid
+	if len(source) < 16 {
+		source += "abcdefghijklmnop"
+	}
+
+	keptnContext, err := uuid.NewRandomFromReader(strings.NewReader(source))
+	if err != nil {
+		return "", err
+	}
+	return keptnContext.String(), nil
+}
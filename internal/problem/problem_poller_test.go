@@ -0,0 +1,161 @@
+package problem
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+type dynatraceClientFactoryMock struct {
+	client dynatrace.ClientInterface
+}
+
+func (m dynatraceClientFactoryMock) CreateClient(ctx context.Context) (dynatrace.ClientInterface, error) {
+	return m.client, nil
+}
+
+func TestProblemPoller_Poll(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems?from=1637588760000&pageSize=500&problemSelector=managementZones%28%22sockshop%22%29&to=1637590680000", "./testdata/problem_poller/problems.json")
+
+	dtClient, url, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	handledEvents := []cloudevents.Event{}
+	watermarkStore := NewInMemoryProblemWatermarkStore()
+	err := watermarkStore.Put(time.Date(2021, 11, 22, 14, 16, 0, 0, time.UTC))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	poller := &ProblemPoller{
+		clientFactory:   dynatraceClientFactoryMock{client: dtClient},
+		watermarkStore:  watermarkStore,
+		problemSelector: "managementZones(\"sockshop\")",
+		keptnProject:    "sockshop",
+		lookback:        30 * time.Minute,
+		handleEvent: func(event cloudevents.Event) {
+			handledEvents = append(handledEvents, event)
+		},
+		now: func() time.Time { return time.Date(2021, 11, 22, 14, 20, 0, 0, time.UTC) },
+	}
+
+	err = poller.poll(context.TODO())
+	assert.NoError(t, err)
+
+	type expectedEvent struct {
+		pid   string
+		state string
+	}
+	expectedEvents := []expectedEvent{
+		// still open, but opened before the watermark
+		{pid: "-3000000000000000000_1637590200000V2", state: "OPEN"},
+		{pid: "-4000000000000000000_1637590590000V2", state: "OPEN"},
+		{pid: "-4511147584335281453_1637590620000V2", state: "OPEN"},
+		{pid: "-4000000000000000000_1637590590000V2", state: "RESOLVED"},
+		// opened before the watermark, so only closed
+		{pid: "-2000000000000000000_1637589600000V2", state: "RESOLVED"},
+	}
+	if !assert.EqualValues(t, len(expectedEvents), len(handledEvents)) {
+		return
+	}
+
+	eventIDs := map[string]bool{}
+	for i, expected := range expectedEvents {
+		adapter, err := NewProblemAdapterFromEvent(handledEvents[i])
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		keptnContext, err := getKeptnContextForPID(expected.pid)
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		assert.EqualValues(t, expected.pid, adapter.GetPID())
+		assert.EqualValues(t, expected.state, adapter.GetState())
+		assert.False(t, adapter.IsNotFromDynatrace())
+		assert.EqualValues(t, keptnContext, adapter.GetShKeptnContext())
+		assert.EqualValues(t, "sockshop", adapter.GetProject())
+		assert.False(t, eventIDs[handledEvents[i].ID()])
+		eventIDs[handledEvents[i].ID()] = true
+	}
+
+	// tags of the affected entities are used in the same way as for problem notifications
+	adapter, err := NewProblemAdapterFromEvent(handledEvents[2])
+	if assert.NoError(t, err) {
+		assert.EqualValues(t, "production", adapter.GetStage())
+		assert.EqualValues(t, "carts", adapter.GetService())
+		assert.EqualValues(t, "Process unavailable", adapter.GetTitle())
+		assert.EqualValues(t, "AVAILABILITY", adapter.GetSeverityLevel())
+		assert.EqualValues(t, []string{"SERVICE"}, adapter.GetImpactedEntityTypes())
+		assert.EqualValues(t, url+"/#problems/problemdetails;pid=-4511147584335281453_1637590620000V2", adapter.GetProblemURL())
+
+		startTime, ok := adapter.GetStartTime()
+		assert.True(t, ok)
+		assert.EqualValues(t, time.Date(2021, 11, 22, 14, 17, 0, 0, time.UTC), startTime.UTC())
+	}
+
+	watermark, err := watermarkStore.Get()
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Date(2021, 11, 22, 14, 18, 0, 0, time.UTC), watermark)
+}
+
+func TestProblemPoller_PollWithoutWatermark(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact("/api/v2/problems?from=1637588760000&pageSize=500&to=1637590560000", "./testdata/problem_poller/problems.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	handledEvents := []cloudevents.Event{}
+	watermarkStore := NewInMemoryProblemWatermarkStore()
+	poller := &ProblemPoller{
+		clientFactory:  dynatraceClientFactoryMock{client: dtClient},
+		watermarkStore: watermarkStore,
+		lookback:       30 * time.Minute,
+		handleEvent: func(event cloudevents.Event) {
+			handledEvents = append(handledEvents, event)
+		},
+		now: func() time.Time { return time.Date(2021, 11, 22, 14, 18, 0, 0, time.UTC) },
+	}
+
+	// without a watermark, e.g. when polling for the first time, only the lookback before now is polled
+	err := poller.poll(context.TODO())
+	assert.NoError(t, err)
+	assert.Len(t, handledEvents, 6)
+
+	watermark, err := watermarkStore.Get()
+	assert.NoError(t, err)
+	assert.EqualValues(t, time.Date(2021, 11, 22, 14, 16, 0, 0, time.UTC), watermark)
+}
+
+func Test_getKeptnContextForPID(t *testing.T) {
+	pids := []string{"-4511147584335281453_1637590620000V2", "12345"}
+	for _, pid := range pids {
+		t.Run(pid, func(t *testing.T) {
+			keptnContext, err := getKeptnContextForPID(pid)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			_, err = uuid.Parse(keptnContext)
+			assert.NoError(t, err)
+
+			otherKeptnContext, err := getKeptnContextForPID(pid)
+			assert.NoError(t, err)
+			assert.Equal(t, keptnContext, otherKeptnContext)
+		})
+	}
+
+	keptnContext, err := getKeptnContextForPID("c4f4b1e0-7a3d-4a8e-9f2b-5d6c7e8f9a0b")
+	assert.NoError(t, err)
+	assert.Equal(t, "c4f4b1e0-7a3d-4a8e-9f2b-5d6c7e8f9a0b", keptnContext)
+}
@@ -0,0 +1,222 @@
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// ProblemStatus is the status of a problem as last seen by the dynatrace-service.
+type ProblemStatus string
+
+const (
+	// ProblemStatusOpen indicates that the problem is open.
+	ProblemStatusOpen ProblemStatus = "OPEN"
+
+	// ProblemStatusClosed indicates that the problem has been resolved or closed.
+	ProblemStatusClosed ProblemStatus = "CLOSED"
+
+	// ProblemStatusMerged indicates that the problem has been merged into another problem.
+	ProblemStatusMerged ProblemStatus = "MERGED"
+)
+
+// ProblemState is the state of a problem received from Dynatrace.
+type ProblemState struct {
+	// Key identifies the problem, i.e. its PID or, if not available, its problem ID.
+	Key string `json:"key"`
+
+	// KeptnContext is the Keptn context used for the events sent for the problem.
+	KeptnContext string `json:"keptnContext"`
+
+	Status      ProblemStatus `json:"status"`
+	LastChanged time.Time     `json:"lastChanged"`
+}
+
+// ProblemStateStore stores the state of problems received from Dynatrace.
+type ProblemStateStore interface {
+	// Get returns the state of the problem with the specified key, or nil if there is none.
+	Get(key string) (*ProblemState, error)
+
+	// Put stores the state of a problem, replacing any existing state with the same key.
+	Put(state ProblemState) error
+
+	// Delete removes the state of the problem with the specified key, if there is one.
+	Delete(key string) error
+}
+
+// InMemoryProblemStateStore is a ProblemStateStore that keeps the state of problems in memory for a limited time.
+type InMemoryProblemStateStore struct {
+	mutex     sync.Mutex
+	retention time.Duration
+	states    map[string]ProblemState
+}
+
+// NewInMemoryProblemStateStore creates a new InMemoryProblemStateStore which forgets problems that have not changed for the specified retention period.
+func NewInMemoryProblemStateStore(retention time.Duration) *InMemoryProblemStateStore {
+	return &InMemoryProblemStateStore{
+		retention: retention,
+		states:    make(map[string]ProblemState),
+	}
+}
+
+// Get returns the state of the problem with the specified key, or nil if there is none.
+func (s *InMemoryProblemStateStore) Get(key string) (*ProblemState, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	state, ok := s.states[key]
+	if !ok || s.isExpired(state) {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+// Put stores the state of a problem, replacing any existing state with the same key.
+func (s *InMemoryProblemStateStore) Put(state ProblemState) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for key, existingState := range s.states {
+		if s.isExpired(existingState) {
+			delete(s.states, key)
+		}
+	}
+
+	s.states[state.Key] = state
+	return nil
+}
+
+// Delete removes the state of the problem with the specified key, if there is one.
+func (s *InMemoryProblemStateStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.states, key)
+	return nil
+}
+
+func (s *InMemoryProblemStateStore) isExpired(state ProblemState) bool {
+	return time.Since(state.LastChanged) > s.retention
+}
+
+// ConfigMapProblemStateStore is a ProblemStateStore that keeps the state of problems for a limited time in a Kubernetes ConfigMap, so that it survives restarts.
+// Each state is stored as JSON under the key of the problem. Concurrent modifications are detected using the resource version of the ConfigMap and retried.
+type ConfigMapProblemStateStore struct {
+	k8sClient kubernetes.Interface
+	namespace string
+	name      string
+	retention time.Duration
+}
+
+// NewConfigMapProblemStateStore creates a new ConfigMapProblemStateStore using the ConfigMap with the specified name and namespace, which is created if it does not exist.
+// Problems that have not changed for the specified retention period are forgotten.
+func NewConfigMapProblemStateStore(k8sClient kubernetes.Interface, namespace string, name string, retention time.Duration) *ConfigMapProblemStateStore {
+	return &ConfigMapProblemStateStore{
+		k8sClient: k8sClient,
+		namespace: namespace,
+		name:      name,
+		retention: retention,
+	}
+}
+
+// Get returns the state of the problem with the specified key, or nil if there is none.
+func (s *ConfigMapProblemStateStore) Get(key string) (*ProblemState, error) {
+	configMap, err := s.k8sClient.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get ConfigMap %s: %w", s.name, err)
+	}
+
+	value, ok := configMap.Data[key]
+	if !ok {
+		return nil, nil
+	}
+
+	state := ProblemState{}
+	err = json.Unmarshal([]byte(value), &state)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal state of problem %s: %w", key, err)
+	}
+
+	if s.isExpired(state) {
+		return nil, nil
+	}
+
+	return &state, nil
+}
+
+// Put stores the state of a problem, replacing any existing state with the same key.
+func (s *ConfigMapProblemStateStore) Put(state ProblemState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("could not marshal state of problem %s: %w", state.Key, err)
+	}
+
+	return s.update(func(data map[string]string) {
+		data[state.Key] = string(value)
+	})
+}
+
+// Delete removes the state of the problem with the specified key, if there is one.
+func (s *ConfigMapProblemStateStore) Delete(key string) error {
+	return s.update(func(data map[string]string) {
+		delete(data, key)
+	})
+}
+
+// update modifies the data of the ConfigMap, removing expired states, and creates the ConfigMap if it does not exist yet.
+func (s *ConfigMapProblemStateStore) update(modify func(data map[string]string)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := s.k8sClient.CoreV1().ConfigMaps(s.namespace)
+		configMap, err := configMaps.Get(context.Background(), s.name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Data:       map[string]string{},
+			}
+			modify(configMap.Data)
+			_, err = configMaps.Create(context.Background(), configMap, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// handled like a conflict, so that the update is retried on the created ConfigMap
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		s.removeExpiredStates(configMap.Data)
+		modify(configMap.Data)
+
+		_, err = configMaps.Update(context.Background(), configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (s *ConfigMapProblemStateStore) removeExpiredStates(data map[string]string) {
+	for key, value := range data {
+		state := ProblemState{}
+		if json.Unmarshal([]byte(value), &state) != nil || s.isExpired(state) {
+			delete(data, key)
+		}
+	}
+}
+
+func (s *ConfigMapProblemStateStore) isExpired(state ProblemState) bool {
+	return time.Since(state.LastChanged) > s.retention
+}
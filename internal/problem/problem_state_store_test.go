@@ -0,0 +1,71 @@
+package problem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInMemoryProblemStateStore(t *testing.T) {
+	store := NewInMemoryProblemStateStore(time.Hour)
+
+	state, err := store.Get("99999")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	err = store.Put(ProblemState{Key: "99999", KeptnContext: "context-1", Status: ProblemStatusOpen, LastChanged: time.Now()})
+	assert.NoError(t, err)
+
+	err = store.Put(ProblemState{Key: "88888", KeptnContext: "context-2", Status: ProblemStatusClosed, LastChanged: time.Now().Add(-2 * time.Hour)})
+	assert.NoError(t, err)
+
+	state, err = store.Get("99999")
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.EqualValues(t, "context-1", state.KeptnContext)
+		assert.EqualValues(t, ProblemStatusOpen, state.Status)
+	}
+
+	// states older than the retention period are forgotten
+	state, err = store.Get("88888")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
+
+func TestConfigMapProblemStateStore(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	store := NewConfigMapProblemStateStore(k8sClient, "keptn", "dynatrace-service-problem-states", time.Hour)
+
+	state, err := store.Get("99999")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	err = store.Put(ProblemState{Key: "99999", KeptnContext: "context-1", Status: ProblemStatusOpen, LastChanged: time.Now()})
+	assert.NoError(t, err)
+
+	err = store.Put(ProblemState{Key: "88888", KeptnContext: "context-2", Status: ProblemStatusClosed, LastChanged: time.Now().Add(-2 * time.Hour)})
+	assert.NoError(t, err)
+
+	// a new store using the same ConfigMap, e.g. after a restart, sees the stored states
+	store = NewConfigMapProblemStateStore(k8sClient, "keptn", "dynatrace-service-problem-states", time.Hour)
+	state, err = store.Get("99999")
+	assert.NoError(t, err)
+	if assert.NotNil(t, state) {
+		assert.EqualValues(t, "context-1", state.KeptnContext)
+		assert.EqualValues(t, ProblemStatusOpen, state.Status)
+	}
+
+	// states older than the retention period are forgotten
+	state, err = store.Get("88888")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+
+	err = store.Delete("99999")
+	assert.NoError(t, err)
+
+	state, err = store.Get("99999")
+	assert.NoError(t, err)
+	assert.Nil(t, state)
+}
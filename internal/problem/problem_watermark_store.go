@@ -0,0 +1,148 @@
+package problem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	keptnkubeutils "github.com/keptn/kubernetes-utils/pkg"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/env"
+)
+
+// problemWatermarkConfigMapName is the name of the ConfigMap the watermark of the problem poller is kept in if it is persisted.
+const problemWatermarkConfigMapName = "dynatrace-service-problem-watermark"
+
+// problemWatermarkKey is the key of the watermark in the ConfigMap.
+const problemWatermarkKey = "watermark"
+
+// ProblemWatermarkStore stores the point in time up to which problems have been polled.
+type ProblemWatermarkStore interface {
+	// Get returns the watermark, or the zero time if there is none.
+	Get() (time.Time, error)
+
+	// Put stores the watermark, replacing any existing one.
+	Put(watermark time.Time) error
+}
+
+// InMemoryProblemWatermarkStore is a ProblemWatermarkStore that keeps the watermark in memory.
+type InMemoryProblemWatermarkStore struct {
+	mutex     sync.Mutex
+	watermark time.Time
+}
+
+// NewInMemoryProblemWatermarkStore creates a new InMemoryProblemWatermarkStore without a watermark.
+func NewInMemoryProblemWatermarkStore() *InMemoryProblemWatermarkStore {
+	return &InMemoryProblemWatermarkStore{}
+}
+
+// Get returns the watermark, or the zero time if there is none.
+func (s *InMemoryProblemWatermarkStore) Get() (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.watermark, nil
+}
+
+// Put stores the watermark, replacing any existing one.
+func (s *InMemoryProblemWatermarkStore) Put(watermark time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.watermark = watermark
+	return nil
+}
+
+// ConfigMapProblemWatermarkStore is a ProblemWatermarkStore that keeps the watermark in a Kubernetes ConfigMap, so that it survives restarts.
+// The watermark is stored in RFC 3339 format. Concurrent modifications are detected using the resource version of the ConfigMap and retried.
+type ConfigMapProblemWatermarkStore struct {
+	k8sClient kubernetes.Interface
+	namespace string
+	name      string
+}
+
+// NewConfigMapProblemWatermarkStore creates a new ConfigMapProblemWatermarkStore using the ConfigMap with the specified name and namespace, which is created if it does not exist.
+func NewConfigMapProblemWatermarkStore(k8sClient kubernetes.Interface, namespace string, name string) *ConfigMapProblemWatermarkStore {
+	return &ConfigMapProblemWatermarkStore{
+		k8sClient: k8sClient,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Get returns the watermark, or the zero time if there is none.
+func (s *ConfigMapProblemWatermarkStore) Get() (time.Time, error) {
+	configMap, err := s.k8sClient.CoreV1().ConfigMaps(s.namespace).Get(context.Background(), s.name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not get ConfigMap %s: %w", s.name, err)
+	}
+
+	value, ok := configMap.Data[problemWatermarkKey]
+	if !ok {
+		return time.Time{}, nil
+	}
+
+	watermark, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse watermark %s: %w", value, err)
+	}
+
+	return watermark, nil
+}
+
+// Put stores the watermark, replacing any existing one, and creates the ConfigMap if it does not exist yet.
+func (s *ConfigMapProblemWatermarkStore) Put(watermark time.Time) error {
+	value := watermark.UTC().Format(time.RFC3339Nano)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := s.k8sClient.CoreV1().ConfigMaps(s.namespace)
+		configMap, err := configMaps.Get(context.Background(), s.name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: s.name, Namespace: s.namespace},
+				Data:       map[string]string{problemWatermarkKey: value},
+			}
+			_, err = configMaps.Create(context.Background(), configMap, metav1.CreateOptions{})
+			if k8serrors.IsAlreadyExists(err) {
+				// handled like a conflict, so that the update is retried on the created ConfigMap
+				return k8serrors.NewConflict(corev1.Resource("configmaps"), s.name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[problemWatermarkKey] = value
+
+		_, err = configMaps.Update(context.Background(), configMap, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// newDefaultProblemWatermarkStore returns a store which keeps the watermark in a ConfigMap if problem states are persisted and this is possible, or otherwise in memory.
+func newDefaultProblemWatermarkStore() ProblemWatermarkStore {
+	if !env.IsProblemStatePersistenceEnabled() {
+		return NewInMemoryProblemWatermarkStore()
+	}
+
+	k8sClient, err := keptnkubeutils.GetClientset(env.GetKubernetesServiceHost() != "")
+	if err != nil {
+		log.WithError(err).Warn("Could not create Kubernetes client, keeping watermark of problem poller in memory")
+		return NewInMemoryProblemWatermarkStore()
+	}
+
+	return NewConfigMapProblemWatermarkStore(k8sClient, env.GetPodNamespace(), problemWatermarkConfigMapName)
+}
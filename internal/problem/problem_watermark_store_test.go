@@ -0,0 +1,30 @@
+package problem
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigMapProblemWatermarkStore(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	store := NewConfigMapProblemWatermarkStore(k8sClient, "keptn", "dynatrace-service-problem-watermark")
+
+	watermark, err := store.Get()
+	assert.NoError(t, err)
+	assert.True(t, watermark.IsZero())
+
+	err = store.Put(time.Date(2021, 11, 22, 14, 16, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	err = store.Put(time.Date(2021, 11, 22, 14, 18, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	// a new store using the same ConfigMap, e.g. after a restart, sees the stored watermark
+	store = NewConfigMapProblemWatermarkStore(k8sClient, "keptn", "dynatrace-service-problem-watermark")
+	watermark, err = store.Get()
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2021, 11, 22, 14, 18, 0, 0, time.UTC).Equal(watermark))
+}
@@ -0,0 +1,32 @@
+package problem
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/credentials"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+const testDynatraceAPIToken = "dt0c01.ST2EY72KQINMH574WMNVI7YN.G3DFPBEJYMODIDAEX454M7YWBUVEFOWKPRVMWFASS64NFH52PX6BNDVFFM572RZM"
+
+func createDynatraceClient(t *testing.T, handler http.Handler) (dynatrace.ClientInterface, string, func()) {
+	httpClient, url, teardown := test.CreateHTTPSClient(handler)
+
+	dynatraceCredentials, err := credentials.NewDynatraceCredentials(url, testDynatraceAPIToken)
+	assert.NoError(t, err)
+
+	return dynatrace.NewClientWithHTTP(dynatraceCredentials, httpClient), url, teardown
+}
+
+// createDynatraceClientWithoutProblems creates a Dynatrace client for a tenant that does not know any problems.
+func createDynatraceClientWithoutProblems(t *testing.T) (dynatrace.ClientInterface, func()) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddStartsWithError("/api/v2/problems/", http.StatusNotFound, "./testdata/problems_v2_not_found.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	return dtClient, teardown
+}
@@ -13,6 +13,9 @@ import (
 
 const NoStatus = -1
 
+const jsonContentType = "application/json"
+const textContentType = "text/plain; charset=utf-8"
+
 type ClientInterface interface {
 	// Get performs an HTTP get request.
 	Get(ctx context.Context, apiPath string) ([]byte, int, string, error)
@@ -20,6 +23,9 @@ type ClientInterface interface {
 	// Post performs an HTTP post request.
 	Post(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error)
 
+	// PostText performs an HTTP post request with a plain text body.
+	PostText(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error)
+
 	// Put performs an HTTP put request.
 	Put(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error)
 
@@ -64,29 +70,34 @@ func NewDefaultClient(httpClient *http.Client, baseURL string) *Client {
 
 // Get performs an HTTP get request.
 func (c *Client) Get(ctx context.Context, apiPath string) ([]byte, int, string, error) {
-	return c.sendRequest(ctx, apiPath, http.MethodGet, nil)
+	return c.sendRequest(ctx, apiPath, http.MethodGet, nil, jsonContentType)
 }
 
 // Post performs an HTTP post request.
 func (c *Client) Post(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error) {
-	return c.sendRequest(ctx, apiPath, http.MethodPost, body)
+	return c.sendRequest(ctx, apiPath, http.MethodPost, body, jsonContentType)
+}
+
+// PostText performs an HTTP post request with a plain text body.
+func (c *Client) PostText(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error) {
+	return c.sendRequest(ctx, apiPath, http.MethodPost, body, textContentType)
 }
 
 // Put performs an HTTP put request.
 func (c *Client) Put(ctx context.Context, apiPath string, body []byte) ([]byte, int, string, error) {
-	return c.sendRequest(ctx, apiPath, http.MethodPut, body)
+	return c.sendRequest(ctx, apiPath, http.MethodPut, body, jsonContentType)
 }
 
 // Delete performs an HTTP delete request.
 func (c *Client) Delete(ctx context.Context, apiPath string) ([]byte, int, string, error) {
-	return c.sendRequest(ctx, apiPath, http.MethodDelete, nil)
+	return c.sendRequest(ctx, apiPath, http.MethodDelete, nil, jsonContentType)
 }
 
 // sendRequest makes an API request and returns the response and the status code or an error.
 // The response will not contain any data in case of an error.
-func (c *Client) sendRequest(ctx context.Context, apiPath string, method string, body []byte) ([]byte, int, string, error) {
+func (c *Client) sendRequest(ctx context.Context, apiPath string, method string, body []byte, contentType string) ([]byte, int, string, error) {
 
-	req, err := c.createRequest(ctx, apiPath, method, body)
+	req, err := c.createRequest(ctx, apiPath, method, body, contentType)
 	if err != nil {
 		return nil, NoStatus, "", err
 	}
@@ -95,7 +106,7 @@ func (c *Client) sendRequest(ctx context.Context, apiPath string, method string,
 }
 
 // createRequest creates an HTTP request for an API call with appropriate headers including authorization.
-func (c *Client) createRequest(ctx context.Context, apiPath string, method string, body []byte) (*http.Request, error) {
+func (c *Client) createRequest(ctx context.Context, apiPath string, method string, body []byte, contentType string) (*http.Request, error) {
 	var url = c.baseURL + apiPath
 
 	log.WithFields(log.Fields{"method": method, "url": url}).Debug("creating HTTP request")
@@ -108,7 +119,7 @@ func (c *Client) createRequest(ctx context.Context, apiPath string, method strin
 		}
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", "keptn-contrib/dynatrace-service:"+env.GetVersion())
 
 	for key, values := range c.additionalHeader {
@@ -72,6 +72,38 @@ func (p *CustomChartingTileProcessing) Process(ctx context.Context, tile *dynatr
 	return p.processSeries(ctx, sloDefinition, &tile.FilterConfig.ChartConfig.Series[0], tileManagementZoneFilter, tile.FilterConfig.FiltersPerEntityType)
 }
 
+// ProcessDefinition returns the SLI definition of the specified Custom Charting dashboard tile without querying its value, or nil if the tile does not define an SLI.
+func (p *CustomChartingTileProcessing) ProcessDefinition(ctx context.Context, tile *dynatrace.Tile, dashboardFilter *dynatrace.DashboardFilter) (*TileDefinition, error) {
+	if tile.FilterConfig == nil {
+		return nil, nil
+	}
+
+	sloDefinition, err := common.ParseSLOFromString(tile.FilterConfig.CustomName)
+	if err != nil {
+		return nil, fmt.Errorf("custom charting tile title parsing error: %w", err)
+	}
+
+	if sloDefinition.SLI == "" {
+		return nil, nil
+	}
+
+	if len(tile.FilterConfig.ChartConfig.Series) != 1 {
+		return nil, errors.New("custom charting tile must have exactly one series")
+	}
+
+	metricQuery, err := p.generateMetricQueryFromChartSeries(ctx, &tile.FilterConfig.ChartConfig.Series[0], NewManagementZoneFilter(dashboardFilter, tile.TileFilter.ManagementZone), tile.FilterConfig.FiltersPerEntityType)
+	if err != nil {
+		return nil, fmt.Errorf("custom charting tile could not be converted to a metric query: %w", err)
+	}
+
+	// only series split by a dimension without a filter on its values need the dimension values to produce their SLIs
+	if metricQuery.entitySelectorTargetSnippet != "" || metricQuery.metricSelectorTargetSnippet != "" {
+		return nil, errTileSplitByDimension
+	}
+
+	return newTileDefinition(sloDefinition, metricQuery), nil
+}
+
 func (p *CustomChartingTileProcessing) processSeries(ctx context.Context, sloDefinition *keptnapi.SLO, series *dynatrace.Series, tileManagementZoneFilter *ManagementZoneFilter, filtersPerEntityType map[string]dynatrace.FilterMap) []*TileResult {
 
 	metricQuery, err := p.generateMetricQueryFromChartSeries(ctx, series, tileManagementZoneFilter, filtersPerEntityType)
@@ -0,0 +1,100 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	keptnapi "github.com/keptn/go-utils/pkg/lib"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/adapter"
+	"github.com/keptn-contrib/dynatrace-service/internal/common"
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+// errTileSplitByDimension is returned for tiles split by a dimension, as their SLIs depend on the dimension values returned by the query.
+var errTileSplitByDimension = errors.New("SLIs of tiles split by a dimension depend on the queried dimension values")
+
+// TileDefinition is the SLI definition and objective of a dashboard tile, obtained without querying its value.
+type TileDefinition struct {
+	objective *keptnapi.SLO
+	sliQuery  string
+}
+
+// DefinitionParsing parses the SLI definitions and objectives of the metric tiles of a Dynatrace dashboard without querying any SLI values.
+// Only the dashboard and the definitions of the charted metrics are retrieved.
+type DefinitionParsing struct {
+	client    dynatrace.ClientInterface
+	eventData adapter.EventContentAdapter
+}
+
+// NewDefinitionParsing creates a new DefinitionParsing.
+func NewDefinitionParsing(client dynatrace.ClientInterface, eventData adapter.EventContentAdapter) *DefinitionParsing {
+	return &DefinitionParsing{
+		client:    client,
+		eventData: eventData,
+	}
+}
+
+// GetSLIDefinitions retrieves the dashboard, which may either be a dashboard ID or query, and returns the objectives and SLI queries of its Data Explorer and Custom Charting tiles.
+// Tiles that cannot be converted to a single SLI query, e.g. because they are split by a dimension, are skipped.
+func (p *DefinitionParsing) GetSLIDefinitions(ctx context.Context, dashboardID string) (*keptnapi.ServiceLevelObjectives, *dynatrace.SLI, error) {
+	dashboard, dashboardID, err := NewRetrieval(p.client, p.eventData).Retrieve(ctx, dashboardID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while processing dashboard config '%s' - %w", dashboardID, err)
+	}
+
+	totalScore := createDefaultSLOScore()
+	comparison := createDefaultSLOComparison()
+	slos := &keptnapi.ServiceLevelObjectives{
+		Objectives: []*keptnapi.SLO{},
+		TotalScore: &totalScore,
+		Comparison: &comparison,
+	}
+	slis := &dynatrace.SLI{
+		SpecVersion: "0.1.4",
+		Indicators:  make(map[string]string),
+	}
+
+	for _, tile := range dashboard.Tiles {
+		var definition *TileDefinition
+		switch tile.TileType {
+		case dynatrace.DataExplorerTileType:
+			definition, err = (&DataExplorerTileProcessing{client: p.client, eventData: p.eventData}).ProcessDefinition(ctx, &tile, dashboard.GetFilter())
+		case dynatrace.CustomChartingTileType:
+			definition, err = (&CustomChartingTileProcessing{client: p.client, eventData: p.eventData}).ProcessDefinition(ctx, &tile, dashboard.GetFilter())
+		default:
+			continue
+		}
+
+		if err != nil {
+			log.WithError(err).WithField("tileName", tile.Name).Warn("Could not parse SLI definition of tile, skipping it")
+			continue
+		}
+
+		if definition == nil {
+			continue
+		}
+
+		slis.Indicators[definition.objective.SLI] = definition.sliQuery
+		slos.Objectives = append(slos.Objectives, definition.objective)
+	}
+
+	return slos, slis, nil
+}
+
+// newTileDefinition creates the TileDefinition of a tile, which is not split by a dimension, with the SLO definition and query components.
+func newTileDefinition(sloDefinition *keptnapi.SLO, metricQueryComponents *queryComponents) *TileDefinition {
+	indicatorName := common.CleanIndicatorName(sloDefinition.SLI)
+	return &TileDefinition{
+		objective: &keptnapi.SLO{
+			SLI:     indicatorName,
+			Weight:  sloDefinition.Weight,
+			KeySLI:  sloDefinition.KeySLI,
+			Pass:    sloDefinition.Pass,
+			Warning: sloDefinition.Warning,
+		},
+		sliQuery: getMetricsQueryString(metricQueryComponents.metricUnit, metricQueryComponents.metricsQuery),
+	}
+}
@@ -0,0 +1,42 @@
+package dashboard
+
+import (
+	"context"
+	"testing"
+
+	keptnapi "github.com/keptn/go-utils/pkg/lib"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+	"github.com/keptn-contrib/dynatrace-service/internal/test"
+)
+
+// TestDefinitionParsing_GetSLIDefinitions tests that the SLIs of metric tiles are parsed without querying the Metrics API, as no handler is registered for metric queries.
+// Tiles split by a dimension as well as other tile types are skipped.
+func TestDefinitionParsing_GetSLIDefinitions(t *testing.T) {
+	handler := test.NewFileBasedURLHandler(t)
+	handler.AddExact(dynatrace.DashboardsPath+"/"+QUALITYGATE_DASHBOARD_ID, "./testdata/dashboard_definitions/dashboard.json")
+	handler.AddExact(dynatrace.MetricsPath+"/builtin:service.response.time", "./testdata/test_get_metrics_svcresponsetime.json")
+	handler.AddExact(dynatrace.MetricsPath+"/builtin:service.requestCount.total", "./testdata/test_get_metrics_requestcount.json")
+
+	dtClient, _, teardown := createDynatraceClient(t, handler)
+	defer teardown()
+
+	slos, slis, err := NewDefinitionParsing(dtClient, createKeptnEvent("sockshop", "staging", "carts")).GetSLIDefinitions(context.TODO(), QUALITYGATE_DASHBOARD_ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.EqualValues(t,
+		map[string]string{
+			"response_time_p95": "MV2;MicroSecond;entitySelector=type(SERVICE),tag(\"keptn_service:carts\")&metricSelector=builtin:service.response.time:splitBy():percentile(95.000000):names",
+			"request_count":     "entitySelector=type(SERVICE),tag(\"keptn_service:carts\")&metricSelector=builtin:service.requestCount.total:splitBy():sum:names",
+		},
+		slis.Indicators)
+	assert.EqualValues(t,
+		[]*keptnapi.SLO{
+			{SLI: "response_time_p95", Weight: 1, Pass: []*keptnapi.SLOCriteria{{Criteria: []string{"<+5%", "<550"}}}},
+			{SLI: "request_count", Weight: 1, Pass: []*keptnapi.SLOCriteria{{Criteria: []string{">100"}}}},
+		},
+		slos.Objectives)
+}
@@ -61,6 +61,33 @@ func (p *DataExplorerTileProcessing) Process(ctx context.Context, tile *dynatrac
 	return p.processQuery(ctx, sloDefinition, tile.Queries[0], managementZoneFilter)
 }
 
+// ProcessDefinition returns the SLI definition of the specified Data Explorer dashboard tile without querying its value, or nil if the tile does not define an SLI.
+func (p *DataExplorerTileProcessing) ProcessDefinition(ctx context.Context, tile *dynatrace.Tile, dashboardFilter *dynatrace.DashboardFilter) (*TileDefinition, error) {
+	sloDefinition, err := common.ParseSLOFromString(tile.Name)
+	if err != nil {
+		return nil, fmt.Errorf("Data Explorer tile title parsing error: %w", err)
+	}
+
+	if sloDefinition.SLI == "" {
+		return nil, nil
+	}
+
+	if len(tile.Queries) != 1 {
+		return nil, errors.New("Data Explorer tile must have exactly one query")
+	}
+
+	if len(tile.Queries[0].SplitBy) > 0 {
+		return nil, errTileSplitByDimension
+	}
+
+	metricQuery, err := p.generateMetricQueryFromDataExplorerQuery(ctx, tile.Queries[0], NewManagementZoneFilter(dashboardFilter, tile.TileFilter.ManagementZone))
+	if err != nil {
+		return nil, fmt.Errorf("Data Explorer tile could not be converted to a metric query: %w", err)
+	}
+
+	return newTileDefinition(sloDefinition, metricQuery), nil
+}
+
 func (p *DataExplorerTileProcessing) processQuery(ctx context.Context, sloDefinition *keptnapi.SLO, dataQuery dynatrace.DataExplorerQuery, managementZoneFilter *ManagementZoneFilter) []*TileResult {
 	log.WithField("metric", dataQuery.Metric).Debug("Processing data explorer query")
 
@@ -98,8 +125,8 @@ func (p *DataExplorerTileProcessing) generateMetricQueryFromDataExplorerQuery(ct
 			return nil, fmt.Errorf("only a single filter is supported")
 		}
 
-		if len(dataQuery.FilterBy.NestedFilters[0].Criteria) != 1 {
-			return nil, fmt.Errorf("only a single filter criterion is supported")
+		if len(dataQuery.FilterBy.NestedFilters[0].Criteria) != 1 && !isTagFilterMatchingAllCriteria(&dataQuery.FilterBy.NestedFilters[0]) {
+			return nil, fmt.Errorf("only a single filter criterion or a tag filter matching all criteria is supported")
 		}
 
 		if len(dataQuery.FilterBy.NestedFilters[0].NestedFilters) > 0 {
@@ -198,8 +225,13 @@ func processFilter(entityType string, filter *dynatrace.DataExplorerFilter) (*pr
 		}, nil
 
 	case "TAG":
+		// a tag filter may match all of several tags, which are combined in the entity selector
+		entitySelectorFilter := fmt.Sprintf("type(%s)", entityType)
+		for _, criterion := range filter.Criteria {
+			entitySelectorFilter += fmt.Sprintf(",tag(\"%s\")", criterion.Value)
+		}
 		return &processedFilterComponents{
-			entitySelectorFilter:        fmt.Sprintf("type(%s),tag(\"%s\")", entityType, filter.Criteria[0].Value),
+			entitySelectorFilter:        entitySelectorFilter,
 			entitySelectorTargetSnippet: ",entityId(FILTERDIMENSIONVALUE)",
 		}, nil
 
@@ -220,6 +252,11 @@ func processFilter(entityType string, filter *dynatrace.DataExplorerFilter) (*pr
 	}
 }
 
+// isTagFilterMatchingAllCriteria returns true if the filter is a tag filter with several criteria which must all be matched.
+func isTagFilterMatchingAllCriteria(filter *dynatrace.DataExplorerFilter) bool {
+	return filter.FilterType == "TAG" && filter.FilterOperator == "AND" && len(filter.Criteria) > 0
+}
+
 func getSpaceAggregationTransformation(spaceAggregation string) (string, error) {
 	switch spaceAggregation {
 	case "AVG":
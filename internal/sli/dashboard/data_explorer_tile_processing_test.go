@@ -0,0 +1,70 @@
+package dashboard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/keptn-contrib/dynatrace-service/internal/dynatrace"
+)
+
+func Test_processFilter_tagFilterMatchingAllCriteria(t *testing.T) {
+	filter := &dynatrace.DataExplorerFilter{
+		Filter:         "dt.entity.service",
+		FilterType:     "TAG",
+		FilterOperator: "AND",
+		Criteria: []dynatrace.DataExplorerCriterion{
+			{Value: "keptn_project:sockshop", Evaluator: "IN"},
+			{Value: "keptn_stage:production", Evaluator: "IN"},
+			{Value: "keptn_service:carts", Evaluator: "IN"},
+		},
+	}
+
+	assert.True(t, isTagFilterMatchingAllCriteria(filter))
+
+	components, err := processFilter("SERVICE", filter)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "type(SERVICE),tag(\"keptn_project:sockshop\"),tag(\"keptn_stage:production\"),tag(\"keptn_service:carts\")", components.entitySelectorFilter)
+		assert.Equal(t, ",entityId(FILTERDIMENSIONVALUE)", components.entitySelectorTargetSnippet)
+	}
+}
+
+func Test_isTagFilterMatchingAllCriteria(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter dynatrace.DataExplorerFilter
+		want   bool
+	}{
+		{
+			name: "tag filter with OR operator",
+			filter: dynatrace.DataExplorerFilter{
+				FilterType:     "TAG",
+				FilterOperator: "OR",
+				Criteria:       []dynatrace.DataExplorerCriterion{{Value: "a"}, {Value: "b"}},
+			},
+			want: false,
+		},
+		{
+			name: "name filter with AND operator",
+			filter: dynatrace.DataExplorerFilter{
+				FilterType:     "NAME",
+				FilterOperator: "AND",
+				Criteria:       []dynatrace.DataExplorerCriterion{{Value: "a"}, {Value: "b"}},
+			},
+			want: false,
+		},
+		{
+			name: "tag filter without criteria",
+			filter: dynatrace.DataExplorerFilter{
+				FilterType:     "TAG",
+				FilterOperator: "AND",
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTagFilterMatchingAllCriteria(&tt.filter))
+		})
+	}
+}
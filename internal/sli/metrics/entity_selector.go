@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var entitySelectorPredicatePattern = regexp.MustCompile(`^([\w.]+)\((.*)\)$`)
+
+// EntitySelectorPredicate is a single predicate of an entity selector, e.g. tag("keptn_service:carts").
+type EntitySelectorPredicate struct {
+	name      string
+	arguments []string
+}
+
+// GetName returns the name of the predicate, e.g. tag.
+func (p EntitySelectorPredicate) GetName() string {
+	return p.name
+}
+
+// GetArguments returns the unquoted arguments of the predicate, e.g. keptn_service:carts for tag("keptn_service:carts").
+func (p EntitySelectorPredicate) GetArguments() []string {
+	return p.arguments
+}
+
+// ParseEntitySelector parses an entity selector, e.g. type(SERVICE),tag(keptn_service:carts), into its predicates or returns an error.
+// An empty entity selector has no predicates.
+func ParseEntitySelector(entitySelector string) ([]EntitySelectorPredicate, error) {
+	entitySelector = strings.TrimSpace(entitySelector)
+	if entitySelector == "" {
+		return nil, nil
+	}
+
+	parts, err := splitSelector(entitySelector, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	predicates := make([]EntitySelectorPredicate, 0, len(parts))
+	for _, part := range parts {
+		matches := entitySelectorPredicatePattern.FindStringSubmatch(strings.TrimSpace(part))
+		if matches == nil {
+			return nil, fmt.Errorf("invalid predicate '%s' in entity selector: %s", part, entitySelector)
+		}
+
+		arguments, err := splitSelector(matches[2], ',')
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range arguments {
+			arguments[i] = unquoteSelectorValue(strings.TrimSpace(arguments[i]))
+		}
+
+		predicates = append(predicates, EntitySelectorPredicate{
+			name:      matches[1],
+			arguments: arguments,
+		})
+	}
+	return predicates, nil
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEntitySelector(t *testing.T) {
+	tests := []struct {
+		name                 string
+		entitySelector       string
+		expectedPredicates   []EntitySelectorPredicate
+		expectError          bool
+		expectedErrorMessage string
+	}{
+		{
+			name:           "type and tags",
+			entitySelector: "type(SERVICE),tag(keptn_project:sockshop),tag(\"keptn_service:carts\")",
+			expectedPredicates: []EntitySelectorPredicate{
+				{name: "type", arguments: []string{"SERVICE"}},
+				{name: "tag", arguments: []string{"keptn_project:sockshop"}},
+				{name: "tag", arguments: []string{"keptn_service:carts"}},
+			},
+		},
+		{
+			name:           "entity IDs and escaped name",
+			entitySelector: "entityId(\"SERVICE-1\", \"SERVICE-2\"), entityName.equals(\"carts ~\"v2~\"\")",
+			expectedPredicates: []EntitySelectorPredicate{
+				{name: "entityId", arguments: []string{"SERVICE-1", "SERVICE-2"}},
+				{name: "entityName.equals", arguments: []string{"carts \"v2\""}},
+			},
+		},
+		{
+			name:           "nested predicate",
+			entitySelector: "type(SERVICE),fromRelationships.runsOn(type(HOST),tag(env:prod))",
+			expectedPredicates: []EntitySelectorPredicate{
+				{name: "type", arguments: []string{"SERVICE"}},
+				{name: "fromRelationships.runsOn", arguments: []string{"type(HOST)", "tag(env:prod)"}},
+			},
+		},
+		{
+			name:           "empty",
+			entitySelector: "",
+		},
+		// Error cases below:
+		{
+			name:                 "invalid predicate",
+			entitySelector:       "type(SERVICE),keptn_service",
+			expectError:          true,
+			expectedErrorMessage: "invalid predicate 'keptn_service'",
+		},
+		{
+			name:                 "unbalanced parentheses",
+			entitySelector:       "type(SERVICE),tag(keptn_service:carts",
+			expectError:          true,
+			expectedErrorMessage: "unbalanced parentheses",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			predicates, err := ParseEntitySelector(tc.entitySelector)
+			if tc.expectError {
+				assert.Nil(t, predicates)
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErrorMessage)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.EqualValues(t, tc.expectedPredicates, predicates)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var metricKeyPartPattern = regexp.MustCompile(`^[\w.\-~]+$`)
+
+var transformationPattern = regexp.MustCompile(`^(\w+)\((.*)\)$`)
+
+// transformationsWithoutArguments are the transformations which may be used without parentheses, e.g. :avg or :names
+var transformationsWithoutArguments = map[string]bool{
+	"auto":     true,
+	"avg":      true,
+	"count":    true,
+	"delta":    true,
+	"fold":     true,
+	"last":     true,
+	"lastReal": true,
+	"max":      true,
+	"median":   true,
+	"min":      true,
+	"names":    true,
+	"parents":  true,
+	"sum":      true,
+	"value":    true,
+}
+
+// aggregationTransformations are the transformations selecting an aggregation of a metric
+var aggregationTransformations = map[string]bool{
+	"avg":        true,
+	"count":      true,
+	"max":        true,
+	"median":     true,
+	"min":        true,
+	"percentile": true,
+	"sum":        true,
+	"value":      true,
+}
+
+// Transformation is a single transformation of a metric selector, e.g. percentile(90) or splitBy("dt.entity.service").
+type Transformation struct {
+	name      string
+	arguments string
+}
+
+// GetName returns the name of the transformation, e.g. percentile.
+func (t Transformation) GetName() string {
+	return t.name
+}
+
+// GetArguments returns the arguments of the transformation without the surrounding parentheses, e.g. 90 for percentile(90).
+func (t Transformation) GetArguments() string {
+	return t.arguments
+}
+
+// IsAggregation returns true if the transformation selects an aggregation of the metric, e.g. avg or percentile(90).
+func (t Transformation) IsAggregation() bool {
+	return aggregationTransformations[t.name]
+}
+
+// String returns the transformation as used in a metric selector.
+func (t Transformation) String() string {
+	if t.arguments == "" && transformationsWithoutArguments[t.name] {
+		return t.name
+	}
+	return t.name + "(" + t.arguments + ")"
+}
+
+// MetricSelector is a metric selector of a single metric split into its metric key and transformations.
+type MetricSelector struct {
+	metricKey       string
+	transformations []Transformation
+}
+
+// ParseMetricSelector parses a metric selector of a single metric, e.g. builtin:service.response.time:merge("dt.entity.service"):percentile(90), or returns an error.
+// Metric selectors combining several metrics, e.g. using arithmetic operations, are not supported.
+func ParseMetricSelector(metricSelector string) (*MetricSelector, error) {
+	metricSelector = strings.TrimSpace(metricSelector)
+	if metricSelector == "" {
+		return nil, errors.New("metric selector should not be empty")
+	}
+
+	parts, err := splitSelector(metricSelector, ':')
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	var metricKeyParts []string
+	for ; i < len(parts) && !isTransformation(parts[i]); i++ {
+		if !metricKeyPartPattern.MatchString(parts[i]) {
+			return nil, fmt.Errorf("unsupported metric selector, only selectors of a single metric are supported: %s", metricSelector)
+		}
+		metricKeyParts = append(metricKeyParts, parts[i])
+	}
+
+	if len(metricKeyParts) == 0 {
+		return nil, fmt.Errorf("metric selector does not start with a metric key: %s", metricSelector)
+	}
+
+	transformations := make([]Transformation, 0, len(parts)-i)
+	for ; i < len(parts); i++ {
+		transformation, err := parseTransformation(parts[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric selector %s: %w", metricSelector, err)
+		}
+		transformations = append(transformations, *transformation)
+	}
+
+	return &MetricSelector{
+		metricKey:       strings.Join(metricKeyParts, ":"),
+		transformations: transformations,
+	}, nil
+}
+
+// GetMetricKey returns the metric key, e.g. builtin:service.response.time.
+func (s MetricSelector) GetMetricKey() string {
+	return s.metricKey
+}
+
+// GetTransformations returns the transformations in the order they are applied.
+func (s MetricSelector) GetTransformations() []Transformation {
+	return s.transformations
+}
+
+// GetAggregation returns the last transformation selecting an aggregation, if any.
+func (s MetricSelector) GetAggregation() (Transformation, bool) {
+	for i := len(s.transformations) - 1; i >= 0; i-- {
+		if s.transformations[i].IsAggregation() {
+			return s.transformations[i], true
+		}
+	}
+	return Transformation{}, false
+}
+
+func isTransformation(part string) bool {
+	return transformationsWithoutArguments[part] || transformationPattern.MatchString(part)
+}
+
+func parseTransformation(part string) (*Transformation, error) {
+	if transformationsWithoutArguments[part] {
+		return &Transformation{name: part}, nil
+	}
+
+	matches := transformationPattern.FindStringSubmatch(part)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid transformation: %s", part)
+	}
+
+	return &Transformation{
+		name:      matches[1],
+		arguments: matches[2],
+	}, nil
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetricSelector(t *testing.T) {
+	tests := []struct {
+		name                    string
+		metricSelector          string
+		expectedMetricKey       string
+		expectedTransformations []string
+		expectedAggregation     string
+		expectError             bool
+		expectedErrorMessage    string
+	}{
+		{
+			name:                    "metric key with percentile",
+			metricSelector:          "builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)",
+			expectedMetricKey:       "builtin:service.response.time",
+			expectedTransformations: []string{"merge(\"dt.entity.service\")", "percentile(90)"},
+			expectedAggregation:     "percentile(90)",
+		},
+		{
+			name:                    "data explorer metric selector",
+			metricSelector:          "builtin:service.errors.server.rate:filter(and(in(\"dt.entity.service\",entitySelector(\"type(SERVICE),tag(~\"keptn_service:carts~\")\")))):splitBy():avg:auto:sort(value(avg,descending)):limit(10)",
+			expectedMetricKey:       "builtin:service.errors.server.rate",
+			expectedTransformations: []string{"filter(and(in(\"dt.entity.service\",entitySelector(\"type(SERVICE),tag(~\"keptn_service:carts~\")\"))))", "splitBy()", "avg", "auto", "sort(value(avg,descending))", "limit(10)"},
+			expectedAggregation:     "avg",
+		},
+		{
+			name:                    "custom metric key without aggregation",
+			metricSelector:          "calc:service.teststep_response_time:splitBy(\"Test Step\")",
+			expectedMetricKey:       "calc:service.teststep_response_time",
+			expectedTransformations: []string{"splitBy(\"Test Step\")"},
+		},
+		{
+			name:                    "metric key only",
+			metricSelector:          " builtin:host.cpu.usage ",
+			expectedMetricKey:       "builtin:host.cpu.usage",
+			expectedTransformations: []string{},
+		},
+		// Error cases below:
+		{
+			name:                 "empty",
+			metricSelector:       "",
+			expectError:          true,
+			expectedErrorMessage: "metric selector should not be empty",
+		},
+		{
+			name:                 "metric expression",
+			metricSelector:       "(builtin:service.errors.total.count:avg/builtin:service.requestCount.total:avg)",
+			expectError:          true,
+			expectedErrorMessage: "only selectors of a single metric are supported",
+		},
+		{
+			name:                 "no metric key",
+			metricSelector:       "avg",
+			expectError:          true,
+			expectedErrorMessage: "does not start with a metric key",
+		},
+		{
+			name:                 "metric key after transformation",
+			metricSelector:       "builtin:service.response.time:avg:response",
+			expectError:          true,
+			expectedErrorMessage: "invalid transformation: response",
+		},
+		{
+			name:                 "unbalanced parentheses",
+			metricSelector:       "builtin:service.response.time:percentile(90",
+			expectError:          true,
+			expectedErrorMessage: "unbalanced parentheses",
+		},
+		{
+			name:                 "unbalanced quotes",
+			metricSelector:       "builtin:service.response.time:merge(\"dt.entity.service)",
+			expectError:          true,
+			expectedErrorMessage: "unbalanced quotes",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			metricSelector, err := ParseMetricSelector(tc.metricSelector)
+			if tc.expectError {
+				assert.Nil(t, metricSelector)
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tc.expectedErrorMessage)
+				}
+				return
+			}
+
+			assert.NoError(t, err)
+			if !assert.NotNil(t, metricSelector) {
+				return
+			}
+
+			assert.Equal(t, tc.expectedMetricKey, metricSelector.GetMetricKey())
+
+			transformations := make([]string, 0, len(metricSelector.GetTransformations()))
+			for _, transformation := range metricSelector.GetTransformations() {
+				transformations = append(transformations, transformation.String())
+			}
+			assert.EqualValues(t, tc.expectedTransformations, transformations)
+
+			aggregation, ok := metricSelector.GetAggregation()
+			assert.Equal(t, tc.expectedAggregation != "", ok)
+			if ok {
+				assert.Equal(t, tc.expectedAggregation, aggregation.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RatioMetricSelector is a metric selector dividing one metric by another, e.g. (builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy()), optionally multiplied by 100.
+type RatioMetricSelector struct {
+	numerator    string
+	denominator  string
+	isPercentage bool
+}
+
+// ParseRatioMetricSelector parses a metric selector of the form (numerator)/(denominator) or (100)*(numerator)/(denominator), where numerator and denominator are selectors of a single metric, or returns an error.
+// The factor of 100 may also be the last operand, e.g. (numerator)/(denominator)*100.
+func ParseRatioMetricSelector(metricSelector string) (*RatioMetricSelector, error) {
+	metricSelector = trimOuterParentheses(metricSelector)
+	if metricSelector == "" {
+		return nil, errors.New("metric selector should not be empty")
+	}
+
+	factors, err := splitSelector(metricSelector, '*')
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := metricSelector
+	isPercentage := false
+	switch len(factors) {
+	case 1:
+	case 2:
+		switch {
+		case trimOuterParentheses(factors[0]) == "100":
+			ratio = factors[1]
+		case trimOuterParentheses(factors[1]) == "100":
+			ratio = factors[0]
+		default:
+			return nil, fmt.Errorf("unsupported metric selector, only ratios of two metrics optionally multiplied by 100 are supported: %s", metricSelector)
+		}
+		isPercentage = true
+	default:
+		return nil, fmt.Errorf("unsupported metric selector, only ratios of two metrics optionally multiplied by 100 are supported: %s", metricSelector)
+	}
+
+	operands, err := splitSelector(trimOuterParentheses(ratio), '/')
+	if err != nil {
+		return nil, err
+	}
+
+	if len(operands) != 2 {
+		return nil, fmt.Errorf("metric selector is not a ratio of two metrics: %s", metricSelector)
+	}
+
+	numerator := trimOuterParentheses(operands[0])
+	if _, err := ParseMetricSelector(numerator); err != nil {
+		return nil, fmt.Errorf("invalid numerator of ratio metric selector: %w", err)
+	}
+
+	denominator := trimOuterParentheses(operands[1])
+	if _, err := ParseMetricSelector(denominator); err != nil {
+		return nil, fmt.Errorf("invalid denominator of ratio metric selector: %w", err)
+	}
+
+	return &RatioMetricSelector{
+		numerator:    numerator,
+		denominator:  denominator,
+		isPercentage: isPercentage,
+	}, nil
+}
+
+// GetNumerator returns the metric selector of the numerator.
+func (s RatioMetricSelector) GetNumerator() string {
+	return s.numerator
+}
+
+// GetDenominator returns the metric selector of the denominator.
+func (s RatioMetricSelector) GetDenominator() string {
+	return s.denominator
+}
+
+// IsPercentage returns true if the ratio is multiplied by 100.
+func (s RatioMetricSelector) IsPercentage() bool {
+	return s.isPercentage
+}
+
+// GetPercentageMetricExpression returns the ratio as a percentage in the format used by metric expressions of Dynatrace SLOs, e.g. (100)*(builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy()).
+func (s RatioMetricSelector) GetPercentageMetricExpression() string {
+	return "(100)*(" + s.numerator + ")/(" + s.denominator + ")"
+}
+
+// trimOuterParentheses removes whitespace and any parentheses enclosing the complete selector, e.g. (a/b) becomes a/b, but (a)/(b) is unchanged.
+func trimOuterParentheses(selector string) string {
+	selector = strings.TrimSpace(selector)
+	for strings.HasPrefix(selector, "(") && strings.HasSuffix(selector, ")") {
+		inner := selector[1 : len(selector)-1]
+
+		// the parentheses only enclose the complete selector if those within it are balanced
+		if _, err := splitSelector(inner, 0); err != nil {
+			return selector
+		}
+		selector = strings.TrimSpace(inner)
+	}
+	return selector
+}
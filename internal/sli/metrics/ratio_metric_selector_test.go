@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRatioMetricSelector(t *testing.T) {
+	tests := []struct {
+		name                       string
+		metricSelector             string
+		expectedNumerator          string
+		expectedDenominator        string
+		expectedIsPercentage       bool
+		expectedPercentageSelector string
+		expectError                bool
+		expectedErrorMessage       string
+	}{
+		{
+			name:                       "percentage of successful requests",
+			metricSelector:             "(100)*(builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy())",
+			expectedNumerator:          "builtin:service.errors.server.successCount:splitBy()",
+			expectedDenominator:        "builtin:service.requestCount.server:splitBy()",
+			expectedIsPercentage:       true,
+			expectedPercentageSelector: "(100)*(builtin:service.errors.server.successCount:splitBy())/(builtin:service.requestCount.server:splitBy())",
+		},
+		{
+			name:                       "factor of 100 last",
+			metricSelector:             " ( (builtin:service.errors.server.successCount:merge(\"dt.entity.service\"):sum) / (builtin:service.requestCount.server:merge(\"dt.entity.service\"):sum) ) * 100",
+			expectedNumerator:          "builtin:service.errors.server.successCount:merge(\"dt.entity.service\"):sum",
+			expectedDenominator:        "builtin:service.requestCount.server:merge(\"dt.entity.service\"):sum",
+			expectedIsPercentage:       true,
+			expectedPercentageSelector: "(100)*(builtin:service.errors.server.successCount:merge(\"dt.entity.service\"):sum)/(builtin:service.requestCount.server:merge(\"dt.entity.service\"):sum)",
+		},
+		{
+			name:                       "ratio without parentheses",
+			metricSelector:             "builtin:service.errors.server.successCount:sum/builtin:service.requestCount.server:sum",
+			expectedNumerator:          "builtin:service.errors.server.successCount:sum",
+			expectedDenominator:        "builtin:service.requestCount.server:sum",
+			expectedPercentageSelector: "(100)*(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum)",
+		},
+		{
+			name:                       "separators in quotes",
+			metricSelector:             "(calc:service.requests:filter(eq(\"Request/Name\",\"a*b\")):sum)/(calc:service.requests:sum)",
+			expectedNumerator:          "calc:service.requests:filter(eq(\"Request/Name\",\"a*b\")):sum",
+			expectedDenominator:        "calc:service.requests:sum",
+			expectedPercentageSelector: "(100)*(calc:service.requests:filter(eq(\"Request/Name\",\"a*b\")):sum)/(calc:service.requests:sum)",
+		},
+		// Error cases below:
+		{
+			name:                 "empty",
+			metricSelector:       "()",
+			expectError:          true,
+			expectedErrorMessage: "metric selector should not be empty",
+		},
+		{
+			name:                 "single metric",
+			metricSelector:       "builtin:service.response.time:merge(\"dt.entity.service\"):percentile(90)",
+			expectError:          true,
+			expectedErrorMessage: "metric selector is not a ratio of two metrics",
+		},
+		{
+			name:                 "factor other than 100",
+			metricSelector:       "(1000)*(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum)",
+			expectError:          true,
+			expectedErrorMessage: "only ratios of two metrics optionally multiplied by 100 are supported",
+		},
+		{
+			name:                 "nested metric expression",
+			metricSelector:       "(builtin:service.errors.server.successCount:sum)/(builtin:service.requestCount.server:sum+builtin:service.errors.server.count:sum)",
+			expectError:          true,
+			expectedErrorMessage: "invalid denominator of ratio metric selector",
+		},
+		{
+			name:                 "unbalanced parentheses",
+			metricSelector:       "(builtin:service.errors.server.successCount:sum/builtin:service.requestCount.server:sum",
+			expectError:          true,
+			expectedErrorMessage: "unbalanced parentheses",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseRatioMetricSelector(tt.metricSelector)
+			if tt.expectError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErrorMessage)
+				assert.Nil(t, selector)
+				return
+			}
+
+			assert.NoError(t, err)
+			if assert.NotNil(t, selector) {
+				assert.Equal(t, tt.expectedNumerator, selector.GetNumerator())
+				assert.Equal(t, tt.expectedDenominator, selector.GetDenominator())
+				assert.Equal(t, tt.expectedIsPercentage, selector.IsPercentage())
+				assert.Equal(t, tt.expectedPercentageSelector, selector.GetPercentageMetricExpression())
+			}
+		})
+	}
+}
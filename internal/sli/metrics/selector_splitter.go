@@ -0,0 +1,66 @@
+package metrics
+
+import "fmt"
+
+// selectorEscapeCharacter is the character used to escape quotes and other special characters in quoted strings of selectors
+const selectorEscapeCharacter = '~'
+
+// splitSelector splits a metric or entity selector at each occurrence of the separator that is neither enclosed in parentheses nor in quotes or returns an error if parentheses or quotes are unbalanced.
+func splitSelector(selector string, separator rune) ([]string, error) {
+	var parts []string
+	depth := 0
+	inQuotes := false
+	escaped := false
+	start := 0
+
+	for i, c := range selector {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes && c == selectorEscapeCharacter:
+			escaped = true
+		case c == '"':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in selector: %s", selector)
+			}
+		case c == separator && depth == 0:
+			parts = append(parts, selector[start:i])
+			start = i + 1
+		}
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unbalanced quotes in selector: %s", selector)
+	}
+
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in selector: %s", selector)
+	}
+
+	return append(parts, selector[start:]), nil
+}
+
+// unquoteSelectorValue removes the quotes surrounding a value of a selector and any escape characters within it.
+func unquoteSelectorValue(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+
+	unquoted := make([]rune, 0, len(value)-2)
+	escaped := false
+	for _, c := range value[1 : len(value)-1] {
+		if !escaped && c == selectorEscapeCharacter {
+			escaped = true
+			continue
+		}
+		escaped = false
+		unquoted = append(unquoted, c)
+	}
+	return string(unquoted)
+}